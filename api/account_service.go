@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/mail"
+	"github.com/neuralcoral/BlogService/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidAccountToken = apperror.Unauthorized("invalid, expired or already used link")
+	ErrUsernameTaken       = dao.ErrUsernameTaken
+	ErrEmailInUse          = dao.ErrEmailInUse
+	ErrInviteForbidden     = apperror.Forbidden("only editors and administrators can invite new accounts")
+)
+
+const (
+	invitationSubject = "You're invited to write for the blog"
+	invitationBody    = `You've been invited to write for the blog.
+
+Choose a username and password here:
+
+%s
+
+The link works once and expires at %s.
+`
+	passwordResetSubject = "Reset your password"
+	passwordResetBody    = `Someone asked to reset the password for your account, %s.
+
+Choose a new password here:
+
+%s
+
+The link works once and expires at %s. If you didn't ask for this, ignore this email; your password
+won't change.
+`
+)
+
+// SessionRevoker ends every session of a user, so a password reset logs out whoever knew the old one.
+type SessionRevoker interface {
+	LogoutAll(ctx context.Context, userID string) error
+}
+
+type AccountOptions struct {
+	// LinkBaseURL is the front end serving /invitations/accept and /password-reset, which read the
+	// token from the query string.
+	LinkBaseURL      string
+	InvitationTTL    time.Duration
+	PasswordResetTTL time.Duration
+}
+
+// AccountService onboards authors by invitation and recovers forgotten passwords, both through
+// single-use links sent by email.
+type AccountService struct {
+	userDao         dao.UserDao
+	accountTokenDao dao.AccountTokenDao
+	sessions        SessionRevoker
+	mailer          mail.Mailer
+	auditor         Auditor
+	options         AccountOptions
+	now             func() time.Time
+}
+
+func NewAccountService(userDao dao.UserDao, accountTokenDao dao.AccountTokenDao, sessions SessionRevoker, mailer mail.Mailer, auditor Auditor, options AccountOptions) *AccountService {
+	return &AccountService{
+		userDao:         userDao,
+		accountTokenDao: accountTokenDao,
+		sessions:        sessions,
+		mailer:          mailer,
+		auditor:         auditor,
+		options:         options,
+		now:             time.Now,
+	}
+}
+
+// Invite mails a link that lets whoever holds it create an account for email.
+// Invite is open to editors and administrators only, since each invitation creates an account.
+func (service *AccountService) Invite(ctx context.Context, inviterID string, email string) (*model.AccountToken, error) {
+	inviter, err := service.userDao.GetUserByID(ctx, inviterID)
+	if err != nil {
+		return nil, err
+	}
+	if inviter == nil || (inviter.EffectiveRole() != model.RoleEditor && inviter.EffectiveRole() != model.RoleAdmin) {
+		return nil, ErrInviteForbidden
+	}
+
+	email = normalizeEmail(email)
+	if err := ValidateEmail(email); err != nil {
+		return nil, err
+	}
+
+	existing, err := service.userDao.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrEmailInUse
+	}
+
+	invitation, token, err := createAccountToken(ctx, service.accountTokenDao, &model.AccountToken{
+		Purpose:   model.PurposeInvitation,
+		Email:     email,
+		CreatedBy: inviterID,
+	}, service.options.InvitationTTL, service.now())
+	if err != nil {
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{
+		Action:     audit.ActionUserInvite,
+		TargetType: audit.TargetInvitation,
+		TargetID:   invitation.ID,
+		After:      map[string]interface{}{"email": email, "expiresAt": invitation.ExpiresAt},
+	})
+
+	body := fmt.Sprintf(invitationBody, service.link("/invitations/accept", token), invitation.ExpiresAt.Format(time.RFC1123))
+	if err := service.mailer.Send(ctx, mail.Message{To: email, Subject: invitationSubject, Body: body}); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// VerifyInvitation checks a link before the author fills in the form, without using it up.
+func (service *AccountService) VerifyInvitation(ctx context.Context, token string) (*model.AccountToken, error) {
+	invitation, _, err := verifyAccountToken(ctx, service.accountTokenDao, model.PurposeInvitation, token, service.now())
+	return invitation, err
+}
+
+// AcceptInvitation uses up the invitation and creates the account. A failure after the token is
+// used, which needs DynamoDB to fail, leaves the invitation spent; the inviter sends a new one.
+func (service *AccountService) AcceptInvitation(ctx context.Context, token string, username string, password string) (*model.User, error) {
+	if err := ValidateNewUser(username, password); err != nil {
+		return nil, err
+	}
+	invitation, hash, err := verifyAccountToken(ctx, service.accountTokenDao, model.PurposeInvitation, token, service.now())
+	if err != nil {
+		return nil, err
+	}
+
+	// These checks give a clear error early; CreateUser claims the username and email in the same
+	// transaction as the user, so a signup racing for the same name still fails with these errors.
+	existing, err := service.userDao.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrUsernameTaken
+	}
+	existing, err = service.userDao.GetUserByEmail(ctx, invitation.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrEmailInUse
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	if err := useAccountToken(ctx, service.accountTokenDao, invitation, hash, service.now()); err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		ID:             newID(),
+		Username:       username,
+		HashedPassword: string(hashedPassword),
+		Email:          invitation.Email,
+	}
+	if err := service.userDao.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{
+		Action:     audit.ActionUserCreate,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		After:      auditedUser(user),
+		ActorID:    user.ID,
+	})
+	return user, nil
+}
+
+// RequestPasswordReset mails a reset link if an account has the address. It succeeds either way so
+// the endpoint doesn't reveal which addresses have accounts.
+func (service *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if err := ValidateEmail(email); err != nil {
+		return err
+	}
+
+	user, err := service.userDao.GetUserByEmail(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+
+	reset, token, err := createAccountToken(ctx, service.accountTokenDao, &model.AccountToken{
+		Purpose: model.PurposePasswordReset,
+		Email:   email,
+		UserID:  user.ID,
+	}, service.options.PasswordResetTTL, service.now())
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(passwordResetBody, user.Username, service.link("/password-reset", token), reset.ExpiresAt.Format(time.RFC1123))
+	return service.mailer.Send(ctx, mail.Message{To: email, Subject: passwordResetSubject, Body: body})
+}
+
+func (service *AccountService) VerifyPasswordReset(ctx context.Context, token string) (*model.AccountToken, error) {
+	reset, _, err := verifyAccountToken(ctx, service.accountTokenDao, model.PurposePasswordReset, token, service.now())
+	return reset, err
+}
+
+// ResetPassword uses up the reset link, sets the new password and logs the user out everywhere.
+func (service *AccountService) ResetPassword(ctx context.Context, token string, password string) error {
+	if err := ValidateNewPassword(password); err != nil {
+		return err
+	}
+	reset, hash, err := verifyAccountToken(ctx, service.accountTokenDao, model.PurposePasswordReset, token, service.now())
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := useAccountToken(ctx, service.accountTokenDao, reset, hash, service.now()); err != nil {
+		return err
+	}
+	if err := service.userDao.UpdatePassword(ctx, reset.UserID, string(hashedPassword)); err != nil {
+		return err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionPasswordReset, TargetType: audit.TargetUser, TargetID: reset.UserID, ActorID: reset.UserID})
+	return service.sessions.LogoutAll(ctx, reset.UserID)
+}
+
+func (service *AccountService) link(path string, token string) string {
+	return strings.TrimRight(service.options.LinkBaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
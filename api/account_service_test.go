@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/mail"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeAccountTokenDao keeps tokens in memory and enforces the same conditions as the DynamoDB DAO.
+type fakeAccountTokenDao struct {
+	tokens map[string]model.AccountToken
+}
+
+func (f *fakeAccountTokenDao) CreateAccountToken(ctx context.Context, token *model.AccountToken) error {
+	f.tokens[token.ID] = *token
+	return nil
+}
+
+func (f *fakeAccountTokenDao) GetAccountToken(ctx context.Context, id string) (*model.AccountToken, error) {
+	token, ok := f.tokens[id]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (f *fakeAccountTokenDao) UseAccountToken(ctx context.Context, id string, purpose model.TokenPurpose, hash string, now time.Time) error {
+	token, ok := f.tokens[id]
+	if !ok || token.Purpose != purpose || token.SecretHash != hash || !token.Usable(now) {
+		return apperror.Conflict("account token already used or expired")
+	}
+	token.Used = true
+	token.UsedAt = now
+	f.tokens[id] = token
+	return nil
+}
+
+type MockMailer struct {
+	messages []mail.Message
+}
+
+func (m *MockMailer) Send(ctx context.Context, message mail.Message) error {
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+type MockSessionRevoker struct {
+	revoked []string
+}
+
+func (m *MockSessionRevoker) LogoutAll(ctx context.Context, userID string) error {
+	m.revoked = append(m.revoked, userID)
+	return nil
+}
+
+var linkPattern = regexp.MustCompile(`https://blog\.example\.com/\S+`)
+
+// linkToken pulls the token out of the link in the last mailed message.
+func linkToken(t *testing.T, mailer *MockMailer) string {
+	t.Helper()
+	if len(mailer.messages) == 0 {
+		t.Fatal("no message was sent")
+	}
+	link, err := url.Parse(linkPattern.FindString(mailer.messages[len(mailer.messages)-1].Body))
+	if err != nil {
+		t.Fatalf("parse link: %v", err)
+	}
+	return link.Query().Get("token")
+}
+
+type accountFixture struct {
+	service  *AccountService
+	users    map[string]*model.User
+	mailer   *MockMailer
+	sessions *MockSessionRevoker
+}
+
+func setupAccountService(t testing.TB) *accountFixture {
+	t.Helper()
+	fixture := &accountFixture{
+		users: map[string]*model.User{
+			"user-1": {ID: "user-1", Username: "alice", HashedPassword: "old", Email: "alice@example.com", Role: model.RoleEditor},
+			"user-2": {ID: "user-2", Username: "carol", HashedPassword: "old", Email: "carol@example.com"},
+		},
+		mailer:   &MockMailer{},
+		sessions: &MockSessionRevoker{},
+	}
+	userDao := &MockUserDao{
+		GetUserFunc: func(ctx context.Context, username string) (*model.User, error) {
+			for _, user := range fixture.users {
+				if user.Username == username {
+					return user, nil
+				}
+			}
+			return nil, nil
+		},
+		GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+			return fixture.users[id], nil
+		},
+		GetUserByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
+			for _, user := range fixture.users {
+				if user.Email == email {
+					return user, nil
+				}
+			}
+			return nil, nil
+		},
+		CreateUserFunc: func(ctx context.Context, user *model.User) error {
+			fixture.users[user.ID] = user
+			return nil
+		},
+		UpdatePasswordFunc: func(ctx context.Context, userID string, hashedPassword string) error {
+			fixture.users[userID].HashedPassword = hashedPassword
+			return nil
+		},
+	}
+	fixture.service = NewAccountService(userDao, &fakeAccountTokenDao{tokens: map[string]model.AccountToken{}}, fixture.sessions, fixture.mailer, nil, AccountOptions{
+		LinkBaseURL:      "https://blog.example.com/",
+		InvitationTTL:    7 * 24 * time.Hour,
+		PasswordResetTTL: time.Hour,
+	})
+	return fixture
+}
+
+func TestAcceptInvitation_ValidLink_CreatesUserOnce(t *testing.T) {
+	fixture := setupAccountService(t)
+	_, err := fixture.service.Invite(context.Background(), "user-1", " Bob@Example.com ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	token := linkToken(t, fixture.mailer)
+
+	verified, verifyErr := fixture.service.VerifyInvitation(context.Background(), token)
+	user, acceptErr := fixture.service.AcceptInvitation(context.Background(), token, "bob", testPassword)
+	_, replayErr := fixture.service.AcceptInvitation(context.Background(), token, "bob2", testPassword)
+
+	assert.Nil(t, verifyErr)
+	assert.Equal(t, "bob@example.com", verified.Email)
+	assert.Equal(t, "bob@example.com", fixture.mailer.messages[0].To)
+	if acceptErr != nil {
+		t.Fatalf("unexpected error: %v", acceptErr)
+	}
+	assert.Equal(t, "bob@example.com", user.Email)
+	assert.Nil(t, bcrypt.CompareHashAndPassword([]byte(fixture.users[user.ID].HashedPassword), []byte(testPassword)))
+	assert.Equal(t, ErrInvalidAccountToken, replayErr)
+}
+
+func TestAcceptInvitation_ExpiredOrTakenUsername_Fails(t *testing.T) {
+	fixture := setupAccountService(t)
+	_, _ = fixture.service.Invite(context.Background(), "user-1", "bob@example.com")
+	token := linkToken(t, fixture.mailer)
+
+	_, takenErr := fixture.service.AcceptInvitation(context.Background(), token, "alice", testPassword)
+	fixture.service.now = func() time.Time { return time.Now().Add(8 * 24 * time.Hour) }
+	_, expiredErr := fixture.service.AcceptInvitation(context.Background(), token, "bob", testPassword)
+
+	assert.Equal(t, ErrUsernameTaken, takenErr)
+	assert.Equal(t, ErrInvalidAccountToken, expiredErr)
+}
+
+func TestAcceptInvitation_UsernameClaimedConcurrently_ReturnsErrUsernameTaken(t *testing.T) {
+	fixture := setupAccountService(t)
+	_, _ = fixture.service.Invite(context.Background(), "user-1", "bob@example.com")
+	token := linkToken(t, fixture.mailer)
+	fixture.service.userDao.(*MockUserDao).CreateUserFunc = func(ctx context.Context, user *model.User) error {
+		return dao.ErrUsernameTaken
+	}
+
+	_, err := fixture.service.AcceptInvitation(context.Background(), token, "bob", testPassword)
+
+	assert.Equal(t, ErrUsernameTaken, err)
+	assert.Len(t, fixture.users, 2)
+}
+
+func TestInvite_ExistingEmail_ReturnsConflict(t *testing.T) {
+	fixture := setupAccountService(t)
+
+	_, err := fixture.service.Invite(context.Background(), "user-1", "alice@example.com")
+
+	assert.Equal(t, ErrEmailInUse, err)
+	assert.Empty(t, fixture.mailer.messages)
+}
+
+func TestInvite_Author_ReturnsForbidden(t *testing.T) {
+	fixture := setupAccountService(t)
+
+	_, err := fixture.service.Invite(context.Background(), "user-2", "bob@example.com")
+
+	assert.Equal(t, ErrInviteForbidden, err)
+	assert.Empty(t, fixture.mailer.messages)
+}
+
+func TestRequestPasswordReset_UnknownEmail_SucceedsWithoutMail(t *testing.T) {
+	fixture := setupAccountService(t)
+
+	err := fixture.service.RequestPasswordReset(context.Background(), "nobody@example.com")
+
+	assert.Nil(t, err)
+	assert.Empty(t, fixture.mailer.messages)
+}
+
+func TestResetPassword_ValidLink_ChangesPasswordAndRevokesSessions(t *testing.T) {
+	fixture := setupAccountService(t)
+	if err := fixture.service.RequestPasswordReset(context.Background(), "alice@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	token := linkToken(t, fixture.mailer)
+
+	_, wrongPurposeErr := fixture.service.VerifyInvitation(context.Background(), token)
+	err := fixture.service.ResetPassword(context.Background(), token, testPassword)
+	replayErr := fixture.service.ResetPassword(context.Background(), token, testPassword+"!")
+
+	assert.Equal(t, ErrInvalidAccountToken, wrongPurposeErr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Nil(t, bcrypt.CompareHashAndPassword([]byte(fixture.users["user-1"].HashedPassword), []byte(testPassword)))
+	assert.Equal(t, []string{"user-1"}, fixture.sessions.revoked)
+	assert.Equal(t, ErrInvalidAccountToken, replayErr)
+}
+
+func TestResetPassword_ShortPassword_ReturnsValidationError(t *testing.T) {
+	fixture := setupAccountService(t)
+
+	err := fixture.service.ResetPassword(context.Background(), "id.secret", "short")
+
+	assert.Equal(t, apperror.KindValidation, apperror.KindOf(err))
+}
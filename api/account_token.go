@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// createAccountToken stores the token with a new ID and secret and returns it with the token to
+// mail.
+func createAccountToken(ctx context.Context, accountTokenDao dao.AccountTokenDao, accountToken *model.AccountToken, ttl time.Duration, now time.Time) (*model.AccountToken, string, error) {
+	accountToken.ID = newID()
+	token, hash, err := auth.NewAccountToken(accountToken.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now = now.UTC()
+	accountToken.SecretHash = hash
+	accountToken.CreatedAt = now
+	accountToken.ExpiresAt = now.Add(ttl)
+	accountToken.DeleteAfter = accountToken.ExpiresAt.Unix()
+	if err := accountTokenDao.CreateAccountToken(ctx, accountToken); err != nil {
+		return nil, "", err
+	}
+	return accountToken, token, nil
+}
+
+// verifyAccountToken looks up the token and returns it with the hash of its secret. Every way a
+// token can be wrong gets the same error.
+func verifyAccountToken(ctx context.Context, accountTokenDao dao.AccountTokenDao, purpose model.TokenPurpose, token string, now time.Time) (*model.AccountToken, string, error) {
+	id, hash, err := auth.ParseAccountToken(token)
+	if err != nil {
+		return nil, "", ErrInvalidAccountToken
+	}
+
+	accountToken, err := accountTokenDao.GetAccountToken(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if accountToken == nil || accountToken.Purpose != purpose || !auth.SecretHashesEqual(accountToken.SecretHash, hash) || !accountToken.Usable(now) {
+		return nil, "", ErrInvalidAccountToken
+	}
+	return accountToken, hash, nil
+}
+
+// useAccountToken marks the token used; of two requests racing with the same link only one gets
+// through.
+func useAccountToken(ctx context.Context, accountTokenDao dao.AccountTokenDao, accountToken *model.AccountToken, hash string, now time.Time) error {
+	err := accountTokenDao.UseAccountToken(ctx, accountToken.ID, accountToken.Purpose, hash, now.UTC())
+	if apperror.KindOf(err) == apperror.KindConflict {
+		return ErrInvalidAccountToken
+	}
+	return err
+}
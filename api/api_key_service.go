@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// lastUsedResolution limits last-used tracking to one write per key per minute.
+const lastUsedResolution = time.Minute
+
+var (
+	ErrInvalidAPIKey  = apperror.Unauthorized("invalid, expired or revoked API key")
+	ErrAPIKeyNotFound = apperror.NotFound("API key not found")
+)
+
+type CreatedAPIKey struct {
+	*model.APIKey
+	// Key is the full secret, returned only when the key is created.
+	Key string
+}
+
+type APIKeyService struct {
+	apiKeyDao dao.APIKeyDao
+	auditor   Auditor
+	now       func() time.Time
+}
+
+func NewAPIKeyService(apiKeyDao dao.APIKeyDao, auditor Auditor) *APIKeyService {
+	return &APIKeyService{apiKeyDao: apiKeyDao, auditor: auditor, now: time.Now}
+}
+
+func (service *APIKeyService) CreateAPIKey(ctx context.Context, ownerID string, name string, scopes []string, expiresAt time.Time) (*CreatedAPIKey, error) {
+	now := service.now().UTC()
+	if err := ValidateAPIKey(name, scopes, expiresAt, now); err != nil {
+		return nil, err
+	}
+
+	id := newID()
+	key, prefix, hash, err := auth.NewAPIKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &model.APIKey{
+		ID:         id,
+		OwnerID:    ownerID,
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: hash,
+		Scopes:     scopes,
+		CreatedAt:  now,
+		ExpiresAt:  expiresAt.UTC(),
+	}
+	if err := service.apiKeyDao.CreateAPIKey(ctx, apiKey); err != nil {
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionAPIKeyCreate, TargetType: audit.TargetAPIKey, TargetID: id, After: apiKey})
+	return &CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (service *APIKeyService) ListAPIKeys(ctx context.Context, ownerID string) ([]*model.APIKey, error) {
+	return service.apiKeyDao.ListAPIKeys(ctx, ownerID)
+}
+
+func (service *APIKeyService) RevokeAPIKey(ctx context.Context, ownerID string, id string) error {
+	apiKey, err := service.apiKeyDao.GetAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if apiKey == nil || apiKey.OwnerID != ownerID {
+		return ErrAPIKeyNotFound
+	}
+	if err := service.apiKeyDao.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+	record(ctx, service.auditor, audit.Entry{
+		Action:     audit.ActionAPIKeyRevoke,
+		TargetType: audit.TargetAPIKey,
+		TargetID:   id,
+		Before:     map[string]bool{"revoked": apiKey.Revoked},
+		After:      map[string]bool{"revoked": true},
+	})
+	return nil
+}
+
+// Authenticate resolves a presented key to the stored key, recording when it was last used.
+func (service *APIKeyService) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	id, hash, err := auth.ParseAPIKey(key)
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := service.apiKeyDao.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := service.now().UTC()
+	if apiKey == nil || !auth.SecretHashesEqual(apiKey.SecretHash, hash) || !apiKey.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if now.Sub(apiKey.LastUsedAt) >= lastUsedResolution {
+		if err := service.apiKeyDao.TouchAPIKey(ctx, id, now); err != nil {
+			return nil, err
+		}
+		apiKey.LastUsedAt = now
+	}
+	return apiKey, nil
+}
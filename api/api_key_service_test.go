@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAPIKeyDao struct {
+	keys    map[string]model.APIKey
+	touches int
+}
+
+func (f *fakeAPIKeyDao) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	f.keys[key.ID] = *key
+	return nil
+}
+
+func (f *fakeAPIKeyDao) GetAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	key, ok := f.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+func (f *fakeAPIKeyDao) ListAPIKeys(ctx context.Context, ownerID string) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	for _, key := range f.keys {
+		if key.OwnerID == ownerID {
+			keys = append(keys, &key)
+		}
+	}
+	return keys, nil
+}
+
+func (f *fakeAPIKeyDao) RevokeAPIKey(ctx context.Context, id string) error {
+	key := f.keys[id]
+	key.Revoked = true
+	f.keys[id] = key
+	return nil
+}
+
+func (f *fakeAPIKeyDao) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	f.touches++
+	key := f.keys[id]
+	key.LastUsedAt = usedAt
+	f.keys[id] = key
+	return nil
+}
+
+func setupAPIKeyService(now *time.Time) (*APIKeyService, *fakeAPIKeyDao) {
+	apiKeyDao := &fakeAPIKeyDao{keys: map[string]model.APIKey{}}
+	service := NewAPIKeyService(apiKeyDao, nil)
+	service.now = func() time.Time { return *now }
+	return service, apiKeyDao
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sut, apiKeyDao := setupAPIKeyService(&now)
+
+	created, err := sut.CreateAPIKey(context.Background(), "user-1", "docs CI", []string{auth.ScopePostsWrite}, time.Time{})
+	assert.NoError(t, err)
+	assert.True(t, len(created.Key) > len(created.Prefix))
+	assert.Contains(t, created.Key, created.Prefix)
+	assert.NotContains(t, apiKeyDao.keys[created.ID].SecretHash, created.Key)
+
+	key, err := sut.Authenticate(context.Background(), created.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", key.OwnerID)
+	assert.Equal(t, now, key.LastUsedAt)
+
+	now = now.Add(10 * time.Second)
+	_, _ = sut.Authenticate(context.Background(), created.Key)
+	assert.Equal(t, 1, apiKeyDao.touches, "last-used should be recorded at most once a minute")
+}
+
+func TestAPIKeyService_Authenticate_RejectsWrongSecretExpiredAndRevoked(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sut, _ := setupAPIKeyService(&now)
+	created, _ := sut.CreateAPIKey(context.Background(), "user-1", "docs CI", []string{auth.ScopePostsRead}, now.Add(time.Hour))
+
+	_, wrongSecret := sut.Authenticate(context.Background(), created.Key[:len(created.Key)-2]+"xx")
+	_, malformed := sut.Authenticate(context.Background(), "bsk_nosecret")
+	now = now.Add(2 * time.Hour)
+	_, expired := sut.Authenticate(context.Background(), created.Key)
+
+	assert.Equal(t, ErrInvalidAPIKey, wrongSecret)
+	assert.Equal(t, ErrInvalidAPIKey, malformed)
+	assert.Equal(t, ErrInvalidAPIKey, expired)
+
+	now = now.Add(-2 * time.Hour)
+	assert.NoError(t, sut.RevokeAPIKey(context.Background(), "user-1", created.ID))
+	_, revoked := sut.Authenticate(context.Background(), created.Key)
+	assert.Equal(t, ErrInvalidAPIKey, revoked)
+}
+
+func TestAPIKeyService_RevokeOtherUsersKey_ReturnsNotFound(t *testing.T) {
+	now := time.Now()
+	sut, _ := setupAPIKeyService(&now)
+	created, _ := sut.CreateAPIKey(context.Background(), "user-1", "docs CI", []string{auth.ScopePostsRead}, time.Time{})
+
+	err := sut.RevokeAPIKey(context.Background(), "user-2", created.ID)
+
+	assert.Equal(t, ErrAPIKeyNotFound, err)
+}
+
+func TestValidateAPIKey_ReportsEachProblem(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	err := ValidateAPIKey("", []string{"posts:delete"}, now.Add(-time.Hour), now)
+
+	fields := map[string]string{}
+	for _, field := range apperror.As(err).Fields {
+		fields[field.Field] = field.Message
+	}
+	assert.Contains(t, fields, "name")
+	assert.Contains(t, fields, "scopes[0]")
+	assert.Equal(t, "must be in the future", fields["expiresAt"])
+	assert.Error(t, ValidateAPIKey("ci", nil, time.Time{}, now))
+	assert.NoError(t, ValidateAPIKey("ci", []string{auth.ScopePostsWrite}, time.Time{}, now))
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// maxAuditTimeRange bounds listings that only filter by time, which read one partition per day.
+const maxAuditTimeRange = 31 * 24 * time.Hour
+
+var ErrAdminRequired = apperror.Forbidden("only administrators can do this")
+
+// Auditor records changes in the audit log; audit.Recorder implements it.
+type Auditor interface {
+	Record(ctx context.Context, entry audit.Entry)
+}
+
+// record adds the entry to the audit log when the service has one.
+func record(ctx context.Context, auditor Auditor, entry audit.Entry) {
+	if auditor != nil {
+		auditor.Record(ctx, entry)
+	}
+}
+
+// auditedUser is what the audit log keeps of a user. model.User has no JSON tags hiding its
+// password hash and TOTP secret, so it is never recorded as is.
+func auditedUser(user *model.User) map[string]string {
+	return map[string]string{
+		"username":        user.Username,
+		"email":           user.Email,
+		"role":            string(user.EffectiveRole()),
+		"externalSubject": user.ExternalSubject,
+	}
+}
+
+func requireAdmin(ctx context.Context, userDao dao.UserDao, callerID string) error {
+	caller, err := userDao.GetUserByID(ctx, callerID)
+	if err != nil {
+		return err
+	}
+	if caller == nil || caller.EffectiveRole() != model.RoleAdmin {
+		return ErrAdminRequired
+	}
+	return nil
+}
+
+// AuditQuery filters the audit log by actor or by target. Without either, it covers From to To,
+// which default to the day before now.
+type AuditQuery struct {
+	ActorID    string
+	TargetType string
+	TargetID   string
+	From       time.Time
+	To         time.Time
+}
+
+type AuditService struct {
+	auditDao dao.AuditDao
+	userDao  dao.UserDao
+	now      func() time.Time
+}
+
+func NewAuditService(auditDao dao.AuditDao, userDao dao.UserDao) *AuditService {
+	return &AuditService{auditDao: auditDao, userDao: userDao, now: time.Now}
+}
+
+// ListAuditEvents returns matching events newest first. Only administrators may read the log.
+func (service *AuditService) ListAuditEvents(ctx context.Context, callerID string, query AuditQuery, limit int, lastEvaluatedKey string) ([]*model.AuditEvent, string, error) {
+	if err := requireAdmin(ctx, service.userDao, callerID); err != nil {
+		return nil, "", err
+	}
+
+	filter, err := service.filter(query)
+	if err != nil {
+		return nil, "", err
+	}
+	return service.auditDao.ListAuditEvents(ctx, filter, limit, lastEvaluatedKey)
+}
+
+func (service *AuditService) filter(query AuditQuery) (model.AuditFilter, error) {
+	filter := model.AuditFilter{ActorID: query.ActorID, From: query.From, To: query.To}
+	if (query.TargetType == "") != (query.TargetID == "") {
+		return filter, apperror.Validation("targetType and targetId must be given together")
+	}
+	if query.TargetType != "" {
+		filter.Target = model.AuditTarget(query.TargetType, query.TargetID)
+	}
+	if filter.ActorID != "" && filter.Target != "" {
+		return filter, apperror.Validation("filter by actor or by target, not both")
+	}
+
+	if filter.ActorID == "" && filter.Target == "" {
+		if filter.To.IsZero() {
+			filter.To = service.now().UTC()
+		}
+		if filter.From.IsZero() {
+			filter.From = filter.To.Add(-24 * time.Hour)
+		}
+		if filter.To.Sub(filter.From) > maxAuditTimeRange {
+			return filter, apperror.Validation("time ranges without an actor or target may span at most 31 days")
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return filter, apperror.Validation("from must not be after to")
+	}
+	return filter, nil
+}
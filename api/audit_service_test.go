@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuditor struct {
+	entries []audit.Entry
+}
+
+func (auditor *fakeAuditor) Record(ctx context.Context, entry audit.Entry) {
+	auditor.entries = append(auditor.entries, entry)
+}
+
+func (auditor *fakeAuditor) actions() []string {
+	var actions []string
+	for _, entry := range auditor.entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+type MockAuditDao struct {
+	AppendAuditEventFunc func(ctx context.Context, event *model.AuditEvent) error
+	ListAuditEventsFunc  func(ctx context.Context, filter model.AuditFilter, limit int, lastEvaluatedKey string) ([]*model.AuditEvent, string, error)
+}
+
+func (m *MockAuditDao) AppendAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	return m.AppendAuditEventFunc(ctx, event)
+}
+
+func (m *MockAuditDao) ListAuditEvents(ctx context.Context, filter model.AuditFilter, limit int, lastEvaluatedKey string) ([]*model.AuditEvent, string, error) {
+	return m.ListAuditEventsFunc(ctx, filter, limit, lastEvaluatedKey)
+}
+
+func setupAuditService(filters *[]model.AuditFilter) *AuditService {
+	userDao := &MockUserDao{GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+		if id == "admin-1" {
+			return &model.User{ID: id, Username: "root", Role: model.RoleAdmin}, nil
+		}
+		return &model.User{ID: id, Username: "alice"}, nil
+	}}
+	auditDao := &MockAuditDao{ListAuditEventsFunc: func(ctx context.Context, filter model.AuditFilter, limit int, lastEvaluatedKey string) ([]*model.AuditEvent, string, error) {
+		*filters = append(*filters, filter)
+		return nil, "", nil
+	}}
+	service := NewAuditService(auditDao, userDao)
+	service.now = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+	return service
+}
+
+func TestListAuditEvents_NonAdmin_ReturnsForbidden(t *testing.T) {
+	var filters []model.AuditFilter
+	sut := setupAuditService(&filters)
+
+	_, _, err := sut.ListAuditEvents(context.Background(), "user-1", AuditQuery{}, 25, "")
+
+	assert.Equal(t, ErrAdminRequired, err)
+	assert.Empty(t, filters)
+}
+
+func TestListAuditEvents_NoFilter_CoversLastDay(t *testing.T) {
+	var filters []model.AuditFilter
+	sut := setupAuditService(&filters)
+
+	_, _, err := sut.ListAuditEvents(context.Background(), "admin-1", AuditQuery{}, 25, "")
+	_, _, targetErr := sut.ListAuditEvents(context.Background(), "admin-1", AuditQuery{TargetType: "post", TargetID: "post-1"}, 25, "")
+
+	assert.Nil(t, err)
+	assert.Nil(t, targetErr)
+	assert.Equal(t, []model.AuditFilter{
+		{From: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), To: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)},
+		{Target: "post#post-1"},
+	}, filters)
+}
+
+func TestListAuditEvents_InvalidQuery_ReturnsValidationError(t *testing.T) {
+	var filters []model.AuditFilter
+	sut := setupAuditService(&filters)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	for _, query := range []AuditQuery{
+		{ActorID: "user-1", TargetType: "post", TargetID: "post-1"},
+		{TargetType: "post"},
+		{From: now.AddDate(0, -2, 0)},
+		{ActorID: "user-1", From: now, To: now.Add(-time.Hour)},
+	} {
+		_, _, err := sut.ListAuditEvents(context.Background(), "admin-1", query, 25, "")
+		assert.Equal(t, apperror.KindValidation, apperror.KindOf(err), "%+v", query)
+	}
+	assert.Empty(t, filters)
+}
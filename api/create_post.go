@@ -1,7 +1,47 @@
 package api
 
-import "github.com/neuralcoral/BlogService/model"
+import (
+	"context"
+	"time"
 
-func CreatePost(model.Post) (*model.Post, error) {
-	return nil, nil
+	"github.com/neuralcoral/BlogService/model"
+)
+
+func (service *PostService) CreatePost(ctx context.Context, authorID string, postToCreate *model.Post) (*model.Post, error) {
+	if err := ValidatePost(postToCreate); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	post := *postToCreate
+	post.ID = newID()
+	post.AuthorID = authorID
+	post.Version = 0
+	post.BodyUrl = postBodyLocation(post.ID)
+	post.CreatedAt = now
+	post.UpdatedAt = now
+	if post.Status == "" {
+		post.Status = model.Draft
+	}
+	if post.PreviewText == "" {
+		post.PreviewText = previewText(post.Body)
+	}
+
+	editor, err := service.postEditor(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.addAssetReferences(ctx, editor, post.ID, nil, post.AssetIDs); err != nil {
+		return nil, err
+	}
+	if err := service.postStore.PutPost(ctx, post.BodyUrl, post.Body); err != nil {
+		return nil, err
+	}
+
+	if err := service.postMetadataDao.CreatePostMetadata(ctx, &post.PostMetadata); err != nil {
+		return nil, err
+	}
+	service.recordPostChange(ctx, nil, &post.PostMetadata)
+
+	return &post, nil
 }
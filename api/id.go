@@ -0,0 +1,14 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+func newID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(bytes)
+}
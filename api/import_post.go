@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// ImportPost creates a post with the ID and dates the caller chose, for bringing posts over from
+// another blog. It reports false without writing anything when the post already exists, so an
+// import can be run again; a dry run validates and checks for the post without writing.
+func (service *PostService) ImportPost(ctx context.Context, postToImport *model.Post, dryRun bool) (bool, error) {
+	post := *postToImport
+	post.BodyUrl = postBodyLocation(post.ID)
+	post.Origin = model.OriginImport
+	if post.ID == "" || post.CreatedAt.IsZero() {
+		return false, apperror.Validation("imported posts need an ID and a creation date")
+	}
+	if err := ValidatePost(&post); err != nil {
+		return false, err
+	}
+
+	existing, err := service.postMetadataDao.GetPostMetadata(ctx, post.ID)
+	if err != nil || existing != nil {
+		return false, err
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	post.CreatedAt = post.CreatedAt.UTC()
+	if post.UpdatedAt.Before(post.CreatedAt) {
+		post.UpdatedAt = post.CreatedAt
+	}
+	post.UpdatedAt = post.UpdatedAt.UTC()
+	if post.Status == "" {
+		post.Status = model.Draft
+	}
+	if post.PreviewText == "" {
+		post.PreviewText = previewText(post.Body)
+	}
+
+	// Writing the body first makes a rerun after a failure repair the post: the body is overwritten
+	// and the metadata created.
+	if err := service.postStore.PutPost(ctx, post.BodyUrl, post.Body); err != nil {
+		return false, err
+	}
+	err = service.postMetadataDao.CreatePostMetadata(ctx, &post.PostMetadata)
+	if apperror.KindOf(err) == apperror.KindConflict {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	*postToImport = post
+	service.recordPostChange(ctx, nil, &post.PostMetadata)
+	return true, nil
+}
@@ -1,7 +1,28 @@
 package api
 
-import "github.com/neuralcoral/BlogService/model"
+import (
+	"context"
 
-func ListPosts(limit int, offset int) ([]model.Post, error) {
-	return nil, nil
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// ListPosts leaves out drafts the caller can't edit, so a page may hold fewer than limit posts
+// while more follow.
+func (service *PostService) ListPosts(ctx context.Context, callerID string, limit int, lastEvaluatedKey string) ([]*model.PostMetadata, string, error) {
+	posts, nextKey, err := service.postMetadataDao.ListPostMetadata(ctx, limit, lastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+	editor, err := service.postEditor(ctx, callerID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	visible := make([]*model.PostMetadata, 0, len(posts))
+	for _, post := range posts {
+		if editor.canRead(post) {
+			visible = append(visible, post)
+		}
+	}
+	return visible, nextKey, nil
 }
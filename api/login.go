@@ -1,5 +1,167 @@
 package api
 
-func Login() {
+import (
+	"context"
+	"time"
 
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidCredentials = apperror.Unauthorized("invalid username or password")
+
+// dummyPasswordHash is compared against when the user doesn't exist so unknown usernames take as
+// long to reject as wrong passwords.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// LoginResult holds either tokens or, when TwoFactorRequired is set, only the challenge to complete
+// with a code. TwoFactorEnrolmentRequired marks tokens restricted to enrolling in two-factor
+// authentication.
+type LoginResult struct {
+	AccessToken                string
+	ExpiresAt                  time.Time
+	RefreshToken               string
+	RefreshExpiresAt           time.Time
+	TwoFactorEnrolmentRequired bool
+
+	TwoFactorRequired  bool
+	ChallengeToken     string
+	ChallengeExpiresAt time.Time
+}
+
+// LoginLockout throttles repeated failed logins for a username.
+type LoginLockout interface {
+	Check(ctx context.Context, username string) error
+	RecordFailure(ctx context.Context, username string) error
+	Reset(ctx context.Context, username string) error
+}
+
+type LoginService struct {
+	userDao         dao.UserDao
+	sessionDao      dao.SessionDao
+	tokenIssuer     *auth.TokenIssuer
+	lockout         LoginLockout
+	twoFactor       *TwoFactorService
+	auditor         Auditor
+	refreshTokenTTL time.Duration
+}
+
+// NewLoginService builds the service; lockout may be nil to disable lockouts, twoFactor nil to log
+// in with passwords alone and auditor nil to keep no audit log.
+func NewLoginService(userDao dao.UserDao, sessionDao dao.SessionDao, tokenIssuer *auth.TokenIssuer, lockout LoginLockout, twoFactor *TwoFactorService, auditor Auditor, refreshTokenTTL time.Duration) *LoginService {
+	return &LoginService{
+		userDao:         userDao,
+		sessionDao:      sessionDao,
+		tokenIssuer:     tokenIssuer,
+		lockout:         lockout,
+		twoFactor:       twoFactor,
+		auditor:         auditor,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+func (service *LoginService) Login(ctx context.Context, username string, password string) (*LoginResult, error) {
+	if err := ValidateCredentials(username, password); err != nil {
+		return nil, err
+	}
+
+	// Unknown usernames are locked out like real ones so lockouts don't reveal which accounts exist.
+	if service.lockout != nil {
+		if err := service.lockout.Check(ctx, username); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := service.userDao.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users created through single sign-on have no password to log in with.
+	if user == nil || user.HashedPassword == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, service.failLogin(ctx, username)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(password)) != nil {
+		return nil, service.failLogin(ctx, username)
+	}
+
+	return service.completeFirstFactor(ctx, user)
+}
+
+// completeFirstFactor continues a login whose password or identity provider check passed: with a
+// two-factor challenge when the user has enrolled, otherwise with a session, restricted to
+// enrolment if the user's role requires two-factor authentication.
+func (service *LoginService) completeFirstFactor(ctx context.Context, user *model.User) (*LoginResult, error) {
+	if service.twoFactor == nil {
+		return service.completeLogin(ctx, user, false)
+	}
+	// The lockout is only reset once the code is right too, or knowing the password would allow
+	// unlimited guesses at codes.
+	if user.TwoFactorEnabled() {
+		return service.twoFactor.startChallenge(ctx, user)
+	}
+	return service.completeLogin(ctx, user, service.twoFactor.Required(user))
+}
+
+// CompleteTwoFactorLogin finishes a login that Login answered with a challenge. Wrong codes count
+// towards the lockout like wrong passwords.
+func (service *LoginService) CompleteTwoFactorLogin(ctx context.Context, challengeToken string, code string) (*LoginResult, error) {
+	if service.twoFactor == nil {
+		return nil, ErrInvalidLoginChallenge
+	}
+	user, challenge, hash, err := service.twoFactor.challengeUser(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if service.lockout != nil {
+		if err := service.lockout.Check(ctx, user.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	err = service.twoFactor.verify(ctx, user, code)
+	if err == ErrInvalidTwoFactorCode {
+		record(ctx, service.auditor, audit.Entry{Action: audit.ActionLoginFailed, TargetType: audit.TargetUser, TargetID: user.ID})
+		if service.lockout != nil {
+			if err := service.lockout.RecordFailure(ctx, user.Username); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := service.twoFactor.useChallenge(ctx, challenge, hash); err != nil {
+		return nil, err
+	}
+	return service.completeLogin(ctx, user, false)
+}
+
+func (service *LoginService) completeLogin(ctx context.Context, user *model.User, twoFactorPending bool) (*LoginResult, error) {
+	if service.lockout != nil {
+		if err := service.lockout.Reset(ctx, user.Username); err != nil {
+			return nil, err
+		}
+	}
+	result, err := service.startSession(ctx, user.ID, twoFactorPending)
+	if err != nil {
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionLogin, TargetType: audit.TargetUser, TargetID: user.ID, ActorID: user.ID})
+	return result, nil
+}
+
+func (service *LoginService) failLogin(ctx context.Context, username string) error {
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionLoginFailed, TargetType: audit.TargetUsername, TargetID: username})
+	if service.lockout != nil {
+		if err := service.lockout.RecordFailure(ctx, username); err != nil {
+			return err
+		}
+	}
+	return ErrInvalidCredentials
 }
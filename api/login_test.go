@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockUserDao struct {
+	GetUserFunc                  func(ctx context.Context, username string) (*model.User, error)
+	GetUserByIDFunc              func(ctx context.Context, id string) (*model.User, error)
+	GetUserByEmailFunc           func(ctx context.Context, email string) (*model.User, error)
+	GetUserByExternalSubjectFunc func(ctx context.Context, externalSubject string) (*model.User, error)
+	CreateUserFunc               func(ctx context.Context, user *model.User) error
+	UpdatePasswordFunc           func(ctx context.Context, userID string, hashedPassword string) error
+	UpdateExternalIdentityFunc   func(ctx context.Context, userID string, externalSubject string, role model.Role) error
+	UpdateTwoFactorFunc          func(ctx context.Context, userID string, twoFactor *model.TwoFactor, previousRevision int) error
+}
+
+func (m *MockUserDao) GetUser(ctx context.Context, username string) (*model.User, error) {
+	return m.GetUserFunc(ctx, username)
+}
+
+func (m *MockUserDao) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+	return m.GetUserByIDFunc(ctx, id)
+}
+
+func (m *MockUserDao) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	return m.GetUserByEmailFunc(ctx, email)
+}
+
+func (m *MockUserDao) GetUserByExternalSubject(ctx context.Context, externalSubject string) (*model.User, error) {
+	return m.GetUserByExternalSubjectFunc(ctx, externalSubject)
+}
+
+func (m *MockUserDao) CreateUser(ctx context.Context, user *model.User) error {
+	return m.CreateUserFunc(ctx, user)
+}
+
+func (m *MockUserDao) UpdatePassword(ctx context.Context, userID string, hashedPassword string) error {
+	return m.UpdatePasswordFunc(ctx, userID, hashedPassword)
+}
+
+func (m *MockUserDao) UpdateExternalIdentity(ctx context.Context, userID string, externalSubject string, role model.Role) error {
+	return m.UpdateExternalIdentityFunc(ctx, userID, externalSubject, role)
+}
+
+func (m *MockUserDao) UpdateTwoFactor(ctx context.Context, userID string, twoFactor *model.TwoFactor, previousRevision int) error {
+	return m.UpdateTwoFactorFunc(ctx, userID, twoFactor, previousRevision)
+}
+
+type MockLoginLockout struct {
+	CheckErr error
+	failures []string
+	resets   []string
+}
+
+func (m *MockLoginLockout) Check(ctx context.Context, username string) error {
+	return m.CheckErr
+}
+
+func (m *MockLoginLockout) RecordFailure(ctx context.Context, username string) error {
+	m.failures = append(m.failures, username)
+	return nil
+}
+
+func (m *MockLoginLockout) Reset(ctx context.Context, username string) error {
+	m.resets = append(m.resets, username)
+	return nil
+}
+
+const testPassword = "correct horse battery staple"
+
+func setupLoginService(t testing.TB, lockout LoginLockout) *LoginService {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	userDao := &MockUserDao{GetUserFunc: func(ctx context.Context, username string) (*model.User, error) {
+		if username != "alice" {
+			return nil, nil
+		}
+		return &model.User{ID: "user-1", Username: "alice", HashedPassword: string(hash)}, nil
+	}}
+	return NewLoginService(userDao, newFakeSessionDao(), auth.NewTokenIssuer("test-signing-key", "test", time.Minute), lockout, nil, nil, time.Hour)
+}
+
+func TestLogin_ValidCredentials_ResetsLockout(t *testing.T) {
+	lockout := &MockLoginLockout{}
+	sut := setupLoginService(t, lockout)
+
+	result, err := sut.Login(context.Background(), "alice", testPassword)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.Equal(t, []string{"alice"}, lockout.resets)
+}
+
+func TestLogin_WrongPasswordOrUnknownUser_RecordsFailure(t *testing.T) {
+	lockout := &MockLoginLockout{}
+	sut := setupLoginService(t, lockout)
+
+	_, wrongPassword := sut.Login(context.Background(), "alice", "not the password")
+	_, unknownUser := sut.Login(context.Background(), "mallory", testPassword)
+
+	assert.Equal(t, ErrInvalidCredentials, wrongPassword)
+	assert.Equal(t, ErrInvalidCredentials, unknownUser)
+	assert.Equal(t, []string{"alice", "mallory"}, lockout.failures)
+}
+
+func TestLogin_LockedOut_RejectsEvenCorrectPassword(t *testing.T) {
+	locked := apperror.RateLimited("too many failed logins; try again later", time.Minute)
+	sut := setupLoginService(t, &MockLoginLockout{CheckErr: locked})
+
+	_, err := sut.Login(context.Background(), "alice", testPassword)
+
+	assert.Equal(t, locked, err)
+}
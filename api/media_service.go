@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/media"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+const (
+	DefaultMaxMediaUploadBytes = 10 << 20
+	mediaUploadURLExpiry       = 15 * time.Minute
+	mediaListPageSize          = 100
+)
+
+var AllowedMediaTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+var ErrMediaAssetNotFound = apperror.NotFound("media asset not found")
+
+type CreateMediaUploadInput struct {
+	OwnerID   string
+	MimeType  string
+	SizeBytes int64
+	Width     int
+	Height    int
+	Checksum  string
+}
+
+type MediaUpload struct {
+	Asset  *model.MediaAsset
+	Upload *objectstore.PresignedUpload
+}
+
+var ErrMediaAssetInUse = apperror.Conflict("media asset is used by a post")
+
+type MediaService struct {
+	mediaAssetDao  dao.MediaAssetDao
+	mediaStore     objectstore.MediaObjectStore
+	auditor        Auditor
+	maxUploadBytes int64
+}
+
+func NewMediaService(mediaAssetDao dao.MediaAssetDao, mediaStore objectstore.MediaObjectStore, auditor Auditor, maxUploadBytes int64) *MediaService {
+	return &MediaService{
+		mediaAssetDao:  mediaAssetDao,
+		mediaStore:     mediaStore,
+		auditor:        auditor,
+		maxUploadBytes: maxUploadBytes,
+	}
+}
+
+// CreateMediaUpload registers the asset and returns a presigned PUT the client uses to upload the file itself.
+func (service *MediaService) CreateMediaUpload(ctx context.Context, input *CreateMediaUploadInput) (*MediaUpload, error) {
+	if !AllowedMediaTypes[input.MimeType] {
+		return nil, apperror.Validation(fmt.Sprintf("unsupported media type %q", input.MimeType))
+	}
+	if input.SizeBytes <= 0 || input.SizeBytes > service.maxUploadBytes {
+		return nil, apperror.Validation(fmt.Sprintf("media size must be between 1 and %d bytes", service.maxUploadBytes))
+	}
+	if input.Checksum != "" && !isSHA256Checksum(input.Checksum) {
+		return nil, apperror.Validation("checksum must be the base64-encoded SHA-256 of the file")
+	}
+
+	id := newID()
+	asset := &model.MediaAsset{
+		ID:        id,
+		OwnerID:   input.OwnerID,
+		Key:       media.OriginalKey(id),
+		MimeType:  input.MimeType,
+		SizeBytes: input.SizeBytes,
+		Width:     input.Width,
+		Height:    input.Height,
+		Checksum:  input.Checksum,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	upload, err := service.mediaStore.PresignUpload(ctx, &objectstore.MediaUpload{
+		Key:       asset.Key,
+		MimeType:  asset.MimeType,
+		SizeBytes: asset.SizeBytes,
+		Checksum:  asset.Checksum,
+		ExpiresIn: mediaUploadURLExpiry,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.mediaAssetDao.CreateMediaAsset(ctx, asset); err != nil {
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionMediaCreate, TargetType: audit.TargetMedia, TargetID: asset.ID, After: asset})
+
+	return &MediaUpload{Asset: asset, Upload: upload}, nil
+}
+
+func (service *MediaService) ListMediaAssets(ctx context.Context, ownerID string, limit int, lastEvaluatedKey string) ([]*model.MediaAsset, string, error) {
+	return service.mediaAssetDao.ListMediaAssets(ctx, ownerID, limit, lastEvaluatedKey)
+}
+
+// ListUnusedMediaAssets returns the owner's assets that no post references through AssetIDs.
+func (service *MediaService) ListUnusedMediaAssets(ctx context.Context, ownerID string) ([]*model.MediaAsset, error) {
+	var assets []*model.MediaAsset
+	lastEvaluatedKey := ""
+	for {
+		page, nextKey, err := service.mediaAssetDao.ListMediaAssets(ctx, ownerID, mediaListPageSize, lastEvaluatedKey)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, model.UnusedMediaAssets(page)...)
+		if nextKey == "" {
+			return assets, nil
+		}
+		lastEvaluatedKey = nextKey
+	}
+}
+
+// DeleteMediaAsset refuses assets that posts still reference. The record goes first, so a post
+// can't take up an asset whose files are already gone.
+func (service *MediaService) DeleteMediaAsset(ctx context.Context, ownerID string, id string) error {
+	asset, err := service.mediaAssetDao.GetMediaAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+	// Assets owned by someone else are reported as missing so IDs can't be probed.
+	if asset == nil || asset.OwnerID != ownerID {
+		return ErrMediaAssetNotFound
+	}
+	if len(asset.PostIDs) > 0 {
+		return ErrMediaAssetInUse
+	}
+
+	if err := service.mediaAssetDao.DeleteMediaAsset(ctx, id); err != nil {
+		return err
+	}
+	if err := service.mediaStore.DeleteMedia(ctx, asset.Key); err != nil {
+		return err
+	}
+	for _, variant := range asset.Variants {
+		if err := service.mediaStore.DeleteMedia(ctx, variant.Key); err != nil {
+			return err
+		}
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionMediaDelete, TargetType: audit.TargetMedia, TargetID: id, Before: asset})
+	return nil
+}
+
+func isSHA256Checksum(checksum string) bool {
+	sum, err := base64.StdEncoding.DecodeString(checksum)
+	return err == nil && len(sum) == sha256.Size
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/dao/daotest"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore/objectstoretest"
+	"github.com/stretchr/testify/assert"
+)
+
+// validChecksum is the base64 SHA-256 of an empty file.
+const validChecksum = "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+func setupMediaService() (*MediaService, *daotest.MediaAssetDao, *objectstoretest.MediaStore) {
+	mediaAssetDao := daotest.NewMediaAssetDao()
+	mediaStore := objectstoretest.NewMediaStore()
+	return NewMediaService(mediaAssetDao, mediaStore, nil, 1024), mediaAssetDao, mediaStore
+}
+
+func TestCreateMediaUpload_ValidInput_RegistersAssetAndPresignsUpload(t *testing.T) {
+	service, mediaAssetDao, _ := setupMediaService()
+
+	upload, err := service.CreateMediaUpload(context.Background(), &CreateMediaUploadInput{
+		OwnerID: "user-1", MimeType: "image/png", SizeBytes: 512, Checksum: validChecksum,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "user-1", upload.Asset.OwnerID)
+	assert.Equal(t, "https://media.test/"+upload.Asset.Key, upload.Upload.URL)
+	assert.Equal(t, validChecksum, upload.Upload.SignedHeader.Get("X-Amz-Checksum-Sha256"))
+	assert.Contains(t, mediaAssetDao.Assets, upload.Asset.ID)
+}
+
+func TestCreateMediaUpload_InvalidInput_ReturnsValidationErr(t *testing.T) {
+	service, mediaAssetDao, _ := setupMediaService()
+
+	for name, input := range map[string]CreateMediaUploadInput{
+		"unsupported type":  {MimeType: "application/pdf", SizeBytes: 512},
+		"too large":         {MimeType: "image/png", SizeBytes: 2048},
+		"empty":             {MimeType: "image/png"},
+		"hex checksum":      {MimeType: "image/png", SizeBytes: 512, Checksum: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		"short checksum":    {MimeType: "image/png", SizeBytes: 512, Checksum: "abc="},
+		"not base64":        {MimeType: "image/png", SizeBytes: 512, Checksum: "not a checksum"},
+		"url-safe alphabet": {MimeType: "image/png", SizeBytes: 512, Checksum: "47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU="},
+		"unpadded":          {MimeType: "image/png", SizeBytes: 512, Checksum: "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU"},
+	} {
+		input.OwnerID = "user-1"
+		_, err := service.CreateMediaUpload(context.Background(), &input)
+
+		assert.Equal(t, apperror.KindValidation, apperror.KindOf(err), name)
+	}
+	assert.Empty(t, mediaAssetDao.Assets)
+}
+
+func TestListUnusedMediaAssets_ReturnsOwnersUnreferencedAssetsAcrossPages(t *testing.T) {
+	service, mediaAssetDao, _ := setupMediaService()
+	for i := 0; i < mediaListPageSize+2; i++ {
+		asset := &model.MediaAsset{ID: string(rune('a'+i/26)) + string(rune('a'+i%26)), OwnerID: "user-1"}
+		if i%2 == 0 {
+			asset.PostIDs = []string{"post-1"}
+		}
+		mediaAssetDao.Assets[asset.ID] = asset
+	}
+	mediaAssetDao.Assets["other"] = &model.MediaAsset{ID: "other", OwnerID: "user-2"}
+
+	result, err := service.ListUnusedMediaAssets(context.Background(), "user-1")
+
+	assert.Nil(t, err)
+	assert.Len(t, result, (mediaListPageSize+2)/2)
+	for _, asset := range result {
+		assert.Empty(t, asset.PostIDs)
+		assert.Equal(t, "user-1", asset.OwnerID)
+	}
+}
+
+func TestDeleteMediaAsset_Unused_DeletesRecordOriginalAndVariants(t *testing.T) {
+	service, mediaAssetDao, mediaStore := setupMediaService()
+	mediaAssetDao.Assets["asset-1"] = &model.MediaAsset{
+		ID: "asset-1", OwnerID: "user-1", Key: "media/asset-1/original",
+		Variants: map[string]model.MediaVariant{"thumbnail": {Key: "media/asset-1/variants/thumbnail.jpg"}},
+	}
+	mediaStore.Objects["media/asset-1/original"] = []byte("original")
+	mediaStore.Objects["media/asset-1/variants/thumbnail.jpg"] = []byte("thumbnail")
+
+	err := service.DeleteMediaAsset(context.Background(), "user-1", "asset-1")
+
+	assert.Nil(t, err)
+	assert.Empty(t, mediaAssetDao.Assets)
+	assert.Empty(t, mediaStore.Objects)
+}
+
+func TestDeleteMediaAsset_ReferencedByPost_ReturnsConflictAndKeepsFiles(t *testing.T) {
+	service, mediaAssetDao, mediaStore := setupMediaService()
+	mediaAssetDao.Assets["asset-1"] = &model.MediaAsset{ID: "asset-1", OwnerID: "user-1", Key: "media/asset-1/original", PostIDs: []string{"post-1"}}
+	mediaStore.Objects["media/asset-1/original"] = []byte("original")
+
+	err := service.DeleteMediaAsset(context.Background(), "user-1", "asset-1")
+
+	assert.Equal(t, apperror.KindConflict, apperror.KindOf(err))
+	assert.Contains(t, mediaAssetDao.Assets, "asset-1")
+	assert.Contains(t, mediaStore.Objects, "media/asset-1/original")
+}
+
+func TestDeleteMediaAsset_OtherOwner_ReturnsNotFound(t *testing.T) {
+	service, mediaAssetDao, _ := setupMediaService()
+	mediaAssetDao.Assets["asset-1"] = &model.MediaAsset{ID: "asset-1", OwnerID: "user-2"}
+
+	err := service.DeleteMediaAsset(context.Background(), "user-1", "asset-1")
+
+	assert.Equal(t, ErrMediaAssetNotFound, err)
+	assert.Contains(t, mediaAssetDao.Assets, "asset-1")
+}
+
+func TestPostService_AssetIDs_TrackReferencesOnAssets(t *testing.T) {
+	mediaAssetDao := daotest.NewMediaAssetDao(
+		&model.MediaAsset{ID: "asset-1", OwnerID: "user-1"},
+		&model.MediaAsset{ID: "asset-2", OwnerID: "user-1"},
+	)
+	service := NewPostService(daotest.NewPostMetadataDao(), objectstoretest.NewPostStore(), nil, mediaAssetDao, nil)
+	post := &model.Post{PostMetadata: model.PostMetadata{Title: "Hello", AssetIDs: []string{"asset-1"}}, Body: "Hi"}
+
+	created, err := service.CreatePost(context.Background(), "user-1", post)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{created.ID}, mediaAssetDao.Assets["asset-1"].PostIDs)
+
+	_, err = service.UpdatePost(context.Background(), "user-1", &PostUpdate{ID: created.ID, AssetIDs: &[]string{"asset-2"}})
+	assert.Nil(t, err)
+	assert.Empty(t, mediaAssetDao.Assets["asset-1"].PostIDs)
+	assert.Equal(t, []string{created.ID}, mediaAssetDao.Assets["asset-2"].PostIDs)
+}
+
+func TestCreatePost_UnknownAsset_ReturnsValidationErr(t *testing.T) {
+	postMetadataDao := daotest.NewPostMetadataDao()
+	service := NewPostService(postMetadataDao, objectstoretest.NewPostStore(), nil, daotest.NewMediaAssetDao(), nil)
+	post := &model.Post{PostMetadata: model.PostMetadata{Title: "Hello", AssetIDs: []string{"missing"}}, Body: "Hi"}
+
+	_, err := service.CreatePost(context.Background(), "user-1", post)
+
+	assert.Equal(t, apperror.KindValidation, apperror.KindOf(err))
+	assert.Empty(t, postMetadataDao.Posts)
+}
+
+func TestCreatePost_OtherOwnersAsset_ReturnsValidationErr(t *testing.T) {
+	service, postMetadataDao, _ := setupPostService()
+	mediaAssetDao := daotest.NewMediaAssetDao(&model.MediaAsset{ID: "asset-1", OwnerID: "someone-else"})
+	service.mediaAssetDao = mediaAssetDao
+	post := &model.Post{PostMetadata: model.PostMetadata{Title: "Hello", AssetIDs: []string{"asset-1"}}, Body: "Hi"}
+
+	_, err := service.CreatePost(context.Background(), "author", post)
+
+	assert.Equal(t, apperror.KindValidation, apperror.KindOf(err))
+	assert.Empty(t, mediaAssetDao.Assets["asset-1"].PostIDs)
+	assert.Empty(t, postMetadataDao.Posts)
+}
+
+func TestUpdatePost_EditorUsesOtherOwnersAsset_AddsReference(t *testing.T) {
+	service, _, _ := setupPostService(draftByAuthor())
+	mediaAssetDao := daotest.NewMediaAssetDao(&model.MediaAsset{ID: "asset-1", OwnerID: "author"})
+	service.mediaAssetDao = mediaAssetDao
+
+	_, err := service.UpdatePost(context.Background(), "editor", &PostUpdate{ID: "post-1", AssetIDs: &[]string{"asset-1"}})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"post-1"}, mediaAssetDao.Assets["asset-1"].PostIDs)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/mail"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/newsletter"
+)
+
+var ErrInvalidUnsubscribeToken = apperror.Unauthorized("invalid unsubscribe link")
+
+const (
+	newsletterConfirmationSubject = "Confirm your subscription"
+	newsletterConfirmationBody    = `Someone, hopefully you, asked to receive the blog's newsletter at this address.
+
+Confirm your subscription here:
+
+%s
+
+The link expires at %s. If you didn't ask for this, ignore this email and you won't hear from us again.
+`
+)
+
+type NewsletterOptions struct {
+	// LinkBaseURL is the front end serving /newsletter/confirm, which reads the token from the query
+	// string.
+	LinkBaseURL     string
+	ConfirmationTTL time.Duration
+}
+
+// NewsletterService manages subscriptions with double opt-in: addresses only receive the newsletter
+// once they confirm a mailed link. Mailing posts is newsletter.Sender's job.
+type NewsletterService struct {
+	subscriberDao   dao.SubscriberDao
+	accountTokenDao dao.AccountTokenDao
+	mailer          mail.Mailer
+	secretBox       *auth.SecretBox
+	options         NewsletterOptions
+	now             func() time.Time
+}
+
+func NewNewsletterService(subscriberDao dao.SubscriberDao, accountTokenDao dao.AccountTokenDao, mailer mail.Mailer, secretBox *auth.SecretBox, options NewsletterOptions) *NewsletterService {
+	return &NewsletterService{
+		subscriberDao:   subscriberDao,
+		accountTokenDao: accountTokenDao,
+		mailer:          mailer,
+		secretBox:       secretBox,
+		options:         options,
+		now:             time.Now,
+	}
+}
+
+// Subscribe mails a confirmation link unless the address is already confirmed. It succeeds either
+// way so the endpoint doesn't reveal who is subscribed.
+func (service *NewsletterService) Subscribe(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if err := ValidateEmail(email); err != nil {
+		return err
+	}
+
+	id := newsletter.SubscriberID(email)
+	subscriber, err := service.subscriberDao.GetSubscriber(ctx, id)
+	if err != nil {
+		return err
+	}
+	if subscriber != nil && subscriber.Status == model.SubscriberConfirmed {
+		return nil
+	}
+
+	if subscriber == nil {
+		unsubscribeToken, _, err := auth.NewAccountToken(id)
+		if err != nil {
+			return err
+		}
+		sealed, err := service.secretBox.Seal(unsubscribeToken, id)
+		if err != nil {
+			return err
+		}
+		subscriber = &model.Subscriber{ID: id, Email: email, SealedUnsubscribeToken: sealed, CreatedAt: service.now().UTC()}
+	}
+	subscriber.Status = model.SubscriberPending
+	if err := service.subscriberDao.PutSubscriber(ctx, subscriber); err != nil {
+		return err
+	}
+
+	confirmation, token, err := createAccountToken(ctx, service.accountTokenDao, &model.AccountToken{
+		Purpose: model.PurposeNewsletterConfirmation,
+		Email:   email,
+	}, service.options.ConfirmationTTL, service.now())
+	if err != nil {
+		return err
+	}
+	link := strings.TrimRight(service.options.LinkBaseURL, "/") + "/newsletter/confirm?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf(newsletterConfirmationBody, link, confirmation.ExpiresAt.Format(time.RFC1123))
+	return service.mailer.Send(ctx, mail.Message{To: email, Subject: newsletterConfirmationSubject, Body: body})
+}
+
+// Confirm uses up a confirmation link and starts sending the newsletter to its address.
+func (service *NewsletterService) Confirm(ctx context.Context, token string) error {
+	confirmation, hash, err := verifyAccountToken(ctx, service.accountTokenDao, model.PurposeNewsletterConfirmation, token, service.now())
+	if err != nil {
+		return err
+	}
+	subscriber, err := service.subscriberDao.GetSubscriber(ctx, newsletter.SubscriberID(confirmation.Email))
+	if err != nil {
+		return err
+	}
+	if subscriber == nil {
+		return ErrInvalidAccountToken
+	}
+	if err := useAccountToken(ctx, service.accountTokenDao, confirmation, hash, service.now()); err != nil {
+		return err
+	}
+
+	subscriber.Status = model.SubscriberConfirmed
+	subscriber.ConfirmedAt = service.now().UTC()
+	return service.subscriberDao.PutSubscriber(ctx, subscriber)
+}
+
+// Unsubscribe stops the newsletter for the subscriber the token in every newsletter belongs to.
+// Tokens keep working, so unsubscribing twice succeeds.
+func (service *NewsletterService) Unsubscribe(ctx context.Context, token string) error {
+	id, _, err := auth.ParseAccountToken(token)
+	if err != nil {
+		return ErrInvalidUnsubscribeToken
+	}
+	subscriber, err := service.subscriberDao.GetSubscriber(ctx, id)
+	if err != nil {
+		return err
+	}
+	if subscriber == nil {
+		return ErrInvalidUnsubscribeToken
+	}
+	expected, err := service.secretBox.Open(subscriber.SealedUnsubscribeToken, subscriber.ID)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(token)) != 1 {
+		return ErrInvalidUnsubscribeToken
+	}
+	if subscriber.Status == model.SubscriberUnsubscribed {
+		return nil
+	}
+
+	subscriber.Status = model.SubscriberUnsubscribed
+	subscriber.UnsubscribedAt = service.now().UTC()
+	return service.subscriberDao.PutSubscriber(ctx, subscriber)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/newsletter"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSubscriberDao struct {
+	subscribers map[string]model.Subscriber
+}
+
+func (f *fakeSubscriberDao) GetSubscriber(ctx context.Context, id string) (*model.Subscriber, error) {
+	subscriber, ok := f.subscribers[id]
+	if !ok {
+		return nil, nil
+	}
+	return &subscriber, nil
+}
+
+func (f *fakeSubscriberDao) PutSubscriber(ctx context.Context, subscriber *model.Subscriber) error {
+	f.subscribers[subscriber.ID] = *subscriber
+	return nil
+}
+
+func (f *fakeSubscriberDao) ListConfirmedSubscribers(ctx context.Context, limit int, afterID string) ([]*model.Subscriber, string, error) {
+	return nil, "", nil
+}
+
+type newsletterFixture struct {
+	service     *NewsletterService
+	subscribers *fakeSubscriberDao
+	mailer      *MockMailer
+	secretBox   *auth.SecretBox
+}
+
+func setupNewsletterService(t *testing.T) *newsletterFixture {
+	t.Helper()
+	secretBox, err := auth.NewSecretBox("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	if err != nil {
+		t.Fatalf("secret box: %v", err)
+	}
+	fixture := &newsletterFixture{
+		subscribers: &fakeSubscriberDao{subscribers: map[string]model.Subscriber{}},
+		mailer:      &MockMailer{},
+		secretBox:   secretBox,
+	}
+	fixture.service = NewNewsletterService(fixture.subscribers, &fakeAccountTokenDao{tokens: map[string]model.AccountToken{}}, fixture.mailer, secretBox, NewsletterOptions{
+		LinkBaseURL:     "https://blog.example.com",
+		ConfirmationTTL: 48 * time.Hour,
+	})
+	fixture.service.now = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+	return fixture
+}
+
+func (fixture *newsletterFixture) subscriber(email string) model.Subscriber {
+	return fixture.subscribers.subscribers[newsletter.SubscriberID(email)]
+}
+
+func (fixture *newsletterFixture) unsubscribeToken(t *testing.T, email string) string {
+	t.Helper()
+	subscriber := fixture.subscriber(email)
+	token, err := fixture.secretBox.Open(subscriber.SealedUnsubscribeToken, subscriber.ID)
+	if err != nil {
+		t.Fatalf("open unsubscribe token: %v", err)
+	}
+	return token
+}
+
+func TestSubscribe_ThenConfirm_ConfirmsSubscriber(t *testing.T) {
+	fixture := setupNewsletterService(t)
+
+	err := fixture.service.Subscribe(context.Background(), " Alice@Example.com ")
+	assert.Nil(t, err)
+	assert.Equal(t, model.SubscriberPending, fixture.subscriber("alice@example.com").Status)
+	assert.Equal(t, "alice@example.com", fixture.mailer.messages[0].To)
+
+	token := linkToken(t, fixture.mailer)
+	err = fixture.service.Confirm(context.Background(), token)
+	reuseErr := fixture.service.Confirm(context.Background(), token)
+
+	assert.Nil(t, err)
+	assert.Equal(t, ErrInvalidAccountToken, reuseErr)
+	subscriber := fixture.subscriber("alice@example.com")
+	assert.Equal(t, model.SubscriberConfirmed, subscriber.Status)
+	assert.Equal(t, time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC), subscriber.ConfirmedAt)
+}
+
+func TestSubscribe_AlreadyConfirmed_SendsNoMail(t *testing.T) {
+	fixture := setupNewsletterService(t)
+	_ = fixture.service.Subscribe(context.Background(), "alice@example.com")
+	_ = fixture.service.Confirm(context.Background(), linkToken(t, fixture.mailer))
+
+	err := fixture.service.Subscribe(context.Background(), "alice@example.com")
+
+	assert.Nil(t, err)
+	assert.Len(t, fixture.mailer.messages, 1)
+	assert.Equal(t, model.SubscriberConfirmed, fixture.subscriber("alice@example.com").Status)
+}
+
+func TestUnsubscribe_ValidToken_IsIdempotent(t *testing.T) {
+	fixture := setupNewsletterService(t)
+	_ = fixture.service.Subscribe(context.Background(), "alice@example.com")
+	_ = fixture.service.Confirm(context.Background(), linkToken(t, fixture.mailer))
+	token := fixture.unsubscribeToken(t, "alice@example.com")
+
+	err := fixture.service.Unsubscribe(context.Background(), token)
+	againErr := fixture.service.Unsubscribe(context.Background(), token)
+
+	assert.Nil(t, err)
+	assert.Nil(t, againErr)
+	assert.Equal(t, model.SubscriberUnsubscribed, fixture.subscriber("alice@example.com").Status)
+}
+
+func TestUnsubscribe_ForgedToken_ReturnsErr(t *testing.T) {
+	fixture := setupNewsletterService(t)
+	_ = fixture.service.Subscribe(context.Background(), "alice@example.com")
+	forged, _, err := auth.NewAccountToken(newsletter.SubscriberID("alice@example.com"))
+	assert.Nil(t, err)
+
+	forgedErr := fixture.service.Unsubscribe(context.Background(), forged)
+	garbageErr := fixture.service.Unsubscribe(context.Background(), "not-a-token")
+
+	assert.Equal(t, ErrInvalidUnsubscribeToken, forgedErr)
+	assert.Equal(t, ErrInvalidUnsubscribeToken, garbageErr)
+	assert.Equal(t, model.SubscriberPending, fixture.subscriber("alice@example.com").Status)
+}
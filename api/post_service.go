@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+const previewTextLength = 280
+
+var (
+	ErrPostNotFound      = apperror.NotFound("post not found")
+	ErrPostEditForbidden = apperror.Forbidden("only the post's author or an editor can change it")
+)
+
+type PostService struct {
+	postMetadataDao dao.PostMetadataDao
+	postStore       objectstore.PostObjectStore
+	// userDao looks up callers' roles; without it only authors can edit their own posts.
+	userDao dao.UserDao
+	// mediaAssetDao is nil when media is disabled; asset references aren't tracked then.
+	mediaAssetDao dao.MediaAssetDao
+	auditor       Auditor
+}
+
+func NewPostService(postMetadataDao dao.PostMetadataDao, postStore objectstore.PostObjectStore, userDao dao.UserDao, mediaAssetDao dao.MediaAssetDao, auditor Auditor) *PostService {
+	return &PostService{
+		postMetadataDao: postMetadataDao,
+		postStore:       postStore,
+		userDao:         userDao,
+		mediaAssetDao:   mediaAssetDao,
+		auditor:         auditor,
+	}
+}
+
+// postEditor decides which posts a caller may change and which drafts they may see. Editors and
+// admins may change any post; everyone else only the posts they wrote.
+type postEditor struct {
+	callerID string
+	editsAll bool
+}
+
+func (service *PostService) postEditor(ctx context.Context, callerID string) (*postEditor, error) {
+	editor := &postEditor{callerID: callerID}
+	if callerID == "" || service.userDao == nil {
+		return editor, nil
+	}
+	caller, err := service.userDao.GetUserByID(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if caller != nil {
+		role := caller.EffectiveRole()
+		editor.editsAll = role == model.RoleEditor || role == model.RoleAdmin
+	}
+	return editor, nil
+}
+
+func (editor *postEditor) canEdit(post *model.PostMetadata) bool {
+	if editor.callerID == "" {
+		return false
+	}
+	return editor.editsAll || post.AuthorID == editor.callerID
+}
+
+// canRead hides drafts from everyone who can't edit them.
+func (editor *postEditor) canRead(post *model.PostMetadata) bool {
+	return post.Status == model.Posted || editor.canEdit(post)
+}
+
+// recordPostChange audits a created or updated post, as a publication when it went live. Only the
+// metadata is diffed; body changes show in the preview text.
+func (service *PostService) recordPostChange(ctx context.Context, before *model.PostMetadata, after *model.PostMetadata) {
+	action := audit.ActionPostUpdate
+	if before == nil {
+		action = audit.ActionPostCreate
+	}
+	if after.Status == model.Posted && (before == nil || before.Status != model.Posted) {
+		action = audit.ActionPostPublish
+	}
+	record(ctx, service.auditor, audit.Entry{Action: action, TargetType: audit.TargetPost, TargetID: after.ID, Before: before, After: after})
+}
+
+// assetOwner is whose media the caller may add to posts: anyone's for editors and admins, otherwise
+// only their own.
+func (editor *postEditor) assetOwner() string {
+	if editor.editsAll {
+		return ""
+	}
+	return editor.callerID
+}
+
+// addAssetReferences records the post on each asset it newly uses, so assets in use can't be
+// deleted. It runs before the post is saved and removeAssetReferences after, so a failed save
+// leaves an extra reference, which only keeps an asset around, and never a missing one. Assets the
+// editor may not use are reported as unknown, like assets that don't exist.
+func (service *PostService) addAssetReferences(ctx context.Context, editor *postEditor, postID string, before []string, after []string) error {
+	if service.mediaAssetDao == nil {
+		return nil
+	}
+	for _, assetID := range missingFrom(after, before) {
+		if editor.callerID == "" {
+			return apperror.Validation(fmt.Sprintf("unknown media asset %q", assetID))
+		}
+		err := service.mediaAssetDao.AddMediaAssetReference(ctx, assetID, postID, editor.assetOwner())
+		if apperror.KindOf(err) == apperror.KindNotFound {
+			return apperror.Validation(fmt.Sprintf("unknown media asset %q", assetID))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (service *PostService) removeAssetReferences(ctx context.Context, postID string, before []string, after []string) error {
+	if service.mediaAssetDao == nil {
+		return nil
+	}
+	for _, assetID := range missingFrom(before, after) {
+		if err := service.mediaAssetDao.RemoveMediaAssetReference(ctx, assetID, postID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// missingFrom returns the IDs that aren't in others.
+func missingFrom(ids []string, others []string) []string {
+	var result []string
+	for _, id := range ids {
+		if !slices.Contains(others, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func postBodyLocation(id string) string {
+	return fmt.Sprintf("posts/%s/body.md", id)
+}
+
+// postBodyRevisionLocation is where an edited body is written; each edit gets a new revision.
+func postBodyRevisionLocation(id string, revision string) string {
+	return fmt.Sprintf("posts/%s/revisions/%s.md", id, revision)
+}
+
+func previewText(body string) string {
+	runes := []rune(body)
+	if len(runes) <= previewTextLength {
+		return body
+	}
+	return string(runes[:previewTextLength])
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/dao/daotest"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore/objectstoretest"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupPostService(posts ...*model.Post) (*PostService, *daotest.PostMetadataDao, *objectstoretest.PostStore) {
+	postMetadataDao := daotest.NewPostMetadataDao()
+	postStore := objectstoretest.NewPostStore()
+	for _, post := range posts {
+		metadata := post.PostMetadata
+		metadata.BodyUrl = postBodyLocation(post.ID)
+		postMetadataDao.Posts[post.ID] = &metadata
+		postStore.Bodies[metadata.BodyUrl] = post.Body
+	}
+	userDao := &MockUserDao{GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+		switch id {
+		case "editor":
+			return &model.User{ID: id, Role: model.RoleEditor}, nil
+		case "":
+			return nil, nil
+		default:
+			return &model.User{ID: id}, nil
+		}
+	}}
+	return NewPostService(postMetadataDao, postStore, userDao, nil, nil), postMetadataDao, postStore
+}
+
+func draftByAuthor() *model.Post {
+	return &model.Post{
+		PostMetadata: model.PostMetadata{
+			ID: "post-1", Title: "Draft", Status: model.Draft, AuthorID: "author", Version: 3,
+			PreviewText: "Custom preview", Tags: []model.Tag{{ID: "tag-1", Label: "go"}},
+		},
+		Body: "Original body",
+	}
+}
+
+func TestCreatePost_RecordsAuthorAndFirstVersion(t *testing.T) {
+	service, postMetadataDao, _ := setupPostService()
+
+	created, err := service.CreatePost(context.Background(), "author", &model.Post{PostMetadata: model.PostMetadata{Title: "Hello", Version: 7}, Body: "Hi"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "author", postMetadataDao.Posts[created.ID].AuthorID)
+	assert.Equal(t, 1, postMetadataDao.Posts[created.ID].Version)
+}
+
+func TestUpdatePost_PartialUpdate_KeepsOmittedFields(t *testing.T) {
+	service, postMetadataDao, postStore := setupPostService(draftByAuthor())
+	title := "New title"
+
+	updated, err := service.UpdatePost(context.Background(), "author", &PostUpdate{ID: "post-1", Title: &title})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "New title", updated.Title)
+	assert.Equal(t, "Original body", updated.Body)
+	assert.Equal(t, "Custom preview", updated.PreviewText)
+	assert.Equal(t, []model.Tag{{ID: "tag-1", Label: "go"}}, postMetadataDao.Posts["post-1"].Tags)
+	assert.Equal(t, model.Draft, postMetadataDao.Posts["post-1"].Status)
+	assert.Equal(t, 4, postMetadataDao.Posts["post-1"].Version)
+	assert.Equal(t, "Original body", postStore.Bodies[postBodyLocation("post-1")])
+}
+
+func TestUpdatePost_NewBody_RegeneratesDerivedPreviewOnly(t *testing.T) {
+	derived := draftByAuthor()
+	derived.PreviewText = previewText(derived.Body)
+	service, _, postStore := setupPostService(derived)
+	body := "Rewritten body"
+
+	updated, err := service.UpdatePost(context.Background(), "author", &PostUpdate{ID: "post-1", Body: &body})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Rewritten body", updated.PreviewText)
+	assert.Equal(t, "Rewritten body", postStore.Bodies[updated.BodyUrl])
+
+	service, _, _ = setupPostService(draftByAuthor())
+	updated, err = service.UpdatePost(context.Background(), "author", &PostUpdate{ID: "post-1", Body: &body})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Custom preview", updated.PreviewText)
+}
+
+func TestUpdatePost_EditorMayEditOthersPosts(t *testing.T) {
+	service, _, _ := setupPostService(draftByAuthor())
+	title := "Edited"
+
+	updated, err := service.UpdatePost(context.Background(), "editor", &PostUpdate{ID: "post-1", Title: &title})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Edited", updated.Title)
+	assert.Equal(t, "author", updated.AuthorID)
+}
+
+func TestUpdatePost_OtherAuthor_IsRejected(t *testing.T) {
+	published := draftByAuthor()
+	published.Status = model.Posted
+	service, postMetadataDao, _ := setupPostService(published)
+	title := "Defaced"
+
+	_, err := service.UpdatePost(context.Background(), "someone-else", &PostUpdate{ID: "post-1", Title: &title})
+	assert.Equal(t, ErrPostEditForbidden, err)
+
+	postMetadataDao.Posts["post-1"].Status = model.Draft
+	_, err = service.UpdatePost(context.Background(), "someone-else", &PostUpdate{ID: "post-1", Title: &title})
+	assert.Equal(t, ErrPostNotFound, err)
+	assert.Equal(t, "Draft", postMetadataDao.Posts["post-1"].Title)
+}
+
+func TestUpdatePost_StaleVersion_ReturnsConflict(t *testing.T) {
+	service, postMetadataDao, _ := setupPostService(draftByAuthor())
+	title := "Stale edit"
+
+	_, err := service.UpdatePost(context.Background(), "author", &PostUpdate{ID: "post-1", Version: 2, Title: &title})
+
+	assert.Equal(t, apperror.KindConflict, apperror.KindOf(err))
+	assert.Equal(t, "Draft", postMetadataDao.Posts["post-1"].Title)
+}
+
+// racingPostMetadataDao lets another edit win between UpdatePost's read and its conditional write.
+type racingPostMetadataDao struct {
+	*daotest.PostMetadataDao
+	winner func()
+}
+
+func (dao *racingPostMetadataDao) UpdatePostMetadata(ctx context.Context, postMetadata *model.PostMetadata) (*model.PostMetadata, error) {
+	dao.winner()
+	return dao.PostMetadataDao.UpdatePostMetadata(ctx, postMetadata)
+}
+
+func TestUpdatePost_LosesRace_LeavesWinnersBody(t *testing.T) {
+	_, postMetadataDao, postStore := setupPostService(draftByAuthor())
+	racing := &racingPostMetadataDao{PostMetadataDao: postMetadataDao, winner: func() {
+		postMetadataDao.Posts["post-1"].Version++
+		postMetadataDao.Posts["post-1"].BodyUrl = "posts/post-1/revisions/winner.md"
+		postStore.Bodies["posts/post-1/revisions/winner.md"] = "Winning body"
+	}}
+	service := NewPostService(racing, postStore, &MockUserDao{GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+		return &model.User{ID: id}, nil
+	}}, nil, nil)
+	body := "Losing body"
+
+	_, err := service.UpdatePost(context.Background(), "author", &PostUpdate{ID: "post-1", Body: &body})
+
+	assert.Equal(t, apperror.KindConflict, apperror.KindOf(err))
+	assert.Equal(t, "Winning body", postStore.Bodies[postMetadataDao.Posts["post-1"].BodyUrl])
+	assert.Equal(t, "Original body", postStore.Bodies[postBodyLocation("post-1")])
+	for _, stored := range postStore.Bodies {
+		assert.NotEqual(t, "Losing body", stored)
+	}
+}
+
+func TestReadPost_Draft_VisibleOnlyToEditors(t *testing.T) {
+	service, _, _ := setupPostService(draftByAuthor())
+
+	for callerID, visible := range map[string]bool{"": false, "someone-else": false, "author": true, "editor": true} {
+		post, err := service.ReadPost(context.Background(), callerID, "post-1")
+
+		if visible {
+			assert.Nil(t, err, callerID)
+			assert.Equal(t, "Original body", post.Body, callerID)
+		} else {
+			assert.Equal(t, ErrPostNotFound, err, callerID)
+		}
+	}
+}
+
+func TestListPosts_Anonymous_LeavesOutDrafts(t *testing.T) {
+	published := draftByAuthor()
+	published.ID = "post-2"
+	published.Status = model.Posted
+	service, _, _ := setupPostService(draftByAuthor(), published)
+
+	posts, _, err := service.ListPosts(context.Background(), "", 10, "")
+	assert.Nil(t, err)
+	assert.Len(t, posts, 1)
+	assert.Equal(t, "post-2", posts[0].ID)
+
+	posts, _, err = service.ListPosts(context.Background(), "author", 10, "")
+	assert.Nil(t, err)
+	assert.Len(t, posts, 2)
+}
@@ -1,7 +1,34 @@
 package api
 
-import "github.com/neuralcoral/BlogService/model"
+import (
+	"context"
 
-func ReadPost(id string) (*model.Post, error) {
-	return nil, nil
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// ReadPost returns a draft only to callers who can edit it; to everyone else it doesn't exist.
+func (service *PostService) ReadPost(ctx context.Context, callerID string, id string) (*model.Post, error) {
+	metadata, err := service.postMetadataDao.GetPostMetadata(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if metadata == nil {
+		return nil, ErrPostNotFound
+	}
+	if metadata.Status != model.Posted {
+		editor, err := service.postEditor(ctx, callerID)
+		if err != nil {
+			return nil, err
+		}
+		if !editor.canRead(metadata) {
+			return nil, ErrPostNotFound
+		}
+	}
+
+	body, err := service.postStore.GetPost(ctx, metadata.BodyUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Post{PostMetadata: *metadata, Body: body}, nil
 }
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+var (
+	ErrInvalidRefreshToken = apperror.Unauthorized("invalid or expired refresh token")
+	// ErrRefreshTokenReused means a rotated-out token came back, so it was probably stolen. The whole
+	// session is revoked, logging out both the thief and the owner.
+	ErrRefreshTokenReused = apperror.Unauthorized("refresh token was already used; the session has been revoked")
+	ErrSessionNotFound    = apperror.NotFound("session not found")
+)
+
+func (service *LoginService) startSession(ctx context.Context, userID string, twoFactorPending bool) (*LoginResult, error) {
+	sessionID := newID()
+	refreshToken, hash, err := auth.NewRefreshToken(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	session := &model.Session{
+		ID:               sessionID,
+		UserID:           userID,
+		RefreshTokenHash: hash,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(service.refreshTokenTTL),
+		DeleteAfter:      now.Add(service.refreshTokenTTL).Unix(),
+		TwoFactorPending: twoFactorPending,
+	}
+	if err := service.sessionDao.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return service.issue(session, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token. Every refresh
+// token works once; the session's expiry slides forward with each use.
+func (service *LoginService) Refresh(ctx context.Context, refreshToken string) (*LoginResult, error) {
+	sessionID, hash, err := auth.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := service.sessionDao.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || !session.Active(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if !auth.SecretHashesEqual(session.RefreshTokenHash, hash) {
+		return nil, service.revokeReusedSession(ctx, session.ID)
+	}
+
+	newRefreshToken, newHash, err := auth.NewRefreshToken(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().UTC().Add(service.refreshTokenTTL)
+	err = service.sessionDao.RotateRefreshToken(ctx, session.ID, hash, newHash, expiresAt)
+	if apperror.KindOf(err) == apperror.KindConflict {
+		// Another request rotated the same token first.
+		return nil, service.revokeReusedSession(ctx, session.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session.ExpiresAt = expiresAt
+	return service.issue(session, newRefreshToken)
+}
+
+// Logout revokes one of the caller's sessions.
+func (service *LoginService) Logout(ctx context.Context, userID string, sessionID string) error {
+	session, err := service.sessionDao.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	if err := service.sessionDao.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionLogout, TargetType: audit.TargetUser, TargetID: userID})
+	return nil
+}
+
+// LogoutAll revokes every session of the user, on every device.
+func (service *LoginService) LogoutAll(ctx context.Context, userID string) error {
+	ids, err := service.sessionDao.ListSessionIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := service.sessionDao.RevokeSession(ctx, id); err != nil && apperror.KindOf(err) != apperror.KindNotFound {
+			return err
+		}
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionLogoutAll, TargetType: audit.TargetUser, TargetID: userID})
+	return nil
+}
+
+// SessionActive lets the router reject access tokens whose session was revoked or expired.
+func (service *LoginService) SessionActive(ctx context.Context, sessionID string) (bool, error) {
+	session, err := service.sessionDao.GetSession(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	return session != nil && session.Active(time.Now()), nil
+}
+
+func (service *LoginService) revokeReusedSession(ctx context.Context, sessionID string) error {
+	if err := service.sessionDao.RevokeSession(ctx, sessionID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func (service *LoginService) issue(session *model.Session, refreshToken string) (*LoginResult, error) {
+	token, expiresAt, err := service.tokenIssuer.IssueForSession(session.UserID, session.ID, session.TwoFactorPending)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{
+		AccessToken:                token,
+		ExpiresAt:                  expiresAt,
+		RefreshToken:               refreshToken,
+		RefreshExpiresAt:           session.ExpiresAt,
+		TwoFactorEnrolmentRequired: session.TwoFactorPending,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSessionDao keeps sessions in memory and enforces the same conditions as the DynamoDB DAO.
+type fakeSessionDao struct {
+	sessions map[string]model.Session
+}
+
+func newFakeSessionDao() *fakeSessionDao {
+	return &fakeSessionDao{sessions: map[string]model.Session{}}
+}
+
+func (f *fakeSessionDao) CreateSession(ctx context.Context, session *model.Session) error {
+	f.sessions[session.ID] = *session
+	return nil
+}
+
+func (f *fakeSessionDao) GetSession(ctx context.Context, id string) (*model.Session, error) {
+	session, ok := f.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func (f *fakeSessionDao) RotateRefreshToken(ctx context.Context, id string, previousHash string, newHash string, expiresAt time.Time) error {
+	session := f.sessions[id]
+	if session.RefreshTokenHash != previousHash || session.Revoked {
+		return apperror.Conflict("refresh token already rotated")
+	}
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = expiresAt
+	f.sessions[id] = session
+	return nil
+}
+
+func (f *fakeSessionDao) RevokeSession(ctx context.Context, id string) error {
+	session, ok := f.sessions[id]
+	if !ok {
+		return apperror.NotFound("session not found")
+	}
+	session.Revoked = true
+	f.sessions[id] = session
+	return nil
+}
+
+func (f *fakeSessionDao) CompleteTwoFactor(ctx context.Context, id string) error {
+	session, ok := f.sessions[id]
+	if !ok {
+		return apperror.NotFound("session not found")
+	}
+	session.TwoFactorPending = false
+	f.sessions[id] = session
+	return nil
+}
+
+func (f *fakeSessionDao) ListSessionIDs(ctx context.Context, userID string) ([]string, error) {
+	var ids []string
+	for id, session := range f.sessions {
+		if session.UserID == userID {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	sut := setupLoginService(t, nil)
+	login, _ := sut.Login(context.Background(), "alice", testPassword)
+
+	refreshed, err := sut.Refresh(context.Background(), login.RefreshToken)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, login.RefreshToken, refreshed.RefreshToken)
+	assert.NotEmpty(t, refreshed.AccessToken)
+	claims, err := sut.tokenIssuer.Verify(refreshed.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.NotEmpty(t, claims.SessionID)
+}
+
+func TestRefresh_ReusedToken_RevokesSession(t *testing.T) {
+	sut := setupLoginService(t, nil)
+	login, _ := sut.Login(context.Background(), "alice", testPassword)
+	refreshed, _ := sut.Refresh(context.Background(), login.RefreshToken)
+
+	_, reuseErr := sut.Refresh(context.Background(), login.RefreshToken)
+	_, latestErr := sut.Refresh(context.Background(), refreshed.RefreshToken)
+
+	assert.Equal(t, ErrRefreshTokenReused, reuseErr)
+	assert.Equal(t, ErrInvalidRefreshToken, latestErr, "the thief's rotation must not outlive the revocation")
+}
+
+func TestRefresh_MalformedOrUnknownToken_ReturnsInvalid(t *testing.T) {
+	sut := setupLoginService(t, nil)
+
+	_, malformed := sut.Refresh(context.Background(), "garbage")
+	_, unknown := sut.Refresh(context.Background(), "missing.secret")
+
+	assert.Equal(t, ErrInvalidRefreshToken, malformed)
+	assert.Equal(t, ErrInvalidRefreshToken, unknown)
+}
+
+func TestLogoutAll_RevokesEverySession(t *testing.T) {
+	sut := setupLoginService(t, nil)
+	first, _ := sut.Login(context.Background(), "alice", testPassword)
+	second, _ := sut.Login(context.Background(), "alice", testPassword)
+	firstClaims, _ := sut.tokenIssuer.Verify(first.AccessToken)
+	secondClaims, _ := sut.tokenIssuer.Verify(second.AccessToken)
+
+	err := sut.LogoutAll(context.Background(), "user-1")
+
+	assert.NoError(t, err)
+	for _, sessionID := range []string{firstClaims.SessionID, secondClaims.SessionID} {
+		active, err := sut.SessionActive(context.Background(), sessionID)
+		assert.NoError(t, err)
+		assert.False(t, active)
+	}
+}
+
+func TestLogout_OtherUsersSession_ReturnsNotFound(t *testing.T) {
+	sut := setupLoginService(t, nil)
+	login, _ := sut.Login(context.Background(), "alice", testPassword)
+	claims, _ := sut.tokenIssuer.Verify(login.AccessToken)
+
+	err := sut.Logout(context.Background(), "user-2", claims.SessionID)
+
+	assert.Equal(t, ErrSessionNotFound, err)
+	active, _ := sut.SessionActive(context.Background(), claims.SessionID)
+	assert.True(t, active)
+}
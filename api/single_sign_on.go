@@ -0,0 +1,322 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/oidc"
+)
+
+const singleSignOnStateTTL = 10 * time.Minute
+
+var (
+	ErrInvalidSingleSignOnState  = apperror.Unauthorized("single sign-on login is invalid or has expired; start again")
+	ErrSingleSignOnUsernameTaken = apperror.Conflict("another account already has your identity provider username; ask an administrator to link them")
+	ErrSingleSignOnNotAllowed    = apperror.Forbidden("your identity provider account is not in a group allowed to use the blog")
+	ErrSingleSignOnLinkRequired  = apperror.Conflict("an account with your email address already exists; sign in to it and link your identity provider account from there")
+	ErrSingleSignOnIdentityInUse = apperror.Conflict("your identity provider account is already linked to another account")
+)
+
+var disallowedUsernameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// roleRank orders roles so a user in several mapped groups gets the most privileged role.
+var roleRank = map[model.Role]int{model.RoleAuthor: 1, model.RoleEditor: 2, model.RoleAdmin: 3}
+
+// IdentityProvider is the OpenID Connect provider users log in with.
+type IdentityProvider interface {
+	AuthorizationURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*oidc.Identity, error)
+}
+
+type SingleSignOnOptions struct {
+	// GroupsClaim names the ID token claim listing the user's groups.
+	GroupsClaim  string
+	RoleMappings map[string]model.Role
+	// DefaultRole is given to users in no mapped group; when empty, they can't log in.
+	DefaultRole model.Role
+}
+
+type SingleSignOnStart struct {
+	AuthorizationURL string
+	// State comes back from the provider with the code and must be passed to Complete.
+	State     string
+	ExpiresAt time.Time
+}
+
+// SingleSignOnService logs users in through an identity provider with the authorization code flow
+// and PKCE, creating blog accounts on first login, and issues the same sessions as password logins.
+type SingleSignOnService struct {
+	loginService    *LoginService
+	provider        IdentityProvider
+	userDao         dao.UserDao
+	accountTokenDao dao.AccountTokenDao
+	auditor         Auditor
+	options         SingleSignOnOptions
+	now             func() time.Time
+}
+
+func NewSingleSignOnService(loginService *LoginService, provider IdentityProvider, userDao dao.UserDao, accountTokenDao dao.AccountTokenDao, auditor Auditor, options SingleSignOnOptions) *SingleSignOnService {
+	return &SingleSignOnService{
+		loginService:    loginService,
+		provider:        provider,
+		userDao:         userDao,
+		accountTokenDao: accountTokenDao,
+		auditor:         auditor,
+		options:         options,
+		now:             time.Now,
+	}
+}
+
+// ParseRoleMappings reads mappings written as "group=ROLE".
+func ParseRoleMappings(mappings []string) (map[string]model.Role, error) {
+	result := map[string]model.Role{}
+	for _, mapping := range mappings {
+		group, role, found := strings.Cut(mapping, "=")
+		if !found || group == "" {
+			return nil, fmt.Errorf("role mapping %q is not group=ROLE", mapping)
+		}
+		if _, known := roleRank[model.Role(role)]; !known {
+			return nil, fmt.Errorf("role mapping %q has unknown role %q", mapping, role)
+		}
+		result[group] = model.Role(role)
+	}
+	return result, nil
+}
+
+// Start records a new login attempt and returns the provider URL to send the browser to. When
+// linkUserID is set, the attempt links the identity to that signed-in user instead of finding or
+// creating one.
+func (service *SingleSignOnService) Start(ctx context.Context, linkUserID string) (*SingleSignOnStart, error) {
+	id := newID()
+	state, hash, err := auth.NewAccountToken(id)
+	if err != nil {
+		return nil, err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return nil, err
+	}
+
+	now := service.now().UTC()
+	attempt := &model.AccountToken{
+		ID:           id,
+		Purpose:      model.PurposeSingleSignOn,
+		SecretHash:   hash,
+		UserID:       linkUserID,
+		Nonce:        newID(),
+		CodeVerifier: verifier,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(singleSignOnStateTTL),
+		DeleteAfter:  now.Add(singleSignOnStateTTL).Unix(),
+	}
+	if err := service.accountTokenDao.CreateAccountToken(ctx, attempt); err != nil {
+		return nil, err
+	}
+
+	authorizationURL, err := service.provider.AuthorizationURL(ctx, state, attempt.Nonce, challenge)
+	if err != nil {
+		return nil, err
+	}
+	return &SingleSignOnStart{AuthorizationURL: authorizationURL, State: state, ExpiresAt: attempt.ExpiresAt}, nil
+}
+
+// Complete redeems the code the provider redirected back with and logs the user in. Users who
+// enabled two-factor authentication still get a challenge; the provider's own second factor only
+// spares everyone else from having to enrol.
+func (service *SingleSignOnService) Complete(ctx context.Context, state string, code string) (*LoginResult, error) {
+	attempt, err := service.useState(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := service.provider.Exchange(ctx, code, attempt.CodeVerifier, attempt.Nonce)
+	if err != nil {
+		return nil, apperror.Wrap(apperror.KindUnauthorized, "the identity provider did not confirm the login", err)
+	}
+	role, allowed := service.role(identity)
+	if !allowed {
+		return nil, ErrSingleSignOnNotAllowed
+	}
+
+	var user *model.User
+	if attempt.UserID != "" {
+		user, err = service.linkUser(ctx, identity, attempt.UserID)
+	} else {
+		user, err = service.resolveUser(ctx, identity, role)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return service.loginService.completeFirstFactor(ctx, user)
+}
+
+func (service *SingleSignOnService) useState(ctx context.Context, state string) (*model.AccountToken, error) {
+	id, hash, err := auth.ParseAccountToken(state)
+	if err != nil {
+		return nil, ErrInvalidSingleSignOnState
+	}
+	attempt, err := service.accountTokenDao.GetAccountToken(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if attempt == nil || attempt.Purpose != model.PurposeSingleSignOn || !auth.SecretHashesEqual(attempt.SecretHash, hash) || !attempt.Usable(service.now()) {
+		return nil, ErrInvalidSingleSignOnState
+	}
+
+	err = service.accountTokenDao.UseAccountToken(ctx, attempt.ID, attempt.Purpose, hash, service.now().UTC())
+	if apperror.KindOf(err) == apperror.KindConflict {
+		return nil, ErrInvalidSingleSignOnState
+	}
+	return attempt, err
+}
+
+func (service *SingleSignOnService) role(identity *oidc.Identity) (model.Role, bool) {
+	role := service.options.DefaultRole
+	for _, group := range identity.Strings(service.options.GroupsClaim) {
+		if mapped, ok := service.options.RoleMappings[group]; ok && roleRank[mapped] > roleRank[role] {
+			role = mapped
+		}
+	}
+	return role, role != ""
+}
+
+// resolveUser finds the user linked to the identity or creates one. An existing user with the same
+// verified email is linked only if nothing but the identity provider could sign in to it; any other
+// account must be linked by its owner.
+func (service *SingleSignOnService) resolveUser(ctx context.Context, identity *oidc.Identity, role model.Role) (*model.User, error) {
+	externalSubject := identitySubject(identity)
+	user, err := service.userDao.GetUserByExternalSubject(ctx, externalSubject)
+	if err != nil {
+		return nil, err
+	}
+	email := normalizeEmail(identity.Email)
+	if user == nil && identity.EmailVerified && email != "" {
+		user, err = service.userDao.GetUserByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && !singleSignOnOnly(user) {
+			return nil, ErrSingleSignOnLinkRequired
+		}
+	}
+
+	if user != nil {
+		if err := service.updateIdentity(ctx, user, externalSubject, role); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	username := singleSignOnUsername(identity)
+	existing, err := service.userDao.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrSingleSignOnUsernameTaken
+	}
+
+	user = &model.User{
+		ID:              newID(),
+		Username:        username,
+		ExternalSubject: externalSubject,
+		Role:            role,
+	}
+	if identity.EmailVerified {
+		user.Email = email
+	}
+	// The lookups above can race with another signup; CreateUser's claims on the username and email
+	// can't, so a lost race ends the same way as one the lookups caught.
+	switch err := service.userDao.CreateUser(ctx, user); err {
+	case nil:
+	case dao.ErrUsernameTaken:
+		return nil, ErrSingleSignOnUsernameTaken
+	case dao.ErrEmailInUse:
+		return nil, ErrSingleSignOnLinkRequired
+	default:
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionUserCreate, TargetType: audit.TargetUser, TargetID: user.ID, After: auditedUser(user), ActorID: user.ID})
+	return user, nil
+}
+
+// linkUser links the identity to the user who started the login from their session.
+func (service *SingleSignOnService) linkUser(ctx context.Context, identity *oidc.Identity, userID string) (*model.User, error) {
+	externalSubject := identitySubject(identity)
+	linked, err := service.userDao.GetUserByExternalSubject(ctx, externalSubject)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil && linked.ID != userID {
+		return nil, ErrSingleSignOnIdentityInUse
+	}
+	user, err := service.userDao.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidSingleSignOnState
+	}
+	if err := service.updateIdentity(ctx, user, externalSubject, user.EffectiveRole()); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// updateIdentity records the identity on the user. Only accounts that exist through single sign-on
+// take their role from the provider's groups; an account with a password keeps its own.
+func (service *SingleSignOnService) updateIdentity(ctx context.Context, user *model.User, externalSubject string, role model.Role) error {
+	if user.HashedPassword != "" {
+		role = user.EffectiveRole()
+	}
+	if user.ExternalSubject == externalSubject && user.EffectiveRole() == role {
+		return nil
+	}
+	if err := service.userDao.UpdateExternalIdentity(ctx, user.ID, externalSubject, role); err != nil {
+		return err
+	}
+	before := auditedUser(user)
+	action := audit.ActionIdentityLink
+	if user.EffectiveRole() != role {
+		action = audit.ActionRoleChange
+	}
+	user.ExternalSubject = externalSubject
+	user.Role = role
+	record(ctx, service.auditor, audit.Entry{Action: action, TargetType: audit.TargetUser, TargetID: user.ID, Before: before, After: auditedUser(user), ActorID: user.ID})
+	return nil
+}
+
+// singleSignOnOnly reports whether the user has no way to sign in but an identity provider, so
+// linking them by email can't hand a provider account someone else's credentials.
+func singleSignOnOnly(user *model.User) bool {
+	return user.HashedPassword == "" && !user.TwoFactorEnabled() && user.ExternalSubject == ""
+}
+
+func identitySubject(identity *oidc.Identity) string {
+	return identity.Issuer + "#" + identity.Subject
+}
+
+// singleSignOnUsername derives a valid username from the provider's preferred username or email,
+// falling back to the subject.
+func singleSignOnUsername(identity *oidc.Identity) string {
+	localPart, _, _ := strings.Cut(identity.Email, "@")
+	for _, candidate := range []string{identity.PreferredUsername, localPart, identity.Subject} {
+		username := disallowedUsernameCharacters.ReplaceAllString(candidate, "-")
+		username = strings.Trim(username, "-")
+		if len(username) > maxUsernameLength {
+			username = username[:maxUsernameLength]
+		}
+		if username != "" {
+			return username
+		}
+	}
+	return "user-" + newID()[:8]
+}
@@ -0,0 +1,277 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/oidc"
+	"github.com/neuralcoral/BlogService/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+)
+
+type singleSignOnFixture struct {
+	service  *SingleSignOnService
+	provider *oidctest.Server
+	auditor  *fakeAuditor
+	users    map[string]*model.User
+}
+
+func setupSingleSignOn(t *testing.T, defaultRole model.Role) *singleSignOnFixture {
+	t.Helper()
+	provider, err := oidctest.NewServer("blog", "client-secret")
+	if err != nil {
+		t.Fatalf("start provider: %v", err)
+	}
+	t.Cleanup(provider.Close)
+
+	fixture := &singleSignOnFixture{
+		provider: provider,
+		auditor:  &fakeAuditor{},
+		users:    map[string]*model.User{"user-1": {ID: "user-1", Username: "alice", HashedPassword: "hash", Email: "alice@example.com"}},
+	}
+	find := func(match func(user *model.User) bool) (*model.User, error) {
+		for _, user := range fixture.users {
+			if match(user) {
+				copied := *user
+				return &copied, nil
+			}
+		}
+		return nil, nil
+	}
+	userDao := &MockUserDao{
+		GetUserFunc: func(ctx context.Context, username string) (*model.User, error) {
+			return find(func(user *model.User) bool { return user.Username == username })
+		},
+		GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+			return find(func(user *model.User) bool { return user.ID == id })
+		},
+		GetUserByEmailFunc: func(ctx context.Context, email string) (*model.User, error) {
+			return find(func(user *model.User) bool { return user.Email == email })
+		},
+		GetUserByExternalSubjectFunc: func(ctx context.Context, externalSubject string) (*model.User, error) {
+			return find(func(user *model.User) bool { return user.ExternalSubject == externalSubject })
+		},
+		CreateUserFunc: func(ctx context.Context, user *model.User) error {
+			copied := *user
+			fixture.users[user.ID] = &copied
+			return nil
+		},
+		UpdateExternalIdentityFunc: func(ctx context.Context, userID string, externalSubject string, role model.Role) error {
+			fixture.users[userID].ExternalSubject = externalSubject
+			fixture.users[userID].Role = role
+			return nil
+		},
+	}
+
+	client := oidc.NewClient(oidc.Config{
+		Issuer:       provider.URL,
+		ClientID:     "blog",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://blog.example.com/login/sso",
+	})
+	sessions := newFakeSessionDao()
+	twoFactor := NewTwoFactorService(userDao, sessions, &fakeAccountTokenDao{tokens: map[string]model.AccountToken{}}, nil, nil, TwoFactorOptions{Issuer: "Blog", RequiredRoles: []model.Role{model.RoleAdmin}})
+	loginService := NewLoginService(userDao, sessions, auth.NewTokenIssuer("test-signing-key", "test", time.Minute), nil, twoFactor, nil, time.Hour)
+	fixture.service = NewSingleSignOnService(loginService, client, userDao, &fakeAccountTokenDao{tokens: map[string]model.AccountToken{}}, fixture.auditor, SingleSignOnOptions{
+		GroupsClaim:  "groups",
+		RoleMappings: map[string]model.Role{"blog-editors": model.RoleEditor, "blog-admins": model.RoleAdmin},
+		DefaultRole:  defaultRole,
+	})
+	return fixture
+}
+
+// login runs the whole flow as the browser and front end would and returns the state and code.
+// linkUserID is the signed-in user linking their account, if any.
+func (fixture *singleSignOnFixture) login(t *testing.T, linkUserID string) (string, string) {
+	t.Helper()
+	start, err := fixture.service.Start(context.Background(), linkUserID)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	noRedirects := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	response, err := noRedirects.Get(start.AuthorizationURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	response.Body.Close()
+	callback, _ := url.Parse(response.Header.Get("Location"))
+	return callback.Query().Get("state"), callback.Query().Get("code")
+}
+
+func TestSingleSignOn_NewIdentity_ProvisionsUserWithMappedRole(t *testing.T) {
+	fixture := setupSingleSignOn(t, model.RoleAuthor)
+	fixture.provider.Claims = map[string]interface{}{
+		"sub":                "idp-42",
+		"email":              "Bob@Example.com",
+		"email_verified":     true,
+		"preferred_username": "bob smith",
+		"groups":             []string{"everyone", "blog-editors"},
+	}
+
+	state, code := fixture.login(t, "")
+	result, err := fixture.service.Complete(context.Background(), state, code)
+	_, replayErr := fixture.service.Complete(context.Background(), state, code)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.NotEmpty(t, result.AccessToken)
+	assert.Len(t, fixture.users, 2)
+	created, _ := fixture.service.userDao.GetUserByExternalSubject(context.Background(), fixture.provider.URL+"#idp-42")
+	assert.Equal(t, "bob-smith", created.Username)
+	assert.Equal(t, "bob@example.com", created.Email)
+	assert.Equal(t, model.RoleEditor, created.Role)
+	assert.Empty(t, created.HashedPassword)
+	assert.Equal(t, ErrInvalidSingleSignOnState, replayErr)
+	assert.Equal(t, []string{audit.ActionUserCreate}, fixture.auditor.actions())
+}
+
+func TestSingleSignOn_NewIdentityLosesSignupRace_ReturnsConflict(t *testing.T) {
+	for claimErr, expected := range map[error]error{
+		dao.ErrUsernameTaken: ErrSingleSignOnUsernameTaken,
+		dao.ErrEmailInUse:    ErrSingleSignOnLinkRequired,
+	} {
+		fixture := setupSingleSignOn(t, model.RoleAuthor)
+		fixture.provider.Claims = map[string]interface{}{"sub": "idp-42", "email": "bob@example.com", "email_verified": true, "preferred_username": "bob"}
+		fixture.service.userDao.(*MockUserDao).CreateUserFunc = func(ctx context.Context, user *model.User) error {
+			return claimErr
+		}
+
+		state, code := fixture.login(t, "")
+		_, err := fixture.service.Complete(context.Background(), state, code)
+
+		assert.Equal(t, expected, err)
+		assert.Empty(t, fixture.auditor.actions())
+	}
+}
+
+func TestSingleSignOn_VerifiedEmailOfPasswordAccount_RequiresExplicitLink(t *testing.T) {
+	fixture := setupSingleSignOn(t, model.RoleAuthor)
+	fixture.provider.Claims = map[string]interface{}{"sub": "idp-1", "email": "alice@example.com", "email_verified": true, "groups": []string{"blog-admins"}}
+
+	state, code := fixture.login(t, "")
+	_, err := fixture.service.Complete(context.Background(), state, code)
+
+	assert.Equal(t, ErrSingleSignOnLinkRequired, err)
+	assert.Len(t, fixture.users, 1)
+	assert.Empty(t, fixture.users["user-1"].ExternalSubject)
+	assert.Empty(t, fixture.users["user-1"].Role)
+	assert.Empty(t, fixture.auditor.actions())
+}
+
+func TestSingleSignOn_VerifiedEmailOfPasswordlessAccount_LinksAccount(t *testing.T) {
+	fixture := setupSingleSignOn(t, model.RoleAuthor)
+	fixture.users["user-2"] = &model.User{ID: "user-2", Username: "dave", Email: "dave@example.com", Role: model.RoleAuthor}
+	fixture.provider.Claims = map[string]interface{}{"sub": "idp-2", "email": "dave@example.com", "email_verified": true, "groups": []string{"blog-admins"}}
+
+	state, code := fixture.login(t, "")
+	_, err := fixture.service.Complete(context.Background(), state, code)
+
+	assert.Nil(t, err)
+	assert.Len(t, fixture.users, 2)
+	assert.Equal(t, fixture.provider.URL+"#idp-2", fixture.users["user-2"].ExternalSubject)
+	assert.Equal(t, model.RoleAdmin, fixture.users["user-2"].Role)
+	assert.Equal(t, []string{audit.ActionRoleChange}, fixture.auditor.actions())
+	assert.Equal(t, `"ADMIN"`, mustDiff(t, fixture.auditor.entries[0])["role"].After)
+}
+
+func TestSingleSignOn_LinkFromSession_KeepsLocalRole(t *testing.T) {
+	fixture := setupSingleSignOn(t, model.RoleAuthor)
+	fixture.users["user-1"].Role = model.RoleEditor
+	fixture.provider.Claims = map[string]interface{}{"sub": "idp-1", "email": "alice@work.example.com", "email_verified": true, "groups": []string{"blog-admins"}}
+
+	state, code := fixture.login(t, "user-1")
+	result, err := fixture.service.Complete(context.Background(), state, code)
+	state, code = fixture.login(t, "")
+	_, relinkErr := fixture.service.Complete(context.Background(), state, code)
+
+	assert.Nil(t, err)
+	assert.Nil(t, relinkErr)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.Len(t, fixture.users, 1)
+	assert.Equal(t, fixture.provider.URL+"#idp-1", fixture.users["user-1"].ExternalSubject)
+	assert.Equal(t, model.RoleEditor, fixture.users["user-1"].Role)
+	assert.Equal(t, []string{audit.ActionIdentityLink}, fixture.auditor.actions())
+}
+
+func TestSingleSignOn_LinkIdentityOfAnotherUser_ReturnsConflict(t *testing.T) {
+	fixture := setupSingleSignOn(t, model.RoleAuthor)
+	fixture.users["user-2"] = &model.User{ID: "user-2", Username: "dave", ExternalSubject: fixture.provider.URL + "#idp-2"}
+	fixture.provider.Claims = map[string]interface{}{"sub": "idp-2"}
+
+	state, code := fixture.login(t, "user-1")
+	_, err := fixture.service.Complete(context.Background(), state, code)
+
+	assert.Equal(t, ErrSingleSignOnIdentityInUse, err)
+	assert.Empty(t, fixture.users["user-1"].ExternalSubject)
+}
+
+func TestSingleSignOn_UserWithTwoFactor_ReturnsChallenge(t *testing.T) {
+	fixture := setupSingleSignOn(t, model.RoleAuthor)
+	fixture.users["user-1"].ExternalSubject = fixture.provider.URL + "#idp-1"
+	fixture.users["user-1"].TwoFactor = &model.TwoFactor{Enabled: true}
+	fixture.provider.Claims = map[string]interface{}{"sub": "idp-1"}
+
+	state, code := fixture.login(t, "")
+	result, err := fixture.service.Complete(context.Background(), state, code)
+
+	assert.Nil(t, err)
+	assert.True(t, result.TwoFactorRequired)
+	assert.NotEmpty(t, result.ChallengeToken)
+	assert.Empty(t, result.AccessToken)
+}
+
+func TestSingleSignOn_RoleRequiringTwoFactor_RestrictsSessionToEnrolment(t *testing.T) {
+	fixture := setupSingleSignOn(t, model.RoleAuthor)
+	fixture.provider.Claims = map[string]interface{}{
+		"sub":            "idp-7",
+		"email":          "root@example.com",
+		"email_verified": true,
+		"groups":         []string{"blog-admins"},
+	}
+
+	state, code := fixture.login(t, "")
+	result, err := fixture.service.Complete(context.Background(), state, code)
+
+	assert.Nil(t, err)
+	assert.True(t, result.TwoFactorEnrolmentRequired)
+	assert.NotEmpty(t, result.AccessToken)
+}
+
+func mustDiff(t *testing.T, entry audit.Entry) map[string]model.AuditChange {
+	t.Helper()
+	changes, err := audit.Diff(entry.Before, entry.After)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	return changes
+}
+
+func TestSingleSignOn_NoMappedGroupWithoutDefaultRole_ReturnsForbidden(t *testing.T) {
+	fixture := setupSingleSignOn(t, "")
+	fixture.provider.Claims = map[string]interface{}{"sub": "idp-2", "groups": []string{"sales"}}
+
+	state, code := fixture.login(t, "")
+	_, err := fixture.service.Complete(context.Background(), state, code)
+
+	assert.Equal(t, ErrSingleSignOnNotAllowed, err)
+	assert.Len(t, fixture.users, 1)
+}
+
+func TestParseRoleMappings_UnknownRole_ReturnsError(t *testing.T) {
+	mappings, err := ParseRoleMappings([]string{"blog-editors=EDITOR"})
+	_, unknownErr := ParseRoleMappings([]string{"blog-owners=OWNER"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]model.Role{"blog-editors": model.RoleEditor}, mappings)
+	assert.ErrorContains(t, unknownErr, "unknown role")
+}
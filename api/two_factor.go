@@ -0,0 +1,307 @@
+package api
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+const (
+	recoveryCodeCount = 10
+	loginChallengeTTL = 5 * time.Minute
+)
+
+var (
+	ErrInvalidTwoFactorCode    = apperror.Unauthorized("invalid two-factor code")
+	ErrInvalidLoginChallenge   = apperror.Unauthorized("login challenge is invalid or has expired; log in again")
+	ErrTwoFactorAlreadyEnabled = apperror.Conflict("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolling   = apperror.Conflict("two-factor enrolment has not been started")
+	ErrTwoFactorNotEnabled     = apperror.Conflict("two-factor authentication is not enabled")
+	ErrTwoFactorRequired       = apperror.Forbidden("two-factor authentication is required for your role")
+	ErrUserNotFound            = apperror.NotFound("user not found")
+)
+
+var totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+type TwoFactorOptions struct {
+	// Issuer names the service in authenticator apps.
+	Issuer string
+	// RequiredRoles must use two-factor authentication. Their users get a session restricted to
+	// enrolment until they have set it up.
+	RequiredRoles []model.Role
+}
+
+type TwoFactorEnrolment struct {
+	Secret          string
+	ProvisioningURI string
+}
+
+// TwoFactorService manages TOTP enrolment and checks the second factor of a login.
+type TwoFactorService struct {
+	userDao         dao.UserDao
+	sessionDao      dao.SessionDao
+	accountTokenDao dao.AccountTokenDao
+	secretBox       *auth.SecretBox
+	auditor         Auditor
+	options         TwoFactorOptions
+	now             func() time.Time
+}
+
+func NewTwoFactorService(userDao dao.UserDao, sessionDao dao.SessionDao, accountTokenDao dao.AccountTokenDao, secretBox *auth.SecretBox, auditor Auditor, options TwoFactorOptions) *TwoFactorService {
+	return &TwoFactorService{
+		userDao:         userDao,
+		sessionDao:      sessionDao,
+		accountTokenDao: accountTokenDao,
+		secretBox:       secretBox,
+		auditor:         auditor,
+		options:         options,
+		now:             time.Now,
+	}
+}
+
+func (service *TwoFactorService) Required(user *model.User) bool {
+	for _, role := range service.options.RequiredRoles {
+		if user.EffectiveRole() == role {
+			return true
+		}
+	}
+	return false
+}
+
+// BeginEnrolment creates a new secret for the user's authenticator app. It isn't used for logins
+// until ConfirmEnrolment proves the app produces matching codes; starting again replaces it.
+func (service *TwoFactorService) BeginEnrolment(ctx context.Context, userID string) (*TwoFactorEnrolment, error) {
+	user, err := service.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := service.secretBox.Seal(secret, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.update(ctx, user, &model.TwoFactor{SealedSecret: sealed}); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrolment{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(service.options.Issuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmEnrolment turns two-factor authentication on once code matches the new secret, lifts the
+// enrolment restriction from the session and returns recovery codes, which are never shown again.
+func (service *TwoFactorService) ConfirmEnrolment(ctx context.Context, userID string, sessionID string, code string) ([]string, error) {
+	user, err := service.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TwoFactor == nil || user.TwoFactor.SealedSecret == "" {
+		return nil, ErrTwoFactorNotEnrolling
+	}
+
+	secret, err := service.secretBox.Open(user.TwoFactor.SealedSecret, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := auth.VerifyTOTP(secret, code, service.now(), user.TwoFactor.LastStep)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	codes, hashes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.update(ctx, user, &model.TwoFactor{
+		SealedSecret:       user.TwoFactor.SealedSecret,
+		Enabled:            true,
+		LastStep:           step,
+		RecoveryCodeHashes: hashes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{
+		Action:     audit.ActionTwoFactorEnable,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]bool{"twoFactorEnabled": false},
+		After:      map[string]bool{"twoFactorEnabled": true},
+	})
+	if sessionID != "" {
+		if err := service.sessionDao.CompleteTwoFactor(ctx, sessionID); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after checking a current code, unless the user's role
+// requires it.
+func (service *TwoFactorService) Disable(ctx context.Context, userID string, code string) error {
+	user, err := service.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if service.Required(user) {
+		return ErrTwoFactorRequired
+	}
+	if !user.TwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := service.verify(ctx, user, code); err != nil {
+		return err
+	}
+	if err := service.update(ctx, user, &model.TwoFactor{}); err != nil {
+		return err
+	}
+	record(ctx, service.auditor, audit.Entry{
+		Action:     audit.ActionTwoFactorDisable,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Before:     map[string]bool{"twoFactorEnabled": true},
+		After:      map[string]bool{"twoFactorEnabled": false},
+	})
+	return nil
+}
+
+// verify accepts a TOTP code or an unused recovery code and records its use, so neither works twice.
+func (service *TwoFactorService) verify(ctx context.Context, user *model.User, code string) error {
+	current := user.TwoFactor
+	next := *current
+	next.RecoveryCodeHashes = nil
+
+	if totpCodePattern.MatchString(code) {
+		secret, err := service.secretBox.Open(current.SealedSecret, user.ID)
+		if err != nil {
+			return err
+		}
+		step, ok := auth.VerifyTOTP(secret, code, service.now(), current.LastStep)
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		next.LastStep = step
+		next.RecoveryCodeHashes = current.RecoveryCodeHashes
+	} else {
+		hash := auth.HashRecoveryCode(code)
+		found := false
+		for _, candidate := range current.RecoveryCodeHashes {
+			if auth.SecretHashesEqual(candidate, hash) {
+				found = true
+				continue
+			}
+			next.RecoveryCodeHashes = append(next.RecoveryCodeHashes, candidate)
+		}
+		if !found {
+			return ErrInvalidTwoFactorCode
+		}
+	}
+
+	err := service.update(ctx, user, &next)
+	if apperror.KindOf(err) == apperror.KindConflict {
+		// Another request used a code at the same moment; make the caller try again.
+		return ErrInvalidTwoFactorCode
+	}
+	return err
+}
+
+// startChallenge records that the user passed the password step and returns the token that lets
+// them complete the login with a code.
+func (service *TwoFactorService) startChallenge(ctx context.Context, user *model.User) (*LoginResult, error) {
+	id := newID()
+	token, hash, err := auth.NewAccountToken(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := service.now().UTC()
+	challenge := &model.AccountToken{
+		ID:          id,
+		Purpose:     model.PurposeLoginChallenge,
+		SecretHash:  hash,
+		UserID:      user.ID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(loginChallengeTTL),
+		DeleteAfter: now.Add(loginChallengeTTL).Unix(),
+	}
+	if err := service.accountTokenDao.CreateAccountToken(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return &LoginResult{TwoFactorRequired: true, ChallengeToken: token, ChallengeExpiresAt: challenge.ExpiresAt}, nil
+}
+
+// challengeUser resolves a challenge token to its user without using the challenge up, so a
+// mistyped code can be retried until the challenge expires.
+func (service *TwoFactorService) challengeUser(ctx context.Context, token string) (*model.User, *model.AccountToken, string, error) {
+	id, hash, err := auth.ParseAccountToken(token)
+	if err != nil {
+		return nil, nil, "", ErrInvalidLoginChallenge
+	}
+	challenge, err := service.accountTokenDao.GetAccountToken(ctx, id)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if challenge == nil || challenge.Purpose != model.PurposeLoginChallenge || !auth.SecretHashesEqual(challenge.SecretHash, hash) || !challenge.Usable(service.now()) {
+		return nil, nil, "", ErrInvalidLoginChallenge
+	}
+
+	user, err := service.userDao.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if user == nil || !user.TwoFactorEnabled() {
+		return nil, nil, "", ErrInvalidLoginChallenge
+	}
+	return user, challenge, hash, nil
+}
+
+func (service *TwoFactorService) useChallenge(ctx context.Context, challenge *model.AccountToken, hash string) error {
+	err := service.accountTokenDao.UseAccountToken(ctx, challenge.ID, challenge.Purpose, hash, service.now().UTC())
+	if apperror.KindOf(err) == apperror.KindConflict {
+		return ErrInvalidLoginChallenge
+	}
+	return err
+}
+
+func (service *TwoFactorService) user(ctx context.Context, userID string) (*model.User, error) {
+	user, err := service.userDao.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// update writes the next settings, bumping the revision the write is conditioned on.
+func (service *TwoFactorService) update(ctx context.Context, user *model.User, next *model.TwoFactor) error {
+	previousRevision := 0
+	if user.TwoFactor != nil {
+		previousRevision = user.TwoFactor.Revision
+	}
+	next.Revision = previousRevision + 1
+	if err := service.userDao.UpdateTwoFactor(ctx, user.ID, next, previousRevision); err != nil {
+		return err
+	}
+	user.TwoFactor = next
+	return nil
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type twoFactorFixture struct {
+	login     *LoginService
+	twoFactor *TwoFactorService
+	users     map[string]*model.User
+	sessions  *fakeSessionDao
+	lockout   *MockLoginLockout
+	now       time.Time
+}
+
+func setupTwoFactor(t testing.TB, role model.Role) *twoFactorFixture {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	secretBox, err := auth.NewSecretBox("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatalf("secret box: %v", err)
+	}
+
+	fixture := &twoFactorFixture{
+		users:    map[string]*model.User{"user-1": {ID: "user-1", Username: "alice", HashedPassword: string(hash), Role: role}},
+		sessions: newFakeSessionDao(),
+		lockout:  &MockLoginLockout{},
+		now:      time.Unix(1_700_000_000, 0),
+	}
+	userDao := &MockUserDao{
+		GetUserFunc: func(ctx context.Context, username string) (*model.User, error) {
+			for _, user := range fixture.users {
+				if user.Username == username {
+					copied := *user
+					return &copied, nil
+				}
+			}
+			return nil, nil
+		},
+		GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+			user, ok := fixture.users[id]
+			if !ok {
+				return nil, nil
+			}
+			copied := *user
+			return &copied, nil
+		},
+		UpdateTwoFactorFunc: func(ctx context.Context, userID string, twoFactor *model.TwoFactor, previousRevision int) error {
+			user := fixture.users[userID]
+			if user.TwoFactor != nil && user.TwoFactor.Revision != previousRevision {
+				return apperror.Conflict("two-factor settings changed concurrently")
+			}
+			copied := *twoFactor
+			user.TwoFactor = &copied
+			return nil
+		},
+	}
+	fixture.twoFactor = NewTwoFactorService(userDao, fixture.sessions, &fakeAccountTokenDao{tokens: map[string]model.AccountToken{}}, secretBox, nil, TwoFactorOptions{
+		Issuer:        "Blog",
+		RequiredRoles: []model.Role{model.RoleEditor, model.RoleAdmin},
+	})
+	fixture.twoFactor.now = func() time.Time { return fixture.now }
+	fixture.login = NewLoginService(userDao, fixture.sessions, auth.NewTokenIssuer("test-signing-key", "test", time.Minute), fixture.lockout, fixture.twoFactor, nil, time.Hour)
+	return fixture
+}
+
+// enrol runs enrolment to completion and returns the TOTP secret and recovery codes.
+func (fixture *twoFactorFixture) enrol(t testing.TB) (string, []string) {
+	t.Helper()
+	enrolment, err := fixture.twoFactor.BeginEnrolment(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("begin enrolment: %v", err)
+	}
+	codes, err := fixture.twoFactor.ConfirmEnrolment(context.Background(), "user-1", "", fixture.code(t, enrolment.Secret))
+	if err != nil {
+		t.Fatalf("confirm enrolment: %v", err)
+	}
+	fixture.now = fixture.now.Add(30 * time.Second)
+	return enrolment.Secret, codes
+}
+
+func (fixture *twoFactorFixture) code(t testing.TB, secret string) string {
+	t.Helper()
+	code, err := auth.TOTPCode(secret, fixture.now.Unix()/30)
+	if err != nil {
+		t.Fatalf("totp code: %v", err)
+	}
+	return code
+}
+
+func TestConfirmEnrolment_WrongCode_LeavesTwoFactorDisabled(t *testing.T) {
+	fixture := setupTwoFactor(t, model.RoleAuthor)
+	enrolment, _ := fixture.twoFactor.BeginEnrolment(context.Background(), "user-1")
+
+	_, err := fixture.twoFactor.ConfirmEnrolment(context.Background(), "user-1", "", "000000")
+
+	assert.Equal(t, ErrInvalidTwoFactorCode, err)
+	assert.False(t, fixture.users["user-1"].TwoFactorEnabled())
+	assert.Contains(t, enrolment.ProvisioningURI, "otpauth://totp/Blog:alice?")
+	assert.NotContains(t, fixture.users["user-1"].TwoFactor.SealedSecret, enrolment.Secret)
+}
+
+func TestLogin_TwoFactorEnabled_RequiresCode(t *testing.T) {
+	fixture := setupTwoFactor(t, model.RoleAuthor)
+	secret, _ := fixture.enrol(t)
+
+	challenge, err := fixture.login.Login(context.Background(), "alice", testPassword)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, wrongErr := fixture.login.CompleteTwoFactorLogin(context.Background(), challenge.ChallengeToken, "000000")
+	result, err := fixture.login.CompleteTwoFactorLogin(context.Background(), challenge.ChallengeToken, fixture.code(t, secret))
+	_, replayErr := fixture.login.CompleteTwoFactorLogin(context.Background(), challenge.ChallengeToken, fixture.code(t, secret))
+
+	assert.True(t, challenge.TwoFactorRequired)
+	assert.Empty(t, challenge.AccessToken)
+	assert.Equal(t, ErrInvalidTwoFactorCode, wrongErr)
+	assert.Equal(t, []string{"alice"}, fixture.lockout.failures)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.NotEmpty(t, result.AccessToken)
+	assert.Equal(t, []string{"alice"}, fixture.lockout.resets)
+	assert.Equal(t, ErrInvalidLoginChallenge, replayErr)
+}
+
+func TestCompleteTwoFactorLogin_RecoveryCode_WorksOnce(t *testing.T) {
+	fixture := setupTwoFactor(t, model.RoleAuthor)
+	_, recoveryCodes := fixture.enrol(t)
+
+	first, _ := fixture.login.Login(context.Background(), "alice", testPassword)
+	_, firstErr := fixture.login.CompleteTwoFactorLogin(context.Background(), first.ChallengeToken, recoveryCodes[0])
+	second, _ := fixture.login.Login(context.Background(), "alice", testPassword)
+	_, secondErr := fixture.login.CompleteTwoFactorLogin(context.Background(), second.ChallengeToken, recoveryCodes[0])
+
+	assert.Nil(t, firstErr)
+	assert.Equal(t, ErrInvalidTwoFactorCode, secondErr)
+	assert.Len(t, fixture.users["user-1"].TwoFactor.RecoveryCodeHashes, recoveryCodeCount-1)
+}
+
+func TestLogin_EditorWithoutTwoFactor_GetsRestrictedSessionUntilEnrolled(t *testing.T) {
+	fixture := setupTwoFactor(t, model.RoleEditor)
+
+	result, err := fixture.login.Login(context.Background(), "alice", testPassword)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claims, _ := auth.NewTokenIssuer("test-signing-key", "test", time.Minute).Verify(result.AccessToken)
+	enrolment, _ := fixture.twoFactor.BeginEnrolment(context.Background(), "user-1")
+	_, confirmErr := fixture.twoFactor.ConfirmEnrolment(context.Background(), "user-1", claims.SessionID, fixture.code(t, enrolment.Secret))
+	refreshed, refreshErr := fixture.login.Refresh(context.Background(), result.RefreshToken)
+
+	assert.True(t, result.TwoFactorEnrolmentRequired)
+	assert.True(t, claims.TwoFactorPending)
+	assert.Nil(t, confirmErr)
+	assert.Nil(t, refreshErr)
+	assert.False(t, refreshed.TwoFactorEnrolmentRequired)
+}
+
+func TestDisable_RequiredRole_ReturnsForbidden(t *testing.T) {
+	fixture := setupTwoFactor(t, model.RoleAdmin)
+	secret, _ := fixture.enrol(t)
+
+	err := fixture.twoFactor.Disable(context.Background(), "user-1", fixture.code(t, secret))
+
+	assert.Equal(t, ErrTwoFactorRequired, err)
+	assert.True(t, fixture.users["user-1"].TwoFactorEnabled())
+}
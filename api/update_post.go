@@ -1,7 +1,115 @@
 package api
 
-import "github.com/neuralcoral/BlogService/model"
+import (
+	"context"
 
-func UpdatePost(postToUpdate *model.Post) (*model.Post, error) {
-	return nil, nil
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// PostUpdate changes the fields that are set and keeps the others. When Version is set it must be
+// the post's current version, so an edit made to an old copy is rejected instead of overwriting
+// newer changes.
+type PostUpdate struct {
+	ID          string
+	Version     int
+	Title       *string
+	Body        *string
+	PreviewText *string
+	Status      *model.Status
+	Tags        *[]model.Tag
+	AssetIDs    *[]string
+}
+
+var ErrPostChanged = apperror.Conflict("post was changed by someone else; reload it and try again")
+
+func (service *PostService) UpdatePost(ctx context.Context, callerID string, update *PostUpdate) (*model.Post, error) {
+	existing, err := service.postMetadataDao.GetPostMetadata(ctx, update.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrPostNotFound
+	}
+	editor, err := service.postEditor(ctx, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if !editor.canEdit(existing) {
+		if !editor.canRead(existing) {
+			return nil, ErrPostNotFound
+		}
+		return nil, ErrPostEditForbidden
+	}
+	if update.Version != 0 && update.Version != existing.Version {
+		return nil, ErrPostChanged
+	}
+
+	body, err := service.postStore.GetPost(ctx, existing.BodyUrl)
+	if err != nil {
+		return nil, err
+	}
+	post := mergePostUpdate(&model.Post{PostMetadata: *existing, Body: body}, update)
+	if err := ValidatePost(post); err != nil {
+		return nil, err
+	}
+
+	if err := service.addAssetReferences(ctx, editor, post.ID, existing.AssetIDs, post.AssetIDs); err != nil {
+		return nil, err
+	}
+	// A new body goes to a new location, which only the Version-conditioned metadata write makes
+	// live, so an edit that loses the race never touches the body readers see. Superseded bodies
+	// are left in place for readers still holding the old metadata.
+	if post.Body != body {
+		post.BodyUrl = postBodyRevisionLocation(post.ID, newID())
+		if err := service.postStore.CreatePost(ctx, post.BodyUrl, post.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := service.postMetadataDao.UpdatePostMetadata(ctx, &post.PostMetadata)
+	if err != nil {
+		if post.BodyUrl != existing.BodyUrl {
+			// Nothing references the new body; one that can't be deleted is only wasted space.
+			_ = service.postStore.DeletePost(ctx, post.BodyUrl)
+		}
+		return nil, err
+	}
+	service.recordPostChange(ctx, existing, updated)
+	if err := service.removeAssetReferences(ctx, post.ID, existing.AssetIDs, updated.AssetIDs); err != nil {
+		return nil, err
+	}
+
+	return &model.Post{PostMetadata: *updated, Body: post.Body}, nil
+}
+
+// mergePostUpdate applies the set fields of update to a copy of post. A preview generated from the
+// old body follows the new one; a preview the author wrote is kept.
+func mergePostUpdate(post *model.Post, update *PostUpdate) *model.Post {
+	merged := *post
+	if update.Title != nil {
+		merged.Title = *update.Title
+	}
+	if update.Body != nil {
+		merged.Body = *update.Body
+		if post.PreviewText == previewText(post.Body) {
+			merged.PreviewText = ""
+		}
+	}
+	if update.PreviewText != nil {
+		merged.PreviewText = *update.PreviewText
+	}
+	if merged.PreviewText == "" {
+		merged.PreviewText = previewText(merged.Body)
+	}
+	if update.Status != nil && *update.Status != "" {
+		merged.Status = *update.Status
+	}
+	if update.Tags != nil {
+		merged.Tags = *update.Tags
+	}
+	if update.AssetIDs != nil {
+		merged.AssetIDs = *update.AssetIDs
+	}
+	return &merged
 }
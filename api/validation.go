@@ -0,0 +1,162 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/validation"
+	"github.com/neuralcoral/BlogService/webhook"
+)
+
+const (
+	maxTitleLength       = 200
+	maxPreviewTextLength = 500
+	maxPostBodyLength    = 200_000
+	maxTagsPerPost       = 10
+	maxTagLabelLength    = 32
+	maxUsernameLength    = 64
+	minPasswordLength    = 12
+	// bcrypt ignores everything after the first 72 bytes.
+	maxPasswordBytes       = 72
+	maxCommentLength       = 5_000
+	maxCommentAuthorLength = 100
+	maxEmailLength         = 254
+	maxAPIKeyNameLength    = 100
+	maxAPIKeyLifetime      = 366 * 24 * time.Hour
+	maxWebhookURLLength    = 2048
+)
+
+var (
+	tagLabelPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	emailPattern    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// URLPolicy lists where user supplied URLs may point. An empty Hosts allows any host.
+type URLPolicy struct {
+	Schemes []string
+	Hosts   []string
+}
+
+// ValidatePost doesn't check BodyUrl: the service chooses where bodies are stored and ignores the
+// one the client sent.
+func ValidatePost(post *model.Post) error {
+	return validation.Validate(
+		validation.Field("title", post.Title, validation.Required(), validation.MaxLength(maxTitleLength)),
+		validation.Field("previewText", post.PreviewText, validation.MaxLength(maxPreviewTextLength)),
+		validation.Field("body", post.Body, validation.MaxLength(maxPostBodyLength)),
+		validation.Field("status", post.Status, validation.Optional(validation.OneOf(model.Draft, model.Posted))),
+		validation.Field("tags", post.Tags, validation.MaxItems[model.Tag](maxTagsPerPost)),
+		validation.Field("tags", tagLabels(post.Tags), validation.Unique[string]()),
+		tagChecks(post.Tags),
+		validation.Field("assetIds", post.AssetIDs, validation.Unique[string]()),
+	)
+}
+
+func ValidateTag(tag model.Tag) error {
+	return validation.Validate(tagLabelChecks(tag)...)
+}
+
+// ValidateCredentials checks login input only for shape, so it can't reveal password rules.
+func ValidateCredentials(username string, password string) error {
+	return validation.Validate(
+		validation.Field("username", username, validation.Required(), validation.MaxLength(maxUsernameLength)),
+		validation.Field("password", password, validation.Required(), validation.MaxBytes(maxPasswordBytes)),
+	)
+}
+
+func ValidateNewUser(username string, password string) error {
+	return validation.Validate(
+		validation.Field("username", username,
+			validation.Required(),
+			validation.MaxLength(maxUsernameLength),
+			validation.Matches(usernamePattern, "letters, digits, '.', '_' or '-'"),
+		),
+		validation.Field("password", password, validation.MinLength(minPasswordLength), validation.MaxBytes(maxPasswordBytes)),
+	)
+}
+
+func ValidateNewPassword(password string) error {
+	return validation.Validate(
+		validation.Field("password", password, validation.MinLength(minPasswordLength), validation.MaxBytes(maxPasswordBytes)),
+	)
+}
+
+func ValidateEmail(email string) error {
+	return validation.Validate(emailCheck("email", email))
+}
+
+func ValidateComment(comment *model.Comment) error {
+	return validation.Validate(
+		validation.Field("postId", comment.PostID, validation.Required()),
+		validation.Field("authorName", comment.AuthorName, validation.Required(), validation.MaxLength(maxCommentAuthorLength)),
+		emailCheck("authorEmail", comment.AuthorEmail),
+		validation.Field("body", comment.Body, validation.Required(), validation.MaxLength(maxCommentLength)),
+	)
+}
+
+// ValidateAPIKey allows keys without an expiry, but an expiry must be in the future and within a year.
+func ValidateAPIKey(name string, scopes []string, expiresAt time.Time, now time.Time) error {
+	return validation.Validate(
+		validation.Field("name", name, validation.Required(), validation.MaxLength(maxAPIKeyNameLength)),
+		validation.Field("scopes", scopes, validation.MinItems[string](1), validation.Unique[string]()),
+		validation.Items("scopes", scopes, validation.OneOf(auth.Scopes...)),
+		validation.Field("expiresAt", expiresAt, func(value time.Time) string {
+			if value.IsZero() {
+				return ""
+			}
+			if !value.After(now) {
+				return "must be in the future"
+			}
+			if value.Sub(now) > maxAPIKeyLifetime {
+				return "must be within a year"
+			}
+			return ""
+		}),
+	)
+}
+
+func ValidateWebhook(url string, eventTypes []string, urlPolicy URLPolicy) error {
+	return validation.Validate(
+		validation.Field("url", url, validation.Required(), validation.MaxLength(maxWebhookURLLength), validation.URL(urlPolicy.Schemes, urlPolicy.Hosts)),
+		validation.Field("events", eventTypes, validation.MinItems[string](1), validation.Unique[string]()),
+		validation.Items("events", eventTypes, validation.OneOf(webhook.EventTypes...)),
+	)
+}
+
+func emailCheck(name string, email string) validation.Check {
+	return validation.Field(name, email,
+		validation.Required(),
+		validation.MaxLength(maxEmailLength),
+		validation.Matches(emailPattern, "an email address"),
+	)
+}
+
+func tagChecks(tags []model.Tag) validation.Check {
+	var checks []validation.Check
+	for i, tag := range tags {
+		checks = append(checks, validation.Nested(fmt.Sprintf("tags[%d]", i), tagLabelChecks(tag)...))
+	}
+	return validation.All(checks...)
+}
+
+func tagLabelChecks(tag model.Tag) []validation.Check {
+	return []validation.Check{
+		validation.Field("label", tag.Label,
+			validation.Required(),
+			validation.MaxLength(maxTagLabelLength),
+			validation.Matches(tagLabelPattern, "lowercase letters and digits separated by single hyphens"),
+		),
+	}
+}
+
+func tagLabels(tags []model.Tag) []string {
+	labels := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		labels = append(labels, tag.Label)
+	}
+	return labels
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func invalidFields(t testing.TB, err error) []string {
+	t.Helper()
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected a domain error, got %v", err)
+	}
+	var fields []string
+	for _, field := range appErr.Fields {
+		fields = append(fields, field.Field)
+	}
+	return fields
+}
+
+func TestValidatePost_ValidPost_ReturnsNil(t *testing.T) {
+	post := &model.Post{
+		PostMetadata: model.PostMetadata{
+			ID:      "post1",
+			Title:   "Hello",
+			Status:  model.Posted,
+			BodyUrl: postBodyLocation("post1"),
+			Tags:    []model.Tag{{Label: "go"}, {Label: "aws-lambda"}},
+		},
+		Body: "Body",
+	}
+
+	assert.Nil(t, ValidatePost(post))
+}
+
+func TestValidatePost_InvalidPost_ListsEveryField(t *testing.T) {
+	post := &model.Post{
+		PostMetadata: model.PostMetadata{
+			Title:  strings.Repeat("a", maxTitleLength+1),
+			Status: "ARCHIVED",
+			Tags:   []model.Tag{{Label: "Go Lang"}, {Label: "go"}, {Label: "go"}},
+		},
+	}
+
+	result := invalidFields(t, ValidatePost(post))
+
+	assert.Equal(t, []string{"title", "status", "tags", "tags[0].label"}, result)
+}
+
+func TestValidatePost_TooManyTags_ReturnsErr(t *testing.T) {
+	var tags []model.Tag
+	for i := 0; i <= maxTagsPerPost; i++ {
+		tags = append(tags, model.Tag{Label: strings.Repeat("t", i+1)})
+	}
+	post := &model.Post{PostMetadata: model.PostMetadata{Title: "Hello", Tags: tags}}
+
+	assert.Equal(t, []string{"tags"}, invalidFields(t, ValidatePost(post)))
+}
+
+func TestValidateNewUser_ShortPassword_ReturnsErr(t *testing.T) {
+	assert.Equal(t, []string{"username", "password"}, invalidFields(t, ValidateNewUser("bad name", "short")))
+}
+
+func TestValidateComment_MissingFields_ReturnsErr(t *testing.T) {
+	result := invalidFields(t, ValidateComment(&model.Comment{AuthorEmail: "not-an-email"}))
+
+	assert.Equal(t, []string{"postId", "authorName", "authorEmail", "body"}, result)
+}
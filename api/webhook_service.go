@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/domainevent"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/webhook"
+)
+
+var ErrWebhookNotFound = apperror.NotFound("webhook not found")
+
+type CreatedWebhook struct {
+	*model.Webhook
+	// Secret is the signing secret, returned only when the webhook is created or its secret rotated.
+	Secret string
+}
+
+// WebhookService manages webhook subscriptions. Webhooks receive every matching post change, so only
+// administrators manage them.
+type WebhookService struct {
+	webhookDao  dao.WebhookDao
+	deliveryDao dao.WebhookDeliveryDao
+	userDao     dao.UserDao
+	dispatcher  *webhook.Dispatcher
+	secretBox   *auth.SecretBox
+	auditor     Auditor
+	urlPolicy   URLPolicy
+	now         func() time.Time
+}
+
+func NewWebhookService(webhookDao dao.WebhookDao, deliveryDao dao.WebhookDeliveryDao, userDao dao.UserDao, dispatcher *webhook.Dispatcher, secretBox *auth.SecretBox, auditor Auditor, urlPolicy URLPolicy) *WebhookService {
+	return &WebhookService{
+		webhookDao:  webhookDao,
+		deliveryDao: deliveryDao,
+		userDao:     userDao,
+		dispatcher:  dispatcher,
+		secretBox:   secretBox,
+		auditor:     auditor,
+		urlPolicy:   urlPolicy,
+		now:         time.Now,
+	}
+}
+
+func (service *WebhookService) CreateWebhook(ctx context.Context, callerID string, url string, eventTypes []string) (*CreatedWebhook, error) {
+	if err := requireAdmin(ctx, service.userDao, callerID); err != nil {
+		return nil, err
+	}
+	if err := ValidateWebhook(url, eventTypes, service.urlPolicy); err != nil {
+		return nil, err
+	}
+
+	now := service.now().UTC()
+	created := &model.Webhook{
+		ID:        newID(),
+		OwnerID:   callerID,
+		URL:       url,
+		Events:    eventTypes,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	secret, err := service.sealNewSecret(created)
+	if err != nil {
+		return nil, err
+	}
+	if err := service.webhookDao.CreateWebhook(ctx, created); err != nil {
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionWebhookCreate, TargetType: audit.TargetWebhook, TargetID: created.ID, After: created})
+	return &CreatedWebhook{Webhook: created, Secret: secret}, nil
+}
+
+func (service *WebhookService) ListWebhooks(ctx context.Context, callerID string) ([]*model.Webhook, error) {
+	if err := requireAdmin(ctx, service.userDao, callerID); err != nil {
+		return nil, err
+	}
+	return service.webhookDao.ListWebhooks(ctx)
+}
+
+func (service *WebhookService) UpdateWebhook(ctx context.Context, callerID string, id string, url string, eventTypes []string, active bool) (*model.Webhook, error) {
+	current, err := service.getWebhook(ctx, callerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateWebhook(url, eventTypes, service.urlPolicy); err != nil {
+		return nil, err
+	}
+
+	updated := *current
+	updated.URL = url
+	updated.Events = eventTypes
+	updated.Active = active
+	updated.UpdatedAt = service.now().UTC()
+	if err := service.webhookDao.UpdateWebhook(ctx, &updated); err != nil {
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionWebhookUpdate, TargetType: audit.TargetWebhook, TargetID: id, Before: current, After: &updated})
+	return &updated, nil
+}
+
+// DeleteWebhook removes the subscription; its pending deliveries are dead-lettered on their next
+// attempt.
+func (service *WebhookService) DeleteWebhook(ctx context.Context, callerID string, id string) error {
+	current, err := service.getWebhook(ctx, callerID, id)
+	if err != nil {
+		return err
+	}
+	if err := service.webhookDao.DeleteWebhook(ctx, id); err != nil {
+		return err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionWebhookDelete, TargetType: audit.TargetWebhook, TargetID: id, Before: current})
+	return nil
+}
+
+// RotateSecret replaces the signing secret; deliveries are signed with the new secret from then on,
+// retries included.
+func (service *WebhookService) RotateSecret(ctx context.Context, callerID string, id string) (*CreatedWebhook, error) {
+	current, err := service.getWebhook(ctx, callerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *current
+	secret, err := service.sealNewSecret(&updated)
+	if err != nil {
+		return nil, err
+	}
+	updated.UpdatedAt = service.now().UTC()
+	if err := service.webhookDao.UpdateWebhook(ctx, &updated); err != nil {
+		return nil, err
+	}
+	record(ctx, service.auditor, audit.Entry{Action: audit.ActionWebhookRotate, TargetType: audit.TargetWebhook, TargetID: id})
+	return &CreatedWebhook{Webhook: &updated, Secret: secret}, nil
+}
+
+// ListDeliveries returns the webhook's delivery log, newest first.
+func (service *WebhookService) ListDeliveries(ctx context.Context, callerID string, id string, limit int, lastEvaluatedKey string) ([]*model.WebhookDelivery, string, error) {
+	if _, err := service.getWebhook(ctx, callerID, id); err != nil {
+		return nil, "", err
+	}
+	return service.deliveryDao.ListDeliveries(ctx, id, limit, lastEvaluatedKey)
+}
+
+// SendTestEvent delivers a test event to the webhook straight away, even if it is inactive, and
+// returns the outcome of the first attempt. A failed test delivery is retried like any other.
+func (service *WebhookService) SendTestEvent(ctx context.Context, callerID string, id string) (*model.WebhookDelivery, error) {
+	current, err := service.getWebhook(ctx, callerID, id)
+	if err != nil {
+		return nil, err
+	}
+	return service.dispatcher.Send(ctx, current, &domainevent.Event{
+		ID:         newID(),
+		Type:       webhook.TypeTest,
+		OccurredAt: service.now().UTC(),
+		Detail:     map[string]string{"webhookId": id},
+	})
+}
+
+func (service *WebhookService) getWebhook(ctx context.Context, callerID string, id string) (*model.Webhook, error) {
+	if err := requireAdmin(ctx, service.userDao, callerID); err != nil {
+		return nil, err
+	}
+	current, err := service.webhookDao.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return current, nil
+}
+
+func (service *WebhookService) sealNewSecret(target *model.Webhook) (string, error) {
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return "", err
+	}
+	if target.SealedSecret, err = service.secretBox.Seal(secret, target.ID); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao/daotest"
+	"github.com/neuralcoral/BlogService/domainevent"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeWebhookDeliveryDao struct {
+	deliveries []model.WebhookDelivery
+}
+
+func (f *fakeWebhookDeliveryDao) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	f.deliveries = append(f.deliveries, *delivery)
+	return nil
+}
+
+func (f *fakeWebhookDeliveryDao) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery, previousAttempts int) error {
+	f.deliveries[len(f.deliveries)-1] = *delivery
+	return nil
+}
+
+func (f *fakeWebhookDeliveryDao) ListDeliveries(ctx context.Context, webhookID string, limit int, lastEvaluatedKey string) ([]*model.WebhookDelivery, string, error) {
+	return nil, "", nil
+}
+
+func (f *fakeWebhookDeliveryDao) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func setupWebhookService(t *testing.T) (*WebhookService, *fakeAuditor) {
+	t.Helper()
+	secretBox, err := auth.NewSecretBox("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	if err != nil {
+		t.Fatalf("secret box: %v", err)
+	}
+	userDao := &MockUserDao{GetUserByIDFunc: func(ctx context.Context, id string) (*model.User, error) {
+		if id == "admin-1" {
+			return &model.User{ID: id, Username: "root", Role: model.RoleAdmin}, nil
+		}
+		return &model.User{ID: id, Username: "alice"}, nil
+	}}
+	webhookDao := daotest.NewWebhookDao()
+	deliveryDao := &fakeWebhookDeliveryDao{}
+	dispatcher := webhook.NewDispatcher(webhookDao, deliveryDao, secretBox, webhook.NewHTTPClient(time.Second, netip.MustParsePrefix("127.0.0.0/8")), webhook.DefaultRetryPolicy)
+	auditor := &fakeAuditor{}
+	// Local receivers listen on plain HTTP.
+	return NewWebhookService(webhookDao, deliveryDao, userDao, dispatcher, secretBox, auditor, URLPolicy{Schemes: []string{"http", "https"}}), auditor
+}
+
+func TestCreateWebhook_NonAdminOrUnknownEvent_Rejected(t *testing.T) {
+	sut, _ := setupWebhookService(t)
+
+	_, forbidden := sut.CreateWebhook(context.Background(), "user-1", "https://hooks.example.com", []string{domainevent.TypePostPublished})
+	_, invalid := sut.CreateWebhook(context.Background(), "admin-1", "https://hooks.example.com", []string{"PostLiked"})
+
+	assert.Equal(t, ErrAdminRequired, forbidden)
+	assert.Equal(t, apperror.KindValidation, apperror.KindOf(invalid))
+}
+
+func TestSendTestEvent_DeliversEventSignedWithReturnedSecret(t *testing.T) {
+	sut, auditor := setupWebhookService(t)
+	var body []byte
+	var signature string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(webhook.HeaderSignature)
+	}))
+	defer receiver.Close()
+
+	created, err := sut.CreateWebhook(context.Background(), "admin-1", receiver.URL, []string{domainevent.TypePostPublished})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	delivery, err := sut.SendTestEvent(context.Background(), "admin-1", created.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.DeliveryDelivered, delivery.Status)
+	assert.Equal(t, webhook.TypeTest, delivery.EventType)
+	assert.NoError(t, webhook.Verify(created.Secret, signature, body, time.Now(), time.Minute))
+	assert.Equal(t, []string{audit.ActionWebhookCreate}, auditor.actions())
+}
@@ -0,0 +1,33 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAs_WrappedDomainError_ReturnsIt(t *testing.T) {
+	notFound := NotFound("post not found")
+
+	result := As(fmt.Errorf("read post: %w", notFound))
+
+	assert.Same(t, notFound, result)
+}
+
+func TestAs_PlainError_ReturnsInternalWithGenericMessage(t *testing.T) {
+	cause := errors.New("ResourceNotFoundException: table Posts does not exist")
+
+	result := As(cause)
+
+	assert.Equal(t, KindInternal, result.Kind)
+	assert.Equal(t, "internal server error", result.Message)
+	assert.True(t, errors.Is(result, cause))
+}
+
+func TestError_IncludesCauseForLogs(t *testing.T) {
+	result := Wrap(KindConflict, "post already exists", errors.New("ConditionalCheckFailedException"))
+
+	assert.Equal(t, "post already exists: ConditionalCheckFailedException", result.Error())
+}
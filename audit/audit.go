@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionPostCreate       = "post.create"
+	ActionPostUpdate       = "post.update"
+	ActionPostPublish      = "post.publish"
+	ActionMediaCreate      = "media.create"
+	ActionMediaDelete      = "media.delete"
+	ActionLogin            = "user.login"
+	ActionLoginFailed      = "user.login_failed"
+	ActionLogout           = "user.logout"
+	ActionLogoutAll        = "user.logout_all"
+	ActionUserInvite       = "user.invite"
+	ActionUserCreate       = "user.create"
+	ActionPasswordReset    = "user.password_reset"
+	ActionRoleChange       = "user.role_change"
+	ActionIdentityLink     = "user.identity_link"
+	ActionTwoFactorEnable  = "user.two_factor_enable"
+	ActionTwoFactorDisable = "user.two_factor_disable"
+	ActionAPIKeyCreate     = "api_key.create"
+	ActionAPIKeyRevoke     = "api_key.revoke"
+	ActionWebhookCreate    = "webhook.create"
+	ActionWebhookUpdate    = "webhook.update"
+	ActionWebhookDelete    = "webhook.delete"
+	ActionWebhookRotate    = "webhook.rotate_secret"
+)
+
+// Target types recorded in the audit log. Failed logins target the username tried, which may not
+// belong to any user.
+const (
+	TargetPost       = "post"
+	TargetMedia      = "media"
+	TargetUser       = "user"
+	TargetUsername   = "username"
+	TargetInvitation = "invitation"
+	TargetAPIKey     = "api_key"
+	TargetWebhook    = "webhook"
+)
+
+// Request describes who made the request being served. The router attaches it to the context so
+// services can record changes without passing it along.
+type Request struct {
+	ActorID   string
+	APIKeyID  string
+	RequestID string
+	SourceIP  string
+}
+
+type requestKey struct{}
+
+func WithRequest(ctx context.Context, request Request) context.Context {
+	return context.WithValue(ctx, requestKey{}, request)
+}
+
+func RequestFrom(ctx context.Context) Request {
+	request, _ := ctx.Value(requestKey{}).(Request)
+	return request
+}
+
+// Entry is one change to record. Before and After are diffed field by field; Before is nil for
+// creations and After for deletions. ActorID overrides the request's actor, for logins, where the
+// request is anonymous.
+type Entry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+	ActorID    string
+}
+
+// Recorder appends entries to the audit log. The change an entry describes has already been made
+// when it is recorded, so a failed write is logged rather than failing the request.
+type Recorder struct {
+	auditDao  dao.AuditDao
+	retention time.Duration
+	now       func() time.Time
+}
+
+func NewRecorder(auditDao dao.AuditDao, retention time.Duration) *Recorder {
+	return &Recorder{auditDao: auditDao, retention: retention, now: time.Now}
+}
+
+func (recorder *Recorder) Record(ctx context.Context, entry Entry) {
+	request := RequestFrom(ctx)
+	now := recorder.now().UTC()
+	event := &model.AuditEvent{
+		ID:          newID(),
+		OccurredAt:  now,
+		Action:      entry.Action,
+		ActorID:     request.ActorID,
+		APIKeyID:    request.APIKeyID,
+		TargetType:  entry.TargetType,
+		TargetID:    entry.TargetID,
+		RequestID:   request.RequestID,
+		SourceIP:    request.SourceIP,
+		DeleteAfter: now.Add(recorder.retention).Unix(),
+	}
+	if entry.ActorID != "" {
+		event.ActorID = entry.ActorID
+	}
+	event.SetKeys()
+
+	changes, err := Diff(entry.Before, entry.After)
+	if err != nil {
+		log.Printf("audit: diff %s %s: %v", entry.Action, event.Target, err)
+	}
+	event.Changes = changes
+
+	if err := recorder.auditDao.AppendAuditEvent(ctx, event); err != nil {
+		log.Printf("audit: record %s %s by %q: %v", entry.Action, event.Target, event.ActorID, err)
+	}
+}
+
+func newID() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockAuditDao struct {
+	events []*model.AuditEvent
+}
+
+func (m *MockAuditDao) AppendAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *MockAuditDao) ListAuditEvents(ctx context.Context, filter model.AuditFilter, limit int, lastEvaluatedKey string) ([]*model.AuditEvent, string, error) {
+	return nil, "", nil
+}
+
+type post struct {
+	Title  string `json:"title"`
+	Status string `json:"status"`
+	Body   string `json:"body,omitempty"`
+	Secret string `json:"-"`
+}
+
+func TestDiff_ChangedAddedAndRemovedFields(t *testing.T) {
+	changes, err := Diff(
+		post{Title: "Draft", Status: "DRAFT", Body: "old", Secret: "a"},
+		post{Title: "Launch", Status: "DRAFT", Secret: "b"},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]model.AuditChange{
+		"title": {Before: `"Draft"`, After: `"Launch"`},
+		"body":  {Before: `"old"`},
+	}, changes)
+}
+
+func TestDiff_Creation_RecordsEveryField(t *testing.T) {
+	changes, err := Diff(nil, &post{Title: "Launch", Status: "POSTED"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]model.AuditChange{
+		"title":  {After: `"Launch"`},
+		"status": {After: `"POSTED"`},
+	}, changes)
+}
+
+func TestDiff_LongValue_IsTruncated(t *testing.T) {
+	changes, err := Diff(nil, post{Body: strings.Repeat("x", 5000)})
+
+	assert.NoError(t, err)
+	assert.Less(t, len(changes["body"].After), maxValueLength+100)
+	assert.Contains(t, changes["body"].After, "(5002 bytes)")
+}
+
+func TestRecorder_Record_TakesRequestFromContext(t *testing.T) {
+	auditDao := &MockAuditDao{}
+	recorder := NewRecorder(auditDao, 24*time.Hour)
+	recorder.now = func() time.Time { return time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC) }
+	ctx := WithRequest(context.Background(), Request{ActorID: "user-1", RequestID: "req-1", SourceIP: "203.0.113.9"})
+
+	recorder.Record(ctx, Entry{Action: ActionPostUpdate, TargetType: TargetPost, TargetID: "post-1", Before: post{Title: "a"}, After: post{Title: "b"}})
+	recorder.Record(context.Background(), Entry{Action: ActionLogin, TargetType: TargetUser, TargetID: "user-2", ActorID: "user-2"})
+
+	assert.Len(t, auditDao.events, 2)
+	event := auditDao.events[0]
+	assert.Equal(t, "user-1", event.ActorID)
+	assert.Equal(t, "req-1", event.RequestID)
+	assert.Equal(t, "203.0.113.9", event.SourceIP)
+	assert.Equal(t, "2026-10-19", event.Day)
+	assert.Equal(t, "2026-10-19T08:30:00.000000000Z#"+event.ID, event.Sequence)
+	assert.Equal(t, "post#post-1", event.Target)
+	assert.Equal(t, model.AuditChange{Before: `"a"`, After: `"b"`}, event.Changes["title"])
+	assert.Equal(t, time.Date(2026, 10, 20, 8, 30, 0, 0, time.UTC).Unix(), event.DeleteAfter)
+	assert.Equal(t, "user-2", auditDao.events[1].ActorID)
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// maxValueLength caps each recorded value so a long field can't push an event past DynamoDB's item
+// size limit.
+const maxValueLength = 1024
+
+// Diff compares the JSON encodings of before and after field by field and returns the fields that
+// differ. Either may be nil. Fields hidden from JSON, such as password hashes, are never recorded.
+func Diff(before interface{}, after interface{}) (map[string]model.AuditChange, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]model.AuditChange{}
+	for name, value := range beforeFields {
+		if !bytes.Equal(value, afterFields[name]) {
+			changes[name] = model.AuditChange{Before: truncate(value), After: truncate(afterFields[name])}
+		}
+	}
+	for name, value := range afterFields {
+		if _, found := beforeFields[name]; !found {
+			changes[name] = model.AuditChange{After: truncate(value)}
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+func fields(value interface{}) (map[string]json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if string(encoded) == "null" {
+		return nil, nil
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return nil, fmt.Errorf("audit values must encode as JSON objects: %w", err)
+	}
+	return result, nil
+}
+
+func truncate(value json.RawMessage) string {
+	if len(value) <= maxValueLength {
+		return string(value)
+	}
+	summary, _ := json.Marshal(fmt.Sprintf("%s… (%d bytes)", value[:maxValueLength], len(value)))
+	return string(summary)
+}
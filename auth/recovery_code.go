@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+)
+
+// Recovery codes stand in for a TOTP code when the authenticator is lost. They are random, so like
+// refresh tokens only a SHA-256 hash is stored.
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewRecoveryCodes returns count codes formatted as "xxxxx-xxxxx" and their hashes.
+func NewRecoveryCodes(count int) (codes []string, hashes []string, err error) {
+	for i := 0; i < count; i++ {
+		random := make([]byte, 7)
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, err
+		}
+		encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(random))[:10]
+		codes = append(codes, encoded[:5]+"-"+encoded[5:])
+		hashes = append(hashes, HashRecoveryCode(encoded))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode ignores case, spaces and dashes, which people add or drop when typing codes.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashSecret(normalized)
+}
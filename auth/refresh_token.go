@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// Refresh tokens are "<session ID>.<secret>" and account tokens "<token ID>.<secret>". Only a SHA-256
+// hash of the secret is stored; the secret is random, so a fast hash is enough to make a leaked
+// table useless.
+
+func NewRefreshToken(sessionID string) (token string, hash string, err error) {
+	return newSecretToken(sessionID)
+}
+
+// ParseRefreshToken splits a refresh token into its session ID and the hash of its secret.
+func ParseRefreshToken(token string) (sessionID string, hash string, err error) {
+	return parseSecretToken(token)
+}
+
+// NewAccountToken creates the single-use token mailed in invitation and password reset links.
+func NewAccountToken(id string) (token string, hash string, err error) {
+	return newSecretToken(id)
+}
+
+// ParseAccountToken splits an account token into its ID and the hash of its secret.
+func ParseAccountToken(token string) (id string, hash string, err error) {
+	return parseSecretToken(token)
+}
+
+// SecretHashesEqual compares hashes in constant time.
+func SecretHashesEqual(a string, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func newSecretToken(id string) (string, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	return id + "." + encoded, hashSecret(encoded), nil
+}
+
+func parseSecretToken(token string) (string, string, error) {
+	id, secret, found := strings.Cut(token, ".")
+	if !found || id == "" || secret == "" {
+		return "", "", ErrInvalidToken
+	}
+	return id, hashSecret(secret), nil
+}
+
+func hashSecret(secret string) string {
+	digest := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var errSealedValue = errors.New("sealed value is corrupt or was sealed for something else")
+
+// SecretBox encrypts small secrets such as TOTP seeds before they are stored, with AES-256-GCM.
+// Sealing binds the ciphertext to a context, e.g. the user ID, so it can't be copied to another item.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox takes a base64 encoded 32 byte key.
+func NewSecretBox(encodedKey string) (*SecretBox, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("secret box key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secret box key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+func (box *SecretBox) Seal(plaintext string, context string) (string, error) {
+	nonce := make([]byte, box.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := box.aead.Seal(nonce, nonce, []byte(plaintext), []byte(context))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (box *SecretBox) Open(sealed string, context string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < box.aead.NonceSize() {
+		return "", errSealedValue
+	}
+	nonce, ciphertext := data[:box.aead.NonceSize()], data[box.aead.NonceSize():]
+	plaintext, err := box.aead.Open(nil, nonce, ciphertext, []byte(context))
+	if err != nil {
+		return "", errSealedValue
+	}
+	return string(plaintext), nil
+}
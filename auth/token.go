@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Claims struct {
+	jwt.RegisteredClaims
+	// SessionID ties the token to the login session it was issued for, so revoking the session
+	// revokes the token too.
+	SessionID string `json:"sid,omitempty"`
+	// TwoFactorPending restricts the token to enrolling in two-factor authentication.
+	TwoFactorPending bool `json:"tfp,omitempty"`
+}
+
+type TokenIssuer struct {
+	signingKey []byte
+	issuer     string
+	ttl        time.Duration
+}
+
+func NewTokenIssuer(signingKey string, issuer string, ttl time.Duration) *TokenIssuer {
+	return &TokenIssuer{
+		signingKey: []byte(signingKey),
+		issuer:     issuer,
+		ttl:        ttl,
+	}
+}
+
+func (issuer *TokenIssuer) Issue(subject string) (string, time.Time, error) {
+	return issuer.IssueForSession(subject, "", false)
+}
+
+func (issuer *TokenIssuer) IssueForSession(subject string, sessionID string, twoFactorPending bool) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(issuer.ttl)
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer.issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		SessionID:        sessionID,
+		TwoFactorPending: twoFactorPending,
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(issuer.signingKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+func (issuer *TokenIssuer) Verify(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return issuer.signingKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(issuer.issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, errors.Join(ErrInvalidToken, err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify_IssuedToken_Succeeds(t *testing.T) {
+	sut := NewTokenIssuer("signing-key", "blog-service", time.Minute)
+	token, _, err := sut.Issue("user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claims, err := sut.Verify(token)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "user1", claims.Subject)
+}
+
+func TestVerify_ExpiredToken_ReturnsErr(t *testing.T) {
+	sut := NewTokenIssuer("signing-key", "blog-service", -time.Minute)
+	token, _, _ := sut.Issue("user1")
+
+	_, err := sut.Verify(token)
+
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestVerify_DifferentIssuer_ReturnsErr(t *testing.T) {
+	token, _, _ := NewTokenIssuer("signing-key", "someone-else", time.Minute).Issue("user1")
+
+	_, err := NewTokenIssuer("signing-key", "blog-service", time.Minute).Verify(token)
+
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
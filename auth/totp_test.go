@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret is the SHA-1 test key from RFC 6238 appendix B, "12345678901234567890".
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238Vectors_Match(t *testing.T) {
+	// The RFC lists eight digit codes; six digit codes are their last six digits.
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, expected := range vectors {
+		code, err := TOTPCode(rfc6238Secret, unix/totpPeriod)
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assert.Equal(t, expected, code, "time %d", unix)
+	}
+}
+
+func TestVerifyTOTP_AdjacentStepAndReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, _ := TOTPCode(rfc6238Secret, now.Unix()/totpPeriod-1)
+	tooOld, _ := TOTPCode(rfc6238Secret, now.Unix()/totpPeriod-2)
+
+	step, ok := VerifyTOTP(rfc6238Secret, previous, now, 0)
+	_, replayed := VerifyTOTP(rfc6238Secret, previous, now, step)
+	_, stale := VerifyTOTP(rfc6238Secret, tooOld, now, 0)
+
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/totpPeriod-1, step)
+	assert.False(t, replayed)
+	assert.False(t, stale)
+}
+
+func TestTOTPProvisioningURI_IncludesSecretAndIssuer(t *testing.T) {
+	uri, err := url.Parse(TOTPProvisioningURI("Blog Service", "alice", "JBSWY3DPEHPK3PXP"))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Blog Service:alice", uri.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	assert.Equal(t, "Blog Service", uri.Query().Get("issuer"))
+}
+
+func TestSecretBox_OpenWithOtherContext_Fails(t *testing.T) {
+	box, err := NewSecretBox("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sealed, _ := box.Seal("JBSWY3DPEHPK3PXP", "user-1")
+
+	opened, openErr := box.Open(sealed, "user-1")
+	_, otherErr := box.Open(sealed, "user-2")
+
+	assert.Nil(t, openErr)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", opened)
+	assert.ErrorIs(t, otherErr, errSealedValue)
+}
+
+func TestHashRecoveryCode_IgnoresFormatting(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes(2)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Len(t, codes[0], 11)
+	assert.NotEqual(t, codes[0], codes[1])
+	assert.Equal(t, hashes[0], HashRecoveryCode(" "+codes[0][:5]+codes[0][6:]))
+}
@@ -1,28 +0,0 @@
-package main
-
-import (
-	"fmt"
-	"log"
-
-	"BlogService/blog"
-)
-
-func main() {
-	// Set properties of the predefined Logger, including
-	// the log entry prefix and a flag to disable printing
-	// the time, source file, and line number.
-	log.SetPrefix("greetings: ")
-	log.SetFlags(0)
-
-	// Request a greeting message.
-	message, err := blog.Hello("Gladys")
-	// If an error was returned, print it to the console and
-	// exit the program.
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// If no error was returned, print the returned message
-	// to the console.
-	fmt.Println(message)
-}
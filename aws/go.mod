@@ -1,7 +0,0 @@
-module BlogService/aws
-
-go 1.23.1
-
-replace BlogService/blog => ../blog
-
-require BlogService/blog v0.0.0-00010101000000-000000000000
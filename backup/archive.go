@@ -0,0 +1,181 @@
+// Package backup exports every post into a single archive and restores one into an empty table and
+// bucket. An archive is a gzipped tar of posts/<id>.json metadata and bodies/<id>.md bodies, ending
+// with manifest.jsonl: a header line with the format version, then a line per post with the
+// SHA-256 of both files. Media uploads aren't included.
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	manifestPath = "manifest.jsonl"
+	formatName   = "blog-service-export"
+	// FormatVersion is the archive version this package writes and the only one it restores.
+	FormatVersion = 1
+)
+
+var ErrNoManifest = errors.New("archive has no manifest; it may be truncated")
+
+type ManifestHeader struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Posts     int       `json:"posts"`
+}
+
+type ManifestEntry struct {
+	PostID         string `json:"postId"`
+	MetadataPath   string `json:"metadataPath"`
+	MetadataSHA256 string `json:"metadataSha256"`
+	BodyPath       string `json:"bodyPath"`
+	BodySHA256     string `json:"bodySha256"`
+	BodySize       int64  `json:"bodySize"`
+}
+
+type Manifest struct {
+	Header  ManifestHeader
+	Entries []ManifestEntry
+}
+
+// checksums maps every file the manifest lists to its SHA-256.
+func (manifest *Manifest) checksums() map[string]string {
+	result := make(map[string]string, 2*len(manifest.Entries))
+	for _, entry := range manifest.Entries {
+		result[entry.MetadataPath] = entry.MetadataSHA256
+		result[entry.BodyPath] = entry.BodySHA256
+	}
+	return result
+}
+
+func metadataPath(postID string) string {
+	return "posts/" + postID + ".json"
+}
+
+func bodyPath(postID string) string {
+	return "bodies/" + postID + ".md"
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reads a whole archive and checks that its manifest is a version this package restores,
+// lists every file in it and matches each file's checksum.
+func Verify(archive io.Reader) (*Manifest, error) {
+	computed := map[string]string{}
+	var manifest *Manifest
+	err := readArchive(archive, func(name string, data []byte) error {
+		if name == manifestPath {
+			var err error
+			manifest, err = parseManifest(data)
+			return err
+		}
+		if _, ok := computed[name]; ok {
+			return fmt.Errorf("archive has %s twice", name)
+		}
+		computed[name] = checksum(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, ErrNoManifest
+	}
+
+	expected := manifest.checksums()
+	if manifest.Header.Posts != len(manifest.Entries) {
+		return nil, fmt.Errorf("manifest header counts %d posts but lists %d", manifest.Header.Posts, len(manifest.Entries))
+	}
+	if len(expected) != 2*len(manifest.Entries) {
+		return nil, fmt.Errorf("manifest lists a file twice")
+	}
+	for name, sum := range expected {
+		actual, ok := computed[name]
+		if !ok {
+			return nil, fmt.Errorf("archive is missing %s", name)
+		}
+		if actual != sum {
+			return nil, fmt.Errorf("checksum mismatch for %s", name)
+		}
+	}
+	for name := range computed {
+		if _, ok := expected[name]; !ok {
+			return nil, fmt.Errorf("archive has %s, which the manifest doesn't list", name)
+		}
+	}
+	return manifest, nil
+}
+
+func parseManifest(data []byte) (*Manifest, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	manifest := &Manifest{}
+	for line := 0; scanner.Scan(); line++ {
+		if line == 0 {
+			if err := json.Unmarshal(scanner.Bytes(), &manifest.Header); err != nil {
+				return nil, fmt.Errorf("manifest header: %w", err)
+			}
+			if manifest.Header.Format != formatName || manifest.Header.Version != FormatVersion {
+				return nil, fmt.Errorf("archive is %s version %d; only %s version %d is supported",
+					manifest.Header.Format, manifest.Header.Version, formatName, FormatVersion)
+			}
+			continue
+		}
+		var entry ManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("manifest line %d: %w", line+1, err)
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if manifest.Header.Format == "" {
+		return nil, fmt.Errorf("manifest is empty")
+	}
+	return manifest, nil
+}
+
+// readArchive calls visit with the name and content of every regular file in the archive, in order.
+func readArchive(archive io.Reader, visit func(name string, data []byte) error) error {
+	gzipReader, err := gzip.NewReader(archive)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tarReader)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", header.Name, err)
+		}
+		if err := visit(header.Name, data); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/dao/daotest"
+	"github.com/neuralcoral/BlogService/migration"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore/objectstoretest"
+	"github.com/stretchr/testify/assert"
+)
+
+// segmentedScanClient serves each segment's items a page of one item at a time.
+type segmentedScanClient struct {
+	segments [][]map[string]types.AttributeValue
+}
+
+func (m *segmentedScanClient) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	items := m.segments[aws.ToInt32(input.Segment)]
+	page := 0
+	if input.ExclusiveStartKey != nil {
+		fmt.Sscan(input.ExclusiveStartKey["page"].(*types.AttributeValueMemberN).Value, &page)
+	}
+	if page >= len(items) {
+		return &dynamodb.ScanOutput{}, nil
+	}
+	output := &dynamodb.ScanOutput{Items: items[page : page+1]}
+	if page+1 < len(items) {
+		output.LastEvaluatedKey = map[string]types.AttributeValue{"page": &types.AttributeValueMemberN{Value: fmt.Sprint(page + 1)}}
+	}
+	return output, nil
+}
+
+func testPost(t *testing.T, id string) (map[string]types.AttributeValue, string) {
+	date := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	item, err := model.ToDynamoDbAttributes(&model.PostMetadata{
+		ID:        id,
+		Title:     "Post " + id,
+		BodyUrl:   "posts/" + id,
+		Status:    model.Posted,
+		CreatedAt: date,
+		UpdatedAt: date,
+		Tags:      []model.Tag{{ID: "tag-1", Label: "go"}},
+	})
+	assert.NoError(t, err)
+	return item, "# Post " + id + "\n"
+}
+
+// exportTestSite exports three posts spread over two scan segments.
+func exportTestSite(t *testing.T) ([]byte, *Manifest) {
+	client := &segmentedScanClient{segments: make([][]map[string]types.AttributeValue, 2)}
+	store := objectstoretest.NewPostStore()
+	for i, id := range []string{"a", "b", "c"} {
+		item, body := testPost(t, id)
+		client.segments[i%2] = append(client.segments[i%2], item)
+		store.Bodies["posts/"+id] = body
+	}
+	exporter := NewExporter(client, store, ExportOptions{TableName: "posts", Segments: 2, PageSize: 1})
+
+	var archive bytes.Buffer
+	manifest, err := exporter.Export(context.Background(), &archive)
+	assert.NoError(t, err)
+	return archive.Bytes(), manifest
+}
+
+// rewriteArchive copies an archive, letting edit replace or, by returning nil, drop each file.
+func rewriteArchive(t *testing.T, archive []byte, edit func(name string, data []byte) []byte) []byte {
+	var out bytes.Buffer
+	gzipWriter := gzip.NewWriter(&out)
+	tarWriter := tar.NewWriter(gzipWriter)
+	err := readArchive(bytes.NewReader(archive), func(name string, data []byte) error {
+		if data = edit(name, data); data == nil {
+			return nil
+		}
+		if err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			return err
+		}
+		_, err := tarWriter.Write(data)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, tarWriter.Close())
+	assert.NoError(t, gzipWriter.Close())
+	return out.Bytes()
+}
+
+func newTestRestorer(t *testing.T) (*Restorer, *daotest.PostMetadataDao, *objectstoretest.PostStore, *migration.FileCheckpointStore[RestoreCheckpoint]) {
+	postMetadataDao := daotest.NewPostMetadataDao()
+	store := objectstoretest.NewPostStore()
+	checkpoints := migration.NewFileCheckpointStore[RestoreCheckpoint](filepath.Join(t.TempDir(), "checkpoint.json"))
+	return NewRestorer(postMetadataDao, store, checkpoints, &bytes.Buffer{}), postMetadataDao, store, checkpoints
+}
+
+func TestExport_RestoreIntoEmptySite_RoundTrips(t *testing.T) {
+	archive, exported := exportTestSite(t)
+	assert.Equal(t, 3, exported.Header.Posts)
+
+	manifest, err := Verify(bytes.NewReader(archive))
+	assert.NoError(t, err)
+	assert.Equal(t, exported.Header.ID, manifest.Header.ID)
+	assert.ElementsMatch(t, exported.Entries, manifest.Entries)
+
+	restorer, postMetadataDao, store, checkpoints := newTestRestorer(t)
+	report, err := restorer.Restore(context.Background(), bytes.NewReader(archive), manifest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, RestoreReport{Restored: 3}, *report)
+	assert.Len(t, postMetadataDao.Posts, 3)
+	assert.Equal(t, "Post b", postMetadataDao.Posts["b"].Title)
+	assert.Equal(t, []model.Tag{{ID: "tag-1", Label: "go"}}, postMetadataDao.Posts["b"].Tags)
+	assert.Equal(t, time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), postMetadataDao.Posts["b"].CreatedAt)
+	assert.Equal(t, model.OriginRestore, postMetadataDao.Posts["b"].Origin)
+	assert.Equal(t, "# Post c\n", store.Bodies["posts/c"])
+	checkpoint, err := checkpoints.Load()
+	assert.NoError(t, err)
+	assert.True(t, checkpoint.Done)
+}
+
+func TestExport_MissingBody_FailsWithoutManifest(t *testing.T) {
+	item, _ := testPost(t, "a")
+	client := &segmentedScanClient{segments: [][]map[string]types.AttributeValue{{item}}}
+	exporter := NewExporter(client, objectstoretest.NewPostStore(), ExportOptions{TableName: "posts", Segments: 1, PageSize: 1})
+
+	var archive bytes.Buffer
+	_, err := exporter.Export(context.Background(), &archive)
+
+	assert.ErrorContains(t, err, "post a body")
+	_, err = Verify(bytes.NewReader(archive.Bytes()))
+	assert.Error(t, err)
+}
+
+func TestVerify_TamperedArchive_ReturnsErr(t *testing.T) {
+	archive, _ := exportTestSite(t)
+	for name, edit := range map[string]func(string, []byte) []byte{
+		"changed body": func(name string, data []byte) []byte {
+			if name == bodyPath("b") {
+				return []byte("# Defaced\n")
+			}
+			return data
+		},
+		"missing body": func(name string, data []byte) []byte {
+			if name == bodyPath("a") {
+				return nil
+			}
+			return data
+		},
+		"truncated manifest": func(name string, data []byte) []byte {
+			if name == manifestPath {
+				return []byte(string(data[:bytes.IndexByte(data, '\n')+1]))
+			}
+			return data
+		},
+	} {
+		_, err := Verify(bytes.NewReader(rewriteArchive(t, archive, edit)))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestVerify_NoManifest_ReturnsErrNoManifest(t *testing.T) {
+	archive, _ := exportTestSite(t)
+	truncated := rewriteArchive(t, archive, func(name string, data []byte) []byte {
+		if name == manifestPath {
+			return nil
+		}
+		return data
+	})
+
+	_, err := Verify(bytes.NewReader(truncated))
+
+	assert.ErrorIs(t, err, ErrNoManifest)
+}
+
+func TestVerify_NewerVersion_ReturnsErr(t *testing.T) {
+	archive, _ := exportTestSite(t)
+	newer := rewriteArchive(t, archive, func(name string, data []byte) []byte {
+		if name == manifestPath {
+			return bytes.Replace(data, []byte(`"version":1`), []byte(`"version":2`), 1)
+		}
+		return data
+	})
+
+	_, err := Verify(bytes.NewReader(newer))
+
+	assert.ErrorContains(t, err, "version 2")
+}
+
+func TestRestore_TableNotEmpty_ReturnsErr(t *testing.T) {
+	archive, manifest := exportTestSite(t)
+	restorer, postMetadataDao, store, _ := newTestRestorer(t)
+	postMetadataDao.Posts["existing"] = &model.PostMetadata{ID: "existing"}
+
+	_, err := restorer.Restore(context.Background(), bytes.NewReader(archive), manifest)
+
+	assert.ErrorIs(t, err, ErrTableNotEmpty)
+	assert.Empty(t, store.Bodies)
+}
+
+func TestRestore_DifferentBodyInBucket_ReturnsErr(t *testing.T) {
+	archive, manifest := exportTestSite(t)
+	restorer, postMetadataDao, store, _ := newTestRestorer(t)
+	store.Bodies["posts/b"] = "# Written since\n"
+
+	_, err := restorer.Restore(context.Background(), bytes.NewReader(archive), manifest)
+
+	assert.ErrorIs(t, err, ErrBodyExists)
+	assert.Equal(t, "# Written since\n", store.Bodies["posts/b"])
+	assert.NotContains(t, postMetadataDao.Posts, "b")
+}
+
+// interruptedCheckpoints fails the first save after a post is restored, as if the run stopped
+// between restoring the post and recording it.
+type interruptedCheckpoints struct {
+	CheckpointStore
+	interrupted bool
+}
+
+func (store *interruptedCheckpoints) Save(checkpoint *RestoreCheckpoint) error {
+	if checkpoint.Restored > 0 && !store.interrupted {
+		store.interrupted = true
+		return errors.New("interrupted")
+	}
+	return store.CheckpointStore.Save(checkpoint)
+}
+
+func TestRestore_InterruptedDuringFirstPost_Resumes(t *testing.T) {
+	archive, manifest := exportTestSite(t)
+	_, postMetadataDao, store, checkpoints := newTestRestorer(t)
+	restorer := NewRestorer(postMetadataDao, store, &interruptedCheckpoints{CheckpointStore: checkpoints}, &bytes.Buffer{})
+
+	_, err := restorer.Restore(context.Background(), bytes.NewReader(archive), manifest)
+	assert.ErrorContains(t, err, "interrupted")
+	assert.Len(t, postMetadataDao.Posts, 1)
+
+	report, err := restorer.Restore(context.Background(), bytes.NewReader(archive), manifest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, RestoreReport{Restored: 3}, *report)
+	assert.Len(t, postMetadataDao.Posts, 3)
+}
+
+func TestRestore_AfterInterruption_ResumesFromCheckpoint(t *testing.T) {
+	archive, manifest := exportTestSite(t)
+	restorer, postMetadataDao, store, checkpoints := newTestRestorer(t)
+	// The archive's second post fails, so the first is restored before the interruption.
+	secondPost := manifest.Entries[1].PostID
+	store.FailPut = "posts/" + secondPost
+
+	_, err := restorer.Restore(context.Background(), bytes.NewReader(archive), manifest)
+	assert.ErrorContains(t, err, "bucket unavailable")
+	checkpoint, err := checkpoints.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, RestoreCheckpoint{ArchiveID: manifest.Header.ID, Restored: 1}, *checkpoint)
+
+	store.FailPut = ""
+	report, err := restorer.Restore(context.Background(), bytes.NewReader(archive), manifest)
+
+	assert.NoError(t, err)
+	assert.Equal(t, RestoreReport{Restored: 2, Skipped: 1}, *report)
+	assert.Len(t, postMetadataDao.Posts, 3)
+	assert.Len(t, store.Bodies, 3)
+}
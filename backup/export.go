@@ -0,0 +1,233 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/neuralcoral/BlogService/migration"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore"
+	"golang.org/x/sync/errgroup"
+)
+
+type ScanAPI interface {
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+type ExportOptions struct {
+	TableName string
+	Segments  int
+	PageSize  int
+}
+
+// exportedPost is a post read by a scan segment, waiting to be written to the archive.
+type exportedPost struct {
+	id       string
+	metadata []byte
+	body     []byte
+}
+
+// Exporter writes every post to an archive. Segments of a parallel Scan read the metadata and
+// bodies; a single writer streams them into the archive as they arrive.
+type Exporter struct {
+	client    ScanAPI
+	postStore objectstore.PostObjectStore
+	runner    *migration.Runner
+	options   ExportOptions
+	now       func() time.Time
+}
+
+func NewExporter(client ScanAPI, postStore objectstore.PostObjectStore, options ExportOptions) *Exporter {
+	return &Exporter{
+		client:    client,
+		postStore: postStore,
+		runner:    migration.PostMetadataRunner(),
+		options:   options,
+		now:       time.Now,
+	}
+}
+
+// Export writes the archive to out and returns its manifest. Items in an old schema are exported
+// upgraded, as the DAO would read them. The manifest is only written once every segment finished,
+// so an archive from a failed export can't pass verification.
+func (exporter *Exporter) Export(ctx context.Context, out io.Writer) (*Manifest, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	posts := make(chan exportedPost, exporter.options.Segments)
+	for segment := 0; segment < exporter.options.Segments; segment++ {
+		group.Go(func() error {
+			return exporter.scanSegment(groupCtx, segment, posts)
+		})
+	}
+	scanned := make(chan error, 1)
+	go func() {
+		scanned <- group.Wait()
+		close(posts)
+	}()
+
+	writer, err := exporter.newArchiveWriter(out)
+	for post := range posts {
+		if err == nil {
+			err = writer.addPost(post)
+		}
+		if err != nil {
+			// Stop the segments; the loop drains what they already sent.
+			cancel()
+		}
+	}
+	if scanErr := <-scanned; err == nil {
+		err = scanErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return writer.finish()
+}
+
+func (exporter *Exporter) scanSegment(ctx context.Context, segment int, posts chan<- exportedPost) error {
+	input := &dynamodb.ScanInput{
+		TableName:      aws.String(exporter.options.TableName),
+		Segment:        aws.Int32(int32(segment)),
+		TotalSegments:  aws.Int32(int32(exporter.options.Segments)),
+		Limit:          aws.Int32(int32(exporter.options.PageSize)),
+		ConsistentRead: aws.Bool(true),
+	}
+	for {
+		output, err := exporter.client.Scan(ctx, input)
+		if err != nil {
+			return fmt.Errorf("scan segment %d: %w", segment, err)
+		}
+
+		for _, item := range output.Items {
+			post, err := exporter.readPost(ctx, item)
+			if err != nil {
+				return fmt.Errorf("segment %d: %w", segment, err)
+			}
+			select {
+			case posts <- *post:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if len(output.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+func (exporter *Exporter) readPost(ctx context.Context, item migration.Item) (*exportedPost, error) {
+	if _, err := exporter.runner.Migrate(item); err != nil {
+		return nil, err
+	}
+	metadata, err := model.FromDynamoDBAttributeValue(item)
+	if err != nil {
+		return nil, err
+	}
+	body, err := exporter.postStore.GetPost(ctx, metadata.BodyUrl)
+	if err != nil {
+		return nil, fmt.Errorf("post %s body: %w", metadata.ID, err)
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &exportedPost{id: metadata.ID, metadata: encoded, body: []byte(body)}, nil
+}
+
+type archiveWriter struct {
+	gzip     *gzip.Writer
+	tar      *tar.Writer
+	manifest *Manifest
+}
+
+func (exporter *Exporter) newArchiveWriter(out io.Writer) (*archiveWriter, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	gzipWriter := gzip.NewWriter(out)
+	return &archiveWriter{
+		gzip: gzipWriter,
+		tar:  tar.NewWriter(gzipWriter),
+		manifest: &Manifest{Header: ManifestHeader{
+			Format:    formatName,
+			Version:   FormatVersion,
+			ID:        hex.EncodeToString(id),
+			CreatedAt: exporter.now().UTC(),
+		}},
+	}, nil
+}
+
+func (writer *archiveWriter) addPost(post exportedPost) error {
+	entry := ManifestEntry{
+		PostID:         post.id,
+		MetadataPath:   metadataPath(post.id),
+		MetadataSHA256: checksum(post.metadata),
+		BodyPath:       bodyPath(post.id),
+		BodySHA256:     checksum(post.body),
+		BodySize:       int64(len(post.body)),
+	}
+	if err := writer.writeFile(entry.MetadataPath, post.metadata); err != nil {
+		return err
+	}
+	if err := writer.writeFile(entry.BodyPath, post.body); err != nil {
+		return err
+	}
+	writer.manifest.Entries = append(writer.manifest.Entries, entry)
+	return nil
+}
+
+// finish appends the manifest and flushes the archive.
+func (writer *archiveWriter) finish() (*Manifest, error) {
+	writer.manifest.Header.Posts = len(writer.manifest.Entries)
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	if err := encoder.Encode(writer.manifest.Header); err != nil {
+		return nil, err
+	}
+	for _, entry := range writer.manifest.Entries {
+		if err := encoder.Encode(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.writeFile(manifestPath, buffer.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writer.tar.Close(); err != nil {
+		return nil, err
+	}
+	if err := writer.gzip.Close(); err != nil {
+		return nil, err
+	}
+	return writer.manifest, nil
+}
+
+func (writer *archiveWriter) writeFile(name string, data []byte) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  writer.manifest.Header.CreatedAt,
+		Typeflag: tar.TypeReg,
+	}
+	if err := writer.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := writer.tar.Write(data)
+	return err
+}
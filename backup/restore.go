@@ -0,0 +1,183 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+var (
+	ErrTableNotEmpty = errors.New("post table isn't empty; restore only into an empty table, or resume with the checkpoint of an earlier run")
+	ErrBodyExists    = errors.New("content bucket already has a different body at this location; restore only into an empty bucket")
+)
+
+// RestoreCheckpoint records how many posts of an archive, in archive order, have been restored.
+type RestoreCheckpoint struct {
+	ArchiveID string `json:"archiveId"`
+	Restored  int    `json:"restored"`
+	Done      bool   `json:"done"`
+}
+
+type CheckpointStore interface {
+	Load() (*RestoreCheckpoint, error)
+	Save(checkpoint *RestoreCheckpoint) error
+}
+
+type RestoreReport struct {
+	Restored int
+	// Skipped counts posts restored by an earlier, interrupted run.
+	Skipped int
+}
+
+// Restorer recreates the posts in an archive. Every body and post is restored with a conditional
+// create, so a restore never overwrites anything, and progress is checkpointed after each post so
+// an interrupted restore resumes where it stopped.
+type Restorer struct {
+	postMetadataDao dao.PostMetadataDao
+	postStore       objectstore.PostObjectStore
+	checkpoints     CheckpointStore
+	out             io.Writer
+}
+
+func NewRestorer(postMetadataDao dao.PostMetadataDao, postStore objectstore.PostObjectStore, checkpoints CheckpointStore, out io.Writer) *Restorer {
+	return &Restorer{postMetadataDao: postMetadataDao, postStore: postStore, checkpoints: checkpoints, out: out}
+}
+
+// Restore reads the archive Verify produced the manifest for, checking each file against it again
+// in case the archive changed since, and restores its posts. It refuses a table with posts in it
+// unless it is resuming a restore of the same archive.
+func (restorer *Restorer) Restore(ctx context.Context, archive io.Reader, manifest *Manifest) (*RestoreReport, error) {
+	checkpoint, err := restorer.startCheckpoint(ctx, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RestoreReport{}
+	expected := manifest.checksums()
+	pending := map[string]*restoringPost{}
+	position := 0
+	err = readArchive(archive, func(name string, data []byte) error {
+		if name == manifestPath {
+			return nil
+		}
+		if sum, ok := expected[name]; !ok || sum != checksum(data) {
+			return fmt.Errorf("%s doesn't match the manifest; verify the archive again", name)
+		}
+
+		postID, isBody := postFile(name)
+		post := pending[postID]
+		if post == nil {
+			post = &restoringPost{}
+			pending[postID] = post
+		}
+		if isBody {
+			post.Body = string(data)
+			post.haveBody = true
+		} else {
+			if err := json.Unmarshal(data, &post.PostMetadata); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			post.haveMetadata = true
+		}
+		if !post.haveMetadata || !post.haveBody {
+			return nil
+		}
+
+		delete(pending, postID)
+		position++
+		if position <= checkpoint.Restored {
+			report.Skipped++
+			return nil
+		}
+		if err := restorer.restorePost(ctx, &post.Post); err != nil {
+			return fmt.Errorf("restore post %s: %w", postID, err)
+		}
+		report.Restored++
+		checkpoint.Restored = position
+		return restorer.checkpoints.Save(checkpoint)
+	})
+	if err != nil {
+		return report, err
+	}
+	if len(pending) > 0 {
+		return report, fmt.Errorf("archive has %d posts missing their metadata or body", len(pending))
+	}
+
+	checkpoint.Done = true
+	return report, restorer.checkpoints.Save(checkpoint)
+}
+
+// restoringPost collects a post's metadata and body, which the archive stores as separate files.
+type restoringPost struct {
+	model.Post
+	haveMetadata bool
+	haveBody     bool
+}
+
+// startCheckpoint resumes the checkpoint of an earlier restore of the archive, or checks that the
+// table is empty and starts a new one.
+func (restorer *Restorer) startCheckpoint(ctx context.Context, manifest *Manifest) (*RestoreCheckpoint, error) {
+	checkpoint, err := restorer.checkpoints.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint: %w", err)
+	}
+	if checkpoint != nil && checkpoint.ArchiveID == manifest.Header.ID {
+		fmt.Fprintf(restorer.out, "resuming after %d of %d posts\n", checkpoint.Restored, len(manifest.Entries))
+		return checkpoint, nil
+	}
+
+	posts, _, err := restorer.postMetadataDao.ListPostMetadata(ctx, 1, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(posts) > 0 {
+		return nil, ErrTableNotEmpty
+	}
+	// Saved before the first write, so a run that stops while restoring the first post resumes
+	// rather than finding a table that is no longer empty.
+	checkpoint = &RestoreCheckpoint{ArchiveID: manifest.Header.ID}
+	if err := restorer.checkpoints.Save(checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// restorePost writes the body before the metadata, so a post is never visible without its body.
+// A post that already exists was restored by an interrupted run that hadn't saved its checkpoint; a
+// body that already exists is accepted only if it is the archive's, for the same reason.
+func (restorer *Restorer) restorePost(ctx context.Context, post *model.Post) error {
+	post.Origin = model.OriginRestore
+	err := restorer.postStore.CreatePost(ctx, post.BodyUrl, post.Body)
+	if apperror.KindOf(err) == apperror.KindConflict {
+		existing, getErr := restorer.postStore.GetPost(ctx, post.BodyUrl)
+		if getErr != nil {
+			return getErr
+		}
+		if existing != post.Body {
+			return fmt.Errorf("%s: %w", post.BodyUrl, ErrBodyExists)
+		}
+	} else if err != nil {
+		return err
+	}
+	err = restorer.postMetadataDao.CreatePostMetadata(ctx, &post.PostMetadata)
+	if apperror.KindOf(err) == apperror.KindConflict {
+		return nil
+	}
+	return err
+}
+
+// postFile returns the post a file in the archive belongs to and whether it is the body.
+func postFile(name string) (string, bool) {
+	if strings.HasPrefix(name, "bodies/") {
+		return strings.TrimSuffix(strings.TrimPrefix(name, "bodies/"), ".md"), true
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(name, "posts/"), ".json"), false
+}
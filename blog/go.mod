@@ -1,3 +0,0 @@
-module BlogService/blog
-
-go 1.23.1
@@ -0,0 +1,125 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/neuralcoral/BlogService/config"
+	"github.com/neuralcoral/BlogService/mail"
+)
+
+const secretProviderEnv = "SECRET_PROVIDER"
+
+type Environment struct {
+	Config    *config.Config
+	AWSConfig aws.Config
+}
+
+// Load reads the AWS configuration and the service configuration. Lambda entry points call it once
+// per cold start and share the result across invocations.
+func Load(ctx context.Context) (*Environment, error) {
+	return LoadWorker(ctx, (*config.Config).Validate)
+}
+
+// LoadWorker is Load for a worker Lambda, validating only the settings it uses.
+func LoadWorker(ctx context.Context, validate func(*config.Config) error) (*Environment, error) {
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+
+	secrets, err := newSecretProvider(awsConfig, os.Getenv(secretProviderEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	serviceConfig, err := config.LoadFor(ctx, secrets, validate)
+	if err != nil {
+		return nil, err
+	}
+	awsConfig.Region = serviceConfig.Region
+
+	return &Environment{Config: serviceConfig, AWSConfig: awsConfig}, nil
+}
+
+// LoadTool is Load for command line tools: the service configuration is neither validated nor given
+// its secrets.
+func LoadTool(ctx context.Context) (*Environment, error) {
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load AWS config: %w", err)
+	}
+
+	serviceConfig, err := config.FromEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	awsConfig.Region = serviceConfig.Region
+
+	return &Environment{Config: serviceConfig, AWSConfig: awsConfig}, nil
+}
+
+func (environment *Environment) DynamoDBClient() *dynamodb.Client {
+	return dynamodb.NewFromConfig(environment.AWSConfig, func(options *dynamodb.Options) {
+		if environment.Config.DynamoDBEndpoint != "" {
+			options.BaseEndpoint = aws.String(environment.Config.DynamoDBEndpoint)
+		}
+	})
+}
+
+// S3Client switches to path-style addressing with an endpoint override, since local stand-ins
+// don't serve bucket subdomains.
+func (environment *Environment) S3Client() *s3.Client {
+	return s3.NewFromConfig(environment.AWSConfig, func(options *s3.Options) {
+		if environment.Config.S3Endpoint != "" {
+			options.BaseEndpoint = aws.String(environment.Config.S3Endpoint)
+			options.UsePathStyle = true
+		}
+	})
+}
+
+// Mailer picks SMTP when a relay is configured. Only when the configuration explicitly asks for it
+// does it write messages where a developer can read them; it never falls back to that.
+func (environment *Environment) Mailer() (mail.Mailer, error) {
+	cfg := environment.Config
+	switch {
+	case cfg.SMTPAddress != "":
+		return mail.NewSMTPMailer(mail.SMTPOptions{
+			Address:  cfg.SMTPAddress,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}), nil
+	case cfg.MailOutboxFile != "":
+		file, err := os.OpenFile(cfg.MailOutboxFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		return mail.NewFileMailer(cfg.MailFrom, file), nil
+	case cfg.MailToStderr:
+		return mail.NewFileMailer(cfg.MailFrom, os.Stderr), nil
+	default:
+		return nil, errors.New("no mailer configured; set smtpAddress, or mailOutboxFile or mailToStderr for local development")
+	}
+}
+
+func newSecretProvider(awsConfig aws.Config, name string) (config.SecretProvider, error) {
+	switch name {
+	case "", "env":
+		return config.EnvSecretProvider{}, nil
+	case "ssm":
+		return config.NewSSMSecretProvider(ssm.NewFromConfig(awsConfig)), nil
+	case "secretsmanager":
+		return config.NewSecretsManagerSecretProvider(secretsmanager.NewFromConfig(awsConfig)), nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q", secretProviderEnv, name)
+	}
+}
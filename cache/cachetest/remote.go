@@ -0,0 +1,41 @@
+// Package cachetest provides an in-memory remote cache for tests.
+package cachetest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Remote ignores TTLs; entries stay until deleted.
+type Remote struct {
+	mutex  sync.Mutex
+	Values map[string][]byte
+}
+
+func NewRemote() *Remote {
+	return &Remote{Values: map[string][]byte{}}
+}
+
+func (remote *Remote) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	remote.mutex.Lock()
+	defer remote.mutex.Unlock()
+	value, ok := remote.Values[key]
+	return value, ok, nil
+}
+
+func (remote *Remote) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	remote.mutex.Lock()
+	defer remote.mutex.Unlock()
+	remote.Values[key] = value
+	return nil
+}
+
+func (remote *Remote) Delete(ctx context.Context, keys ...string) error {
+	remote.mutex.Lock()
+	defer remote.mutex.Unlock()
+	for _, key := range keys {
+		delete(remote.Values, key)
+	}
+	return nil
+}
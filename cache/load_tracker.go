@@ -0,0 +1,65 @@
+package cache
+
+import "sync"
+
+// LoadTracker tells a read-through load whether the key was invalidated while it ran, so a value read
+// before a write isn't left in the cache after the write's invalidation.
+type LoadTracker struct {
+	mutex    sync.Mutex
+	inFlight map[string]map[*TrackedLoad]struct{}
+}
+
+type TrackedLoad struct {
+	tracker     *LoadTracker
+	key         string
+	invalidated bool
+}
+
+func NewLoadTracker() *LoadTracker {
+	return &LoadTracker{inFlight: make(map[string]map[*TrackedLoad]struct{})}
+}
+
+// Start must be called before the load reads anything, and Done once it has stored what it read.
+func (tracker *LoadTracker) Start(key string) *TrackedLoad {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	load := &TrackedLoad{tracker: tracker, key: key}
+	if tracker.inFlight[key] == nil {
+		tracker.inFlight[key] = make(map[*TrackedLoad]struct{})
+	}
+	tracker.inFlight[key][load] = struct{}{}
+	return load
+}
+
+// Invalidate marks the loads in flight for the keys. Call it before deleting the cached entries: a
+// load that stores its value and then checks Invalidated either sees the mark or has its value
+// deleted after it.
+func (tracker *LoadTracker) Invalidate(keys ...string) {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+	for _, key := range keys {
+		for load := range tracker.inFlight[key] {
+			load.invalidated = true
+		}
+	}
+}
+
+func (load *TrackedLoad) Invalidated() bool {
+	load.tracker.mutex.Lock()
+	defer load.tracker.mutex.Unlock()
+	return load.invalidated
+}
+
+func (load *TrackedLoad) Done() {
+	load.tracker.mutex.Lock()
+	defer load.tracker.mutex.Unlock()
+	loads := load.tracker.inFlight[load.key]
+	delete(loads, load)
+	if len(loads) == 0 {
+		delete(load.tracker.inFlight, load.key)
+	}
+}
+
+func (load *TrackedLoad) Key() string {
+	return load.key
+}
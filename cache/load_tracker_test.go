@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTracker_Invalidate_MarksOnlyLoadsInFlightForKey(t *testing.T) {
+	tracker := NewLoadTracker()
+	finished := tracker.Start("a")
+	finished.Done()
+	load := tracker.Start("a")
+	other := tracker.Start("b")
+
+	tracker.Invalidate("a")
+	later := tracker.Start("a")
+
+	assert.True(t, load.Invalidated())
+	assert.False(t, finished.Invalidated())
+	assert.False(t, other.Invalidated())
+	assert.False(t, later.Invalidated())
+}
+
+func TestLoadTracker_Done_ForgetsKey(t *testing.T) {
+	tracker := NewLoadTracker()
+	first := tracker.Start("a")
+	second := tracker.Start("a")
+
+	first.Done()
+	assert.Len(t, tracker.inFlight["a"], 1)
+	second.Done()
+
+	assert.Empty(t, tracker.inFlight)
+}
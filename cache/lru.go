@@ -0,0 +1,92 @@
+// Package cache provides the in-process and remote caches behind the read-through decorators.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is a size-bounded cache whose entries also expire after a TTL. It lives in package state of the
+// Lambda, so it survives warm invocations but is never shared between instances.
+type LRU[K comparable, V any] struct {
+	mutex    sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[K]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+func NewLRU[K comparable, V any](capacity int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[K]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+func (lru *LRU[K, V]) Get(key K) (V, bool) {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	var zero V
+	element, ok := lru.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := element.Value.(*lruEntry[K, V])
+	if !lru.now().Before(entry.expiresAt) {
+		lru.remove(element)
+		return zero, false
+	}
+	lru.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (lru *LRU[K, V]) Set(key K, value V) {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	expiresAt := lru.now().Add(lru.ttl)
+	if element, ok := lru.entries[key]; ok {
+		entry := element.Value.(*lruEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		lru.order.MoveToFront(element)
+		return
+	}
+
+	lru.entries[key] = lru.order.PushFront(&lruEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+	for lru.order.Len() > lru.capacity {
+		lru.remove(lru.order.Back())
+	}
+}
+
+func (lru *LRU[K, V]) Delete(key K) {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	if element, ok := lru.entries[key]; ok {
+		lru.remove(element)
+	}
+}
+
+func (lru *LRU[K, V]) Len() int {
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+	return lru.order.Len()
+}
+
+func (lru *LRU[K, V]) remove(element *list.Element) {
+	lru.order.Remove(element)
+	delete(lru.entries, element.Value.(*lruEntry[K, V]).key)
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_OverCapacity_EvictsLeastRecentlyUsed(t *testing.T) {
+	lru := NewLRU[string, int](2, time.Minute)
+	lru.Set("a", 1)
+	lru.Set("b", 2)
+	lru.Get("a")
+
+	lru.Set("c", 3)
+
+	_, ok := lru.Get("b")
+	assert.False(t, ok)
+	value, ok := lru.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 2, lru.Len())
+}
+
+func TestLRU_ExpiredEntry_IsAMiss(t *testing.T) {
+	now := time.Now()
+	lru := NewLRU[string, int](2, time.Minute)
+	lru.now = func() time.Time { return now }
+	lru.Set("a", 1)
+
+	now = now.Add(time.Minute)
+
+	_, ok := lru.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, lru.Len())
+}
+
+func TestLRU_Delete_RemovesEntry(t *testing.T) {
+	lru := NewLRU[string, int](2, time.Minute)
+	lru.Set("a", 1)
+
+	lru.Delete("a")
+
+	_, ok := lru.Get("a")
+	assert.False(t, ok)
+}
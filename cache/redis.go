@@ -0,0 +1,232 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+type RedisOptions struct {
+	Address  string
+	Password string
+	Timeout  time.Duration
+	PoolSize int
+}
+
+// RedisCache speaks the Redis protocol (RESP) directly, so it works with Redis, Valkey and
+// ElastiCache without another dependency. Connections are pooled and dropped after any error.
+type RedisCache struct {
+	options RedisOptions
+	idle    chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+type redisError string
+
+func (err redisError) Error() string {
+	return "redis: " + string(err)
+}
+
+func NewRedisCache(options RedisOptions) *RedisCache {
+	if options.Timeout <= 0 {
+		options.Timeout = 200 * time.Millisecond
+	}
+	if options.PoolSize <= 0 {
+		options.PoolSize = 4
+	}
+	return &RedisCache{options: options, idle: make(chan *redisConn, options.PoolSize)}
+}
+
+func (cache *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := cache.do(ctx, "GET", []byte(key))
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+func (cache *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := cache.do(ctx, "SET", []byte(key), value, []byte("PX"), []byte(strconv.FormatInt(ttl.Milliseconds(), 10)))
+	return err
+}
+
+func (cache *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := make([][]byte, len(keys))
+	for i, key := range keys {
+		args[i] = []byte(key)
+	}
+	_, err := cache.do(ctx, "DEL", args...)
+	return err
+}
+
+func (cache *RedisCache) Close() error {
+	for {
+		select {
+		case conn := <-cache.idle:
+			conn.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (cache *RedisCache) do(ctx context.Context, command string, args ...[]byte) (interface{}, error) {
+	conn, err := cache.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(cache.options.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.conn.SetDeadline(deadline)
+
+	reply, err := conn.roundTrip(command, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection may hold half a reply; don't reuse it.
+		conn.conn.Close()
+		return nil, err
+	}
+	cache.release(conn)
+	return reply, err
+}
+
+func (cache *RedisCache) acquire(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-cache.idle:
+		return conn, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: cache.options.Timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", cache.options.Address)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if cache.options.Password != "" {
+		conn.conn.SetDeadline(time.Now().Add(cache.options.Timeout))
+		if _, err := conn.roundTrip("AUTH", []byte(cache.options.Password)); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (cache *RedisCache) release(conn *redisConn) {
+	select {
+	case cache.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+func (conn *redisConn) roundTrip(command string, args ...[]byte) (interface{}, error) {
+	if _, err := conn.conn.Write(encodeCommand(command, args...)); err != nil {
+		return nil, err
+	}
+	return readReply(conn.reader)
+}
+
+// encodeCommand writes the command as a RESP array of bulk strings.
+func encodeCommand(command string, args ...[]byte) []byte {
+	buffer := []byte("*" + strconv.Itoa(len(args)+1) + "\r\n")
+	buffer = appendBulk(buffer, []byte(command))
+	for _, arg := range args {
+		buffer = appendBulk(buffer, arg)
+	}
+	return buffer
+}
+
+func appendBulk(buffer []byte, value []byte) []byte {
+	buffer = append(buffer, '$')
+	buffer = strconv.AppendInt(buffer, int64(len(value)), 10)
+	buffer = append(buffer, '\r', '\n')
+	buffer = append(buffer, value...)
+	return append(buffer, '\r', '\n')
+}
+
+// readReply decodes one RESP value: simple strings as string, errors as redisError, integers as
+// int64, bulk strings as []byte, arrays as []interface{} and null as nil.
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		value := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		return value[:length], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, count)
+		for i := range values {
+			if values[i], err = readReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+func readLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed line %q", line)
+	}
+	return line[:len(line)-2], nil
+}
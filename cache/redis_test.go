@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis is a local stand-in that understands the handful of commands RedisCache sends.
+type fakeRedis struct {
+	mutex    sync.Mutex
+	values   map[string][]byte
+	ttls     map[string]string
+	password string
+	listener net.Listener
+}
+
+func startFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fakeRedis{values: map[string][]byte{}, ttls: map[string]string{}, password: password, listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (server *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := server.password == ""
+	for {
+		request, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, arg := range request.([]interface{}) {
+			args = append(args, string(arg.([]byte)))
+		}
+
+		server.mutex.Lock()
+		var reply string
+		switch {
+		case args[0] == "AUTH":
+			authenticated = args[1] == server.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required\r\n"
+		case args[0] == "GET":
+			value, ok := server.values[args[1]]
+			reply = "$-1\r\n"
+			if ok {
+				reply = "$" + strconv.Itoa(len(value)) + "\r\n" + string(value) + "\r\n"
+			}
+		case args[0] == "SET":
+			server.values[args[1]] = []byte(args[2])
+			server.ttls[args[1]] = args[4]
+			reply = "+OK\r\n"
+		case args[0] == "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, ok := server.values[key]; ok {
+					delete(server.values, key)
+					deleted++
+				}
+			}
+			reply = ":" + strconv.Itoa(deleted) + "\r\n"
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		server.mutex.Unlock()
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (server *fakeRedis) ttl(key string) string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.ttls[key]
+}
+
+func TestRedisCache_SetGetDelete_RoundTrips(t *testing.T) {
+	server := startFakeRedis(t, "")
+	cache := NewRedisCache(RedisOptions{Address: server.listener.Addr().String()})
+	defer cache.Close()
+	ctx := context.Background()
+
+	assert.NoError(t, cache.Set(ctx, "post:1", []byte("hello\r\nworld"), 30*time.Second))
+	value, ok, err := cache.Get(ctx, "post:1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "hello\r\nworld", string(value))
+	assert.Equal(t, "30000", server.ttl("post:1"))
+
+	assert.NoError(t, cache.Delete(ctx, "post:1", "post:2"))
+	_, ok, err = cache.Get(ctx, "post:1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRedisCache_Password_Authenticates(t *testing.T) {
+	server := startFakeRedis(t, "secret")
+	cache := NewRedisCache(RedisOptions{Address: server.listener.Addr().String(), Password: "secret"})
+	defer cache.Close()
+
+	assert.NoError(t, cache.Set(context.Background(), "key", []byte("value"), time.Second))
+}
+
+func TestRedisCache_ErrorReply_ReturnsError(t *testing.T) {
+	server := startFakeRedis(t, "secret")
+	cache := NewRedisCache(RedisOptions{Address: server.listener.Addr().String()})
+	defer cache.Close()
+
+	_, _, err := cache.Get(context.Background(), "key")
+
+	assert.EqualError(t, err, "redis: NOAUTH Authentication required")
+}
+
+func TestRedisCache_Unreachable_ReturnsError(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	address := listener.Addr().String()
+	listener.Close()
+	cache := NewRedisCache(RedisOptions{Address: address})
+
+	_, _, err := cache.Get(context.Background(), "key")
+
+	assert.Error(t, err)
+}
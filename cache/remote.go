@@ -0,0 +1,15 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Remote is a cache shared by every Lambda instance. The decorators treat it as best effort: a
+// failing remote cache slows requests down but never fails them.
+type Remote interface {
+	// Get reports false for a missing key.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,159 @@
+// Command backup exports every post into an archive, verifies an archive and restores one.
+//
+//	backup export -out site.tar.gz
+//	backup verify -in site.tar.gz
+//	backup restore -in site.tar.gz
+//
+// Restore verifies the whole archive before writing anything and only restores into an empty post
+// table and content bucket: it refuses a table with posts in it, and stops at a body the bucket
+// already holds unless it is the archive's own. It records progress in a checkpoint file, so
+// running it again after an interruption resumes where it stopped.
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/neuralcoral/BlogService/backup"
+	"github.com/neuralcoral/BlogService/bootstrap"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/migration"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal("usage: backup export|verify|restore [flags]")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "export":
+		err = export(ctx, args)
+	case "verify":
+		err = verify(args)
+	case "restore":
+		err = restore(ctx, args)
+	default:
+		err = fmt.Errorf("unknown command %q; use export, verify or restore", command)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func export(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	outPath := flags.String("out", "", "file to write the archive to")
+	segments := flags.Int("segments", 4, "number of parallel scan segments")
+	pageSize := flags.Int("page-size", 100, "items per scan page")
+	flags.Parse(args)
+	if *outPath == "" {
+		return fmt.Errorf("out is required")
+	}
+	if *segments < 1 || *pageSize < 1 {
+		return fmt.Errorf("segments and page-size must be positive")
+	}
+
+	environment, err := bootstrap.LoadTool(ctx)
+	if err != nil {
+		return err
+	}
+	cfg := environment.Config
+	exporter := backup.NewExporter(
+		environment.DynamoDBClient(),
+		objectstore.NewPostS3ObjectStore(environment.S3Client(), cfg.ContentBucket),
+		backup.ExportOptions{TableName: cfg.PostTableName, Segments: *segments, PageSize: *pageSize},
+	)
+
+	file, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	manifest, err := exporter.Export(ctx, io.MultiWriter(file, hash))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// A partial archive has no manifest and can't be restored; don't leave it looking like a backup.
+		os.Remove(*outPath)
+		return err
+	}
+	fmt.Printf("exported %d posts to %s\narchive %s sha256 %s\n",
+		manifest.Header.Posts, *outPath, manifest.Header.ID, hex.EncodeToString(hash.Sum(nil)))
+	return nil
+}
+
+func verify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	inPath := flags.String("in", "", "archive to verify")
+	flags.Parse(args)
+	if *inPath == "" {
+		return fmt.Errorf("in is required")
+	}
+
+	file, err := os.Open(*inPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	manifest, err := backup.Verify(file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("archive %s from %s is intact: %d posts\n",
+		manifest.Header.ID, manifest.Header.CreatedAt.Format("2006-01-02 15:04:05 MST"), manifest.Header.Posts)
+	return nil
+}
+
+func restore(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	inPath := flags.String("in", "", "archive to restore")
+	checkpointPath := flags.String("checkpoint", "restore.checkpoint.json", "file that records progress for resuming")
+	flags.Parse(args)
+	if *inPath == "" {
+		return fmt.Errorf("in is required")
+	}
+
+	file, err := os.Open(*inPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	manifest, err := backup.Verify(file)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	environment, err := bootstrap.LoadTool(ctx)
+	if err != nil {
+		return err
+	}
+	cfg := environment.Config
+	restorer := backup.NewRestorer(
+		dao.NewPostMetadataDdbDao(environment.DynamoDBClient(), cfg.PostTableName),
+		objectstore.NewPostS3ObjectStore(environment.S3Client(), cfg.ContentBucket),
+		migration.NewFileCheckpointStore[backup.RestoreCheckpoint](*checkpointPath),
+		os.Stdout,
+	)
+
+	report, err := restorer.Restore(ctx, file, manifest)
+	if report != nil {
+		fmt.Printf("restored %d of %d posts, %d restored by an earlier run\n",
+			report.Restored, manifest.Header.Posts, report.Skipped)
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+// Command import creates posts from a directory of Markdown files with YAML or TOML front matter,
+// a WordPress export (WXR) or a Ghost JSON export.
+//
+// Each post's ID comes from its slug, so running the command again skips the posts it already
+// created. Use -dry-run to see what would be created first, and -mapping to write a CSV of old
+// addresses and new post IDs for redirects.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/neuralcoral/BlogService/api"
+	"github.com/neuralcoral/BlogService/bootstrap"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/importer"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+func main() {
+	format := flag.String("format", "markdown", "markdown, wordpress or ghost")
+	source := flag.String("source", "", "directory of Markdown files, or the export file to import")
+	siteURL := flag.String("site-url", "", "address of the old blog, for recognizing links between posts")
+	mappingPath := flag.String("mapping", "", "file to write the CSV mapping of old addresses to post IDs to")
+	dryRun := flag.Bool("dry-run", false, "report the posts that would be created without writing them")
+	flag.Parse()
+	if *source == "" {
+		log.Fatal("source is required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	environment, err := bootstrap.LoadTool(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	documents, err := readDocuments(*format, *source, *siteURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *mappingPath != "" {
+		if err := writeMapping(*mappingPath, documents); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	cfg := environment.Config
+	postService := api.NewPostService(
+		dao.NewPostMetadataDdbDao(environment.DynamoDBClient(), cfg.PostTableName),
+		objectstore.NewPostS3ObjectStore(environment.S3Client(), cfg.ContentBucket),
+		nil,
+		nil,
+		nil,
+	)
+
+	report, err := importer.NewImporter(postService, importer.Options{DryRun: *dryRun}, os.Stdout).Run(ctx, documents)
+	if report != nil {
+		fmt.Printf("read %d, created %d, already imported %d, invalid %d\n",
+			len(documents), report.Created, report.Existing, report.Invalid)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func readDocuments(format string, source string, siteURL string) ([]*importer.Document, error) {
+	if format == "markdown" {
+		return importer.ReadMarkdownDir(os.DirFS(source))
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	switch format {
+	case "wordpress":
+		return importer.ReadWordPress(file, siteURL)
+	case "ghost":
+		return importer.ReadGhost(file, siteURL)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func writeMapping(path string, documents []*importer.Document) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := importer.WriteMapping(file, documents); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/neuralcoral/BlogService/bootstrap"
+	"github.com/neuralcoral/BlogService/config"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/media"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+func main() {
+	environment, err := bootstrap.LoadWorker(context.Background(), (*config.Config).ValidateMediaProcessor)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s3Client := environment.S3Client()
+	mediaAssetDao := dao.NewMediaAssetDdbDao(environment.DynamoDBClient(), environment.Config.MediaTableName)
+	mediaStore := objectstore.NewMediaS3ObjectStore(s3Client, s3.NewPresignClient(s3Client), environment.Config.ContentBucket)
+	processor := media.NewProcessor(mediaAssetDao, mediaStore, media.DefaultVariants)
+
+	lambda.Start(processor.HandleS3Event)
+}
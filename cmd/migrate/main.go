@@ -0,0 +1,57 @@
+// Command migrate upgrades every post metadata item to the latest schema version.
+//
+// The lazy upgrade in the DAO already serves old items correctly; running this command persists the
+// upgrade so the migration code can eventually be retired.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/neuralcoral/BlogService/bootstrap"
+	"github.com/neuralcoral/BlogService/migration"
+)
+
+func main() {
+	table := flag.String("table", "", "table to migrate (defaults to POST_TABLE_NAME)")
+	segments := flag.Int("segments", 4, "number of parallel scan segments")
+	pageSize := flag.Int("page-size", 100, "items per scan page")
+	checkpointPath := flag.String("checkpoint", "migrate.checkpoint.json", "file that records progress for resuming")
+	dryRun := flag.Bool("dry-run", false, "report the items that would change without writing them")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	environment, err := bootstrap.LoadTool(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *table == "" {
+		*table = environment.Config.PostTableName
+	}
+	if *segments < 1 || *pageSize < 1 {
+		log.Fatal("segments and page-size must be positive")
+	}
+
+	migrator := migration.NewBulkMigrator(
+		environment.DynamoDBClient(),
+		migration.PostMetadataRunner(),
+		migration.NewFileCheckpointStore[migration.Checkpoint](*checkpointPath),
+		migration.BulkOptions{TableName: *table, Segments: *segments, PageSize: *pageSize, DryRun: *dryRun},
+		os.Stdout,
+	)
+
+	report, err := migrator.Run(ctx)
+	if report != nil {
+		fmt.Printf("scanned %d, migrated %d, skipped %d modified during migration\n",
+			report.Scanned, report.Migrated, report.Conflicts)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
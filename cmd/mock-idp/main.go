@@ -0,0 +1,42 @@
+// Command mock-idp runs a local OpenID Connect provider for trying single sign-on without a real
+// one. Every authorization request logs in the user given by the flags.
+//
+// Point OIDC_ISSUER at its address, e.g. http://localhost:9400, with matching OIDC_CLIENT_ID.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/neuralcoral/BlogService/oidc/oidctest"
+)
+
+func main() {
+	address := flag.String("address", "localhost:9400", "address to listen on")
+	clientID := flag.String("client-id", "blog", "client ID to accept")
+	clientSecret := flag.String("client-secret", "", "client secret to accept")
+	subject := flag.String("sub", "user-1", "subject of the logged in user")
+	email := flag.String("email", "author@example.com", "email of the logged in user")
+	username := flag.String("username", "author", "preferred_username of the logged in user")
+	groups := flag.String("groups", "", "comma separated groups of the logged in user")
+	flag.Parse()
+
+	provider, err := oidctest.NewProvider("http://"+*address, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatal(err)
+	}
+	provider.Claims = map[string]interface{}{
+		"sub":                *subject,
+		"email":              *email,
+		"email_verified":     true,
+		"preferred_username": *username,
+	}
+	if *groups != "" {
+		provider.Claims["groups"] = strings.Split(*groups, ",")
+	}
+
+	log.Printf("mock OpenID Connect provider at %s", provider.URL)
+	log.Fatal(http.ListenAndServe(*address, provider))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/bootstrap"
+	"github.com/neuralcoral/BlogService/config"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/newsletter"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+// newsletter mails newly published posts to subscribers. It is triggered by a bus rule matching
+// PostPublished events from the post events' source.
+func main() {
+	environment, err := bootstrap.LoadWorker(context.Background(), (*config.Config).ValidateNewsletter)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mailer, err := environment.Mailer()
+	if err != nil {
+		log.Fatal(err)
+	}
+	secretBox, err := auth.NewSecretBox(environment.Config.TOTPEncryptionKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dynamoDBClient := environment.DynamoDBClient()
+	sender := newsletter.NewSender(
+		dao.NewSubscriberDdbDao(dynamoDBClient, environment.Config.SubscriberTableName),
+		dao.NewNewsletterIssueDdbDao(dynamoDBClient, environment.Config.NewsletterIssueTableName),
+		objectstore.NewPostS3ObjectStore(environment.S3Client(), environment.Config.ContentBucket),
+		mailer,
+		secretBox,
+		newsletter.Options{LinkBaseURL: environment.Config.AppBaseURL, APIBaseURL: environment.Config.APIBaseURL},
+	)
+
+	lambda.Start(sender.HandleEventBridgeEvent)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/neuralcoral/BlogService/bootstrap"
+	"github.com/neuralcoral/BlogService/config"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/domainevent"
+)
+
+// post-events consumes the post table's stream, which must be configured with new and old images
+// and ReportBatchItemFailures.
+func main() {
+	environment, err := bootstrap.LoadWorker(context.Background(), (*config.Config).ValidatePostEvents)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var publishedEventDao dao.PublishedEventDao
+	if environment.Config.PublishedEventTableName != "" {
+		publishedEventDao = dao.NewPublishedEventDdbDao(environment.DynamoDBClient(), environment.Config.PublishedEventTableName)
+	}
+	publisher := domainevent.NewPublisher(newSink(environment), publishedEventDao)
+
+	lambda.Start(publisher.HandleDynamoDBEvent)
+}
+
+func newSink(environment *bootstrap.Environment) domainevent.Sink {
+	if environment.Config.PostEventSink == "sns" {
+		return domainevent.NewSNSSink(sns.NewFromConfig(environment.AWSConfig), environment.Config.PostEventTopicARN)
+	}
+	return domainevent.NewEventBridgeSink(eventbridge.NewFromConfig(environment.AWSConfig), environment.Config.EventBusName, environment.Config.PostEventSource)
+}
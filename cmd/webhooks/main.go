@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/bootstrap"
+	"github.com/neuralcoral/BlogService/config"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/webhook"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// webhooks delivers domain events to webhooks. It is triggered by a bus rule matching the post
+// events' source and by a schedule, every minute or so, that retries due deliveries.
+func main() {
+	environment, err := bootstrap.LoadWorker(context.Background(), (*config.Config).ValidateWebhooks)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	secretBox, err := auth.NewSecretBox(environment.Config.TOTPEncryptionKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dynamoDBClient := environment.DynamoDBClient()
+	dispatcher := webhook.NewDispatcher(
+		dao.NewWebhookDdbDao(dynamoDBClient, environment.Config.WebhookTableName),
+		dao.NewWebhookDeliveryDdbDao(dynamoDBClient, environment.Config.WebhookDeliveryTableName),
+		secretBox,
+		webhook.NewHTTPClient(webhookTimeout),
+		webhook.DefaultRetryPolicy,
+	)
+
+	lambda.Start(dispatcher.HandleEventBridgeEvent)
+}
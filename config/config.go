@@ -0,0 +1,460 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const configFileEnv = "CONFIG_FILE"
+
+type Config struct {
+	Region string `json:"region" env:"AWS_REGION"`
+
+	// Endpoint overrides point the clients at local stand-ins such as DynamoDB Local or LocalStack.
+	DynamoDBEndpoint string `json:"dynamoDbEndpoint" env:"DYNAMODB_ENDPOINT"`
+	S3Endpoint       string `json:"s3Endpoint" env:"S3_ENDPOINT"`
+
+	PostTableName    string `json:"postTableName" env:"POST_TABLE_NAME"`
+	MediaTableName   string `json:"mediaTableName" env:"MEDIA_TABLE_NAME"`
+	UserTableName    string `json:"userTableName" env:"USER_TABLE_NAME"`
+	SessionTableName string `json:"sessionTableName" env:"SESSION_TABLE_NAME"`
+	APIKeyTableName  string `json:"apiKeyTableName" env:"API_KEY_TABLE_NAME"`
+	// AccountTokenTableName holds invitation and password reset tokens; DeleteAfter is its TTL attribute.
+	AccountTokenTableName string `json:"accountTokenTableName" env:"ACCOUNT_TOKEN_TABLE_NAME"`
+	ContentBucket         string `json:"contentBucket" env:"CONTENT_BUCKET"`
+	// AuditTableName holds the audit log; events are deleted through its DeleteAfter TTL attribute
+	// once AuditRetention has passed.
+	AuditTableName string        `json:"auditTableName" env:"AUDIT_TABLE_NAME"`
+	AuditRetention time.Duration `json:"auditRetention" env:"AUDIT_RETENTION"`
+
+	// JWTSigningKeySecret names the secret holding the signing key; Load resolves it into JWTSigningKey.
+	JWTSigningKeySecret string        `json:"jwtSigningKeySecret" env:"JWT_SIGNING_KEY_SECRET"`
+	JWTSigningKey       string        `json:"-"`
+	JWTIssuer           string        `json:"jwtIssuer" env:"JWT_ISSUER"`
+	AccessTokenTTL      time.Duration `json:"accessTokenTtl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL     time.Duration `json:"refreshTokenTtl" env:"REFRESH_TOKEN_TTL"`
+
+	// TOTPEncryptionKeySecret names the secret holding the base64 AES-256 key that TOTP and webhook
+	// secrets are stored under; Load resolves it into TOTPEncryptionKey. TwoFactorRequiredRoles must enrol.
+	TOTPEncryptionKeySecret string   `json:"totpEncryptionKeySecret" env:"TOTP_ENCRYPTION_KEY_SECRET"`
+	TOTPEncryptionKey       string   `json:"-"`
+	TOTPIssuer              string   `json:"totpIssuer" env:"TOTP_ISSUER"`
+	TwoFactorRequiredRoles  []string `json:"twoFactorRequiredRoles" env:"TWO_FACTOR_REQUIRED_ROLES"`
+
+	// AppBaseURL is the front end that emailed links point to.
+	AppBaseURL       string        `json:"appBaseUrl" env:"APP_BASE_URL"`
+	InvitationTTL    time.Duration `json:"invitationTtl" env:"INVITATION_TTL"`
+	PasswordResetTTL time.Duration `json:"passwordResetTtl" env:"PASSWORD_RESET_TTL"`
+
+	// Mail is sent through SMTPAddress. For local development it can instead be appended to
+	// MailOutboxFile or, with MailToStderr, written to stderr; one of the three must be set, since
+	// mail carries live sign-in links that mustn't end up in logs by accident. SMTPPasswordSecret is
+	// resolved like JWTSigningKeySecret.
+	MailFrom           string `json:"mailFrom" env:"MAIL_FROM"`
+	SMTPAddress        string `json:"smtpAddress" env:"SMTP_ADDRESS"`
+	SMTPUsername       string `json:"smtpUsername" env:"SMTP_USERNAME"`
+	SMTPPasswordSecret string `json:"smtpPasswordSecret" env:"SMTP_PASSWORD_SECRET"`
+	SMTPPassword       string `json:"-"`
+	MailOutboxFile     string `json:"mailOutboxFile" env:"MAIL_OUTBOX_FILE"`
+	MailToStderr       bool   `json:"mailToStderr" env:"MAIL_TO_STDERR"`
+
+	// Single sign-on is on when OIDCIssuer is set. OIDCRoleMappings lists group=ROLE pairs read from
+	// the OIDCGroupsClaim; users without a mapped group get OIDCDefaultRole, or are refused if it is empty.
+	OIDCIssuer             string   `json:"oidcIssuer" env:"OIDC_ISSUER"`
+	OIDCClientID           string   `json:"oidcClientId" env:"OIDC_CLIENT_ID"`
+	OIDCClientSecretSecret string   `json:"oidcClientSecretSecret" env:"OIDC_CLIENT_SECRET_SECRET"`
+	OIDCClientSecret       string   `json:"-"`
+	OIDCRedirectURL        string   `json:"oidcRedirectUrl" env:"OIDC_REDIRECT_URL"`
+	OIDCGroupsClaim        string   `json:"oidcGroupsClaim" env:"OIDC_GROUPS_CLAIM"`
+	OIDCRoleMappings       []string `json:"oidcRoleMappings" env:"OIDC_ROLE_MAPPINGS"`
+	OIDCDefaultRole        string   `json:"oidcDefaultRole" env:"OIDC_DEFAULT_ROLE"`
+
+	// PostEventSink picks where the post-events function sends domain events: "eventbridge" to
+	// EventBusName with PostEventSource as the source, or "sns" to PostEventTopicARN. Delivered events
+	// are recorded in PublishedEventTableName, when set, so stream retries don't repeat them.
+	PostEventSink           string `json:"postEventSink" env:"POST_EVENT_SINK"`
+	EventBusName            string `json:"eventBusName" env:"EVENT_BUS_NAME"`
+	PostEventSource         string `json:"postEventSource" env:"POST_EVENT_SOURCE"`
+	PostEventTopicARN       string `json:"postEventTopicArn" env:"POST_EVENT_TOPIC_ARN"`
+	PublishedEventTableName string `json:"publishedEventTableName" env:"PUBLISHED_EVENT_TABLE_NAME"`
+
+	// Webhooks need the "webhooks" feature. Deliveries are keyed by WebhookID and Sequence, with the
+	// Status-RetryAt-index listing due retries, and expire through their DeleteAfter TTL attribute.
+	WebhookTableName         string `json:"webhookTableName" env:"WEBHOOK_TABLE_NAME"`
+	WebhookDeliveryTableName string `json:"webhookDeliveryTableName" env:"WEBHOOK_DELIVERY_TABLE_NAME"`
+
+	// The newsletter needs the "newsletter" feature. SubscriberTableName has a Status-ID-index listing
+	// subscribers by status; APIBaseURL is the public API address one-click unsubscribe links point to.
+	SubscriberTableName       string        `json:"subscriberTableName" env:"SUBSCRIBER_TABLE_NAME"`
+	NewsletterIssueTableName  string        `json:"newsletterIssueTableName" env:"NEWSLETTER_ISSUE_TABLE_NAME"`
+	APIBaseURL                string        `json:"apiBaseUrl" env:"API_BASE_URL"`
+	NewsletterConfirmationTTL time.Duration `json:"newsletterConfirmationTtl" env:"NEWSLETTER_CONFIRMATION_TTL"`
+
+	DefaultPageSize     int   `json:"defaultPageSize" env:"DEFAULT_PAGE_SIZE"`
+	MaxPageSize         int   `json:"maxPageSize" env:"MAX_PAGE_SIZE"`
+	MaxMediaUploadBytes int64 `json:"maxMediaUploadBytes" env:"MAX_MEDIA_UPLOAD_BYTES"`
+
+	// A zero CacheTTL turns read-through caching off. RemoteCacheAddress adds a shared Redis-protocol
+	// cache behind the in-process one.
+	CacheTTL           time.Duration `json:"cacheTtl" env:"CACHE_TTL"`
+	CacheSize          int           `json:"cacheSize" env:"CACHE_SIZE"`
+	RemoteCacheAddress string        `json:"remoteCacheAddress" env:"REMOTE_CACHE_ADDRESS"`
+
+	// Rate limiting and login lockout are on when RateLimitTableName is set. RateLimits lists
+	// policies as name=capacity/period; routes use "login", "password-reset", "invitation",
+	// "newsletter", "unsubscribe" and "write", each with its own buckets. Policies left out keep
+	// their defaults. One-click unsubscribes come from mail providers' shared addresses, so
+	// "unsubscribe" allows far more than the others.
+	RateLimitTableName string   `json:"rateLimitTableName" env:"RATE_LIMIT_TABLE_NAME"`
+	RateLimits         []string `json:"rateLimits" env:"RATE_LIMITS"`
+
+	// Features is read from FEATURES as a comma separated list of enabled toggles, e.g. "media,newsletter".
+	Features map[string]bool `json:"features" env:"FEATURES"`
+}
+
+func Default() *Config {
+	return &Config{
+		Region:                    "us-east-1",
+		JWTIssuer:                 "blog-service",
+		AccessTokenTTL:            15 * time.Minute,
+		RefreshTokenTTL:           14 * 24 * time.Hour,
+		InvitationTTL:             7 * 24 * time.Hour,
+		PasswordResetTTL:          time.Hour,
+		NewsletterConfirmationTTL: 48 * time.Hour,
+		AuditRetention:            365 * 24 * time.Hour,
+		MailFrom:                  "noreply@localhost",
+		TOTPIssuer:                "Blog",
+		TwoFactorRequiredRoles:    []string{"EDITOR", "ADMIN"},
+		OIDCGroupsClaim:           "groups",
+		OIDCDefaultRole:           "AUTHOR",
+		PostEventSink:             "eventbridge",
+		EventBusName:              "default",
+		PostEventSource:           "blog.posts",
+		DefaultPageSize:           25,
+		MaxPageSize:               100,
+		MaxMediaUploadBytes:       10 << 20,
+		CacheTTL:                  30 * time.Second,
+		CacheSize:                 1000,
+		RateLimits:                []string{"login=10/1m", "password-reset=5/1m", "invitation=10/1m", "newsletter=10/1m", "unsubscribe=600/1m", "write=60/1m"},
+		Features:                  map[string]bool{},
+	}
+}
+
+// Load builds the configuration from the defaults, then the optional JSON file named by CONFIG_FILE,
+// then environment variables, resolves secrets through the provider and validates the result.
+func Load(ctx context.Context, secrets SecretProvider) (*Config, error) {
+	return LoadFor(ctx, secrets, (*Config).Validate)
+}
+
+// LoadFor is Load with another validation in place of the API's, for workers that need only some of
+// the settings. Only secrets whose names are configured are resolved.
+func LoadFor(ctx context.Context, secrets SecretProvider, validate func(*Config) error) (*Config, error) {
+	config, err := FromEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.JWTSigningKeySecret != "" {
+		key, err := secrets.GetSecret(ctx, config.JWTSigningKeySecret)
+		if err != nil {
+			return nil, fmt.Errorf("resolve jwtSigningKeySecret: %w", err)
+		}
+		config.JWTSigningKey = key
+	}
+	if config.TOTPEncryptionKeySecret != "" {
+		key, err := secrets.GetSecret(ctx, config.TOTPEncryptionKeySecret)
+		if err != nil {
+			return nil, fmt.Errorf("resolve totpEncryptionKeySecret: %w", err)
+		}
+		config.TOTPEncryptionKey = key
+	}
+	if config.SMTPPasswordSecret != "" {
+		password, err := secrets.GetSecret(ctx, config.SMTPPasswordSecret)
+		if err != nil {
+			return nil, fmt.Errorf("resolve smtpPasswordSecret: %w", err)
+		}
+		config.SMTPPassword = password
+	}
+	if config.OIDCClientSecretSecret != "" {
+		secret, err := secrets.GetSecret(ctx, config.OIDCClientSecretSecret)
+		if err != nil {
+			return nil, fmt.Errorf("resolve oidcClientSecretSecret: %w", err)
+		}
+		config.OIDCClientSecret = secret
+	}
+
+	if err := validate(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// FromEnvironment applies the JSON file and environment over the defaults without resolving secrets
+// or validating, for command line tools that only need a few of the settings.
+func FromEnvironment() (*Config, error) {
+	config := Default()
+
+	if path := os.Getenv(configFileEnv); path != "" {
+		if err := config.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.loadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (config *Config) FeatureEnabled(name string) bool {
+	return config.Features[name]
+}
+
+// Validate reports every invalid setting at once rather than stopping at the first.
+func (config *Config) Validate() error {
+	errs := requireSettings(
+		setting{"region", config.Region},
+		setting{"postTableName", config.PostTableName},
+		setting{"userTableName", config.UserTableName},
+		setting{"sessionTableName", config.SessionTableName},
+		setting{"apiKeyTableName", config.APIKeyTableName},
+		setting{"accountTokenTableName", config.AccountTokenTableName},
+		setting{"contentBucket", config.ContentBucket},
+		setting{"auditTableName", config.AuditTableName},
+		setting{"jwtSigningKey", config.JWTSigningKey},
+		setting{"totpEncryptionKey", config.TOTPEncryptionKey},
+		setting{"appBaseUrl", config.AppBaseURL},
+		setting{"mailFrom", config.MailFrom},
+	)
+
+	errs = append(errs, config.validateMailer()...)
+	if config.FeatureEnabled("media") && config.MediaTableName == "" {
+		errs = append(errs, errors.New("mediaTableName is required when the media feature is enabled"))
+	}
+	if config.FeatureEnabled("webhooks") && (config.WebhookTableName == "" || config.WebhookDeliveryTableName == "") {
+		errs = append(errs, errors.New("webhookTableName and webhookDeliveryTableName are required when the webhooks feature is enabled"))
+	}
+	if config.FeatureEnabled("newsletter") && (config.SubscriberTableName == "" || config.NewsletterIssueTableName == "" || config.APIBaseURL == "") {
+		errs = append(errs, errors.New("subscriberTableName, newsletterIssueTableName and apiBaseUrl are required when the newsletter feature is enabled"))
+	}
+	if config.OIDCIssuer != "" && (config.OIDCClientID == "" || config.OIDCRedirectURL == "") {
+		errs = append(errs, errors.New("oidcClientId and oidcRedirectUrl are required when oidcIssuer is set"))
+	}
+	errs = append(errs, config.validatePostEventSink()...)
+	if config.AccessTokenTTL <= 0 {
+		errs = append(errs, errors.New("accessTokenTtl must be positive"))
+	}
+	if config.RefreshTokenTTL <= config.AccessTokenTTL {
+		errs = append(errs, errors.New("refreshTokenTtl must be longer than accessTokenTtl"))
+	}
+	if config.AuditRetention <= 0 {
+		errs = append(errs, errors.New("auditRetention must be positive"))
+	}
+	if config.InvitationTTL <= 0 || config.PasswordResetTTL <= 0 || config.NewsletterConfirmationTTL <= 0 {
+		errs = append(errs, errors.New("invitationTtl, passwordResetTtl and newsletterConfirmationTtl must be positive"))
+	}
+	if config.DefaultPageSize <= 0 {
+		errs = append(errs, errors.New("defaultPageSize must be positive"))
+	}
+	if config.MaxPageSize < config.DefaultPageSize {
+		errs = append(errs, errors.New("maxPageSize must not be smaller than defaultPageSize"))
+	}
+	if config.MaxMediaUploadBytes <= 0 {
+		errs = append(errs, errors.New("maxMediaUploadBytes must be positive"))
+	}
+	if config.CacheTTL < 0 {
+		errs = append(errs, errors.New("cacheTtl must not be negative"))
+	}
+	if config.CacheTTL > 0 && config.CacheSize <= 0 {
+		errs = append(errs, errors.New("cacheSize must be positive when caching is enabled"))
+	}
+
+	return invalid(errs)
+}
+
+// ValidatePostEvents checks what the post table's stream consumer needs.
+func (config *Config) ValidatePostEvents() error {
+	errs := requireSettings(setting{"region", config.Region})
+	errs = append(errs, config.validatePostEventSink()...)
+	return invalid(errs)
+}
+
+// ValidateWebhooks checks what the webhook dispatcher needs; the encryption key opens the
+// webhooks' signing secrets.
+func (config *Config) ValidateWebhooks() error {
+	return invalid(requireSettings(
+		setting{"region", config.Region},
+		setting{"webhookTableName", config.WebhookTableName},
+		setting{"webhookDeliveryTableName", config.WebhookDeliveryTableName},
+		setting{"totpEncryptionKey", config.TOTPEncryptionKey},
+	))
+}
+
+// ValidateNewsletter checks what the newsletter sender needs; the encryption key opens the
+// subscribers' unsubscribe tokens.
+func (config *Config) ValidateNewsletter() error {
+	errs := requireSettings(
+		setting{"region", config.Region},
+		setting{"subscriberTableName", config.SubscriberTableName},
+		setting{"newsletterIssueTableName", config.NewsletterIssueTableName},
+		setting{"contentBucket", config.ContentBucket},
+		setting{"totpEncryptionKey", config.TOTPEncryptionKey},
+		setting{"appBaseUrl", config.AppBaseURL},
+		setting{"apiBaseUrl", config.APIBaseURL},
+		setting{"mailFrom", config.MailFrom},
+	)
+	errs = append(errs, config.validateMailer()...)
+	return invalid(errs)
+}
+
+// ValidateMediaProcessor checks what the media processor needs.
+func (config *Config) ValidateMediaProcessor() error {
+	return invalid(requireSettings(
+		setting{"region", config.Region},
+		setting{"mediaTableName", config.MediaTableName},
+		setting{"contentBucket", config.ContentBucket},
+	))
+}
+
+func (config *Config) validateMailer() []error {
+	if config.SMTPAddress == "" && config.MailOutboxFile == "" && !config.MailToStderr {
+		return []error{errors.New("smtpAddress is required; for local development set mailOutboxFile or mailToStderr instead")}
+	}
+	return nil
+}
+
+func (config *Config) validatePostEventSink() []error {
+	switch config.PostEventSink {
+	case "eventbridge":
+		if config.EventBusName == "" || config.PostEventSource == "" {
+			return []error{errors.New("eventBusName and postEventSource are required when postEventSink is eventbridge")}
+		}
+	case "sns":
+		if config.PostEventTopicARN == "" {
+			return []error{errors.New("postEventTopicArn is required when postEventSink is sns")}
+		}
+	default:
+		return []error{fmt.Errorf("postEventSink %q is not eventbridge or sns", config.PostEventSink)}
+	}
+	return nil
+}
+
+type setting struct {
+	name  string
+	value string
+}
+
+func requireSettings(settings ...setting) []error {
+	var errs []error
+	for _, setting := range settings {
+		if setting.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", setting.name))
+		}
+	}
+	return errs
+}
+
+func invalid(errs []error) error {
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (config *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	var file struct {
+		*Config
+		AccessTokenTTL string `json:"accessTokenTtl"`
+	}
+	file.Config = config
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	if file.AccessTokenTTL != "" {
+		ttl, err := time.ParseDuration(file.AccessTokenTTL)
+		if err != nil {
+			return fmt.Errorf("parse config file %s: accessTokenTtl: %w", path, err)
+		}
+		config.AccessTokenTTL = ttl
+	}
+	return nil
+}
+
+func (config *Config) loadEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+	value := reflect.ValueOf(config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setField(value.Field(i), raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(raw)
+	case bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case int, int64:
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case time.Duration:
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(parsed))
+	case []string:
+		field.Set(reflect.ValueOf(splitList(raw)))
+	case map[string]bool:
+		features := map[string]bool{}
+		for _, name := range splitList(raw) {
+			features[name] = true
+		}
+		field.Set(reflect.ValueOf(features))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	var result []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
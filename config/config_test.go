@@ -0,0 +1,215 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockSecretProvider struct {
+	GetSecretFunc func(ctx context.Context, name string) (string, error)
+}
+
+func (m *MockSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	return m.GetSecretFunc(ctx, name)
+}
+
+type MockSSMClient struct {
+	GetParameterFunc func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+func (m *MockSSMClient) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	return m.GetParameterFunc(ctx, params, optFns...)
+}
+
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("POST_TABLE_NAME", "Posts")
+	t.Setenv("USER_TABLE_NAME", "Users")
+	t.Setenv("SESSION_TABLE_NAME", "Sessions")
+	t.Setenv("API_KEY_TABLE_NAME", "ApiKeys")
+	t.Setenv("ACCOUNT_TOKEN_TABLE_NAME", "AccountTokens")
+	t.Setenv("APP_BASE_URL", "https://blog.example.com")
+	t.Setenv("CONTENT_BUCKET", "blog-content")
+	t.Setenv("AUDIT_TABLE_NAME", "AuditEvents")
+	t.Setenv("JWT_SIGNING_KEY_SECRET", "/blog/jwt-key")
+	t.Setenv("TOTP_ENCRYPTION_KEY_SECRET", "/blog/totp-key")
+	t.Setenv("SMTP_ADDRESS", "smtp.example.com:587")
+}
+
+func staticSecrets(value string) *MockSecretProvider {
+	return &MockSecretProvider{GetSecretFunc: func(ctx context.Context, name string) (string, error) {
+		return value, nil
+	}}
+}
+
+func TestLoad_FromEnvironment_Succeeds(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("MAX_PAGE_SIZE", "50")
+	t.Setenv("ACCESS_TOKEN_TTL", "1h")
+	t.Setenv("FEATURES", "media, newsletter")
+	t.Setenv("MEDIA_TABLE_NAME", "Media")
+	t.Setenv("SUBSCRIBER_TABLE_NAME", "Subscribers")
+	t.Setenv("NEWSLETTER_ISSUE_TABLE_NAME", "NewsletterIssues")
+	t.Setenv("API_BASE_URL", "https://api.example.com")
+
+	result, err := Load(context.Background(), staticSecrets("signing-key"))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "Posts", result.PostTableName)
+	assert.Equal(t, 50, result.MaxPageSize)
+	assert.Equal(t, time.Hour, result.AccessTokenTTL)
+	assert.Equal(t, "signing-key", result.JWTSigningKey)
+	assert.True(t, result.FeatureEnabled("media"))
+	assert.True(t, result.FeatureEnabled("newsletter"))
+	assert.False(t, result.FeatureEnabled("webhooks"))
+}
+
+func TestLoad_EnvironmentOverridesFile(t *testing.T) {
+	setRequiredEnv(t)
+	path := filepath.Join(t.TempDir(), "config.json")
+	contents := `{"postTableName": "FilePosts", "region": "eu-west-1", "accessTokenTtl": "30m"}`
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+
+	result, err := Load(context.Background(), staticSecrets("signing-key"))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "Posts", result.PostTableName)
+	assert.Equal(t, "eu-west-1", result.Region)
+	assert.Equal(t, 30*time.Minute, result.AccessTokenTTL)
+}
+
+func TestLoad_InvalidConfiguration_ReportsEveryError(t *testing.T) {
+	t.Setenv("MAX_PAGE_SIZE", "5")
+
+	_, err := Load(context.Background(), staticSecrets(""))
+
+	assert.ErrorContains(t, err, "postTableName is required")
+	assert.ErrorContains(t, err, "userTableName is required")
+	assert.ErrorContains(t, err, "contentBucket is required")
+	assert.ErrorContains(t, err, "jwtSigningKey is required")
+	assert.ErrorContains(t, err, "maxPageSize must not be smaller than defaultPageSize")
+}
+
+func TestLoad_NoMailer_ReturnsErr(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("SMTP_ADDRESS", "")
+
+	_, err := Load(context.Background(), staticSecrets("signing-key"))
+	assert.ErrorContains(t, err, "smtpAddress is required")
+
+	t.Setenv("MAIL_TO_STDERR", "true")
+	result, err := Load(context.Background(), staticSecrets("signing-key"))
+	assert.NoError(t, err)
+	assert.True(t, result.MailToStderr)
+}
+
+func TestLoadFor_Newsletter_RequiresOnlyItsSettings(t *testing.T) {
+	t.Setenv("SUBSCRIBER_TABLE_NAME", "Subscribers")
+	t.Setenv("NEWSLETTER_ISSUE_TABLE_NAME", "NewsletterIssues")
+	t.Setenv("CONTENT_BUCKET", "blog-content")
+	t.Setenv("APP_BASE_URL", "https://blog.example.com")
+	t.Setenv("API_BASE_URL", "https://api.example.com")
+	t.Setenv("TOTP_ENCRYPTION_KEY_SECRET", "/blog/totp-key")
+	requested := []string{}
+	secrets := &MockSecretProvider{GetSecretFunc: func(ctx context.Context, name string) (string, error) {
+		requested = append(requested, name)
+		return "encryption-key", nil
+	}}
+
+	_, err := LoadFor(context.Background(), secrets, (*Config).ValidateNewsletter)
+	assert.ErrorContains(t, err, "smtpAddress is required")
+	assert.NotContains(t, err.Error(), "jwtSigningKey")
+
+	t.Setenv("SMTP_ADDRESS", "smtp.example.com:587")
+	result, err := LoadFor(context.Background(), secrets, (*Config).ValidateNewsletter)
+	assert.NoError(t, err)
+	assert.Equal(t, "encryption-key", result.TOTPEncryptionKey)
+	assert.Equal(t, []string{"/blog/totp-key", "/blog/totp-key"}, requested)
+}
+
+func TestLoadFor_PostEvents_ReportsOnlyItsSettings(t *testing.T) {
+	t.Setenv("POST_EVENT_SINK", "sns")
+
+	_, err := LoadFor(context.Background(), staticSecrets(""), (*Config).ValidatePostEvents)
+
+	assert.EqualError(t, err, "invalid configuration: postEventTopicArn is required when postEventSink is sns")
+}
+
+func TestLoad_OIDCIssuerWithoutClient_ReturnsErr(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("OIDC_ISSUER", "https://idp.example.com")
+
+	_, err := Load(context.Background(), staticSecrets("signing-key"))
+
+	assert.ErrorContains(t, err, "oidcClientId and oidcRedirectUrl are required when oidcIssuer is set")
+}
+
+func TestLoad_SNSPostEventSinkWithoutTopic_ReturnsErr(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("POST_EVENT_SINK", "sns")
+
+	_, err := Load(context.Background(), staticSecrets("signing-key"))
+
+	assert.ErrorContains(t, err, "postEventTopicArn is required when postEventSink is sns")
+}
+
+func TestLoad_MalformedEnvironmentValue_ReturnsErr(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("DEFAULT_PAGE_SIZE", "many")
+
+	_, err := Load(context.Background(), staticSecrets("signing-key"))
+
+	assert.ErrorContains(t, err, "DEFAULT_PAGE_SIZE")
+}
+
+func TestLoad_SecretProviderFailure_ReturnsErr(t *testing.T) {
+	setRequiredEnv(t)
+	secrets := &MockSecretProvider{GetSecretFunc: func(ctx context.Context, name string) (string, error) {
+		return "", errors.New("mock error for testing")
+	}}
+
+	_, err := Load(context.Background(), secrets)
+
+	assert.ErrorContains(t, err, "mock error for testing")
+}
+
+func TestEnvSecretProvider_NormalizesName(t *testing.T) {
+	t.Setenv("BLOG_JWT_KEY", "from-env")
+
+	result, err := EnvSecretProvider{}.GetSecret(context.Background(), "/blog/jwt-key")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "from-env", result)
+}
+
+func TestSSMSecretProvider_RequestsDecryption(t *testing.T) {
+	client := &MockSSMClient{GetParameterFunc: func(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+		assert.True(t, *params.WithDecryption)
+		return &ssm.GetParameterOutput{Parameter: &types.Parameter{Value: aws.String("from-ssm")}}, nil
+	}}
+
+	result, err := NewSSMSecretProvider(client).GetSecret(context.Background(), "/blog/jwt-key")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "from-ssm", result)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+type SecretProvider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// EnvSecretProvider reads secrets from environment variables, upper-casing the name and replacing
+// anything that isn't a letter or digit with an underscore, so "/blog/jwt-key" reads BLOG_JWT_KEY.
+type EnvSecretProvider struct{}
+
+func (provider EnvSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	envName := strings.Trim(strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(name)), "_")
+
+	value, ok := os.LookupEnv(envName)
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in environment variable %s", name, envName)
+	}
+	return value, nil
+}
+
+type SSMAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+type SSMSecretProvider struct {
+	client SSMAPI
+}
+
+func NewSSMSecretProvider(client SSMAPI) *SSMSecretProvider {
+	return &SSMSecretProvider{client: client}
+}
+
+func (provider *SSMSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	output, err := provider.client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if output.Parameter == nil || output.Parameter.Value == nil {
+		return "", fmt.Errorf("parameter %s has no value", name)
+	}
+	return *output.Parameter.Value, nil
+}
+
+type SecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+type SecretsManagerSecretProvider struct {
+	client SecretsManagerAPI
+}
+
+func NewSecretsManagerSecretProvider(client SecretsManagerAPI) *SecretsManagerSecretProvider {
+	return &SecretsManagerSecretProvider{client: client}
+}
+
+func (provider *SecretsManagerSecretProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	output, err := provider.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return "", err
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no string value", name)
+	}
+	return *output.SecretString, nil
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+)
+
+type emailRequest struct {
+	Email string `json:"email"`
+}
+
+type accountTokenRequest struct {
+	Token string `json:"token"`
+}
+
+type acceptInvitationRequest struct {
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type invitationResponse struct {
+	ID        string    `json:"id,omitempty"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type passwordResetResponse struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type userResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type AccountController struct {
+	accountService *api.AccountService
+}
+
+func NewAccountController(accountService *api.AccountService) *AccountController {
+	return &AccountController{accountService: accountService}
+}
+
+func (controller *AccountController) CreateInvitation(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	inviterID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	var body emailRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	invitation, err := controller.accountService.Invite(ctx, inviterID, body.Email)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return jsonResponse(http.StatusCreated, invitationResponse{ID: invitation.ID, Email: invitation.Email, ExpiresAt: invitation.ExpiresAt})
+}
+
+func (controller *AccountController) VerifyInvitation(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body accountTokenRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	invitation, err := controller.accountService.VerifyInvitation(ctx, body.Token)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return jsonResponse(http.StatusOK, invitationResponse{Email: invitation.Email, ExpiresAt: invitation.ExpiresAt})
+}
+
+func (controller *AccountController) AcceptInvitation(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body acceptInvitationRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	user, err := controller.accountService.AcceptInvitation(ctx, body.Token, body.Username, body.Password)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return jsonResponse(http.StatusCreated, userResponse{ID: user.ID, Username: user.Username, Email: user.Email})
+}
+
+// RequestPasswordReset answers 202 whether or not the address has an account.
+func (controller *AccountController) RequestPasswordReset(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body emailRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := controller.accountService.RequestPasswordReset(ctx, body.Email); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusAccepted}, nil
+}
+
+func (controller *AccountController) VerifyPasswordReset(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body accountTokenRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	reset, err := controller.accountService.VerifyPasswordReset(ctx, body.Token)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return jsonResponse(http.StatusOK, passwordResetResponse{ExpiresAt: reset.ExpiresAt})
+}
+
+func (controller *AccountController) ResetPassword(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body resetPasswordRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := controller.accountService.ResetPassword(ctx, body.Token, body.Password); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return noContentResponse()
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type apiKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	Revoked    bool       `json:"revoked"`
+	// Key is only present in the response to creating the key.
+	Key string `json:"key,omitempty"`
+}
+
+type listAPIKeysResponse struct {
+	APIKeys []apiKeyResponse `json:"apiKeys"`
+}
+
+type APIKeyController struct {
+	apiKeyService *api.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService *api.APIKeyService) *APIKeyController {
+	return &APIKeyController{apiKeyService: apiKeyService}
+}
+
+func (controller *APIKeyController) CreateAPIKey(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	ownerID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	var body createAPIKeyRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	var expiresAt time.Time
+	if body.ExpiresAt != nil {
+		expiresAt = *body.ExpiresAt
+	}
+
+	created, err := controller.apiKeyService.CreateAPIKey(ctx, ownerID, body.Name, body.Scopes, expiresAt)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	response := newAPIKeyResponse(created.APIKey)
+	response.Key = created.Key
+	return noStoreJSONResponse(http.StatusCreated, response)
+}
+
+func (controller *APIKeyController) ListAPIKeys(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	ownerID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	keys, err := controller.apiKeyService.ListAPIKeys(ctx, ownerID)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	responses := make([]apiKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		responses = append(responses, newAPIKeyResponse(key))
+	}
+	return jsonResponse(http.StatusOK, listAPIKeysResponse{APIKeys: responses})
+}
+
+func (controller *APIKeyController) RevokeAPIKey(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	ownerID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := controller.apiKeyService.RevokeAPIKey(ctx, ownerID, request.PathParameters["id"]); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return noContentResponse()
+}
+
+func newAPIKeyResponse(key *model.APIKey) apiKeyResponse {
+	response := apiKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+		Revoked:   key.Revoked,
+	}
+	if !key.ExpiresAt.IsZero() {
+		response.ExpiresAt = &key.ExpiresAt
+	}
+	if !key.LastUsedAt.IsZero() {
+		response.LastUsedAt = &key.LastUsedAt
+	}
+	return response
+}
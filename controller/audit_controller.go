@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+const defaultAuditPageSize = 50
+
+type listAuditEventsResponse struct {
+	Events           []*model.AuditEvent `json:"events"`
+	LastEvaluatedKey string              `json:"lastEvaluatedKey,omitempty"`
+}
+
+type AuditController struct {
+	auditService *api.AuditService
+	maxPageSize  int
+}
+
+func NewAuditController(auditService *api.AuditService, maxPageSize int) *AuditController {
+	return &AuditController{auditService: auditService, maxPageSize: maxPageSize}
+}
+
+// ListAuditEvents filters by actorId, or by targetType and targetId, and by from and to given as
+// RFC 3339 times.
+func (controller *AuditController) ListAuditEvents(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	query := api.AuditQuery{
+		ActorID:    request.QueryStringParameters["actorId"],
+		TargetType: request.QueryStringParameters["targetType"],
+		TargetID:   request.QueryStringParameters["targetId"],
+	}
+	if query.From, err = queryTime(request, "from"); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	if query.To, err = queryTime(request, "to"); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	limit := queryInt(request, "limit", defaultAuditPageSize)
+	if limit > controller.maxPageSize {
+		limit = controller.maxPageSize
+	}
+
+	auditEvents, lastEvaluatedKey, err := controller.auditService.ListAuditEvents(ctx, caller, query, limit, request.QueryStringParameters["lastEvaluatedKey"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	if auditEvents == nil {
+		auditEvents = []*model.AuditEvent{}
+	}
+
+	return noStoreJSONResponse(http.StatusOK, listAuditEventsResponse{Events: auditEvents, LastEvaluatedKey: lastEvaluatedKey})
+}
+
+func queryTime(request events.APIGatewayProxyRequest, name string) (time.Time, error) {
+	value := request.QueryStringParameters[name]
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, apperror.Validation(name + " must be an RFC 3339 time")
+	}
+	return parsed, nil
+}
@@ -1 +1,28 @@
 package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+func (controller *PostController) CreatePost(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authorID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	var post model.Post
+	if err := decodeBody(request, &post); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	created, err := controller.postService.CreatePost(ctx, authorID, &post)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return jsonResponse(http.StatusCreated, created)
+}
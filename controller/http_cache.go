@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// Published content may be served by a CDN for a few minutes; drafts stay out of shared caches and
+// are revalidated on every read.
+const (
+	publishedCacheControl = "public, max-age=60, s-maxage=300, stale-while-revalidate=60"
+	listCacheControl      = "public, max-age=30, s-maxage=60"
+	draftCacheControl     = "private, no-cache"
+)
+
+// cacheableResponse renders body as JSON with validators and answers conditional requests with 304.
+// The ETag hashes the exact representation, so it changes with UpdatedAt, the body or anything else
+// the client would see, which makes it safe as a strong validator.
+func cacheableResponse(request events.APIGatewayProxyRequest, body interface{}, lastModified time.Time, cacheControl string) (events.APIGatewayProxyResponse, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	digest := sha256.Sum256(encoded)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(digest[:]) + `"`
+	headers := map[string]string{
+		"ETag":          etag,
+		"Cache-Control": cacheControl,
+	}
+	if !lastModified.IsZero() {
+		headers["Last-Modified"] = lastModified.UTC().Format(http.TimeFormat)
+	}
+
+	if notModified(request, etag, lastModified) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusNotModified, Headers: headers}, nil
+	}
+
+	headers["Content-Type"] = "application/json"
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers:    headers,
+		Body:       string(encoded),
+	}, nil
+}
+
+// notModified applies RFC 9110: If-None-Match wins over If-Modified-Since when both are present.
+func notModified(request events.APIGatewayProxyRequest, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := headerValue(request, "If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// If-None-Match uses the weak comparison, so a W/ prefix still matches.
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince := headerValue(request, "If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		// Last-Modified only has second precision.
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+func postCacheControl(status model.Status) string {
+	if status == model.Posted {
+		return publishedCacheControl
+	}
+	return draftCacheControl
+}
+
+// listCacheHints takes the newest UpdatedAt as the list's Last-Modified and keeps the page out of
+// shared caches if it includes a draft.
+func listCacheHints(posts []*model.PostMetadata) (time.Time, string) {
+	var lastModified time.Time
+	cacheControl := listCacheControl
+	for _, post := range posts {
+		if post.UpdatedAt.After(lastModified) {
+			lastModified = post.UpdatedAt
+		}
+		if post.Status != model.Posted {
+			cacheControl = draftCacheControl
+		}
+	}
+	return lastModified, cacheControl
+}
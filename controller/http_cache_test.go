@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func conditionalRequest(headers map[string]string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/posts/123", Headers: headers}
+}
+
+func TestCacheableResponse_SetsValidators(t *testing.T) {
+	updatedAt := time.Date(2024, 3, 1, 12, 30, 15, 500, time.UTC)
+
+	response, err := cacheableResponse(conditionalRequest(nil), map[string]string{"id": "123"}, updatedAt, publishedCacheControl)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Regexp(t, `^"[A-Za-z0-9_-]+"$`, response.Headers["ETag"])
+	assert.Equal(t, "Fri, 01 Mar 2024 12:30:15 GMT", response.Headers["Last-Modified"])
+	assert.Equal(t, publishedCacheControl, response.Headers["Cache-Control"])
+}
+
+func TestCacheableResponse_MatchingETag_Returns304(t *testing.T) {
+	body := map[string]string{"id": "123"}
+	first, _ := cacheableResponse(conditionalRequest(nil), body, time.Time{}, publishedCacheControl)
+
+	response, err := cacheableResponse(conditionalRequest(map[string]string{
+		"if-none-match": `"other", W/` + first.Headers["ETag"],
+	}), body, time.Time{}, publishedCacheControl)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, response.StatusCode)
+	assert.Empty(t, response.Body)
+	assert.Equal(t, first.Headers["ETag"], response.Headers["ETag"])
+}
+
+func TestCacheableResponse_ChangedBody_ChangesETag(t *testing.T) {
+	first, _ := cacheableResponse(conditionalRequest(nil), map[string]string{"body": "a"}, time.Time{}, publishedCacheControl)
+
+	response, _ := cacheableResponse(conditionalRequest(map[string]string{"If-None-Match": first.Headers["ETag"]}),
+		map[string]string{"body": "b"}, time.Time{}, publishedCacheControl)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotEqual(t, first.Headers["ETag"], response.Headers["ETag"])
+}
+
+func TestCacheableResponse_IfModifiedSince(t *testing.T) {
+	updatedAt := time.Date(2024, 3, 1, 12, 30, 15, 500, time.UTC)
+
+	unchanged, _ := cacheableResponse(conditionalRequest(map[string]string{
+		"If-Modified-Since": "Fri, 01 Mar 2024 12:30:15 GMT",
+	}), "body", updatedAt, publishedCacheControl)
+	changed, _ := cacheableResponse(conditionalRequest(map[string]string{
+		"If-Modified-Since": "Fri, 01 Mar 2024 12:30:14 GMT",
+	}), "body", updatedAt, publishedCacheControl)
+	etagWins, _ := cacheableResponse(conditionalRequest(map[string]string{
+		"If-None-Match":     `"stale"`,
+		"If-Modified-Since": "Fri, 01 Mar 2024 12:30:15 GMT",
+	}), "body", updatedAt, publishedCacheControl)
+
+	assert.Equal(t, http.StatusNotModified, unchanged.StatusCode)
+	assert.Equal(t, http.StatusOK, changed.StatusCode)
+	assert.Equal(t, http.StatusOK, etagWins.StatusCode)
+}
+
+func TestListCacheHints_DraftInPage_IsPrivate(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	lastModified, cacheControl := listCacheHints([]*model.PostMetadata{
+		{Status: model.Posted, UpdatedAt: newer},
+		{Status: model.Draft, UpdatedAt: older},
+	})
+
+	assert.Equal(t, newer, lastModified)
+	assert.Equal(t, draftCacheControl, cacheControl)
+	assert.Equal(t, publishedCacheControl, postCacheControl(model.Posted))
+}
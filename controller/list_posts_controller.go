@@ -1 +1,28 @@
 package controller
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type listPostsResponse struct {
+	Posts            []*model.PostMetadata `json:"posts"`
+	LastEvaluatedKey string                `json:"lastEvaluatedKey,omitempty"`
+}
+
+func (controller *PostController) ListPosts(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	limit := queryInt(request, "limit", defaultPostPageSize)
+	if limit > controller.maxPageSize {
+		limit = controller.maxPageSize
+	}
+
+	posts, lastEvaluatedKey, err := controller.postService.ListPosts(ctx, callerID(request), limit, request.QueryStringParameters["lastEvaluatedKey"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	lastModified, cacheControl := listCacheHints(posts)
+	return cacheableResponse(request, listPostsResponse{Posts: posts, LastEvaluatedKey: lastEvaluatedKey}, lastModified, cacheControl)
+}
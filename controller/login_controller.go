@@ -1 +1,136 @@
 package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type twoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code"`
+}
+
+type loginResponse struct {
+	AccessToken      string    `json:"accessToken"`
+	TokenType        string    `json:"tokenType"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+	// TwoFactorEnrolmentRequired means the tokens only work for enrolling in two-factor
+	// authentication and logging out.
+	TwoFactorEnrolmentRequired bool `json:"twoFactorEnrolmentRequired,omitempty"`
+}
+
+type loginChallengeResponse struct {
+	TwoFactorRequired  bool      `json:"twoFactorRequired"`
+	ChallengeToken     string    `json:"challengeToken"`
+	ChallengeExpiresAt time.Time `json:"challengeExpiresAt"`
+}
+
+type LoginController struct {
+	loginService *api.LoginService
+}
+
+func NewLoginController(loginService *api.LoginService) *LoginController {
+	return &LoginController{loginService: loginService}
+}
+
+func (controller *LoginController) Login(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body loginRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	result, err := controller.loginService.Login(ctx, body.Username, body.Password)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return loginResultResponse(result)
+}
+
+// CompleteTwoFactorLogin takes the challenge a login answered with and a TOTP or recovery code.
+func (controller *LoginController) CompleteTwoFactorLogin(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body twoFactorLoginRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	result, err := controller.loginService.CompleteTwoFactorLogin(ctx, body.ChallengeToken, body.Code)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return loginResultResponse(result)
+}
+
+func (controller *LoginController) Refresh(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body refreshRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	result, err := controller.loginService.Refresh(ctx, body.RefreshToken)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return loginResultResponse(result)
+}
+
+// Logout ends the session the request's access token belongs to.
+func (controller *LoginController) Logout(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := controller.loginService.Logout(ctx, caller, sessionID(request)); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return noContentResponse()
+}
+
+func (controller *LoginController) LogoutAll(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := controller.loginService.LogoutAll(ctx, caller); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return noContentResponse()
+}
+
+func loginResultResponse(result *api.LoginResult) (events.APIGatewayProxyResponse, error) {
+	var body interface{} = loginResponse{
+		AccessToken:                result.AccessToken,
+		TokenType:                  "Bearer",
+		ExpiresAt:                  result.ExpiresAt,
+		RefreshToken:               result.RefreshToken,
+		RefreshExpiresAt:           result.RefreshExpiresAt,
+		TwoFactorEnrolmentRequired: result.TwoFactorEnrolmentRequired,
+	}
+	if result.TwoFactorRequired {
+		body = loginChallengeResponse{
+			TwoFactorRequired:  true,
+			ChallengeToken:     result.ChallengeToken,
+			ChallengeExpiresAt: result.ChallengeExpiresAt,
+		}
+	}
+	return noStoreJSONResponse(http.StatusOK, body)
+}
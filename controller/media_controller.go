@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+const defaultMediaPageSize = 25
+
+type createMediaUploadRequest struct {
+	MimeType  string `json:"mimeType"`
+	SizeBytes int64  `json:"sizeBytes"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Checksum  string `json:"checksum"`
+}
+
+type createMediaUploadResponse struct {
+	Asset        *model.MediaAsset   `json:"asset"`
+	UploadURL    string              `json:"uploadUrl"`
+	UploadMethod string              `json:"uploadMethod"`
+	UploadHeader map[string][]string `json:"uploadHeaders"`
+	ExpiresAt    time.Time           `json:"expiresAt"`
+}
+
+type listMediaAssetsResponse struct {
+	Assets           []*model.MediaAsset `json:"assets"`
+	LastEvaluatedKey string              `json:"lastEvaluatedKey,omitempty"`
+}
+
+type MediaController struct {
+	mediaService *api.MediaService
+	maxPageSize  int
+}
+
+func NewMediaController(mediaService *api.MediaService, maxPageSize int) *MediaController {
+	return &MediaController{mediaService: mediaService, maxPageSize: maxPageSize}
+}
+
+func (controller *MediaController) CreateMediaUpload(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	ownerID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	var body createMediaUploadRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	upload, err := controller.mediaService.CreateMediaUpload(ctx, &api.CreateMediaUploadInput{
+		OwnerID:   ownerID,
+		MimeType:  body.MimeType,
+		SizeBytes: body.SizeBytes,
+		Width:     body.Width,
+		Height:    body.Height,
+		Checksum:  body.Checksum,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return jsonResponse(http.StatusCreated, createMediaUploadResponse{
+		Asset:        upload.Asset,
+		UploadURL:    upload.Upload.URL,
+		UploadMethod: upload.Upload.Method,
+		UploadHeader: upload.Upload.SignedHeader,
+		ExpiresAt:    upload.Upload.ExpiresAt,
+	})
+}
+
+func (controller *MediaController) ListMediaAssets(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	ownerID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if request.QueryStringParameters["unused"] == "true" {
+		assets, err := controller.mediaService.ListUnusedMediaAssets(ctx, ownerID)
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		return jsonResponse(http.StatusOK, listMediaAssetsResponse{Assets: assets})
+	}
+
+	limit := queryInt(request, "limit", defaultMediaPageSize)
+	if limit > controller.maxPageSize {
+		limit = controller.maxPageSize
+	}
+	assets, lastEvaluatedKey, err := controller.mediaService.ListMediaAssets(ctx, ownerID, limit, request.QueryStringParameters["lastEvaluatedKey"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return jsonResponse(http.StatusOK, listMediaAssetsResponse{Assets: assets, LastEvaluatedKey: lastEvaluatedKey})
+}
+
+func (controller *MediaController) DeleteMediaAsset(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	ownerID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	err = controller.mediaService.DeleteMediaAsset(ctx, ownerID, request.PathParameters["id"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return noContentResponse()
+}
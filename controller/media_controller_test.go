@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/dao/daotest"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore/objectstoretest"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMediaController() (*MediaController, *daotest.MediaAssetDao) {
+	mediaAssetDao := daotest.NewMediaAssetDao()
+	service := api.NewMediaService(mediaAssetDao, objectstoretest.NewMediaStore(), nil, 1024)
+	return NewMediaController(service, 2), mediaAssetDao
+}
+
+func mediaRequest(callerID string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{}
+	if callerID != "" {
+		request.RequestContext.Authorizer = map[string]interface{}{"principalId": callerID}
+	}
+	return request
+}
+
+func TestMediaController_CreateMediaUpload_ReturnsAssetAndUpload(t *testing.T) {
+	sut, mediaAssetDao := setupMediaController()
+	request := mediaRequest("user-1")
+	request.Body = `{"mimeType": "image/png", "sizeBytes": 512, "checksum": "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}`
+
+	response, err := sut.CreateMediaUpload(context.Background(), request)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	var body createMediaUploadResponse
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "user-1", body.Asset.OwnerID)
+	assert.Equal(t, http.MethodPut, body.UploadMethod)
+	assert.Equal(t, "https://media.test/"+body.Asset.Key, body.UploadURL)
+	assert.Contains(t, mediaAssetDao.Assets, body.Asset.ID)
+}
+
+func TestMediaController_CreateMediaUpload_Unauthenticated_ReturnsUnauthorized(t *testing.T) {
+	sut, _ := setupMediaController()
+	request := mediaRequest("")
+	request.Body = `{"mimeType": "image/png", "sizeBytes": 512}`
+
+	_, err := sut.CreateMediaUpload(context.Background(), request)
+
+	assert.Equal(t, apperror.KindUnauthorized, apperror.KindOf(err))
+}
+
+func TestMediaController_ListMediaAssets_Unused_ReturnsOnlyUnreferencedAssets(t *testing.T) {
+	sut, mediaAssetDao := setupMediaController()
+	mediaAssetDao.Assets["asset-1"] = &model.MediaAsset{ID: "asset-1", OwnerID: "user-1", PostIDs: []string{"post-1"}}
+	mediaAssetDao.Assets["asset-2"] = &model.MediaAsset{ID: "asset-2", OwnerID: "user-1"}
+	request := mediaRequest("user-1")
+	request.QueryStringParameters = map[string]string{"unused": "true"}
+
+	response, err := sut.ListMediaAssets(context.Background(), request)
+
+	assert.Nil(t, err)
+	var body listMediaAssetsResponse
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Len(t, body.Assets, 1)
+	assert.Equal(t, "asset-2", body.Assets[0].ID)
+}
+
+func TestMediaController_ListMediaAssets_PagesWithLastEvaluatedKey(t *testing.T) {
+	sut, mediaAssetDao := setupMediaController()
+	for _, id := range []string{"asset-1", "asset-2", "asset-3"} {
+		mediaAssetDao.Assets[id] = &model.MediaAsset{ID: id, OwnerID: "user-1"}
+	}
+	request := mediaRequest("user-1")
+	request.QueryStringParameters = map[string]string{"limit": "2", "lastEvaluatedKey": "asset-1"}
+
+	response, err := sut.ListMediaAssets(context.Background(), request)
+
+	assert.Nil(t, err)
+	var body listMediaAssetsResponse
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Len(t, body.Assets, 2)
+	assert.Equal(t, "asset-2", body.Assets[0].ID)
+}
+
+func TestMediaController_ListMediaAssets_LimitAboveMax_IsCapped(t *testing.T) {
+	sut, mediaAssetDao := setupMediaController()
+	for _, id := range []string{"asset-1", "asset-2", "asset-3"} {
+		mediaAssetDao.Assets[id] = &model.MediaAsset{ID: id, OwnerID: "user-1"}
+	}
+	request := mediaRequest("user-1")
+	request.QueryStringParameters = map[string]string{"limit": "1000"}
+
+	response, err := sut.ListMediaAssets(context.Background(), request)
+
+	assert.Nil(t, err)
+	var body listMediaAssetsResponse
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Len(t, body.Assets, 2)
+	assert.Equal(t, "asset-2", body.LastEvaluatedKey)
+}
+
+func TestMediaController_DeleteMediaAsset_InUse_ReturnsConflict(t *testing.T) {
+	sut, mediaAssetDao := setupMediaController()
+	mediaAssetDao.Assets["asset-1"] = &model.MediaAsset{ID: "asset-1", OwnerID: "user-1", PostIDs: []string{"post-1"}}
+	request := mediaRequest("user-1")
+	request.PathParameters = map[string]string{"id": "asset-1"}
+
+	_, err := sut.DeleteMediaAsset(context.Background(), request)
+
+	assert.Equal(t, apperror.KindConflict, apperror.KindOf(err))
+}
+
+func TestMediaController_DeleteMediaAsset_Unused_ReturnsNoContent(t *testing.T) {
+	sut, mediaAssetDao := setupMediaController()
+	mediaAssetDao.Assets["asset-1"] = &model.MediaAsset{ID: "asset-1", OwnerID: "user-1"}
+	request := mediaRequest("user-1")
+	request.PathParameters = map[string]string{"id": "asset-1"}
+
+	response, err := sut.DeleteMediaAsset(context.Background(), request)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+	assert.Empty(t, mediaAssetDao.Assets)
+}
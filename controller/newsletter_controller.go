@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+)
+
+type NewsletterController struct {
+	newsletterService *api.NewsletterService
+}
+
+func NewNewsletterController(newsletterService *api.NewsletterService) *NewsletterController {
+	return &NewsletterController{newsletterService: newsletterService}
+}
+
+func (controller *NewsletterController) Subscribe(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body emailRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := controller.newsletterService.Subscribe(ctx, body.Email); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusAccepted}, nil
+}
+
+func (controller *NewsletterController) Confirm(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body accountTokenRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := controller.newsletterService.Confirm(ctx, body.Token); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return noContentResponse()
+}
+
+// Unsubscribe takes the token from the query string, as in the List-Unsubscribe URL. Mail clients
+// POST "List-Unsubscribe=One-Click" to it (RFC 8058); the body is ignored.
+func (controller *NewsletterController) Unsubscribe(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if err := controller.newsletterService.Unsubscribe(ctx, request.QueryStringParameters["token"]); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return noContentResponse()
+}
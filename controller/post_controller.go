@@ -0,0 +1,17 @@
+package controller
+
+import "github.com/neuralcoral/BlogService/api"
+
+const defaultPostPageSize = 25
+
+type PostController struct {
+	postService *api.PostService
+	maxPageSize int
+}
+
+func NewPostController(postService *api.PostService, maxPageSize int) *PostController {
+	return &PostController{
+		postService: postService,
+		maxPageSize: maxPageSize,
+	}
+}
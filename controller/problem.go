@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+)
+
+const problemTypePrefix = "urn:blogservice:problem:"
+
+// problem is an RFC 7807 problem details document.
+type problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+
+	Errors []apperror.FieldError `json:"errors,omitempty"`
+}
+
+var statusByKind = map[apperror.Kind]int{
+	apperror.KindNotFound:     http.StatusNotFound,
+	apperror.KindConflict:     http.StatusConflict,
+	apperror.KindValidation:   http.StatusBadRequest,
+	apperror.KindUnauthorized: http.StatusUnauthorized,
+	apperror.KindForbidden:    http.StatusForbidden,
+	apperror.KindRateLimited:  http.StatusTooManyRequests,
+	apperror.KindInternal:     http.StatusInternalServerError,
+}
+
+func statusForKind(kind apperror.Kind) int {
+	if status, ok := statusByKind[kind]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// problemResponse renders err as problem+json. Only the domain error's client-safe message is
+// included, so causes such as raw AWS errors never reach the response.
+func problemResponse(err error, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	appErr := apperror.As(err)
+	response, marshalErr := writeProblem(statusForKind(appErr.Kind), string(appErr.Kind), appErr.Message, appErr.Fields, request)
+	if marshalErr != nil {
+		return response, marshalErr
+	}
+
+	if appErr.RetryAfter > 0 {
+		response.Headers["Retry-After"] = strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds())))
+	}
+	return response, nil
+}
+
+func writeProblem(status int, problemType string, detail string, fields []apperror.FieldError, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	body := problem{
+		Type:      problemTypePrefix + problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  request.Path,
+		RequestID: requestID(request),
+		Errors:    fields,
+	}
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	headers := map[string]string{"Content-Type": "application/problem+json"}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    headers,
+		Body:       string(encoded),
+	}, nil
+}
+
+// requestID prefers the API Gateway request ID and falls back to a caller supplied X-Request-Id.
+func requestID(request events.APIGatewayProxyRequest) string {
+	if request.RequestContext.RequestID != "" {
+		return request.RequestContext.RequestID
+	}
+	return headerValue(request, "X-Request-Id")
+}
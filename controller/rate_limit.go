@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/ratelimit"
+)
+
+type RateLimiter interface {
+	Allow(ctx context.Context, policy ratelimit.Policy, subject string) (ratelimit.Decision, error)
+}
+
+// RateLimit wraps a handler in a policy. Authenticated callers get a bucket per user; anonymous
+// requests share a bucket per source IP.
+func RateLimit(limiter RateLimiter, policy ratelimit.Policy, handler HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		decision, err := limiter.Allow(ctx, policy, rateLimitSubject(request))
+		if err != nil {
+			return events.APIGatewayProxyResponse{}, err
+		}
+		if !decision.Allowed {
+			return events.APIGatewayProxyResponse{}, apperror.RateLimited("rate limit exceeded", decision.RetryAfter)
+		}
+		return handler(ctx, request)
+	}
+}
+
+func rateLimitSubject(request events.APIGatewayProxyRequest) string {
+	if id := callerID(request); id != "" {
+		return "user:" + id
+	}
+	return "ip:" + request.RequestContext.Identity.SourceIP
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockRateLimiter struct {
+	AllowFunc func(ctx context.Context, policy ratelimit.Policy, subject string) (ratelimit.Decision, error)
+}
+
+func (m *MockRateLimiter) Allow(ctx context.Context, policy ratelimit.Policy, subject string) (ratelimit.Decision, error) {
+	return m.AllowFunc(ctx, policy, subject)
+}
+
+func TestRateLimit_Denied_Returns429WithRetryAfter(t *testing.T) {
+	var subjects []string
+	limiter := &MockRateLimiter{AllowFunc: func(ctx context.Context, policy ratelimit.Policy, subject string) (ratelimit.Decision, error) {
+		subjects = append(subjects, subject)
+		return ratelimit.Decision{RetryAfter: 2500 * time.Millisecond}, nil
+	}}
+	router, tokenIssuer := setupRouter(t)
+	router.Handle(http.MethodPost, "/posts", RateLimit(limiter, ratelimit.Policy{Name: "write"}, echoCallerHandler))
+	token, _, _ := tokenIssuer.Issue("user-1")
+
+	anonymous := events.APIGatewayProxyRequest{HTTPMethod: http.MethodPost, Path: "/posts"}
+	anonymous.RequestContext.Identity.SourceIP = "1.2.3.4"
+	response, _ := router.ServeAPIGateway(context.Background(), anonymous)
+	_, _ = router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/posts",
+		Headers:    map[string]string{"Authorization": "Bearer " + token},
+	})
+
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, "3", response.Headers["Retry-After"])
+	assert.Equal(t, []string{"ip:1.2.3.4", "user:user-1"}, subjects)
+}
+
+func TestRateLimit_Allowed_CallsHandler(t *testing.T) {
+	limiter := &MockRateLimiter{AllowFunc: func(ctx context.Context, policy ratelimit.Policy, subject string) (ratelimit.Decision, error) {
+		return ratelimit.Decision{Allowed: true}, nil
+	}}
+	handler := RateLimit(limiter, ratelimit.Policy{Name: "write"}, echoCallerHandler)
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
@@ -1 +1,16 @@
 package controller
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func (controller *PostController) ReadPost(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	post, err := controller.postService.ReadPost(ctx, callerID(request), request.PathParameters["id"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return cacheableResponse(request, post, post.UpdatedAt, postCacheControl(post.Status))
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+)
+
+func jsonResponse(statusCode int, body interface{}) (events.APIGatewayProxyResponse, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(encoded),
+	}, nil
+}
+
+// noStoreJSONResponse is jsonResponse for bodies that must never be stored by a browser or CDN
+// cache, such as secrets, tokens and audit events.
+func noStoreJSONResponse(statusCode int, body interface{}) (events.APIGatewayProxyResponse, error) {
+	response, err := jsonResponse(statusCode, body)
+	if err != nil {
+		return response, err
+	}
+	response.Headers["Cache-Control"] = "no-store"
+	return response, nil
+}
+
+func noContentResponse() (events.APIGatewayProxyResponse, error) {
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+}
+
+// requireCaller returns the authenticated caller, or an unauthorized error for anonymous requests.
+func requireCaller(request events.APIGatewayProxyRequest) (string, error) {
+	id := callerID(request)
+	if id == "" {
+		return "", apperror.Unauthorized("authentication required")
+	}
+	return id, nil
+}
+
+func decodeBody(request events.APIGatewayProxyRequest, target interface{}) error {
+	if err := json.Unmarshal([]byte(request.Body), target); err != nil {
+		return apperror.Validation("request body is not valid JSON")
+	}
+	return nil
+}
+
+// callerID returns the user the API Gateway authorizer attached to the request.
+func callerID(request events.APIGatewayProxyRequest) string {
+	if principalID, ok := request.RequestContext.Authorizer["principalId"].(string); ok {
+		return principalID
+	}
+	return ""
+}
+
+// sessionID returns the login session of the access token the request was authenticated with.
+func sessionID(request events.APIGatewayProxyRequest) string {
+	if id, ok := request.RequestContext.Authorizer["sessionId"].(string); ok {
+		return id
+	}
+	return ""
+}
+
+func queryInt(request events.APIGatewayProxyRequest, name string, defaultValue int) int {
+	value, err := strconv.Atoi(request.QueryStringParameters[name])
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoStoreJSONResponse_SetsCacheControl(t *testing.T) {
+	response, err := noStoreJSONResponse(http.StatusCreated, map[string]string{"key": "secret"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, "no-store", response.Headers["Cache-Control"])
+}
+
+func TestNoStoreJSONResponse_UnencodableBody_ReturnsErr(t *testing.T) {
+	assert.NotPanics(t, func() {
+		_, err := noStoreJSONResponse(http.StatusOK, make(chan int))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+var errInvalidAccessToken = apperror.Unauthorized("invalid access token")
+
+type HandlerFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+type route struct {
+	method   string
+	segments []string
+	handler  HandlerFunc
+}
+
+// SessionValidator reports whether the login session behind an access token is still active.
+type SessionValidator interface {
+	SessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// APIKeyAuthenticator resolves an API key sent in place of an access token.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*model.APIKey, error)
+}
+
+type Router struct {
+	routes      []route
+	tokenIssuer *auth.TokenIssuer
+	sessions    SessionValidator
+	apiKeys     APIKeyAuthenticator
+}
+
+// NewRouter builds a router. With a nil sessions validator, tokens are trusted until they expire;
+// with a nil API key authenticator, API keys are rejected.
+func NewRouter(tokenIssuer *auth.TokenIssuer, sessions SessionValidator, apiKeys APIKeyAuthenticator) *Router {
+	return &Router{tokenIssuer: tokenIssuer, sessions: sessions, apiKeys: apiKeys}
+}
+
+// Handle registers a handler for a method and a path pattern such as "/posts/{id}".
+func (router *Router) Handle(method string, pattern string, handler HandlerFunc) {
+	router.routes = append(router.routes, route{
+		method:   method,
+		segments: splitPath(pattern),
+		handler:  handler,
+	})
+}
+
+// ServeAPIGateway is the Lambda handler. Handler errors are logged and rendered as problem+json
+// rather than returned to Lambda, which API Gateway would surface as a 502.
+func (router *Router) ServeAPIGateway(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	response, err := router.dispatch(ctx, request)
+	if err != nil {
+		if apperror.KindOf(err) == apperror.KindInternal {
+			log.Printf("request %s: %s %s failed: %v", requestID(request), request.HTTPMethod, request.Path, err)
+		}
+		response, err = problemResponse(err, request)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	if id := requestID(request); id != "" {
+		if response.Headers == nil {
+			response.Headers = map[string]string{}
+		}
+		response.Headers["X-Request-Id"] = id
+	}
+	return response, nil
+}
+
+func (router *Router) dispatch(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	request, err := router.authenticate(ctx, request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	handler, pathParameters, methodAllowed := router.match(request.HTTPMethod, request.Path)
+	if handler == nil {
+		if methodAllowed {
+			return writeProblem(http.StatusMethodNotAllowed, "method-not-allowed", "method not allowed", nil, request)
+		}
+		return events.APIGatewayProxyResponse{}, apperror.NotFound("no resource at this path")
+	}
+
+	if request.PathParameters == nil {
+		request.PathParameters = map[string]string{}
+	}
+	for name, value := range pathParameters {
+		request.PathParameters[name] = value
+	}
+
+	ctx = audit.WithRequest(ctx, audit.Request{
+		ActorID:   callerID(request),
+		APIKeyID:  apiKeyID(request),
+		RequestID: requestID(request),
+		SourceIP:  request.RequestContext.Identity.SourceIP,
+	})
+	return handler(ctx, request)
+}
+
+// authenticate verifies a bearer token or API key, when one is sent, and records the principal the
+// controllers read through callerID. A principal set by an API Gateway authorizer is kept as is.
+func (router *Router) authenticate(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyRequest, error) {
+	if callerID(request) != "" {
+		return request, nil
+	}
+
+	if key := headerValue(request, "X-Api-Key"); key != "" {
+		return router.authenticateAPIKey(ctx, request, key)
+	}
+	header := headerValue(request, "Authorization")
+	if header == "" {
+		return request, nil
+	}
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return request, errInvalidAccessToken
+	}
+	if auth.IsAPIKey(token) {
+		return router.authenticateAPIKey(ctx, request, token)
+	}
+
+	claims, err := router.tokenIssuer.Verify(token)
+	if err != nil {
+		return request, errInvalidAccessToken
+	}
+
+	if router.sessions != nil {
+		if claims.SessionID == "" {
+			return request, errInvalidAccessToken
+		}
+		active, err := router.sessions.SessionActive(ctx, claims.SessionID)
+		if err != nil {
+			return request, err
+		}
+		if !active {
+			return request, apperror.Unauthorized("session has been revoked or has expired")
+		}
+	}
+
+	values := map[string]interface{}{
+		"principalId": claims.Subject,
+		"sessionId":   claims.SessionID,
+	}
+	if claims.TwoFactorPending {
+		values["twoFactorPending"] = "true"
+	}
+	return withPrincipal(request, values), nil
+}
+
+// authenticateAPIKey makes the key's owner the principal, limited to the key's scopes.
+func (router *Router) authenticateAPIKey(ctx context.Context, request events.APIGatewayProxyRequest, key string) (events.APIGatewayProxyRequest, error) {
+	if router.apiKeys == nil {
+		return request, errInvalidAccessToken
+	}
+	apiKey, err := router.apiKeys.Authenticate(ctx, key)
+	if err != nil {
+		return request, err
+	}
+
+	return withPrincipal(request, map[string]interface{}{
+		"principalId": apiKey.OwnerID,
+		"apiKeyId":    apiKey.ID,
+		"scopes":      strings.Join(apiKey.Scopes, " "),
+	}), nil
+}
+
+func withPrincipal(request events.APIGatewayProxyRequest, values map[string]interface{}) events.APIGatewayProxyRequest {
+	authorizer := map[string]interface{}{}
+	for key, value := range request.RequestContext.Authorizer {
+		authorizer[key] = value
+	}
+	for key, value := range values {
+		authorizer[key] = value
+	}
+	request.RequestContext.Authorizer = authorizer
+	return request
+}
+
+func (router *Router) match(method string, path string) (HandlerFunc, map[string]string, bool) {
+	segments := splitPath(path)
+	methodAllowed := false
+	for _, route := range router.routes {
+		pathParameters, ok := matchSegments(route.segments, segments)
+		if !ok {
+			continue
+		}
+		if route.method != method {
+			methodAllowed = true
+			continue
+		}
+		return route.handler, pathParameters, true
+	}
+	return nil, nil, methodAllowed
+}
+
+func matchSegments(pattern []string, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+
+	pathParameters := map[string]string{}
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			pathParameters[segment[1:len(segment)-1]] = segments[i]
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return pathParameters, true
+}
+
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
+
+// headerValue looks a header up case-insensitively, since API Gateway passes them through as sent.
+func headerValue(request events.APIGatewayProxyRequest, name string) string {
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
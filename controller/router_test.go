@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func echoCallerHandler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return jsonResponse(http.StatusOK, map[string]string{
+		"caller": callerID(request),
+		"id":     request.PathParameters["id"],
+	})
+}
+
+func setupRouter(t testing.TB) (*Router, *auth.TokenIssuer) {
+	t.Helper()
+	tokenIssuer := auth.NewTokenIssuer("test-signing-key", "test", time.Minute)
+	router := NewRouter(tokenIssuer, nil, nil)
+	router.Handle(http.MethodGet, "/posts/{id}", echoCallerHandler)
+	router.Handle(http.MethodGet, "/failing", func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{}, errors.New("mock error for testing")
+	})
+	router.Handle(http.MethodGet, "/limited", func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{}, fmt.Errorf("wrapped: %w", apperror.RateLimited("slow down", 1500*time.Millisecond))
+	})
+	return router, tokenIssuer
+}
+
+func TestServeAPIGateway_MatchesPathParameters(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	response, err := router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/posts/123"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.JSONEq(t, `{"caller": "", "id": "123"}`, response.Body)
+}
+
+func TestServeAPIGateway_UnknownRoute_Returns404Or405(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	notFound, _ := router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/missing"})
+	notAllowed, _ := router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodDelete, Path: "/posts/123"})
+
+	assert.Equal(t, http.StatusNotFound, notFound.StatusCode)
+	assert.Equal(t, http.StatusMethodNotAllowed, notAllowed.StatusCode)
+}
+
+func TestServeAPIGateway_ValidBearerToken_SetsCaller(t *testing.T) {
+	router, tokenIssuer := setupRouter(t)
+	token, _, _ := tokenIssuer.Issue("user1")
+
+	response, _ := router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/posts/123",
+		Headers:    map[string]string{"authorization": "Bearer " + token},
+	})
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.JSONEq(t, `{"caller": "user1", "id": "123"}`, response.Body)
+}
+
+func TestServeAPIGateway_AttachesAuditRequest(t *testing.T) {
+	router, tokenIssuer := setupRouter(t)
+	var recorded audit.Request
+	router.Handle(http.MethodPost, "/audited", func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		recorded = audit.RequestFrom(ctx)
+		return noContentResponse()
+	})
+	token, _, _ := tokenIssuer.Issue("user1")
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPost,
+		Path:       "/audited",
+		Headers:    map[string]string{"Authorization": "Bearer " + token},
+	}
+	request.RequestContext.RequestID = "req-1"
+	request.RequestContext.Identity.SourceIP = "203.0.113.9"
+
+	router.ServeAPIGateway(context.Background(), request)
+
+	assert.Equal(t, audit.Request{ActorID: "user1", RequestID: "req-1", SourceIP: "203.0.113.9"}, recorded)
+}
+
+func TestServeAPIGateway_InvalidBearerToken_Returns401(t *testing.T) {
+	router, _ := setupRouter(t)
+	forged, _, _ := auth.NewTokenIssuer("other-key", "test", time.Minute).Issue("user1")
+
+	response, _ := router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/posts/123",
+		Headers:    map[string]string{"Authorization": "Bearer " + forged},
+	})
+
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestServeAPIGateway_HandlerError_Returns500(t *testing.T) {
+	router, _ := setupRouter(t)
+
+	response, err := router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/failing"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	assert.NotContains(t, response.Body, "mock error for testing")
+}
+
+func TestServeAPIGateway_DomainError_ReturnsProblemWithRequestID(t *testing.T) {
+	router, _ := setupRouter(t)
+	request := events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/limited"}
+	request.RequestContext.RequestID = "request-1"
+
+	response, err := router.ServeAPIGateway(context.Background(), request)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, "application/problem+json", response.Headers["Content-Type"])
+	assert.Equal(t, "2", response.Headers["Retry-After"])
+	assert.Equal(t, "request-1", response.Headers["X-Request-Id"])
+	assert.JSONEq(t, `{
+		"type": "urn:blogservice:problem:rate-limited",
+		"title": "Too Many Requests",
+		"status": 429,
+		"detail": "slow down",
+		"instance": "/limited",
+		"requestId": "request-1"
+	}`, response.Body)
+}
+
+type MockSessionValidator struct {
+	SessionActiveFunc func(ctx context.Context, sessionID string) (bool, error)
+}
+
+func (m *MockSessionValidator) SessionActive(ctx context.Context, sessionID string) (bool, error) {
+	return m.SessionActiveFunc(ctx, sessionID)
+}
+
+func TestServeAPIGateway_RevokedSession_Returns401(t *testing.T) {
+	tokenIssuer := auth.NewTokenIssuer("test-signing-key", "test", time.Minute)
+	router := NewRouter(tokenIssuer, &MockSessionValidator{SessionActiveFunc: func(ctx context.Context, sessionID string) (bool, error) {
+		return sessionID == "active", nil
+	}}, nil)
+	router.Handle(http.MethodGet, "/posts/{id}", echoCallerHandler)
+	activeToken, _, _ := tokenIssuer.IssueForSession("user1", "active", false)
+	revokedToken, _, _ := tokenIssuer.IssueForSession("user1", "revoked", false)
+	sessionlessToken, _, _ := tokenIssuer.Issue("user1")
+
+	request := func(token string) events.APIGatewayProxyRequest {
+		return events.APIGatewayProxyRequest{
+			HTTPMethod: http.MethodGet,
+			Path:       "/posts/123",
+			Headers:    map[string]string{"Authorization": "Bearer " + token},
+		}
+	}
+	active, _ := router.ServeAPIGateway(context.Background(), request(activeToken))
+	revoked, _ := router.ServeAPIGateway(context.Background(), request(revokedToken))
+	sessionless, _ := router.ServeAPIGateway(context.Background(), request(sessionlessToken))
+
+	assert.Equal(t, http.StatusOK, active.StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, revoked.StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, sessionless.StatusCode)
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+)
+
+var errTwoFactorEnrolmentRequired = apperror.Forbidden("two-factor authentication must be set up before using this account")
+
+// RequireScope limits API key requests to keys granted the scope. User tokens carry every scope
+// unless their user still has to enrol in two-factor authentication, and anonymous requests are
+// left for the handler to accept or reject.
+func RequireScope(scope string, handler HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if twoFactorPending(request) {
+			return events.APIGatewayProxyResponse{}, errTwoFactorEnrolmentRequired
+		}
+		if apiKeyID(request) != "" && !hasScope(request, scope) {
+			return events.APIGatewayProxyResponse{}, apperror.Forbidden("API key lacks the " + scope + " scope")
+		}
+		return handler(ctx, request)
+	}
+}
+
+// RequireUserSession rejects API keys, for endpoints such as key management that only a person
+// logged in with a password should reach.
+func RequireUserSession(handler HandlerFunc) HandlerFunc {
+	return AllowTwoFactorPending(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if twoFactorPending(request) {
+			return events.APIGatewayProxyResponse{}, errTwoFactorEnrolmentRequired
+		}
+		return handler(ctx, request)
+	})
+}
+
+// AllowTwoFactorPending is RequireUserSession for the endpoints a user who still has to enrol in
+// two-factor authentication may reach: enrolment itself and logout.
+func AllowTwoFactorPending(handler HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if apiKeyID(request) != "" {
+			return events.APIGatewayProxyResponse{}, apperror.Forbidden("this endpoint requires a user login, not an API key")
+		}
+		return handler(ctx, request)
+	}
+}
+
+func apiKeyID(request events.APIGatewayProxyRequest) string {
+	if id, ok := request.RequestContext.Authorizer["apiKeyId"].(string); ok {
+		return id
+	}
+	return ""
+}
+
+func twoFactorPending(request events.APIGatewayProxyRequest) bool {
+	pending, _ := request.RequestContext.Authorizer["twoFactorPending"].(string)
+	return pending == "true"
+}
+
+func hasScope(request events.APIGatewayProxyRequest, scope string) bool {
+	scopes, _ := request.RequestContext.Authorizer["scopes"].(string)
+	for _, granted := range strings.Fields(scopes) {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
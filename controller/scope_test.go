@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockAPIKeyAuthenticator struct {
+	AuthenticateFunc func(ctx context.Context, key string) (*model.APIKey, error)
+}
+
+func (m *MockAPIKeyAuthenticator) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	return m.AuthenticateFunc(ctx, key)
+}
+
+func setupAPIKeyRouter(t testing.TB) *Router {
+	t.Helper()
+	authenticator := &MockAPIKeyAuthenticator{AuthenticateFunc: func(ctx context.Context, key string) (*model.APIKey, error) {
+		if key != "bsk_valid_secret" {
+			return nil, apperror.Unauthorized("invalid, expired or revoked API key")
+		}
+		return &model.APIKey{ID: "key-1", OwnerID: "user-1", Scopes: []string{auth.ScopePostsRead}}, nil
+	}}
+	router := NewRouter(auth.NewTokenIssuer("test-signing-key", "test", time.Minute), nil, authenticator)
+	router.Handle(http.MethodGet, "/posts/{id}", RequireScope(auth.ScopePostsRead, echoCallerHandler))
+	router.Handle(http.MethodPut, "/posts/{id}", RequireScope(auth.ScopePostsWrite, echoCallerHandler))
+	router.Handle(http.MethodGet, "/api-keys", RequireUserSession(echoCallerHandler))
+	return router
+}
+
+func TestServeAPIGateway_APIKey_ActsAsOwnerWithinScopes(t *testing.T) {
+	router := setupAPIKeyRouter(t)
+	request := func(method string, path string, headers map[string]string) events.APIGatewayProxyResponse {
+		response, _ := router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: method, Path: path, Headers: headers})
+		return response
+	}
+
+	viaHeader := request(http.MethodGet, "/posts/123", map[string]string{"X-Api-Key": "bsk_valid_secret"})
+	viaBearer := request(http.MethodGet, "/posts/123", map[string]string{"Authorization": "Bearer bsk_valid_secret"})
+	missingScope := request(http.MethodPut, "/posts/123", map[string]string{"X-Api-Key": "bsk_valid_secret"})
+	keyManagement := request(http.MethodGet, "/api-keys", map[string]string{"X-Api-Key": "bsk_valid_secret"})
+	invalid := request(http.MethodGet, "/posts/123", map[string]string{"X-Api-Key": "bsk_other_secret"})
+
+	assert.Equal(t, http.StatusOK, viaHeader.StatusCode)
+	assert.JSONEq(t, `{"caller": "user-1", "id": "123"}`, viaHeader.Body)
+	assert.Equal(t, http.StatusOK, viaBearer.StatusCode)
+	assert.Equal(t, http.StatusForbidden, missingScope.StatusCode)
+	assert.Equal(t, http.StatusForbidden, keyManagement.StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, invalid.StatusCode)
+}
+
+func TestRequireScope_UserToken_HasEveryScope(t *testing.T) {
+	router := setupAPIKeyRouter(t)
+	token, _, _ := auth.NewTokenIssuer("test-signing-key", "test", time.Minute).Issue("user-1")
+
+	response, _ := router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodPut,
+		Path:       "/posts/123",
+		Headers:    map[string]string{"Authorization": "Bearer " + token},
+	})
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestRequireScope_TwoFactorPending_OnlyEnrolmentAllowed(t *testing.T) {
+	tokenIssuer := auth.NewTokenIssuer("test-signing-key", "test", time.Minute)
+	router := NewRouter(tokenIssuer, nil, nil)
+	router.Handle(http.MethodGet, "/posts/{id}", RequireScope(auth.ScopePostsRead, echoCallerHandler))
+	router.Handle(http.MethodGet, "/api-keys", RequireUserSession(echoCallerHandler))
+	router.Handle(http.MethodPost, "/two-factor/enrolment", AllowTwoFactorPending(echoCallerHandler))
+	token, _, _ := tokenIssuer.IssueForSession("user-1", "session-1", true)
+	request := func(method string, path string) events.APIGatewayProxyResponse {
+		response, _ := router.ServeAPIGateway(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: method,
+			Path:       path,
+			Headers:    map[string]string{"Authorization": "Bearer " + token},
+		})
+		return response
+	}
+
+	posts := request(http.MethodGet, "/posts/123")
+	keys := request(http.MethodGet, "/api-keys")
+	enrolment := request(http.MethodPost, "/two-factor/enrolment")
+
+	assert.Equal(t, http.StatusForbidden, posts.StatusCode)
+	assert.Equal(t, http.StatusForbidden, keys.StatusCode)
+	assert.Equal(t, http.StatusOK, enrolment.StatusCode)
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+)
+
+type singleSignOnStartResponse struct {
+	AuthorizationURL string    `json:"authorizationUrl"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+}
+
+type singleSignOnCallbackRequest struct {
+	State string `json:"state"`
+	Code  string `json:"code"`
+}
+
+type SingleSignOnController struct {
+	singleSignOnService *api.SingleSignOnService
+}
+
+func NewSingleSignOnController(singleSignOnService *api.SingleSignOnService) *SingleSignOnController {
+	return &SingleSignOnController{singleSignOnService: singleSignOnService}
+}
+
+// Start returns the identity provider URL to send the browser to.
+func (controller *SingleSignOnController) Start(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return controller.start(ctx, "")
+}
+
+// StartLink is Start for a signed-in user linking their account to their identity provider account.
+func (controller *SingleSignOnController) StartLink(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	userID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return controller.start(ctx, userID)
+}
+
+func (controller *SingleSignOnController) start(ctx context.Context, linkUserID string) (events.APIGatewayProxyResponse, error) {
+	start, err := controller.singleSignOnService.Start(ctx, linkUserID)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return noStoreJSONResponse(http.StatusOK, singleSignOnStartResponse{
+		AuthorizationURL: start.AuthorizationURL,
+		State:            start.State,
+		ExpiresAt:        start.ExpiresAt,
+	})
+}
+
+// Callback takes the state and code the identity provider redirected back with and logs the user in.
+func (controller *SingleSignOnController) Callback(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var body singleSignOnCallbackRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	result, err := controller.singleSignOnService.Complete(ctx, body.State, body.Code)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return loginResultResponse(result)
+}
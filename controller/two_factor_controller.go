@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+)
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type twoFactorEnrolmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type TwoFactorController struct {
+	twoFactorService *api.TwoFactorService
+}
+
+func NewTwoFactorController(twoFactorService *api.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{twoFactorService: twoFactorService}
+}
+
+func (controller *TwoFactorController) BeginEnrolment(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	enrolment, err := controller.twoFactorService.BeginEnrolment(ctx, caller)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return noStoreJSONResponse(http.StatusOK, twoFactorEnrolmentResponse{Secret: enrolment.Secret, ProvisioningURI: enrolment.ProvisioningURI})
+}
+
+// ConfirmEnrolment returns the recovery codes. A session that was restricted to enrolment is
+// unrestricted from its next token refresh.
+func (controller *TwoFactorController) ConfirmEnrolment(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	var body twoFactorCodeRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	codes, err := controller.twoFactorService.ConfirmEnrolment(ctx, caller, sessionID(request), body.Code)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return noStoreJSONResponse(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (controller *TwoFactorController) Disable(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	var body twoFactorCodeRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := controller.twoFactorService.Disable(ctx, caller, body.Code); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return noContentResponse()
+}
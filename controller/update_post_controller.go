@@ -1 +1,49 @@
 package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// updatePostRequest leaves a field nil when the request omits it, so the post keeps its value.
+type updatePostRequest struct {
+	Version     int           `json:"version"`
+	Title       *string       `json:"title"`
+	Body        *string       `json:"body"`
+	PreviewText *string       `json:"previewText"`
+	Status      *model.Status `json:"status"`
+	Tags        *[]model.Tag  `json:"tags"`
+	AssetIDs    *[]string     `json:"assetIds"`
+}
+
+func (controller *PostController) UpdatePost(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	callerID, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	var body updatePostRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	updated, err := controller.postService.UpdatePost(ctx, callerID, &api.PostUpdate{
+		ID:          request.PathParameters["id"],
+		Version:     body.Version,
+		Title:       body.Title,
+		Body:        body.Body,
+		PreviewText: body.PreviewText,
+		Status:      body.Status,
+		Tags:        body.Tags,
+		AssetIDs:    body.AssetIDs,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return jsonResponse(http.StatusOK, updated)
+}
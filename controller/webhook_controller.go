@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/api"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+const defaultWebhookDeliveryPageSize = 50
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Active defaults to true when omitted from an update.
+	Active *bool `json:"active"`
+}
+
+type webhookResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Secret is only present when the webhook is created or its secret rotated.
+	Secret string `json:"secret,omitempty"`
+}
+
+type listWebhooksResponse struct {
+	Webhooks []webhookResponse `json:"webhooks"`
+}
+
+type webhookDeliveryResponse struct {
+	EventID        string               `json:"eventId"`
+	EventType      string               `json:"eventType"`
+	OccurredAt     time.Time            `json:"occurredAt"`
+	Status         model.DeliveryStatus `json:"status"`
+	Attempts       int                  `json:"attempts"`
+	LastAttemptAt  *time.Time           `json:"lastAttemptAt,omitempty"`
+	LastStatusCode int                  `json:"lastStatusCode,omitempty"`
+	LastError      string               `json:"lastError,omitempty"`
+	NextAttemptAt  *time.Time           `json:"nextAttemptAt,omitempty"`
+}
+
+type listWebhookDeliveriesResponse struct {
+	Deliveries       []webhookDeliveryResponse `json:"deliveries"`
+	LastEvaluatedKey string                    `json:"lastEvaluatedKey,omitempty"`
+}
+
+type WebhookController struct {
+	webhookService *api.WebhookService
+	maxPageSize    int
+}
+
+func NewWebhookController(webhookService *api.WebhookService, maxPageSize int) *WebhookController {
+	return &WebhookController{webhookService: webhookService, maxPageSize: maxPageSize}
+}
+
+func (controller *WebhookController) CreateWebhook(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	var body webhookRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	created, err := controller.webhookService.CreateWebhook(ctx, caller, body.URL, body.Events)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return secretWebhookResponse(http.StatusCreated, created)
+}
+
+func (controller *WebhookController) ListWebhooks(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	webhooks, err := controller.webhookService.ListWebhooks(ctx, caller)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	responses := make([]webhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		responses = append(responses, newWebhookResponse(webhook))
+	}
+	return jsonResponse(http.StatusOK, listWebhooksResponse{Webhooks: responses})
+}
+
+func (controller *WebhookController) UpdateWebhook(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	var body webhookRequest
+	if err := decodeBody(request, &body); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	active := body.Active == nil || *body.Active
+
+	updated, err := controller.webhookService.UpdateWebhook(ctx, caller, request.PathParameters["id"], body.URL, body.Events, active)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return jsonResponse(http.StatusOK, newWebhookResponse(updated))
+}
+
+func (controller *WebhookController) DeleteWebhook(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if err := controller.webhookService.DeleteWebhook(ctx, caller, request.PathParameters["id"]); err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return noContentResponse()
+}
+
+func (controller *WebhookController) RotateSecret(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	rotated, err := controller.webhookService.RotateSecret(ctx, caller, request.PathParameters["id"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return secretWebhookResponse(http.StatusOK, rotated)
+}
+
+func (controller *WebhookController) ListDeliveries(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	limit := queryInt(request, "limit", defaultWebhookDeliveryPageSize)
+	if limit > controller.maxPageSize {
+		limit = controller.maxPageSize
+	}
+
+	deliveries, lastEvaluatedKey, err := controller.webhookService.ListDeliveries(ctx, caller, request.PathParameters["id"], limit, request.QueryStringParameters["lastEvaluatedKey"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	responses := make([]webhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		responses = append(responses, newWebhookDeliveryResponse(delivery))
+	}
+	return noStoreJSONResponse(http.StatusOK, listWebhookDeliveriesResponse{Deliveries: responses, LastEvaluatedKey: lastEvaluatedKey})
+}
+
+// SendTestEvent responds with the delivery, so the outcome of the first attempt is visible at once.
+func (controller *WebhookController) SendTestEvent(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	caller, err := requireCaller(request)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	delivery, err := controller.webhookService.SendTestEvent(ctx, caller, request.PathParameters["id"])
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	return jsonResponse(http.StatusOK, newWebhookDeliveryResponse(delivery))
+}
+
+func secretWebhookResponse(statusCode int, webhook *api.CreatedWebhook) (events.APIGatewayProxyResponse, error) {
+	body := newWebhookResponse(webhook.Webhook)
+	body.Secret = webhook.Secret
+	return noStoreJSONResponse(statusCode, body)
+}
+
+func newWebhookResponse(webhook *model.Webhook) webhookResponse {
+	return webhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
+
+func newWebhookDeliveryResponse(delivery *model.WebhookDelivery) webhookDeliveryResponse {
+	response := webhookDeliveryResponse{
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		OccurredAt:     delivery.OccurredAt,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+	}
+	if !delivery.LastAttemptAt.IsZero() {
+		response.LastAttemptAt = &delivery.LastAttemptAt
+	}
+	if !delivery.NextAttemptAt.IsZero() {
+		response.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	return response
+}
@@ -0,0 +1,16 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type AccountTokenDao interface {
+	CreateAccountToken(ctx context.Context, token *model.AccountToken) error
+	GetAccountToken(ctx context.Context, id string) (*model.AccountToken, error)
+	// UseAccountToken marks the token used. It fails with a conflict unless the token still has the
+	// purpose and secret hash, is unused and hasn't expired at now, so each token works once.
+	UseAccountToken(ctx context.Context, id string, purpose model.TokenPurpose, hash string, now time.Time) error
+}
@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type AccountTokenDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewAccountTokenDdbDao(client DynamoDBAPI, tableName string) *AccountTokenDdbDao {
+	return &AccountTokenDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *AccountTokenDdbDao) CreateAccountToken(ctx context.Context, token *model.AccountToken) error {
+	item, err := model.AccountTokenToDynamoDbAttributes(token)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(ID)"),
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "account token already exists")
+}
+
+func (dao *AccountTokenDdbDao) GetAccountToken(ctx context.Context, id string) (*model.AccountToken, error) {
+	output, err := dao.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(dao.tableName),
+		Key:            accountTokenKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output == nil || output.Item == nil {
+		return nil, nil
+	}
+	return model.AccountTokenFromDynamoDBAttributeValue(output.Item)
+}
+
+func (dao *AccountTokenDdbDao) UseAccountToken(ctx context.Context, id string, purpose model.TokenPurpose, hash string, now time.Time) error {
+	_, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 accountTokenKey(id),
+		UpdateExpression:    aws.String("SET Used = :true, UsedAt = :now"),
+		ConditionExpression: aws.String("Purpose = :purpose AND SecretHash = :hash AND Used = :false AND ExpiresAt > :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":purpose": &types.AttributeValueMemberS{Value: string(purpose)},
+			":hash":    &types.AttributeValueMemberS{Value: hash},
+			":now":     &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339Nano)},
+			":true":    &types.AttributeValueMemberBOOL{Value: true},
+			":false":   &types.AttributeValueMemberBOOL{Value: false},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "account token already used or expired")
+}
+
+func accountTokenKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}}
+}
@@ -0,0 +1,16 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type APIKeyDao interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	GetAPIKey(ctx context.Context, id string) (*model.APIKey, error)
+	ListAPIKeys(ctx context.Context, ownerID string) ([]*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error
+}
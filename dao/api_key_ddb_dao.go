@@ -0,0 +1,109 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const apiKeyOwnerIDIndex = "OwnerID-index"
+
+type APIKeyDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewAPIKeyDdbDao(client DynamoDBAPI, tableName string) *APIKeyDdbDao {
+	return &APIKeyDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *APIKeyDdbDao) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	item, err := model.APIKeyToDynamoDbAttributes(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(ID)"),
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "API key already exists")
+}
+
+func (dao *APIKeyDdbDao) GetAPIKey(ctx context.Context, id string) (*model.APIKey, error) {
+	output, err := dao.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(dao.tableName),
+		Key:       apiKeyKey(id),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output == nil || output.Item == nil {
+		return nil, nil
+	}
+	return model.APIKeyFromDynamoDBAttributeValue(output.Item)
+}
+
+func (dao *APIKeyDdbDao) ListAPIKeys(ctx context.Context, ownerID string) ([]*model.APIKey, error) {
+	var items []map[string]types.AttributeValue
+	var startKey map[string]types.AttributeValue
+	for {
+		output, err := dao.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(dao.tableName),
+			IndexName:              aws.String(apiKeyOwnerIDIndex),
+			KeyConditionExpression: aws.String("OwnerID = :ownerID"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":ownerID": &types.AttributeValueMemberS{Value: ownerID},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, output.Items...)
+		if len(output.LastEvaluatedKey) == 0 {
+			return model.APIKeysFromDynamoDBAttributeValues(items)
+		}
+		startKey = output.LastEvaluatedKey
+	}
+}
+
+func (dao *APIKeyDdbDao) RevokeAPIKey(ctx context.Context, id string) error {
+	_, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 apiKeyKey(id),
+		UpdateExpression:    aws.String("SET Revoked = :true"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":true": &types.AttributeValueMemberBOOL{Value: true},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "API key not found")
+}
+
+func (dao *APIKeyDdbDao) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 apiKeyKey(id),
+		UpdateExpression:    aws.String("SET LastUsedAt = :usedAt"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":usedAt": &types.AttributeValueMemberS{Value: usedAt.UTC().Format(time.RFC3339Nano)},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "API key not found")
+}
+
+func apiKeyKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}}
+}
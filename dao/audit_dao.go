@@ -0,0 +1,15 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type AuditDao interface {
+	// AppendAuditEvent never overwrites; events can't be changed once written.
+	AppendAuditEvent(ctx context.Context, event *model.AuditEvent) error
+	// ListAuditEvents returns events newest first. Without an actor or target the filter must have
+	// both ends of its time range. lastEvaluatedKey is the Sequence of the previous page's last event.
+	ListAuditEvents(ctx context.Context, filter model.AuditFilter, limit int, lastEvaluatedKey string) ([]*model.AuditEvent, string, error)
+}
@@ -0,0 +1,175 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	auditActorIndex  = "ActorID-Sequence-index"
+	auditTargetIndex = "Target-Sequence-index"
+)
+
+var errInvalidAuditCursor = apperror.Validation("lastEvaluatedKey is not valid")
+
+type AuditDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewAuditDdbDao(client DynamoDBAPI, tableName string) *AuditDdbDao {
+	return &AuditDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *AuditDdbDao) AppendAuditEvent(ctx context.Context, event *model.AuditEvent) error {
+	item, err := model.AuditEventToDynamoDbAttributes(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(dao.tableName),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#sequence)"),
+		ExpressionAttributeNames: map[string]string{"#sequence": "Sequence"},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "audit event already exists")
+}
+
+func (dao *AuditDdbDao) ListAuditEvents(ctx context.Context, filter model.AuditFilter, limit int, lastEvaluatedKey string) ([]*model.AuditEvent, string, error) {
+	if lastEvaluatedKey != "" && len(lastEvaluatedKey) < len(time.DateOnly) {
+		return nil, "", errInvalidAuditCursor
+	}
+	switch {
+	case filter.ActorID != "":
+		return dao.queryIndex(ctx, auditActorIndex, "ActorID", filter.ActorID, filter, limit, lastEvaluatedKey)
+	case filter.Target != "":
+		return dao.queryIndex(ctx, auditTargetIndex, "Target", filter.Target, filter, limit, lastEvaluatedKey)
+	default:
+		return dao.queryDays(ctx, filter, limit, lastEvaluatedKey)
+	}
+}
+
+func (dao *AuditDdbDao) queryIndex(ctx context.Context, indexName string, attribute string, value string, filter model.AuditFilter, limit int, lastEvaluatedKey string) ([]*model.AuditEvent, string, error) {
+	input := dao.queryInput(attribute, value, filter, limit)
+	input.IndexName = aws.String(indexName)
+	if lastEvaluatedKey != "" {
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			attribute:  &types.AttributeValueMemberS{Value: value},
+			"Day":      &types.AttributeValueMemberS{Value: lastEvaluatedKey[:len(time.DateOnly)]},
+			"Sequence": &types.AttributeValueMemberS{Value: lastEvaluatedKey},
+		}
+	}
+
+	output, err := dao.client.Query(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextKey := ""
+	if sequence, ok := output.LastEvaluatedKey["Sequence"].(*types.AttributeValueMemberS); ok {
+		nextKey = sequence.Value
+	}
+
+	result, err := model.AuditEventsFromDynamoDBAttributeValues(output.Items)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, nextKey, nil
+}
+
+// queryDays reads the day partitions from the end of the range back to its start until the page
+// is full.
+func (dao *AuditDdbDao) queryDays(ctx context.Context, filter model.AuditFilter, limit int, lastEvaluatedKey string) ([]*model.AuditEvent, string, error) {
+	if filter.From.IsZero() || filter.To.IsZero() {
+		return nil, "", errors.New("listing audit events by time needs both ends of the range")
+	}
+
+	day := filter.To.UTC().Truncate(24 * time.Hour)
+	var startKey map[string]types.AttributeValue
+	if lastEvaluatedKey != "" {
+		resumeDay, err := time.Parse(time.DateOnly, lastEvaluatedKey[:len(time.DateOnly)])
+		if err != nil {
+			return nil, "", errInvalidAuditCursor
+		}
+		day = resumeDay
+		startKey = map[string]types.AttributeValue{
+			"Day":      &types.AttributeValueMemberS{Value: lastEvaluatedKey[:len(time.DateOnly)]},
+			"Sequence": &types.AttributeValueMemberS{Value: lastEvaluatedKey},
+		}
+	}
+
+	var items []map[string]types.AttributeValue
+	for firstDay := filter.From.UTC().Truncate(24 * time.Hour); !day.Before(firstDay); day = day.AddDate(0, 0, -1) {
+		for {
+			input := dao.queryInput("Day", model.AuditDay(day), filter, limit-len(items))
+			input.ExclusiveStartKey = startKey
+			output, err := dao.client.Query(ctx, input)
+			if err != nil {
+				return nil, "", err
+			}
+			items = append(items, output.Items...)
+
+			if len(items) >= limit {
+				result, err := model.AuditEventsFromDynamoDBAttributeValues(items)
+				if err != nil {
+					return nil, "", err
+				}
+				return result, result[len(result)-1].Sequence, nil
+			}
+			startKey = output.LastEvaluatedKey
+			if len(startKey) == 0 {
+				break
+			}
+		}
+	}
+
+	result, err := model.AuditEventsFromDynamoDBAttributeValues(items)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, "", nil
+}
+
+// queryInput matches attribute to value within the filter's time range, newest first. Day and
+// Sequence are DynamoDB reserved words, so expressions name attributes through placeholders.
+func (dao *AuditDdbDao) queryInput(attribute string, value string, filter model.AuditFilter, limit int) *dynamodb.QueryInput {
+	condition := "#key = :key"
+	values := map[string]types.AttributeValue{
+		":key": &types.AttributeValueMemberS{Value: value},
+	}
+	from := &types.AttributeValueMemberS{Value: model.AuditSequence(filter.From, "")}
+	// "~" sorts after every ID, so events at exactly To are included.
+	to := &types.AttributeValueMemberS{Value: model.AuditSequence(filter.To, "~")}
+	switch {
+	case !filter.From.IsZero() && !filter.To.IsZero():
+		condition += " AND #sequence BETWEEN :from AND :to"
+		values[":from"], values[":to"] = from, to
+	case !filter.From.IsZero():
+		condition += " AND #sequence >= :from"
+		values[":from"] = from
+	case !filter.To.IsZero():
+		condition += " AND #sequence <= :to"
+		values[":to"] = to
+	}
+
+	return &dynamodb.QueryInput{
+		TableName:                 aws.String(dao.tableName),
+		KeyConditionExpression:    aws.String(condition),
+		ExpressionAttributeNames:  map[string]string{"#key": attribute, "#sequence": "Sequence"},
+		ExpressionAttributeValues: values,
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(int32(limit)),
+	}
+}
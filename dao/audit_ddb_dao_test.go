@@ -0,0 +1,73 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func auditItem(day string, sequence string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"ID":         &types.AttributeValueMemberS{Value: sequence[len(sequence)-1:]},
+		"Day":        &types.AttributeValueMemberS{Value: day},
+		"Sequence":   &types.AttributeValueMemberS{Value: sequence},
+		"OccurredAt": &types.AttributeValueMemberS{Value: day + "T00:00:00Z"},
+		"Action":     &types.AttributeValueMemberS{Value: "post.update"},
+		"TargetType": &types.AttributeValueMemberS{Value: "post"},
+		"TargetID":   &types.AttributeValueMemberS{Value: "post-1"},
+		"Target":     &types.AttributeValueMemberS{Value: "post#post-1"},
+	}
+}
+
+func TestListAuditEvents_TimeRange_WalksDaysNewestFirst(t *testing.T) {
+	partitions := map[string][]map[string]types.AttributeValue{
+		"2026-10-19": {auditItem("2026-10-19", "2026-10-19T09:00:00.000000000Z#c")},
+		"2026-10-18": {},
+		"2026-10-17": {auditItem("2026-10-17", "2026-10-17T23:00:00.000000000Z#b"), auditItem("2026-10-17", "2026-10-17T01:00:00.000000000Z#a")},
+	}
+	var days []string
+	queryFunc := func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		day := input.ExpressionAttributeValues[":key"].(*types.AttributeValueMemberS).Value
+		days = append(days, day)
+		items := partitions[day]
+		if limit := int(*input.Limit); len(items) > limit {
+			return &dynamodb.QueryOutput{Items: items[:limit], LastEvaluatedKey: items[limit-1]}, nil
+		}
+		return &dynamodb.QueryOutput{Items: items}, nil
+	}
+	sut := &AuditDdbDao{client: &MockDynamoDBClient{QueryFunc: queryFunc}}
+	filter := model.AuditFilter{From: time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC), To: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)}
+
+	result, nextKey, err := sut.ListAuditEvents(context.Background(), filter, 2, "")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, []string{"2026-10-19", "2026-10-18", "2026-10-17"}, days)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "c", result[0].ID)
+	assert.Equal(t, "b", result[1].ID)
+	assert.Equal(t, "2026-10-17T23:00:00.000000000Z#b", nextKey)
+}
+
+func TestListAuditEvents_ByActor_QueriesActorIndex(t *testing.T) {
+	var input *dynamodb.QueryInput
+	queryFunc := func(ctx context.Context, params *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		input = params
+		return &dynamodb.QueryOutput{}, nil
+	}
+	sut := &AuditDdbDao{client: &MockDynamoDBClient{QueryFunc: queryFunc}}
+
+	_, _, err := sut.ListAuditEvents(context.Background(), model.AuditFilter{ActorID: "user-1"}, 10, "2026-10-17T23:00:00.000000000Z#b")
+
+	assert.NoError(t, err)
+	assert.Equal(t, auditActorIndex, *input.IndexName)
+	assert.Equal(t, "#key = :key", *input.KeyConditionExpression)
+	assert.False(t, *input.ScanIndexForward)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2026-10-17"}, input.ExclusiveStartKey["Day"])
+}
@@ -0,0 +1,102 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDB rejects batches larger than these.
+const (
+	maxBatchGetKeys   = 100
+	maxBatchWriteKeys = 25
+)
+
+type backoff struct {
+	base     time.Duration
+	max      time.Duration
+	attempts int
+}
+
+// batchBackoff paces retries of unprocessed keys, which DynamoDB returns when the table is throttled.
+var batchBackoff = backoff{base: 50 * time.Millisecond, max: 5 * time.Second, attempts: 8}
+
+// delay uses full jitter so clients throttled together don't retry together.
+func (b backoff) delay(attempt int) time.Duration {
+	ceiling := b.base << attempt
+	if ceiling <= 0 || ceiling > b.max {
+		ceiling = b.max
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// sleep is a variable so tests can skip the waits.
+var sleep = func(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// batchGetItems reads the keys from one table in chunks, retrying unprocessed keys. Items come back
+// in no particular order and missing keys are simply absent.
+func batchGetItems(ctx context.Context, client DynamoDBAPI, tableName string, keys []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+	for start := 0; start < len(keys); start += maxBatchGetKeys {
+		pending := keys[start:min(start+maxBatchGetKeys, len(keys))]
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt == batchBackoff.attempts {
+					return nil, fmt.Errorf("batch get from %s: %d keys still unprocessed after %d attempts", tableName, len(pending), attempt)
+				}
+				if err := sleep(ctx, batchBackoff.delay(attempt)); err != nil {
+					return nil, err
+				}
+			}
+
+			output, err := client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: map[string]types.KeysAndAttributes{tableName: {Keys: pending}},
+			})
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, output.Responses[tableName]...)
+			pending = output.UnprocessedKeys[tableName].Keys
+		}
+	}
+	return items, nil
+}
+
+// batchWriteItems applies the write requests to one table in chunks, retrying unprocessed requests.
+func batchWriteItems(ctx context.Context, client DynamoDBAPI, tableName string, requests []types.WriteRequest) error {
+	for start := 0; start < len(requests); start += maxBatchWriteKeys {
+		pending := requests[start:min(start+maxBatchWriteKeys, len(requests))]
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt > 0 {
+				if attempt == batchBackoff.attempts {
+					return fmt.Errorf("batch write to %s: %d requests still unprocessed after %d attempts", tableName, len(pending), attempt)
+				}
+				if err := sleep(ctx, batchBackoff.delay(attempt)); err != nil {
+					return err
+				}
+			}
+
+			output, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]types.WriteRequest{tableName: pending},
+			})
+			if err != nil {
+				return err
+			}
+			pending = output.UnprocessedItems[tableName]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func skipBackoff(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	original := sleep
+	sleep = func(ctx context.Context, duration time.Duration) error {
+		waits = append(waits, duration)
+		return nil
+	}
+	t.Cleanup(func() { sleep = original })
+	return &waits
+}
+
+func postMetadataItem(t *testing.T, id string) map[string]types.AttributeValue {
+	t.Helper()
+	item, err := model.ToDynamoDbAttributes(&model.PostMetadata{
+		ID:        id,
+		Title:     "Title " + id,
+		BodyUrl:   "posts/" + id + "/body.md",
+		Status:    model.Draft,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	})
+	assert.NoError(t, err)
+	return item
+}
+
+func TestBatchGetPostMetadata_ChunksAndKeepsInputOrder(t *testing.T) {
+	var ids []string
+	for i := 0; i < 150; i++ {
+		ids = append(ids, fmt.Sprintf("ID%03d", i))
+	}
+	ids = append(ids, "missing", "ID000")
+
+	var chunkSizes []int
+	client := &MockDynamoDBClient{BatchGetFunc: func(ctx context.Context, input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+		keys := input.RequestItems["posts"].Keys
+		chunkSizes = append(chunkSizes, len(keys))
+		var items []map[string]types.AttributeValue
+		// Respond in reverse order to prove the DAO reorders.
+		for i := len(keys) - 1; i >= 0; i-- {
+			id := keys[i]["ID"].(*types.AttributeValueMemberS).Value
+			if id != "missing" {
+				items = append(items, postMetadataItem(t, id))
+			}
+		}
+		return &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{"posts": items}}, nil
+	}}
+	sut := NewPostMetadataDdbDao(client, "posts")
+
+	result, err := sut.BatchGetPostMetadata(context.Background(), ids)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{100, 51}, chunkSizes)
+	assert.Len(t, result, len(ids))
+	assert.Equal(t, "ID000", result[0].ID)
+	assert.Equal(t, "ID149", result[149].ID)
+	assert.Nil(t, result[150])
+	assert.Equal(t, "ID000", result[151].ID)
+}
+
+func TestBatchGetPostMetadata_UnprocessedKeys_RetriesWithBackoff(t *testing.T) {
+	waits := skipBackoff(t)
+	calls := 0
+	client := &MockDynamoDBClient{BatchGetFunc: func(ctx context.Context, input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+		calls++
+		keys := input.RequestItems["posts"].Keys
+		first := keys[0]["ID"].(*types.AttributeValueMemberS).Value
+		output := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{
+			"posts": {postMetadataItem(t, first)},
+		}}
+		if len(keys) > 1 {
+			output.UnprocessedKeys = map[string]types.KeysAndAttributes{"posts": {Keys: keys[1:]}}
+		}
+		return output, nil
+	}}
+	sut := NewPostMetadataDdbDao(client, "posts")
+
+	result, err := sut.BatchGetPostMetadata(context.Background(), []string{"a", "b", "c"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Len(t, *waits, 2)
+	assert.Equal(t, []string{"a", "b", "c"}, []string{result[0].ID, result[1].ID, result[2].ID})
+}
+
+func TestBatchWritePostMetadata_StillUnprocessed_ReturnsError(t *testing.T) {
+	waits := skipBackoff(t)
+	client := &MockDynamoDBClient{BatchWriteFunc: func(ctx context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+		return &dynamodb.BatchWriteItemOutput{UnprocessedItems: input.RequestItems}, nil
+	}}
+	sut := NewPostMetadataDdbDao(client, "posts")
+
+	err := sut.BatchWritePostMetadata(context.Background(), []*model.PostMetadata{{ID: "a", Status: model.Draft}})
+
+	assert.Error(t, err)
+	assert.Len(t, *waits, batchBackoff.attempts-1)
+}
+
+func TestBatchWritePostMetadata_ChunksToTwentyFive(t *testing.T) {
+	var chunkSizes []int
+	client := &MockDynamoDBClient{BatchWriteFunc: func(ctx context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+		chunkSizes = append(chunkSizes, len(input.RequestItems["posts"]))
+		return &dynamodb.BatchWriteItemOutput{}, nil
+	}}
+	sut := NewPostMetadataDdbDao(client, "posts")
+	var posts []*model.PostMetadata
+	for i := 0; i < 60; i++ {
+		posts = append(posts, &model.PostMetadata{ID: fmt.Sprint(i), Status: model.Draft})
+	}
+
+	err := sut.BatchWritePostMetadata(context.Background(), posts)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{25, 25, 10}, chunkSizes)
+}
+
+func TestBackoffDelay_StaysWithinCeiling(t *testing.T) {
+	policy := backoff{base: 10 * time.Millisecond, max: 100 * time.Millisecond, attempts: 5}
+
+	for attempt := 0; attempt < 20; attempt++ {
+		delay := policy.delay(attempt)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, 100*time.Millisecond)
+	}
+}
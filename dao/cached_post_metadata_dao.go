@@ -0,0 +1,220 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/neuralcoral/BlogService/cache"
+	"github.com/neuralcoral/BlogService/model"
+	"golang.org/x/sync/singleflight"
+)
+
+const postMetadataCachePrefix = "post-metadata:"
+
+// CachedPostMetadataDao is a read-through cache in front of another PostMetadataDao. Reads check the
+// in-process cache, then the optional remote cache, and concurrent misses for the same post share a
+// single load. Writes invalidate both caches, and a load that overlapped a write doesn't keep what
+// it read; other Lambda instances may serve their local copy until it expires.
+type CachedPostMetadataDao struct {
+	next     PostMetadataDao
+	local    *cache.LRU[string, model.PostMetadata]
+	remote   cache.Remote
+	ttl      time.Duration
+	loads    singleflight.Group
+	inFlight *cache.LoadTracker
+}
+
+// NewCachedPostMetadataDao caches up to size posts for ttl. remote may be nil.
+func NewCachedPostMetadataDao(next PostMetadataDao, size int, ttl time.Duration, remote cache.Remote) *CachedPostMetadataDao {
+	return &CachedPostMetadataDao{
+		next:     next,
+		local:    cache.NewLRU[string, model.PostMetadata](size, ttl),
+		remote:   remote,
+		ttl:      ttl,
+		inFlight: cache.NewLoadTracker(),
+	}
+}
+
+func (dao *CachedPostMetadataDao) GetPostMetadata(ctx context.Context, id string) (*model.PostMetadata, error) {
+	if cached, ok := dao.local.Get(id); ok {
+		return clonePostMetadata(&cached), nil
+	}
+
+	// The load is shared, so one caller giving up mustn't cancel it for the others.
+	ctx = context.WithoutCancel(ctx)
+	loaded, err, _ := dao.loads.Do(id, func() (interface{}, error) {
+		load := dao.inFlight.Start(id)
+		defer load.Done()
+
+		if cached, ok := dao.getRemote(ctx, id); ok {
+			dao.local.Set(id, *cached)
+			dao.discardIfInvalidated(ctx, load)
+			return cached, nil
+		}
+
+		postMetadata, err := dao.next.GetPostMetadata(ctx, id)
+		if err != nil || postMetadata == nil {
+			return postMetadata, err
+		}
+		dao.local.Set(id, *clonePostMetadata(postMetadata))
+		dao.setRemote(ctx, postMetadata)
+		dao.discardIfInvalidated(ctx, load)
+		return postMetadata, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every caller that shared the load gets its own copy to modify.
+	postMetadata := loaded.(*model.PostMetadata)
+	if postMetadata == nil {
+		return nil, nil
+	}
+	return clonePostMetadata(postMetadata), nil
+}
+
+func (dao *CachedPostMetadataDao) UpdatePostMetadata(ctx context.Context, postMetadataToUpdate *model.PostMetadata) (*model.PostMetadata, error) {
+	defer dao.invalidate(ctx, postMetadataToUpdate.ID)
+	return dao.next.UpdatePostMetadata(ctx, postMetadataToUpdate)
+}
+
+func (dao *CachedPostMetadataDao) ListPostMetadata(ctx context.Context, limit int, lastEvaluatedKey string) ([]*model.PostMetadata, string, error) {
+	return dao.next.ListPostMetadata(ctx, limit, lastEvaluatedKey)
+}
+
+func (dao *CachedPostMetadataDao) CreatePostMetadata(ctx context.Context, postMetadataToCreate *model.PostMetadata) error {
+	return dao.next.CreatePostMetadata(ctx, postMetadataToCreate)
+}
+
+// BatchGetPostMetadata serves what it can from the local and then the remote cache, and batches the
+// rest.
+func (dao *CachedPostMetadataDao) BatchGetPostMetadata(ctx context.Context, ids []string) ([]*model.PostMetadata, error) {
+	result := make([]*model.PostMetadata, len(ids))
+	var missing []string
+	var missingIndexes []int
+	var loads []*cache.TrackedLoad
+	defer func() {
+		for _, load := range loads {
+			load.Done()
+		}
+	}()
+	for i, id := range ids {
+		if cached, ok := dao.local.Get(id); ok {
+			result[i] = clonePostMetadata(&cached)
+			continue
+		}
+		load := dao.inFlight.Start(id)
+		loads = append(loads, load)
+		if cached, ok := dao.getRemote(ctx, id); ok {
+			dao.local.Set(id, *clonePostMetadata(cached))
+			dao.discardIfInvalidated(ctx, load)
+			result[i] = cached
+			continue
+		}
+		missing = append(missing, id)
+		missingIndexes = append(missingIndexes, i)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	loaded, err := dao.next.BatchGetPostMetadata(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, postMetadata := range loaded {
+		if postMetadata != nil {
+			dao.local.Set(postMetadata.ID, *clonePostMetadata(postMetadata))
+			dao.setRemote(ctx, postMetadata)
+		}
+		result[missingIndexes[j]] = postMetadata
+	}
+	for _, load := range loads {
+		dao.discardIfInvalidated(ctx, load)
+	}
+	return result, nil
+}
+
+func (dao *CachedPostMetadataDao) BatchWritePostMetadata(ctx context.Context, posts []*model.PostMetadata) error {
+	ids := make([]string, len(posts))
+	for i, postMetadata := range posts {
+		ids[i] = postMetadata.ID
+	}
+	defer dao.invalidate(ctx, ids...)
+	return dao.next.BatchWritePostMetadata(ctx, posts)
+}
+
+// invalidate runs after the write whether or not it succeeded, since a failed write may still
+// have been applied.
+func (dao *CachedPostMetadataDao) invalidate(ctx context.Context, ids ...string) {
+	dao.inFlight.Invalidate(ids...)
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		// Callers arriving after the write start a new load instead of sharing one that may predate it.
+		dao.loads.Forget(id)
+		dao.local.Delete(id)
+		keys[i] = postMetadataCachePrefix + id
+	}
+	if dao.remote == nil {
+		return
+	}
+	if err := dao.remote.Delete(ctx, keys...); err != nil {
+		log.Printf("remote cache: invalidate %v: %v", ids, err)
+	}
+}
+
+// discardIfInvalidated runs after a load has cached what it read. If a write invalidated the post
+// meanwhile, the value may predate the write, so it is removed again.
+func (dao *CachedPostMetadataDao) discardIfInvalidated(ctx context.Context, load *cache.TrackedLoad) {
+	if !load.Invalidated() {
+		return
+	}
+	dao.local.Delete(load.Key())
+	if dao.remote == nil {
+		return
+	}
+	if err := dao.remote.Delete(ctx, postMetadataCachePrefix+load.Key()); err != nil {
+		log.Printf("remote cache: discard post %s: %v", load.Key(), err)
+	}
+}
+
+func (dao *CachedPostMetadataDao) getRemote(ctx context.Context, id string) (*model.PostMetadata, bool) {
+	if dao.remote == nil {
+		return nil, false
+	}
+	data, ok, err := dao.remote.Get(ctx, postMetadataCachePrefix+id)
+	if err != nil {
+		log.Printf("remote cache: get post %s: %v", id, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var postMetadata model.PostMetadata
+	if err := json.Unmarshal(data, &postMetadata); err != nil {
+		return nil, false
+	}
+	return &postMetadata, true
+}
+
+func (dao *CachedPostMetadataDao) setRemote(ctx context.Context, postMetadata *model.PostMetadata) {
+	if dao.remote == nil {
+		return
+	}
+	data, err := json.Marshal(postMetadata)
+	if err != nil {
+		return
+	}
+	if err := dao.remote.Set(ctx, postMetadataCachePrefix+postMetadata.ID, data, dao.ttl); err != nil {
+		log.Printf("remote cache: set post %s: %v", postMetadata.ID, err)
+	}
+}
+
+func clonePostMetadata(postMetadata *model.PostMetadata) *model.PostMetadata {
+	clone := *postMetadata
+	clone.Tags = append([]model.Tag(nil), postMetadata.Tags...)
+	clone.AssetIDs = append([]string(nil), postMetadata.AssetIDs...)
+	return &clone
+}
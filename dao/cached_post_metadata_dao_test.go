@@ -0,0 +1,197 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/cache/cachetest"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockPostMetadataDao struct {
+	PostMetadataDao
+	GetFunc      func(ctx context.Context, id string) (*model.PostMetadata, error)
+	UpdateFunc   func(ctx context.Context, postMetadata *model.PostMetadata) (*model.PostMetadata, error)
+	BatchGetFunc func(ctx context.Context, ids []string) ([]*model.PostMetadata, error)
+}
+
+func (m *MockPostMetadataDao) GetPostMetadata(ctx context.Context, id string) (*model.PostMetadata, error) {
+	return m.GetFunc(ctx, id)
+}
+
+func (m *MockPostMetadataDao) UpdatePostMetadata(ctx context.Context, postMetadata *model.PostMetadata) (*model.PostMetadata, error) {
+	return m.UpdateFunc(ctx, postMetadata)
+}
+
+func (m *MockPostMetadataDao) BatchGetPostMetadata(ctx context.Context, ids []string) ([]*model.PostMetadata, error) {
+	return m.BatchGetFunc(ctx, ids)
+}
+
+func TestCachedPostMetadataDao_ConcurrentMisses_LoadOnce(t *testing.T) {
+	var loads int32
+	release := make(chan struct{})
+	next := &MockPostMetadataDao{GetFunc: func(ctx context.Context, id string) (*model.PostMetadata, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return &model.PostMetadata{ID: id, Title: "Title"}, nil
+	}}
+	sut := NewCachedPostMetadataDao(next, 10, time.Minute, nil)
+
+	var wg sync.WaitGroup
+	results := make([]*model.PostMetadata, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = sut.GetPostMetadata(context.Background(), "123")
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	for _, result := range results {
+		assert.Equal(t, "Title", result.Title)
+	}
+	assert.NotSame(t, results[0], results[1], "each caller should get its own copy")
+}
+
+func TestCachedPostMetadataDao_Hit_SkipsNextAndReturnsCopy(t *testing.T) {
+	loads := 0
+	next := &MockPostMetadataDao{GetFunc: func(ctx context.Context, id string) (*model.PostMetadata, error) {
+		loads++
+		return &model.PostMetadata{ID: id, Title: "Title", Tags: []model.Tag{{ID: "t", Label: "go"}}}, nil
+	}}
+	sut := NewCachedPostMetadataDao(next, 10, time.Minute, nil)
+
+	first, _ := sut.GetPostMetadata(context.Background(), "123")
+	first.Tags[0].Label = "changed"
+	second, err := sut.GetPostMetadata(context.Background(), "123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, loads)
+	assert.Equal(t, "go", second.Tags[0].Label)
+}
+
+func TestCachedPostMetadataDao_Update_InvalidatesLocalAndRemote(t *testing.T) {
+	title := "Before"
+	next := &MockPostMetadataDao{
+		GetFunc: func(ctx context.Context, id string) (*model.PostMetadata, error) {
+			return &model.PostMetadata{ID: id, Title: title}, nil
+		},
+		UpdateFunc: func(ctx context.Context, postMetadata *model.PostMetadata) (*model.PostMetadata, error) {
+			title = postMetadata.Title
+			return postMetadata, nil
+		},
+	}
+	remote := cachetest.NewRemote()
+	sut := NewCachedPostMetadataDao(next, 10, time.Minute, remote)
+	_, _ = sut.GetPostMetadata(context.Background(), "123")
+	assert.Contains(t, remote.Values, "post-metadata:123")
+
+	_, err := sut.UpdatePostMetadata(context.Background(), &model.PostMetadata{ID: "123", Title: "After"})
+	result, _ := sut.GetPostMetadata(context.Background(), "123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "After", result.Title)
+}
+
+func TestCachedPostMetadataDao_RemoteHit_SkipsNext(t *testing.T) {
+	next := &MockPostMetadataDao{GetFunc: func(ctx context.Context, id string) (*model.PostMetadata, error) {
+		t.Fatal("next should not be called on a remote hit")
+		return nil, nil
+	}}
+	cached, _ := json.Marshal(&model.PostMetadata{ID: "123", Title: "Remote"})
+	remote := cachetest.NewRemote()
+	remote.Values["post-metadata:123"] = cached
+	sut := NewCachedPostMetadataDao(next, 10, time.Minute, remote)
+
+	result, err := sut.GetPostMetadata(context.Background(), "123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Remote", result.Title)
+}
+
+func TestCachedPostMetadataDao_Missing_IsNotCached(t *testing.T) {
+	loads := 0
+	next := &MockPostMetadataDao{GetFunc: func(ctx context.Context, id string) (*model.PostMetadata, error) {
+		loads++
+		return nil, nil
+	}}
+	sut := NewCachedPostMetadataDao(next, 10, time.Minute, nil)
+
+	_, _ = sut.GetPostMetadata(context.Background(), "123")
+	result, err := sut.GetPostMetadata(context.Background(), "123")
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, 2, loads)
+}
+
+func TestCachedPostMetadataDao_UpdateDuringLoad_DoesNotCacheStaleValue(t *testing.T) {
+	title := "Before"
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	next := &MockPostMetadataDao{
+		GetFunc: func(ctx context.Context, id string) (*model.PostMetadata, error) {
+			read := &model.PostMetadata{ID: id, Title: title}
+			if read.Title == "Before" {
+				close(loading)
+				<-release
+			}
+			return read, nil
+		},
+		UpdateFunc: func(ctx context.Context, postMetadata *model.PostMetadata) (*model.PostMetadata, error) {
+			title = postMetadata.Title
+			return postMetadata, nil
+		},
+	}
+	remote := cachetest.NewRemote()
+	sut := NewCachedPostMetadataDao(next, 10, time.Minute, remote)
+
+	staleRead := make(chan *model.PostMetadata)
+	go func() {
+		result, _ := sut.GetPostMetadata(context.Background(), "123")
+		staleRead <- result
+	}()
+	<-loading
+	_, err := sut.UpdatePostMetadata(context.Background(), &model.PostMetadata{ID: "123", Title: "After"})
+	assert.NoError(t, err)
+	close(release)
+	assert.Equal(t, "Before", (<-staleRead).Title)
+
+	assert.NotContains(t, remote.Values, "post-metadata:123")
+	result, err := sut.GetPostMetadata(context.Background(), "123")
+	assert.NoError(t, err)
+	assert.Equal(t, "After", result.Title)
+}
+
+func TestCachedPostMetadataDao_BatchGet_UsesRemoteCacheBeforeNext(t *testing.T) {
+	var requested []string
+	next := &MockPostMetadataDao{BatchGetFunc: func(ctx context.Context, ids []string) ([]*model.PostMetadata, error) {
+		requested = ids
+		result := make([]*model.PostMetadata, len(ids))
+		for i, id := range ids {
+			result[i] = &model.PostMetadata{ID: id, Title: "Loaded"}
+		}
+		return result, nil
+	}}
+	cached, _ := json.Marshal(&model.PostMetadata{ID: "1", Title: "Remote"})
+	remote := cachetest.NewRemote()
+	remote.Values["post-metadata:1"] = cached
+	sut := NewCachedPostMetadataDao(next, 10, time.Minute, remote)
+
+	result, err := sut.BatchGetPostMetadata(context.Background(), []string{"1", "2"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, requested)
+	assert.Equal(t, "Remote", result[0].Title)
+	assert.Equal(t, "Loaded", result[1].Title)
+	assert.Contains(t, remote.Values, "post-metadata:2")
+}
@@ -0,0 +1,4 @@
+// Package daotest provides in-memory DAOs for tests. They keep the contracts of the DynamoDB
+// implementations that callers rely on, such as conditional creates and not found errors, and are
+// safe for concurrent use.
+package daotest
@@ -0,0 +1,125 @@
+package daotest
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type MediaAssetDao struct {
+	mutex  sync.Mutex
+	Assets map[string]*model.MediaAsset
+}
+
+func NewMediaAssetDao(assets ...*model.MediaAsset) *MediaAssetDao {
+	dao := &MediaAssetDao{Assets: map[string]*model.MediaAsset{}}
+	for _, asset := range assets {
+		dao.Assets[asset.ID] = asset
+	}
+	return dao
+}
+
+func (dao *MediaAssetDao) GetMediaAsset(ctx context.Context, id string) (*model.MediaAsset, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	asset, ok := dao.Assets[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *asset
+	return &copied, nil
+}
+
+func (dao *MediaAssetDao) CreateMediaAsset(ctx context.Context, assetToCreate *model.MediaAsset) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	if _, exists := dao.Assets[assetToCreate.ID]; exists {
+		return apperror.Conflict("media asset already exists")
+	}
+	copied := *assetToCreate
+	dao.Assets[copied.ID] = &copied
+	return nil
+}
+
+// ListMediaAssets returns the owner's assets ordered by ID.
+func (dao *MediaAssetDao) ListMediaAssets(ctx context.Context, ownerID string, limit int, lastEvaluatedKey string) ([]*model.MediaAsset, string, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	var ids []string
+	for id, asset := range dao.Assets {
+		if asset.OwnerID == ownerID && id > lastEvaluatedKey {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	var result []*model.MediaAsset
+	for _, id := range ids {
+		if len(result) == limit {
+			return result, result[len(result)-1].ID, nil
+		}
+		copied := *dao.Assets[id]
+		result = append(result, &copied)
+	}
+	return result, "", nil
+}
+
+func (dao *MediaAssetDao) UpdateMediaAssetVariants(ctx context.Context, id string, variants map[string]model.MediaVariant) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	asset, ok := dao.Assets[id]
+	if !ok {
+		return apperror.NotFound("media asset not found")
+	}
+	asset.Variants = variants
+	return nil
+}
+
+func (dao *MediaAssetDao) MarkMediaAssetFailed(ctx context.Context, id string, reason string) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	asset, ok := dao.Assets[id]
+	if !ok {
+		return apperror.NotFound("media asset not found")
+	}
+	asset.ProcessingError = reason
+	return nil
+}
+
+func (dao *MediaAssetDao) AddMediaAssetReference(ctx context.Context, id string, postID string, ownerID string) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	asset, ok := dao.Assets[id]
+	if !ok || (ownerID != "" && asset.OwnerID != ownerID) {
+		return apperror.NotFound("media asset not found")
+	}
+	if !slices.Contains(asset.PostIDs, postID) {
+		asset.PostIDs = append(asset.PostIDs, postID)
+	}
+	return nil
+}
+
+func (dao *MediaAssetDao) RemoveMediaAssetReference(ctx context.Context, id string, postID string) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	if asset, ok := dao.Assets[id]; ok {
+		asset.PostIDs = slices.DeleteFunc(asset.PostIDs, func(referenced string) bool { return referenced == postID })
+		if len(asset.PostIDs) == 0 {
+			asset.PostIDs = nil
+		}
+	}
+	return nil
+}
+
+func (dao *MediaAssetDao) DeleteMediaAsset(ctx context.Context, id string) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	if asset, ok := dao.Assets[id]; ok && len(asset.PostIDs) > 0 {
+		return apperror.Conflict("media asset is used by a post")
+	}
+	delete(dao.Assets, id)
+	return nil
+}
@@ -0,0 +1,114 @@
+package daotest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type PostMetadataDao struct {
+	mutex sync.Mutex
+	Posts map[string]*model.PostMetadata
+}
+
+func NewPostMetadataDao(posts ...*model.PostMetadata) *PostMetadataDao {
+	dao := &PostMetadataDao{Posts: map[string]*model.PostMetadata{}}
+	for _, post := range posts {
+		dao.Posts[post.ID] = post
+	}
+	return dao
+}
+
+func (dao *PostMetadataDao) GetPostMetadata(ctx context.Context, id string) (*model.PostMetadata, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	post, ok := dao.Posts[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *post
+	return &copied, nil
+}
+
+// UpdatePostMetadata checks Version like the DynamoDB DAO does.
+func (dao *PostMetadataDao) UpdatePostMetadata(ctx context.Context, postMetadataToUpdate *model.PostMetadata) (*model.PostMetadata, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	existing, ok := dao.Posts[postMetadataToUpdate.ID]
+	if !ok {
+		return nil, apperror.NotFound("post not found")
+	}
+	if existing.Version != postMetadataToUpdate.Version {
+		return nil, apperror.Conflict("post was changed by someone else; reload it and try again")
+	}
+	copied := *postMetadataToUpdate
+	copied.Version++
+	copied.UpdatedAt = time.Now().UTC()
+	dao.Posts[copied.ID] = &copied
+	updated := copied
+	return &updated, nil
+}
+
+// ListPostMetadata returns posts ordered by ID.
+func (dao *PostMetadataDao) ListPostMetadata(ctx context.Context, limit int, lastEvaluatedKey string) ([]*model.PostMetadata, string, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	var ids []string
+	for id := range dao.Posts {
+		if id > lastEvaluatedKey {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	var result []*model.PostMetadata
+	for _, id := range ids {
+		if len(result) == limit {
+			return result, result[len(result)-1].ID, nil
+		}
+		copied := *dao.Posts[id]
+		result = append(result, &copied)
+	}
+	return result, "", nil
+}
+
+func (dao *PostMetadataDao) CreatePostMetadata(ctx context.Context, postMetadataToCreate *model.PostMetadata) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	if _, exists := dao.Posts[postMetadataToCreate.ID]; exists {
+		return apperror.Conflict("post already exists")
+	}
+	if postMetadataToCreate.Version == 0 {
+		postMetadataToCreate.Version = 1
+	}
+	copied := *postMetadataToCreate
+	dao.Posts[copied.ID] = &copied
+	return nil
+}
+
+func (dao *PostMetadataDao) BatchGetPostMetadata(ctx context.Context, ids []string) ([]*model.PostMetadata, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	result := make([]*model.PostMetadata, len(ids))
+	for i, id := range ids {
+		if post, ok := dao.Posts[id]; ok {
+			copied := *post
+			result[i] = &copied
+		}
+	}
+	return result, nil
+}
+
+func (dao *PostMetadataDao) BatchWritePostMetadata(ctx context.Context, posts []*model.PostMetadata) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	for _, post := range posts {
+		copied := *post
+		dao.Posts[copied.ID] = &copied
+	}
+	return nil
+}
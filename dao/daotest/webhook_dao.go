@@ -0,0 +1,83 @@
+package daotest
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type WebhookDao struct {
+	mutex    sync.Mutex
+	Webhooks map[string]*model.Webhook
+}
+
+func NewWebhookDao(webhooks ...*model.Webhook) *WebhookDao {
+	dao := &WebhookDao{Webhooks: map[string]*model.Webhook{}}
+	for _, webhook := range webhooks {
+		dao.Webhooks[webhook.ID] = webhook
+	}
+	return dao
+}
+
+func (dao *WebhookDao) CreateWebhook(ctx context.Context, webhookToCreate *model.Webhook) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	if _, exists := dao.Webhooks[webhookToCreate.ID]; exists {
+		return apperror.Conflict("webhook already exists")
+	}
+	copied := *webhookToCreate
+	dao.Webhooks[copied.ID] = &copied
+	return nil
+}
+
+func (dao *WebhookDao) GetWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	webhook, ok := dao.Webhooks[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *webhook
+	return &copied, nil
+}
+
+// ListWebhooks returns webhooks ordered by ID.
+func (dao *WebhookDao) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	var ids []string
+	for id := range dao.Webhooks {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	result := make([]*model.Webhook, 0, len(ids))
+	for _, id := range ids {
+		copied := *dao.Webhooks[id]
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (dao *WebhookDao) UpdateWebhook(ctx context.Context, webhookToUpdate *model.Webhook) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	if _, exists := dao.Webhooks[webhookToUpdate.ID]; !exists {
+		return apperror.NotFound("webhook not found")
+	}
+	copied := *webhookToUpdate
+	dao.Webhooks[copied.ID] = &copied
+	return nil
+}
+
+func (dao *WebhookDao) DeleteWebhook(ctx context.Context, id string) error {
+	dao.mutex.Lock()
+	defer dao.mutex.Unlock()
+	if _, exists := dao.Webhooks[id]; !exists {
+		return apperror.NotFound("webhook not found")
+	}
+	delete(dao.Webhooks, id)
+	return nil
+}
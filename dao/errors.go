@@ -0,0 +1,23 @@
+package dao
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/apperror"
+)
+
+func isConditionalCheckFailure(err error) bool {
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	return errors.As(err, &conditionalCheckFailed)
+}
+
+// translateConditionalCheckFailure turns a failed condition expression into the given domain error
+// kind and leaves every other error untouched.
+func translateConditionalCheckFailure(err error, kind apperror.Kind, message string) error {
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionalCheckFailed) {
+		return apperror.Wrap(kind, message, err)
+	}
+	return err
+}
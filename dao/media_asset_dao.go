@@ -0,0 +1,21 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type MediaAssetDao interface {
+	GetMediaAsset(ctx context.Context, id string) (*model.MediaAsset, error)
+	CreateMediaAsset(ctx context.Context, assetToCreate *model.MediaAsset) error
+	ListMediaAssets(ctx context.Context, ownerID string, limit int, lastEvaluatedKey string) ([]*model.MediaAsset, string, error)
+	UpdateMediaAssetVariants(ctx context.Context, id string, variants map[string]model.MediaVariant) error
+	MarkMediaAssetFailed(ctx context.Context, id string, reason string) error
+	// AddMediaAssetReference records that a post uses the asset. It fails with a not found error
+	// when the asset doesn't exist or, if ownerID is set, belongs to someone else.
+	AddMediaAssetReference(ctx context.Context, id string, postID string, ownerID string) error
+	RemoveMediaAssetReference(ctx context.Context, id string, postID string) error
+	// DeleteMediaAsset fails with a conflict while a post still references the asset.
+	DeleteMediaAsset(ctx context.Context, id string) error
+}
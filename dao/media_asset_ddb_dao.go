@@ -0,0 +1,188 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const mediaAssetOwnerIndex = "OwnerID-index"
+
+type MediaAssetDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewMediaAssetDdbDao(client DynamoDBAPI, tableName string) *MediaAssetDdbDao {
+	return &MediaAssetDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *MediaAssetDdbDao) GetMediaAsset(ctx context.Context, id string) (*model.MediaAsset, error) {
+	ddbInput := &dynamodb.GetItemInput{
+		TableName: aws.String(dao.tableName),
+		Key: map[string]types.AttributeValue{
+			"ID": &types.AttributeValueMemberS{Value: id},
+		},
+	}
+	output, err := dao.client.GetItem(ctx, ddbInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if output == nil || len(output.Item) == 0 {
+		return nil, nil
+	}
+
+	return model.MediaAssetFromDynamoDBAttributeValue(output.Item)
+}
+
+func (dao *MediaAssetDdbDao) CreateMediaAsset(ctx context.Context, assetToCreate *model.MediaAsset) error {
+	if assetToCreate == nil {
+		return nil
+	}
+
+	item, err := model.MediaAssetToDynamoDbAttributes(assetToCreate)
+	if err != nil {
+		return err
+	}
+
+	ddbInput := &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(ID)"),
+	}
+
+	_, err = dao.client.PutItem(ctx, ddbInput)
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "media asset already exists")
+}
+
+func (dao *MediaAssetDdbDao) ListMediaAssets(ctx context.Context, ownerID string, limit int, lastEvaluatedKey string) ([]*model.MediaAsset, string, error) {
+	ddbInput := &dynamodb.QueryInput{
+		TableName:              aws.String(dao.tableName),
+		IndexName:              aws.String(mediaAssetOwnerIndex),
+		KeyConditionExpression: aws.String("OwnerID = :ownerID"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ownerID": &types.AttributeValueMemberS{Value: ownerID},
+		},
+		Limit: aws.Int32(int32(limit)),
+	}
+
+	if lastEvaluatedKey != "" {
+		ddbInput.ExclusiveStartKey = map[string]types.AttributeValue{
+			"ID":      &types.AttributeValueMemberS{Value: lastEvaluatedKey},
+			"OwnerID": &types.AttributeValueMemberS{Value: ownerID},
+		}
+	}
+
+	output, err := dao.client.Query(ctx, ddbInput)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextKey := ""
+	if id, ok := output.LastEvaluatedKey["ID"].(*types.AttributeValueMemberS); ok {
+		nextKey = id.Value
+	}
+
+	result, err := model.MediaAssetsFromDynamoDBAttributeValues(output.Items)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, nextKey, nil
+}
+
+func (dao *MediaAssetDdbDao) UpdateMediaAssetVariants(ctx context.Context, id string, variants map[string]model.MediaVariant) error {
+	variantsAttribute, err := model.MediaVariantsToDynamoDbAttribute(variants)
+	if err != nil {
+		return err
+	}
+
+	ddbInput := &dynamodb.UpdateItemInput{
+		TableName: aws.String(dao.tableName),
+		Key: map[string]types.AttributeValue{
+			"ID": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("SET Variants = :variants"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":variants": variantsAttribute,
+		},
+	}
+
+	_, err = dao.client.UpdateItem(ctx, ddbInput)
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "media asset not found")
+}
+
+func (dao *MediaAssetDdbDao) MarkMediaAssetFailed(ctx context.Context, id string, reason string) error {
+	ddbInput := &dynamodb.UpdateItemInput{
+		TableName: aws.String(dao.tableName),
+		Key: map[string]types.AttributeValue{
+			"ID": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("SET ProcessingError = :reason"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":reason": &types.AttributeValueMemberS{Value: reason},
+		},
+	}
+
+	_, err := dao.client.UpdateItem(ctx, ddbInput)
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "media asset not found")
+}
+
+func (dao *MediaAssetDdbDao) AddMediaAssetReference(ctx context.Context, id string, postID string, ownerID string) error {
+	ddbInput := dao.referenceUpdate(id, postID, "ADD PostIDs :postID")
+	if ownerID != "" {
+		ddbInput.ConditionExpression = aws.String("attribute_exists(ID) AND OwnerID = :ownerID")
+		ddbInput.ExpressionAttributeValues[":ownerID"] = &types.AttributeValueMemberS{Value: ownerID}
+	}
+	_, err := dao.client.UpdateItem(ctx, ddbInput)
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "media asset not found")
+}
+
+// RemoveMediaAssetReference ignores assets that no longer exist. DynamoDB drops PostIDs when its
+// last post is removed, which is what DeleteMediaAsset checks for.
+func (dao *MediaAssetDdbDao) RemoveMediaAssetReference(ctx context.Context, id string, postID string) error {
+	_, err := dao.client.UpdateItem(ctx, dao.referenceUpdate(id, postID, "DELETE PostIDs :postID"))
+	if apperror.KindOf(translateConditionalCheckFailure(err, apperror.KindNotFound, "media asset not found")) == apperror.KindNotFound {
+		return nil
+	}
+	return err
+}
+
+// referenceUpdate changes the PostIDs set of an existing asset; without the condition an update
+// would create an item holding nothing but the set.
+func (dao *MediaAssetDdbDao) referenceUpdate(id string, postID string, updateExpression string) *dynamodb.UpdateItemInput {
+	return &dynamodb.UpdateItemInput{
+		TableName: aws.String(dao.tableName),
+		Key: map[string]types.AttributeValue{
+			"ID": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String(updateExpression),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":postID": &types.AttributeValueMemberSS{Value: []string{postID}},
+		},
+	}
+}
+
+func (dao *MediaAssetDdbDao) DeleteMediaAsset(ctx context.Context, id string) error {
+	ddbInput := &dynamodb.DeleteItemInput{
+		TableName: aws.String(dao.tableName),
+		Key: map[string]types.AttributeValue{
+			"ID": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(PostIDs)"),
+	}
+
+	_, err := dao.client.DeleteItem(ctx, ddbInput)
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "media asset is used by a post")
+}
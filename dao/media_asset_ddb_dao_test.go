@@ -0,0 +1,209 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMediaAsset_Succeeds(t *testing.T) {
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	getItemFunc := func(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+		return &dynamodb.GetItemOutput{
+			Item: map[string]types.AttributeValue{
+				"ID":        &types.AttributeValueMemberS{Value: "asset1"},
+				"OwnerID":   &types.AttributeValueMemberS{Value: "user1"},
+				"Key":       &types.AttributeValueMemberS{Value: "media/asset1/original"},
+				"MimeType":  &types.AttributeValueMemberS{Value: "image/png"},
+				"SizeBytes": &types.AttributeValueMemberN{Value: "2048"},
+				"Width":     &types.AttributeValueMemberN{Value: "640"},
+				"Height":    &types.AttributeValueMemberN{Value: "480"},
+				"Checksum":  &types.AttributeValueMemberS{Value: "abc="},
+				"CreatedAt": &types.AttributeValueMemberS{Value: createdAt.Format(time.RFC3339)},
+			},
+		}, nil
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{GetItemFunc: getItemFunc}}
+
+	result, err := sut.GetMediaAsset(context.Background(), "asset1")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, &model.MediaAsset{
+		ID:        "asset1",
+		OwnerID:   "user1",
+		Key:       "media/asset1/original",
+		MimeType:  "image/png",
+		SizeBytes: 2048,
+		Width:     640,
+		Height:    480,
+		Checksum:  "abc=",
+		CreatedAt: createdAt,
+	}, result)
+}
+
+func TestGetMediaAsset_MissingItem_ReturnsNil(t *testing.T) {
+	getItemFunc := func(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{GetItemFunc: getItemFunc}}
+
+	result, err := sut.GetMediaAsset(context.Background(), "asset1")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Nil(t, result)
+}
+
+func TestCreateMediaAsset_UsesConditionalPut(t *testing.T) {
+	var captured *dynamodb.PutItemInput
+	putItemFunc := func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+		captured = input
+		return &dynamodb.PutItemOutput{}, nil
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{PutItemFunc: putItemFunc}, tableName: "MediaAssets"}
+
+	err := sut.CreateMediaAsset(context.Background(), &model.MediaAsset{ID: "asset1", OwnerID: "user1"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "MediaAssets", *captured.TableName)
+	assert.Equal(t, "attribute_not_exists(ID)", *captured.ConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "user1"}, captured.Item["OwnerID"])
+}
+
+func TestListMediaAssets_QueriesOwnerIndexWithLastEvaluatedKey(t *testing.T) {
+	queryFunc := func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		assert.Equal(t, mediaAssetOwnerIndex, *input.IndexName)
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "user1"}, input.ExpressionAttributeValues[":ownerID"])
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "asset1"}, input.ExclusiveStartKey["ID"])
+		return &dynamodb.QueryOutput{
+			Items: []map[string]types.AttributeValue{
+				{
+					"ID":        &types.AttributeValueMemberS{Value: "asset2"},
+					"OwnerID":   &types.AttributeValueMemberS{Value: "user1"},
+					"Key":       &types.AttributeValueMemberS{Value: "media/asset2/original"},
+					"MimeType":  &types.AttributeValueMemberS{Value: "image/png"},
+					"CreatedAt": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)},
+				},
+			},
+			LastEvaluatedKey: map[string]types.AttributeValue{
+				"ID":      &types.AttributeValueMemberS{Value: "asset2"},
+				"OwnerID": &types.AttributeValueMemberS{Value: "user1"},
+			},
+		}, nil
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{QueryFunc: queryFunc}}
+
+	result, nextKey, err := sut.ListMediaAssets(context.Background(), "user1", 1, "asset1")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Len(t, result, 1)
+	assert.Equal(t, "asset2", result[0].ID)
+	assert.Equal(t, "asset2", nextKey)
+}
+
+func TestDeleteMediaAsset_DynamoDBFailure_ReturnsErr(t *testing.T) {
+	expectedErr := errors.New("mock error for testing")
+	deleteItemFunc := func(ctx context.Context, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+		return nil, errors.New("mock error for testing")
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{DeleteItemFunc: deleteItemFunc}}
+
+	err := sut.DeleteMediaAsset(context.Background(), "asset1")
+
+	assert.Equal(t, expectedErr, err)
+}
+
+func TestDeleteMediaAsset_ReferencedByPost_ReturnsConflict(t *testing.T) {
+	deleteItemFunc := func(ctx context.Context, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+		assert.Equal(t, "attribute_not_exists(PostIDs)", *input.ConditionExpression)
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{DeleteItemFunc: deleteItemFunc}}
+
+	err := sut.DeleteMediaAsset(context.Background(), "asset1")
+
+	assert.Equal(t, apperror.KindConflict, apperror.KindOf(err))
+}
+
+func TestAddMediaAssetReference_AddsPostToExistingAsset(t *testing.T) {
+	var captured *dynamodb.UpdateItemInput
+	updateItemFunc := func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		captured = input
+		return &dynamodb.UpdateItemOutput{}, nil
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{UpdateItemFunc: updateItemFunc}, tableName: "MediaAssets"}
+
+	err := sut.AddMediaAssetReference(context.Background(), "asset1", "post1", "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "ADD PostIDs :postID", *captured.UpdateExpression)
+	assert.Equal(t, "attribute_exists(ID)", *captured.ConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberSS{Value: []string{"post1"}}, captured.ExpressionAttributeValues[":postID"])
+}
+
+func TestAddMediaAssetReference_Owner_ConditionsOnOwner(t *testing.T) {
+	var captured *dynamodb.UpdateItemInput
+	updateItemFunc := func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		captured = input
+		return &dynamodb.UpdateItemOutput{}, nil
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{UpdateItemFunc: updateItemFunc}, tableName: "MediaAssets"}
+
+	err := sut.AddMediaAssetReference(context.Background(), "asset1", "post1", "user-1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "attribute_exists(ID) AND OwnerID = :ownerID", *captured.ConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "user-1"}, captured.ExpressionAttributeValues[":ownerID"])
+}
+
+func TestAddMediaAssetReference_MissingAsset_ReturnsNotFound(t *testing.T) {
+	updateItemFunc := func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{UpdateItemFunc: updateItemFunc}}
+
+	err := sut.AddMediaAssetReference(context.Background(), "asset1", "post1", "user-1")
+
+	assert.Equal(t, apperror.KindNotFound, apperror.KindOf(err))
+}
+
+func TestRemoveMediaAssetReference_MissingAsset_Succeeds(t *testing.T) {
+	updateItemFunc := func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		assert.Equal(t, "DELETE PostIDs :postID", *input.UpdateExpression)
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{UpdateItemFunc: updateItemFunc}}
+
+	err := sut.RemoveMediaAssetReference(context.Background(), "asset1", "post1")
+
+	assert.Nil(t, err)
+}
+
+func TestMarkMediaAssetFailed_SetsProcessingErrorOnExistingAsset(t *testing.T) {
+	var captured *dynamodb.UpdateItemInput
+	updateItemFunc := func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		captured = input
+		return &dynamodb.UpdateItemOutput{}, nil
+	}
+	sut := &MediaAssetDdbDao{client: &MockDynamoDBClient{UpdateItemFunc: updateItemFunc}}
+
+	err := sut.MarkMediaAssetFailed(context.Background(), "asset1", "unsupported or corrupt image")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SET ProcessingError = :reason", *captured.UpdateExpression)
+	assert.Equal(t, "attribute_exists(ID)", *captured.ConditionExpression)
+}
@@ -1,10 +1,19 @@
 package dao
 
-import "github.com/neuralcoral/BlogService/model"
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/model"
+)
 
 type PostMetadataDao interface {
-	GetPostMetadata(id string) (*model.PostMetadata, error)
-	UpdatePostMetadata(postMetadataToUpdate *model.PostMetadata) (*model.PostMetadata, error)
-	ListPostMetadata(limit int, lastEvaluatedKey string) ([]*model.PostMetadata, string, error)
-	CreatePostMetadata(postMetadataToCreate *model.PostMetadata) error
+	GetPostMetadata(ctx context.Context, id string) (*model.PostMetadata, error)
+	// UpdatePostMetadata replaces a post that still has the Version it was read with, and stamps a
+	// new Version and UpdatedAt. A deleted post is not found; one written since it was read is a
+	// conflict.
+	UpdatePostMetadata(ctx context.Context, postMetadataToUpdate *model.PostMetadata) (*model.PostMetadata, error)
+	ListPostMetadata(ctx context.Context, limit int, lastEvaluatedKey string) ([]*model.PostMetadata, string, error)
+	CreatePostMetadata(ctx context.Context, postMetadataToCreate *model.PostMetadata) error
+	BatchGetPostMetadata(ctx context.Context, ids []string) ([]*model.PostMetadata, error)
+	BatchWritePostMetadata(ctx context.Context, posts []*model.PostMetadata) error
 }
@@ -2,8 +2,12 @@ package dao
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"time"
 
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/migration"
 	"github.com/neuralcoral/BlogService/model"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,14 +16,29 @@ import (
 )
 
 type DynamoDBAPI interface {
-	GetItem(context context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
-	PutItem(context context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
-	Scan(context context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	GetItem(context context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Scan(context context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	Query(context context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	DeleteItem(context context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	UpdateItem(context context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	BatchGetItem(context context.Context, input *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	BatchWriteItem(context context.Context, input *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	TransactWriteItems(context context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
 }
 
 type PostMetadataDdbDao struct {
-	client    DynamoDBAPI
-	tableName string
+	client     DynamoDBAPI
+	tableName  string
+	migrations *migration.Runner
+}
+
+func NewPostMetadataDdbDao(client DynamoDBAPI, tableName string) *PostMetadataDdbDao {
+	return &PostMetadataDdbDao{
+		client:     client,
+		tableName:  tableName,
+		migrations: migration.PostMetadataRunner(),
+	}
 }
 
 func (dao *PostMetadataDdbDao) GetPostMetadata(context context.Context, id string) (*model.PostMetadata, error) {
@@ -34,38 +53,60 @@ func (dao *PostMetadataDdbDao) GetPostMetadata(context context.Context, id strin
 		return nil, err
 	}
 
-	if output == nil {
+	if output == nil || len(output.Item) == 0 {
 		return nil, nil
 	}
 
-	result := model.FromDynamoDBAttributeValue(output.Item)
+	if err := dao.upgrade(output.Item); err != nil {
+		return nil, err
+	}
 
-	return result, nil
+	return model.FromDynamoDBAttributeValue(output.Item)
 }
 
 func (dao *PostMetadataDdbDao) UpdatePostMetadata(context context.Context, postMetadataToUpdate *model.PostMetadata) (*model.PostMetadata, error) {
 	if postMetadataToUpdate == nil {
 		return nil, nil
 	}
-	postMetadataToUpdate.UpdatedAt = time.Now().Truncate(time.Second)
+	updated := *postMetadataToUpdate
+	updated.UpdatedAt = time.Now().UTC()
+	updated.Version++
 
-	attributeValueMap := model.ToDynamoDbAttributes(postMetadataToUpdate)
+	attributeValueMap, err := model.ToDynamoDbAttributes(&updated)
+	if err != nil {
+		return nil, err
+	}
 
 	ddbInput := &dynamodb.PutItemInput{
-		TableName: aws.String(dao.tableName),
-		Item:      attributeValueMap,
+		TableName:                           aws.String(dao.tableName),
+		Item:                                attributeValueMap,
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}
+	if postMetadataToUpdate.Version == 0 {
+		ddbInput.ConditionExpression = aws.String("attribute_exists(ID) AND attribute_not_exists(Version)")
+	} else {
+		ddbInput.ConditionExpression = aws.String("Version = :version")
+		ddbInput.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.Itoa(postMetadataToUpdate.Version)},
+		}
 	}
 
-	_, err := dao.client.PutItem(context, ddbInput)
-
+	_, err = dao.client.PutItem(context, ddbInput)
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionalCheckFailed) {
+		if len(conditionalCheckFailed.Item) == 0 {
+			return nil, apperror.Wrap(apperror.KindNotFound, "post not found", err)
+		}
+		return nil, apperror.Wrap(apperror.KindConflict, "post was changed by someone else; reload it and try again", err)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return postMetadataToUpdate, nil
+	return &updated, nil
 }
 
-func (dao *PostMetadataDdbDao) ListPostMetadata(context context.Context, limit int, lastEvaluatedKey string) ([]*model.PostMetadata, error) {
+func (dao *PostMetadataDdbDao) ListPostMetadata(context context.Context, limit int, lastEvaluatedKey string) ([]*model.PostMetadata, string, error) {
 	ddbInput := &dynamodb.ScanInput{
 		TableName: aws.String(dao.tableName),
 		Limit:     aws.Int32(int32(limit)),
@@ -73,19 +114,112 @@ func (dao *PostMetadataDdbDao) ListPostMetadata(context context.Context, limit i
 
 	if lastEvaluatedKey != "" {
 		ddbInput.ExclusiveStartKey = map[string]types.AttributeValue{
-			"PrimaryKeyAttribute": &types.AttributeValueMemberS{Value: lastEvaluatedKey},
+			"ID": &types.AttributeValueMemberS{Value: lastEvaluatedKey},
 		}
 
 	}
 	output, err := dao.client.Scan(context, ddbInput)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextKey := ""
+	if id, ok := output.LastEvaluatedKey["ID"].(*types.AttributeValueMemberS); ok {
+		nextKey = id.Value
+	}
+
+	for _, item := range output.Items {
+		if err := dao.upgrade(item); err != nil {
+			return nil, "", err
+		}
+	}
+
+	result, err := model.FromDynamoDBAttributeValues(output.Items)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, nextKey, nil
+}
+
+func (dao *PostMetadataDdbDao) CreatePostMetadata(context context.Context, postMetadataToCreate *model.PostMetadata) error {
+	if postMetadataToCreate == nil {
+		return nil
+	}
+
+	if postMetadataToCreate.Version == 0 {
+		postMetadataToCreate.Version = 1
+	}
+	item, err := model.ToDynamoDbAttributes(postMetadataToCreate)
+	if err != nil {
+		return err
+	}
+
+	ddbInput := &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(ID)"),
+	}
+
+	_, err = dao.client.PutItem(context, ddbInput)
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "post already exists")
+}
+
+// BatchGetPostMetadata returns the posts in the order of ids, with nil where a post doesn't exist.
+func (dao *PostMetadataDdbDao) BatchGetPostMetadata(ctx context.Context, ids []string) ([]*model.PostMetadata, error) {
+	// DynamoDB rejects a batch that names the same key twice.
+	seen := make(map[string]bool, len(ids))
+	var keys []map[string]types.AttributeValue
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		keys = append(keys, map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}})
+	}
+
+	items, err := batchGetItems(ctx, dao.client, dao.tableName, keys)
 	if err != nil {
 		return nil, err
 	}
 
-	result := model.FromDynamoDBAttributeValues(output.Items)
+	byID := make(map[string]*model.PostMetadata, len(items))
+	for _, item := range items {
+		if err := dao.upgrade(item); err != nil {
+			return nil, err
+		}
+		postMetadata, err := model.FromDynamoDBAttributeValue(item)
+		if err != nil {
+			return nil, err
+		}
+		byID[postMetadata.ID] = postMetadata
+	}
+
+	result := make([]*model.PostMetadata, len(ids))
+	for i, id := range ids {
+		result[i] = byID[id]
+	}
 	return result, nil
 }
 
-func (dao *PostMetadataDdbDao) CreatePostMetadata(postMetadataToCreate *model.PostMetadata) error {
-	return nil
+// BatchWritePostMetadata stores the posts as given, overwriting existing items. Unlike
+// UpdatePostMetadata it leaves UpdatedAt alone, so imports and restores keep their timestamps.
+func (dao *PostMetadataDdbDao) BatchWritePostMetadata(ctx context.Context, posts []*model.PostMetadata) error {
+	requests := make([]types.WriteRequest, 0, len(posts))
+	for _, postMetadata := range posts {
+		item, err := model.ToDynamoDbAttributes(postMetadata)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+	}
+	return batchWriteItems(ctx, dao.client, dao.tableName, requests)
+}
+
+// upgrade migrates items written under an older schema in memory; the next write persists them.
+func (dao *PostMetadataDdbDao) upgrade(item map[string]types.AttributeValue) error {
+	if dao.migrations == nil {
+		return nil
+	}
+	_, err := dao.migrations.Migrate(item)
+	return err
 }
@@ -8,28 +8,59 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/neuralcoral/BlogService/apperror"
 	"github.com/neuralcoral/BlogService/model"
 	"github.com/stretchr/testify/assert"
 )
 
 type MockDynamoDBClient struct {
-	GetItemFunc func(context context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
-	PutItemFunc func(context context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
-	ScanFunc    func(context context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	GetItemFunc    func(context context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	PutItemFunc    func(context context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	ScanFunc       func(context context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	QueryFunc      func(context context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	DeleteItemFunc func(context context.Context, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	UpdateItemFunc func(context context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	BatchGetFunc   func(context context.Context, input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error)
+	BatchWriteFunc func(context context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	TransactFunc   func(context context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
 }
 
-func (m *MockDynamoDBClient) GetItem(context context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+func (m *MockDynamoDBClient) GetItem(context context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
 	return m.GetItemFunc(context, input)
 }
 
-func (m *MockDynamoDBClient) PutItem(context context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+func (m *MockDynamoDBClient) PutItem(context context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
 	return m.PutItemFunc(context, input)
 }
 
-func (m *MockDynamoDBClient) Scan(context context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+func (m *MockDynamoDBClient) Scan(context context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
 	return m.ScanFunc(context, input)
 }
 
+func (m *MockDynamoDBClient) Query(context context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return m.QueryFunc(context, input)
+}
+
+func (m *MockDynamoDBClient) DeleteItem(context context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return m.DeleteItemFunc(context, input)
+}
+
+func (m *MockDynamoDBClient) UpdateItem(context context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return m.UpdateItemFunc(context, input)
+}
+
+func (m *MockDynamoDBClient) BatchGetItem(context context.Context, input *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return m.BatchGetFunc(context, input)
+}
+
+func (m *MockDynamoDBClient) BatchWriteItem(context context.Context, input *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return m.BatchWriteFunc(context, input)
+}
+
+func (m *MockDynamoDBClient) TransactWriteItems(context context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return m.TransactFunc(context, input)
+}
+
 func TestGetPostMetadata_Succeeds(t *testing.T) {
 	ID := "123"
 	Title := "Title Post"
@@ -76,6 +107,30 @@ func TestGetPostMetadata_Succeeds(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
+func TestGetPostMetadata_LegacyItem_UpgradesOnRead(t *testing.T) {
+	client := &MockDynamoDBClient{
+		GetItemFunc: func(context context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{
+				Item: map[string]types.AttributeValue{
+					"ID":        &types.AttributeValueMemberS{Value: "123"},
+					"Title":     &types.AttributeValueMemberS{Value: "Title Post"},
+					"BodyUrl":   &types.AttributeValueMemberS{Value: "posts/123/body.md"},
+					"CreatedAt": &types.AttributeValueMemberS{Value: "2023-05-01T10:00:00+02:00"},
+					"UpdatedAt": &types.AttributeValueMemberS{Value: "2023-05-01T10:00:00+02:00"},
+				},
+			}, nil
+		},
+	}
+	sut := NewPostMetadataDdbDao(client, "posts")
+
+	result, err := sut.GetPostMetadata(context.Background(), "123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.PostMetadataSchemaVersion, result.SchemaVersion)
+	assert.Equal(t, model.Draft, result.Status)
+	assert.Equal(t, time.UTC, result.CreatedAt.Location())
+}
+
 func TestGetPostMetadata_DynamoDBFailure_ReturnsErr(t *testing.T) {
 	expectedErr := errors.New("mock error for testing")
 
@@ -116,34 +171,12 @@ func TestUpdatePostMetadata_Succeeds(t *testing.T) {
 	BodyUrl := "http://example.com/bodyText"
 	PreviewText := "This is a preview"
 	Status := model.Draft
-	CreatedAt := time.Now().Format(time.RFC3339)
-	UpdatedAt := time.Now().Format(time.RFC3339)
-
-	parsedCreatedAt, _ := time.Parse(time.RFC3339, CreatedAt)
-	parsedUpdatedAt, _ := time.Parse(time.RFC3339, UpdatedAt)
-
-	expectedResult := &model.PostMetadata{
-		ID:          ID,
-		Title:       Title,
-		BodyUrl:     BodyUrl,
-		PreviewText: PreviewText,
-		Status:      Status,
-		CreatedAt:   parsedCreatedAt,
-		UpdatedAt:   parsedUpdatedAt,
-	}
+	CreatedAt := time.Now().UTC().Add(-time.Hour)
 
+	var captured *dynamodb.PutItemInput
 	putItemFunc := func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
-		return &dynamodb.PutItemOutput{
-			Attributes: map[string]types.AttributeValue{
-				"ID":          &types.AttributeValueMemberS{Value: ID},
-				"Title":       &types.AttributeValueMemberS{Value: Title},
-				"BodyUrl":     &types.AttributeValueMemberS{Value: BodyUrl},
-				"PreviewText": &types.AttributeValueMemberS{Value: PreviewText},
-				"Status":      &types.AttributeValueMemberS{Value: string(Status)},
-				"CreatedAt":   &types.AttributeValueMemberS{Value: CreatedAt},
-				"UpdatedAt":   &types.AttributeValueMemberS{Value: UpdatedAt},
-			},
-		}, nil
+		captured = input
+		return &dynamodb.PutItemOutput{}, nil
 	}
 
 	sut := setupMockDynamoDBForPut(t, putItemFunc)
@@ -154,17 +187,29 @@ func TestUpdatePostMetadata_Succeeds(t *testing.T) {
 		BodyUrl:     BodyUrl,
 		PreviewText: PreviewText,
 		Status:      Status,
-		CreatedAt:   parsedCreatedAt,
+		CreatedAt:   CreatedAt,
 	}
 
+	before := time.Now().UTC()
 	result, err := sut.UpdatePostMetadata(context.Background(), input)
 
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
 	}
 
-	assert.Equal(t, expectedResult, result)
+	assert.Equal(t, ID, result.ID)
+	assert.Equal(t, Title, result.Title)
+	assert.Equal(t, BodyUrl, result.BodyUrl)
+	assert.Equal(t, PreviewText, result.PreviewText)
+	assert.Equal(t, Status, result.Status)
+	assert.Equal(t, CreatedAt, result.CreatedAt)
+	assert.False(t, result.UpdatedAt.Before(before), "UpdatedAt should be set to the time of the update")
 
+	written, err := model.FromDynamoDBAttributeValue(captured.Item)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	assert.Equal(t, result.UpdatedAt, written.UpdatedAt, "UpdatedAt should be stored without losing precision")
 }
 
 func TestUpdatePostMetadata_DynamoDBFailure_ReturnsErr(t *testing.T) {
@@ -185,6 +230,42 @@ func TestUpdatePostMetadata_DynamoDBFailure_ReturnsErr(t *testing.T) {
 	assert.Equal(t, expectedErr, err)
 }
 
+func TestUpdatePostMetadata_ConditionsOnVersion(t *testing.T) {
+	var captured *dynamodb.PutItemInput
+	sut := setupMockDynamoDBForPut(t, func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+		captured = input
+		return &dynamodb.PutItemOutput{}, nil
+	})
+
+	result, err := sut.UpdatePostMetadata(context.Background(), &model.PostMetadata{ID: "123", Version: 4})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 5, result.Version)
+	assert.Equal(t, "Version = :version", *captured.ConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "4"}, captured.ExpressionAttributeValues[":version"])
+
+	_, err = sut.UpdatePostMetadata(context.Background(), &model.PostMetadata{ID: "123"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "attribute_exists(ID) AND attribute_not_exists(Version)", *captured.ConditionExpression)
+}
+
+func TestUpdatePostMetadata_ConditionFails_ReturnsNotFoundOrConflict(t *testing.T) {
+	for expected, oldItem := range map[apperror.Kind]map[string]types.AttributeValue{
+		apperror.KindNotFound: nil,
+		apperror.KindConflict: {"ID": &types.AttributeValueMemberS{Value: "123"}},
+	} {
+		sut := setupMockDynamoDBForPut(t, func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			return nil, &types.ConditionalCheckFailedException{Item: oldItem}
+		})
+
+		result, err := sut.UpdatePostMetadata(context.Background(), &model.PostMetadata{ID: "123", Version: 4})
+
+		assert.Nil(t, result)
+		assert.Equal(t, expected, apperror.KindOf(err))
+	}
+}
+
 func TestUpdatePostMetadata_EmptyInput_ReturnsEmpty(t *testing.T) {
 
 	putItemFunc := func(context context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
@@ -270,7 +351,7 @@ func TestListPostMetadata_Success(t *testing.T) {
 		UpdatedAt:   parsedUpdatedAt,
 	})
 
-	result, err := sut.ListPostMetadata(context.Background(), 2, "")
+	result, _, err := sut.ListPostMetadata(context.Background(), 2, "")
 
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
@@ -312,9 +393,9 @@ func TestListPostMetadata_Succeeds_WithLastEvaluatedKey(t *testing.T) {
 	}
 
 	scanFunc := func(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
-		if key, ok := input.ExclusiveStartKey["PrimaryKeyAttribute"]; ok {
+		if key, ok := input.ExclusiveStartKey["ID"]; ok {
 			if id, ok := key.(*types.AttributeValueMemberS); ok {
-				input.ExclusiveStartKey["PrimaryKeyAttribute"] = id
+				input.ExclusiveStartKey["ID"] = id
 				return scanFunc2(ctx, input)
 			}
 		}
@@ -336,7 +417,7 @@ func TestListPostMetadata_Succeeds_WithLastEvaluatedKey(t *testing.T) {
 		UpdatedAt:   parsedUpdatedAt,
 	})
 
-	result, err := sut.ListPostMetadata(context.Background(), 1, ID1)
+	result, _, err := sut.ListPostMetadata(context.Background(), 1, ID1)
 
 	if err != nil {
 		t.Fatalf("unexpected err: %v", err)
@@ -352,7 +433,7 @@ func TestListPostMetadata_DynamoDBFailure_ReturnsErr(t *testing.T) {
 	}
 	sut := setupMockDynamoDBForScan(t, scanFunc)
 
-	result, err := sut.ListPostMetadata(context.Background(), 2, "")
+	result, _, err := sut.ListPostMetadata(context.Background(), 2, "")
 
 	if result != nil {
 		t.Fatalf("unexpected result: %v", result)
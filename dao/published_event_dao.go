@@ -0,0 +1,13 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type PublishedEventDao interface {
+	IsEventPublished(ctx context.Context, id string) (bool, error)
+	// MarkEventPublished fails with a conflict if the event is already marked.
+	MarkEventPublished(ctx context.Context, event *model.PublishedEvent) error
+}
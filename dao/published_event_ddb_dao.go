@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type PublishedEventDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewPublishedEventDdbDao(client DynamoDBAPI, tableName string) *PublishedEventDdbDao {
+	return &PublishedEventDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *PublishedEventDdbDao) IsEventPublished(ctx context.Context, id string) (bool, error) {
+	output, err := dao.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            aws.String(dao.tableName),
+		Key:                  map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}},
+		ProjectionExpression: aws.String("ID"),
+		ConsistentRead:       aws.Bool(true),
+	})
+	if err != nil {
+		return false, err
+	}
+	return output != nil && len(output.Item) > 0, nil
+}
+
+func (dao *PublishedEventDdbDao) MarkEventPublished(ctx context.Context, event *model.PublishedEvent) error {
+	item, err := model.PublishedEventToDynamoDbAttributes(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(ID)"),
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "event already published")
+}
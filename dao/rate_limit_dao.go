@@ -0,0 +1,22 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type RateLimitDao interface {
+	GetTokenBucket(ctx context.Context, key string) (*model.TokenBucket, error)
+	// PutTokenBucket fails with a conflict when the bucket changed since it was read at
+	// previousUpdatedAt; a zero previousUpdatedAt requires that the bucket doesn't exist yet.
+	PutTokenBucket(ctx context.Context, bucket *model.TokenBucket, previousUpdatedAt time.Time) error
+	GetLoginLockout(ctx context.Context, key string) (*model.LoginLockout, error)
+	// RecordLoginFailure atomically counts a failure and returns the new count. The count starts
+	// again when the previous window expired before now; TTL deletion can lag by days, so the
+	// item's presence doesn't mean the window is still open.
+	RecordLoginFailure(ctx context.Context, key string, now time.Time, expiresAt time.Time) (int, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	DeleteLoginLockout(ctx context.Context, key string) error
+}
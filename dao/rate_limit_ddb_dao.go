@@ -0,0 +1,148 @@
+package dao
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// RateLimitDdbDao keeps token buckets and login lockouts in one table keyed by "Key", with TTL
+// enabled on "ExpiresAt".
+type RateLimitDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewRateLimitDdbDao(client DynamoDBAPI, tableName string) *RateLimitDdbDao {
+	return &RateLimitDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *RateLimitDdbDao) GetTokenBucket(ctx context.Context, key string) (*model.TokenBucket, error) {
+	item, err := dao.getItem(ctx, key)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	return model.TokenBucketFromDynamoDBAttributeValue(item)
+}
+
+func (dao *RateLimitDdbDao) PutTokenBucket(ctx context.Context, bucket *model.TokenBucket, previousUpdatedAt time.Time) error {
+	item, err := model.TokenBucketToDynamoDbAttributes(bucket)
+	if err != nil {
+		return err
+	}
+
+	ddbInput := &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(#key)"),
+		ExpressionAttributeNames: map[string]string{
+			"#key": "Key",
+		},
+	}
+	if !previousUpdatedAt.IsZero() {
+		ddbInput.ConditionExpression = aws.String("UpdatedAt = :previous")
+		ddbInput.ExpressionAttributeNames = nil
+		ddbInput.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":previous": &types.AttributeValueMemberS{Value: previousUpdatedAt.UTC().Format(time.RFC3339Nano)},
+		}
+	}
+
+	_, err = dao.client.PutItem(ctx, ddbInput)
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "token bucket changed concurrently")
+}
+
+func (dao *RateLimitDdbDao) GetLoginLockout(ctx context.Context, key string) (*model.LoginLockout, error) {
+	item, err := dao.getItem(ctx, key)
+	if err != nil || item == nil {
+		return nil, err
+	}
+	return model.LoginLockoutFromDynamoDBAttributeValue(item)
+}
+
+// loginFailureAttempts bounds the retries when concurrent failures keep racing the window reset.
+const loginFailureAttempts = 3
+
+// RecordLoginFailure adds to the count while its window is open and starts a new count of one once
+// it has closed. Each step is conditional on the window, so concurrent failures can't lose counts
+// or reset a window twice.
+func (dao *RateLimitDdbDao) RecordLoginFailure(ctx context.Context, key string, now time.Time, expiresAt time.Time) (int, error) {
+	for attempt := 0; attempt < loginFailureAttempts; attempt++ {
+		failures, err := dao.updateLoginFailures(ctx, key, now, expiresAt,
+			"ADD Failures :one SET ExpiresAt = :expiresAt", "attribute_not_exists(ExpiresAt) OR ExpiresAt > :now")
+		if !isConditionalCheckFailure(err) {
+			return failures, err
+		}
+		failures, err = dao.updateLoginFailures(ctx, key, now, expiresAt,
+			"SET Failures = :one, ExpiresAt = :expiresAt", "ExpiresAt <= :now")
+		if !isConditionalCheckFailure(err) {
+			return failures, err
+		}
+	}
+	return 0, apperror.Conflict("login failures changed concurrently")
+}
+
+func (dao *RateLimitDdbDao) updateLoginFailures(ctx context.Context, key string, now time.Time, expiresAt time.Time, update string, condition string) (int, error) {
+	output, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 map[string]types.AttributeValue{"Key": &types.AttributeValueMemberS{Value: key}},
+		UpdateExpression:    aws.String(update),
+		ConditionExpression: aws.String(condition),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one":       &types.AttributeValueMemberN{Value: "1"},
+			":now":       &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+			":expiresAt": &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)},
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	failures, ok := output.Attributes["Failures"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, nil
+	}
+	return strconv.Atoi(failures.Value)
+}
+
+func (dao *RateLimitDdbDao) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(dao.tableName),
+		Key:              map[string]types.AttributeValue{"Key": &types.AttributeValueMemberS{Value: key}},
+		UpdateExpression: aws.String("SET LockedUntil = :until"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":until": &types.AttributeValueMemberS{Value: until.UTC().Format(time.RFC3339Nano)},
+		},
+	})
+	return err
+}
+
+func (dao *RateLimitDdbDao) DeleteLoginLockout(ctx context.Context, key string) error {
+	_, err := dao.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(dao.tableName),
+		Key:       map[string]types.AttributeValue{"Key": &types.AttributeValueMemberS{Value: key}},
+	})
+	return err
+}
+
+func (dao *RateLimitDdbDao) getItem(ctx context.Context, key string) (map[string]types.AttributeValue, error) {
+	output, err := dao.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(dao.tableName),
+		Key:            map[string]types.AttributeValue{"Key": &types.AttributeValueMemberS{Value: key}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil || output == nil || output.Item == nil {
+		return nil, err
+	}
+	return output.Item, nil
+}
@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordLoginFailure_OpenWindow_AddsToCount(t *testing.T) {
+	var captured *dynamodb.UpdateItemInput
+	updateItemFunc := func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		captured = input
+		return &dynamodb.UpdateItemOutput{Attributes: map[string]types.AttributeValue{
+			"Failures": &types.AttributeValueMemberN{Value: "4"},
+		}}, nil
+	}
+	sut := NewRateLimitDdbDao(&MockDynamoDBClient{UpdateItemFunc: updateItemFunc}, "rate-limits")
+	now := time.Unix(1_700_000_000, 0)
+
+	failures, err := sut.RecordLoginFailure(context.Background(), "login#alice", now, now.Add(time.Minute))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, failures)
+	assert.Equal(t, "ADD Failures :one SET ExpiresAt = :expiresAt", *captured.UpdateExpression)
+	assert.Equal(t, "attribute_not_exists(ExpiresAt) OR ExpiresAt > :now", *captured.ConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "1700000000"}, captured.ExpressionAttributeValues[":now"])
+}
+
+func TestRecordLoginFailure_ExpiredWindow_StartsNewCount(t *testing.T) {
+	var updates []string
+	updateItemFunc := func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+		updates = append(updates, *input.UpdateExpression)
+		if len(updates) == 1 {
+			return nil, &types.ConditionalCheckFailedException{}
+		}
+		return &dynamodb.UpdateItemOutput{Attributes: map[string]types.AttributeValue{
+			"Failures": &types.AttributeValueMemberN{Value: "1"},
+		}}, nil
+	}
+	sut := NewRateLimitDdbDao(&MockDynamoDBClient{UpdateItemFunc: updateItemFunc}, "rate-limits")
+	now := time.Unix(1_700_000_000, 0)
+
+	failures, err := sut.RecordLoginFailure(context.Background(), "login#alice", now, now.Add(time.Minute))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, []string{"ADD Failures :one SET ExpiresAt = :expiresAt", "SET Failures = :one, ExpiresAt = :expiresAt"}, updates)
+}
@@ -0,0 +1,20 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type SessionDao interface {
+	CreateSession(ctx context.Context, session *model.Session) error
+	GetSession(ctx context.Context, id string) (*model.Session, error)
+	// RotateRefreshToken replaces the refresh token hash and extends the session. It fails with a
+	// conflict unless the session still holds previousHash and isn't revoked.
+	RotateRefreshToken(ctx context.Context, id string, previousHash string, newHash string, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, id string) error
+	// CompleteTwoFactor lifts the enrolment restriction once the session's user has enrolled.
+	CompleteTwoFactor(ctx context.Context, id string) error
+	ListSessionIDs(ctx context.Context, userID string) ([]string, error)
+}
@@ -0,0 +1,132 @@
+package dao
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const sessionUserIDIndex = "UserID-index"
+
+type SessionDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewSessionDdbDao(client DynamoDBAPI, tableName string) *SessionDdbDao {
+	return &SessionDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *SessionDdbDao) CreateSession(ctx context.Context, session *model.Session) error {
+	item, err := model.SessionToDynamoDbAttributes(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(ID)"),
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "session already exists")
+}
+
+func (dao *SessionDdbDao) GetSession(ctx context.Context, id string) (*model.Session, error) {
+	output, err := dao.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(dao.tableName),
+		Key:            sessionKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output == nil || output.Item == nil {
+		return nil, nil
+	}
+	return model.SessionFromDynamoDBAttributeValue(output.Item)
+}
+
+func (dao *SessionDdbDao) RotateRefreshToken(ctx context.Context, id string, previousHash string, newHash string, expiresAt time.Time) error {
+	_, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 sessionKey(id),
+		UpdateExpression:    aws.String("SET RefreshTokenHash = :newHash, ExpiresAt = :expiresAt, DeleteAfter = :deleteAfter"),
+		ConditionExpression: aws.String("RefreshTokenHash = :previousHash AND Revoked = :false"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":newHash":      &types.AttributeValueMemberS{Value: newHash},
+			":previousHash": &types.AttributeValueMemberS{Value: previousHash},
+			":expiresAt":    &types.AttributeValueMemberS{Value: expiresAt.UTC().Format(time.RFC3339Nano)},
+			":deleteAfter":  &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)},
+			":false":        &types.AttributeValueMemberBOOL{Value: false},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "refresh token already rotated")
+}
+
+func (dao *SessionDdbDao) RevokeSession(ctx context.Context, id string) error {
+	_, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 sessionKey(id),
+		UpdateExpression:    aws.String("SET Revoked = :true"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":true": &types.AttributeValueMemberBOOL{Value: true},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "session not found")
+}
+
+func (dao *SessionDdbDao) CompleteTwoFactor(ctx context.Context, id string) error {
+	_, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 sessionKey(id),
+		UpdateExpression:    aws.String("SET TwoFactorPending = :false"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":false": &types.AttributeValueMemberBOOL{Value: false},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "session not found")
+}
+
+func (dao *SessionDdbDao) ListSessionIDs(ctx context.Context, userID string) ([]string, error) {
+	var ids []string
+	var startKey map[string]types.AttributeValue
+	for {
+		output, err := dao.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(dao.tableName),
+			IndexName:              aws.String(sessionUserIDIndex),
+			KeyConditionExpression: aws.String("UserID = :userID"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":userID": &types.AttributeValueMemberS{Value: userID},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range output.Items {
+			if id, ok := item["ID"].(*types.AttributeValueMemberS); ok {
+				ids = append(ids, id.Value)
+			}
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return ids, nil
+		}
+		startKey = output.LastEvaluatedKey
+	}
+}
+
+func sessionKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}}
+}
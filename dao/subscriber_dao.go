@@ -0,0 +1,22 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type SubscriberDao interface {
+	GetSubscriber(ctx context.Context, id string) (*model.Subscriber, error)
+	PutSubscriber(ctx context.Context, subscriber *model.Subscriber) error
+	// ListConfirmedSubscribers returns confirmed subscribers in ID order after afterID, and the ID to
+	// continue after, which is empty on the last page.
+	ListConfirmedSubscribers(ctx context.Context, limit int, afterID string) ([]*model.Subscriber, string, error)
+}
+
+type NewsletterIssueDao interface {
+	GetIssue(ctx context.Context, postID string) (*model.NewsletterIssue, error)
+	// CreateIssue fails with a conflict if the post already has an issue.
+	CreateIssue(ctx context.Context, issue *model.NewsletterIssue) error
+	UpdateIssue(ctx context.Context, issue *model.NewsletterIssue) error
+}
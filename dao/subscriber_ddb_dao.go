@@ -0,0 +1,144 @@
+package dao
+
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const subscriberStatusIndex = "Status-ID-index"
+
+type SubscriberDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewSubscriberDdbDao(client DynamoDBAPI, tableName string) *SubscriberDdbDao {
+	return &SubscriberDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *SubscriberDdbDao) GetSubscriber(ctx context.Context, id string) (*model.Subscriber, error) {
+	output, err := dao.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(dao.tableName),
+		Key:            map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output == nil || output.Item == nil {
+		return nil, nil
+	}
+	return model.SubscriberFromDynamoDBAttributeValue(output.Item)
+}
+
+func (dao *SubscriberDdbDao) PutSubscriber(ctx context.Context, subscriber *model.Subscriber) error {
+	item, err := model.SubscriberToDynamoDbAttributes(subscriber)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(dao.tableName),
+		Item:      item,
+	})
+	return err
+}
+
+// ListConfirmedSubscribers reads the status index. Status is a DynamoDB reserved word.
+func (dao *SubscriberDdbDao) ListConfirmedSubscribers(ctx context.Context, limit int, afterID string) ([]*model.Subscriber, string, error) {
+	input := &dynamodb.QueryInput{
+		TableName:                aws.String(dao.tableName),
+		IndexName:                aws.String(subscriberStatusIndex),
+		KeyConditionExpression:   aws.String("#status = :confirmed"),
+		ExpressionAttributeNames: map[string]string{"#status": "Status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":confirmed": &types.AttributeValueMemberS{Value: string(model.SubscriberConfirmed)},
+		},
+		Limit: aws.Int32(int32(limit)),
+	}
+	if afterID != "" {
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"Status": &types.AttributeValueMemberS{Value: string(model.SubscriberConfirmed)},
+			"ID":     &types.AttributeValueMemberS{Value: afterID},
+		}
+	}
+
+	output, err := dao.client.Query(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+	nextID := ""
+	if id, ok := output.LastEvaluatedKey["ID"].(*types.AttributeValueMemberS); ok {
+		nextID = id.Value
+	}
+
+	subscribers, err := model.SubscribersFromDynamoDBAttributeValues(output.Items)
+	if err != nil {
+		return nil, "", err
+	}
+	return subscribers, nextID, nil
+}
+
+type NewsletterIssueDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewNewsletterIssueDdbDao(client DynamoDBAPI, tableName string) *NewsletterIssueDdbDao {
+	return &NewsletterIssueDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *NewsletterIssueDdbDao) GetIssue(ctx context.Context, postID string) (*model.NewsletterIssue, error) {
+	output, err := dao.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(dao.tableName),
+		Key:            map[string]types.AttributeValue{"PostID": &types.AttributeValueMemberS{Value: postID}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output == nil || output.Item == nil {
+		return nil, nil
+	}
+	return model.NewsletterIssueFromDynamoDBAttributeValue(output.Item)
+}
+
+func (dao *NewsletterIssueDdbDao) CreateIssue(ctx context.Context, issue *model.NewsletterIssue) error {
+	item, err := model.NewsletterIssueToDynamoDbAttributes(issue)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PostID)"),
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "newsletter issue already exists")
+}
+
+func (dao *NewsletterIssueDdbDao) UpdateIssue(ctx context.Context, issue *model.NewsletterIssue) error {
+	item, err := model.NewsletterIssueToDynamoDbAttributes(issue)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(PostID)"),
+	})
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "newsletter issue not found")
+}
@@ -0,0 +1,138 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/apperror"
+)
+
+// maxTransactItems is DynamoDB's limit on actions in one TransactWriteItems call.
+const maxTransactItems = 100
+
+// Condition guards one action of a unit of work. Err is returned from Commit when the condition
+// fails, so callers can tell a taken slug from a missing post; it defaults to a conflict.
+type Condition struct {
+	Expression string
+	Names      map[string]string
+	Values     map[string]types.AttributeValue
+	Err        error
+}
+
+// ItemNotExists fails when an item with the action's key already exists.
+func ItemNotExists(keyAttribute string, err error) *Condition {
+	return &Condition{
+		Expression: "attribute_not_exists(#key)",
+		Names:      map[string]string{"#key": keyAttribute},
+		Err:        err,
+	}
+}
+
+// ItemExists fails when there is no item with the action's key.
+func ItemExists(keyAttribute string, err error) *Condition {
+	return &Condition{
+		Expression: "attribute_exists(#key)",
+		Names:      map[string]string{"#key": keyAttribute},
+		Err:        err,
+	}
+}
+
+// UnitOfWork collects writes across items and tables and commits them atomically with
+// TransactWriteItems.
+type UnitOfWork struct {
+	client  DynamoDBAPI
+	actions []types.TransactWriteItem
+	errs    []error
+}
+
+func NewUnitOfWork(client DynamoDBAPI) *UnitOfWork {
+	return &UnitOfWork{client: client}
+}
+
+func (unit *UnitOfWork) Put(tableName string, item map[string]types.AttributeValue, condition *Condition) {
+	put := &types.Put{TableName: aws.String(tableName), Item: item}
+	if condition != nil {
+		put.ConditionExpression = aws.String(condition.Expression)
+		put.ExpressionAttributeNames = condition.Names
+		put.ExpressionAttributeValues = condition.Values
+	}
+	unit.add(types.TransactWriteItem{Put: put}, condition)
+}
+
+func (unit *UnitOfWork) Delete(tableName string, key map[string]types.AttributeValue, condition *Condition) {
+	remove := &types.Delete{TableName: aws.String(tableName), Key: key}
+	if condition != nil {
+		remove.ConditionExpression = aws.String(condition.Expression)
+		remove.ExpressionAttributeNames = condition.Names
+		remove.ExpressionAttributeValues = condition.Values
+	}
+	unit.add(types.TransactWriteItem{Delete: remove}, condition)
+}
+
+// Check asserts a condition on an item the unit of work doesn't write.
+func (unit *UnitOfWork) Check(tableName string, key map[string]types.AttributeValue, condition Condition) {
+	unit.add(types.TransactWriteItem{ConditionCheck: &types.ConditionCheck{
+		TableName:                 aws.String(tableName),
+		Key:                       key,
+		ConditionExpression:       aws.String(condition.Expression),
+		ExpressionAttributeNames:  condition.Names,
+		ExpressionAttributeValues: condition.Values,
+	}}, &condition)
+}
+
+func (unit *UnitOfWork) add(action types.TransactWriteItem, condition *Condition) {
+	var err error
+	if condition != nil {
+		err = condition.Err
+	}
+	if err == nil {
+		err = apperror.Conflict("item was modified concurrently")
+	}
+	unit.actions = append(unit.actions, action)
+	unit.errs = append(unit.errs, err)
+}
+
+func (unit *UnitOfWork) Len() int {
+	return len(unit.actions)
+}
+
+func (unit *UnitOfWork) Commit(ctx context.Context) error {
+	if len(unit.actions) == 0 {
+		return nil
+	}
+	if len(unit.actions) > maxTransactItems {
+		return fmt.Errorf("unit of work has %d actions, more than the %d a transaction allows", len(unit.actions), maxTransactItems)
+	}
+
+	_, err := unit.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: unit.actions})
+	return unit.translate(err)
+}
+
+// translate maps the first meaningful cancellation reason to a domain error. Reasons line up with
+// the actions; the ones that didn't cause the cancellation have the code "None".
+func (unit *UnitOfWork) translate(err error) error {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return err
+	}
+
+	for i, reason := range canceled.CancellationReasons {
+		switch aws.ToString(reason.Code) {
+		case "ConditionalCheckFailed":
+			if i < len(unit.errs) {
+				return unit.errs[i]
+			}
+			return apperror.Wrap(apperror.KindConflict, "item was modified concurrently", err)
+		case "TransactionConflict":
+			return apperror.Wrap(apperror.KindConflict, "another request is updating the same items; retry", err)
+		case "ThrottlingError", "ProvisionedThroughputExceeded", "RequestLimitExceeded":
+			return apperror.RateLimited("too many requests; retry shortly", time.Second)
+		}
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/stretchr/testify/assert"
+)
+
+func canceled(codes ...string) error {
+	var reasons []types.CancellationReason
+	for _, code := range codes {
+		reasons = append(reasons, types.CancellationReason{Code: aws.String(code)})
+	}
+	return &types.TransactionCanceledException{CancellationReasons: reasons}
+}
+
+func slugKey(slug string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: "slug#" + slug}}
+}
+
+func TestUnitOfWork_Commit_SendsActionsInOrder(t *testing.T) {
+	var captured *dynamodb.TransactWriteItemsInput
+	client := &MockDynamoDBClient{TransactFunc: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+		captured = input
+		return &dynamodb.TransactWriteItemsOutput{}, nil
+	}}
+	unit := NewUnitOfWork(client)
+	unit.Put("posts", slugKey("hello"), ItemNotExists("ID", nil))
+	unit.Delete("posts", slugKey("old"), nil)
+	unit.Check("posts", slugKey("tag"), *ItemExists("ID", nil))
+
+	err := unit.Commit(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, captured.TransactItems, 3)
+	assert.Equal(t, "attribute_not_exists(#key)", aws.ToString(captured.TransactItems[0].Put.ConditionExpression))
+	assert.Nil(t, captured.TransactItems[1].Delete.ConditionExpression)
+	assert.NotNil(t, captured.TransactItems[2].ConditionCheck)
+}
+
+func TestUnitOfWork_Commit_ConditionFailure_ReturnsThatActionsError(t *testing.T) {
+	slugTaken := apperror.Conflict("slug is already in use")
+	client := &MockDynamoDBClient{TransactFunc: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+		return nil, canceled("None", "ConditionalCheckFailed")
+	}}
+	unit := NewUnitOfWork(client)
+	unit.Put("posts", slugKey("post"), nil)
+	unit.Put("posts", slugKey("hello"), ItemNotExists("ID", slugTaken))
+
+	err := unit.Commit(context.Background())
+
+	assert.Equal(t, slugTaken, err)
+}
+
+func TestUnitOfWork_Commit_ConditionWithoutError_ReturnsConflict(t *testing.T) {
+	client := &MockDynamoDBClient{TransactFunc: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+		return nil, canceled("ConditionalCheckFailed")
+	}}
+	unit := NewUnitOfWork(client)
+	unit.Put("posts", slugKey("hello"), ItemNotExists("ID", nil))
+
+	err := unit.Commit(context.Background())
+
+	assert.Equal(t, apperror.KindConflict, apperror.KindOf(err))
+}
+
+func TestUnitOfWork_Commit_TransactionConflictAndThrottling_MapToDomainErrors(t *testing.T) {
+	for code, kind := range map[string]apperror.Kind{
+		"TransactionConflict": apperror.KindConflict,
+		"ThrottlingError":     apperror.KindRateLimited,
+	} {
+		client := &MockDynamoDBClient{TransactFunc: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			return nil, canceled(code)
+		}}
+		unit := NewUnitOfWork(client)
+		unit.Put("posts", slugKey("hello"), nil)
+
+		err := unit.Commit(context.Background())
+
+		assert.Equal(t, kind, apperror.KindOf(err), code)
+	}
+}
+
+func TestUnitOfWork_Commit_OtherError_ReturnsItUnchanged(t *testing.T) {
+	expected := errors.New("network down")
+	client := &MockDynamoDBClient{TransactFunc: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+		return nil, expected
+	}}
+	unit := NewUnitOfWork(client)
+	unit.Put("posts", slugKey("hello"), nil)
+
+	assert.Equal(t, expected, unit.Commit(context.Background()))
+}
+
+func TestUnitOfWork_Commit_TooManyActions_ReturnsError(t *testing.T) {
+	unit := NewUnitOfWork(&MockDynamoDBClient{})
+	for i := 0; i <= maxTransactItems; i++ {
+		unit.Delete("posts", slugKey("x"), nil)
+	}
+
+	assert.Error(t, unit.Commit(context.Background()))
+}
@@ -1,7 +1,22 @@
 package dao
 
-import "github.com/neuralcoral/BlogService/model"
+import (
+	"context"
+
+	"github.com/neuralcoral/BlogService/model"
+)
 
 type UserDao interface {
-	GetUser(username string) (model.User, error)
+	GetUser(ctx context.Context, username string) (*model.User, error)
+	GetUserByID(ctx context.Context, id string) (*model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetUserByExternalSubject(ctx context.Context, externalSubject string) (*model.User, error)
+	CreateUser(ctx context.Context, user *model.User) error
+	UpdatePassword(ctx context.Context, userID string, hashedPassword string) error
+	// UpdateExternalIdentity links the user to an identity provider account and sets the role the
+	// provider's groups map to.
+	UpdateExternalIdentity(ctx context.Context, userID string, externalSubject string, role model.Role) error
+	// UpdateTwoFactor replaces the user's two-factor settings. It fails with a conflict unless the
+	// stored settings are still at previousRevision, 0 meaning the user never had any.
+	UpdateTwoFactor(ctx context.Context, userID string, twoFactor *model.TwoFactor, previousRevision int) error
 }
@@ -0,0 +1,171 @@
+package dao
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	userUsernameIndex = "Username-index"
+	userEmailIndex    = "Email-index"
+	// userExternalSubjectIndex is sparse: only users linked to an identity provider appear in it.
+	userExternalSubjectIndex = "ExternalSubject-index"
+)
+
+type UserDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewUserDdbDao(client DynamoDBAPI, tableName string) *UserDdbDao {
+	return &UserDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *UserDdbDao) GetUser(ctx context.Context, username string) (*model.User, error) {
+	return dao.queryOne(ctx, userUsernameIndex, "Username", username)
+}
+
+func (dao *UserDdbDao) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+	output, err := dao.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(dao.tableName),
+		Key:            userKey(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output == nil || output.Item == nil {
+		return nil, nil
+	}
+	return model.UserFromDynamoDBAttributeValue(output.Item)
+}
+
+func (dao *UserDdbDao) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	return dao.queryOne(ctx, userEmailIndex, "Email", email)
+}
+
+func (dao *UserDdbDao) GetUserByExternalSubject(ctx context.Context, externalSubject string) (*model.User, error) {
+	return dao.queryOne(ctx, userExternalSubjectIndex, "ExternalSubject", externalSubject)
+}
+
+var (
+	ErrUsernameTaken = apperror.Conflict("username is already taken")
+	ErrEmailInUse    = apperror.Conflict("an account with this email address already exists")
+)
+
+// CreateUser fails with a conflict if the ID is taken, and with ErrUsernameTaken or ErrEmailInUse if
+// another user has the username or email. The indexes can't enforce uniqueness, so the user is
+// written together with a "Username#" and an "Email#" item that claim them. Users created before
+// those items existed only appear in the indexes, so callers still check those first.
+func (dao *UserDdbDao) CreateUser(ctx context.Context, user *model.User) error {
+	item, err := model.UserToDynamoDbAttributes(user)
+	if err != nil {
+		return err
+	}
+
+	unit := NewUnitOfWork(dao.client)
+	unit.Put(dao.tableName, item, ItemNotExists("ID", apperror.Conflict("user already exists")))
+	unit.Put(dao.tableName, userClaimItem("Username#"+user.Username, user.ID), ItemNotExists("ID", ErrUsernameTaken))
+	if user.Email != "" {
+		unit.Put(dao.tableName, userClaimItem("Email#"+user.Email, user.ID), ItemNotExists("ID", ErrEmailInUse))
+	}
+	return unit.Commit(ctx)
+}
+
+func (dao *UserDdbDao) UpdatePassword(ctx context.Context, userID string, hashedPassword string) error {
+	_, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 userKey(userID),
+		UpdateExpression:    aws.String("SET HashedPassword = :hashedPassword"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":hashedPassword": &types.AttributeValueMemberS{Value: hashedPassword},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "user not found")
+}
+
+func (dao *UserDdbDao) UpdateExternalIdentity(ctx context.Context, userID string, externalSubject string, role model.Role) error {
+	_, err := dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 userKey(userID),
+		UpdateExpression:    aws.String("SET ExternalSubject = :externalSubject, #role = :role"),
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+		ExpressionAttributeNames: map[string]string{
+			"#role": "Role",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":externalSubject": &types.AttributeValueMemberS{Value: externalSubject},
+			":role":            &types.AttributeValueMemberS{Value: string(role)},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "user not found")
+}
+
+func (dao *UserDdbDao) UpdateTwoFactor(ctx context.Context, userID string, twoFactor *model.TwoFactor, previousRevision int) error {
+	attribute, err := model.TwoFactorToDynamoDbAttribute(twoFactor)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 userKey(userID),
+		UpdateExpression:    aws.String("SET TwoFactor = :twoFactor"),
+		ConditionExpression: aws.String("attribute_exists(ID) AND (attribute_not_exists(TwoFactor) OR TwoFactor.Revision = :previousRevision)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":twoFactor":        attribute,
+			":previousRevision": &types.AttributeValueMemberN{Value: strconv.Itoa(previousRevision)},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "two-factor settings changed concurrently")
+}
+
+func (dao *UserDdbDao) queryOne(ctx context.Context, indexName string, attribute string, value string) (*model.User, error) {
+	ddbInput := &dynamodb.QueryInput{
+		TableName:              aws.String(dao.tableName),
+		IndexName:              aws.String(indexName),
+		KeyConditionExpression: aws.String("#attribute = :value"),
+		ExpressionAttributeNames: map[string]string{
+			"#attribute": attribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":value": &types.AttributeValueMemberS{Value: value},
+		},
+		Limit: aws.Int32(1),
+	}
+
+	output, err := dao.client.Query(ctx, ddbInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output.Items) == 0 {
+		return nil, nil
+	}
+
+	return model.UserFromDynamoDBAttributeValue(output.Items[0])
+}
+
+// userClaimItem reserves a username or email for a user. It has none of the indexed attributes, so
+// it never shows up in lookups.
+func userClaimItem(id string, userID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"ID":     &types.AttributeValueMemberS{Value: id},
+		"UserID": &types.AttributeValueMemberS{Value: userID},
+	}
+}
+
+func userKey(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}}
+}
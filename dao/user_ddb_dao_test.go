@@ -0,0 +1,61 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateUser_ClaimsUsernameAndEmailInOneTransaction(t *testing.T) {
+	var captured *dynamodb.TransactWriteItemsInput
+	transactFunc := func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+		captured = input
+		return &dynamodb.TransactWriteItemsOutput{}, nil
+	}
+	sut := NewUserDdbDao(&MockDynamoDBClient{TransactFunc: transactFunc}, "users")
+
+	err := sut.CreateUser(context.Background(), &model.User{ID: "user-1", Username: "alice", Email: "alice@example.com"})
+
+	assert.NoError(t, err)
+	if assert.Len(t, captured.TransactItems, 3) {
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "user-1"}, captured.TransactItems[0].Put.Item["ID"])
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "Username#alice"}, captured.TransactItems[1].Put.Item["ID"])
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "Email#alice@example.com"}, captured.TransactItems[2].Put.Item["ID"])
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "user-1"}, captured.TransactItems[2].Put.Item["UserID"])
+		assert.Equal(t, "attribute_not_exists(#key)", *captured.TransactItems[2].Put.ConditionExpression)
+	}
+}
+
+func TestCreateUser_NoEmail_ClaimsOnlyUsername(t *testing.T) {
+	var captured *dynamodb.TransactWriteItemsInput
+	transactFunc := func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+		captured = input
+		return &dynamodb.TransactWriteItemsOutput{}, nil
+	}
+	sut := NewUserDdbDao(&MockDynamoDBClient{TransactFunc: transactFunc}, "users")
+
+	err := sut.CreateUser(context.Background(), &model.User{ID: "user-1", Username: "alice"})
+
+	assert.NoError(t, err)
+	assert.Len(t, captured.TransactItems, 2)
+}
+
+func TestCreateUser_ClaimTaken_ReturnsWhichOne(t *testing.T) {
+	for reasons, expected := range map[[3]string]error{
+		{"None", "ConditionalCheckFailed", "None"}: ErrUsernameTaken,
+		{"None", "None", "ConditionalCheckFailed"}: ErrEmailInUse,
+	} {
+		transactFunc := func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			return nil, canceled(reasons[:]...)
+		}
+		sut := NewUserDdbDao(&MockDynamoDBClient{TransactFunc: transactFunc}, "users")
+
+		err := sut.CreateUser(context.Background(), &model.User{ID: "user-1", Username: "alice", Email: "alice@example.com"})
+
+		assert.Equal(t, expected, err)
+	}
+}
@@ -0,0 +1,26 @@
+package dao
+
+import (
+	"context"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+type WebhookDao interface {
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) error
+	GetWebhook(ctx context.Context, id string) (*model.Webhook, error)
+	// ListWebhooks returns every webhook; there are few enough to read in full on each event.
+	ListWebhooks(ctx context.Context) ([]*model.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *model.Webhook) error
+	DeleteWebhook(ctx context.Context, id string) error
+}
+
+type WebhookDeliveryDao interface {
+	// CreateDelivery fails with a conflict if the event was already delivered to the webhook.
+	CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	// UpdateDelivery fails with a conflict if another attempt was recorded since previousAttempts.
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery, previousAttempts int) error
+	ListDeliveries(ctx context.Context, webhookID string, limit int, lastEvaluatedKey string) ([]*model.WebhookDelivery, string, error)
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error)
+}
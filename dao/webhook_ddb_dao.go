@@ -0,0 +1,198 @@
+package dao
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const webhookDeliveryRetryIndex = "Status-RetryAt-index"
+
+type WebhookDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewWebhookDdbDao(client DynamoDBAPI, tableName string) *WebhookDdbDao {
+	return &WebhookDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *WebhookDdbDao) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	item, err := model.WebhookToDynamoDbAttributes(webhook)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(ID)"),
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "webhook already exists")
+}
+
+func (dao *WebhookDdbDao) GetWebhook(ctx context.Context, id string) (*model.Webhook, error) {
+	output, err := dao.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(dao.tableName),
+		Key:       map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if output == nil || output.Item == nil {
+		return nil, nil
+	}
+	return model.WebhookFromDynamoDBAttributeValue(output.Item)
+}
+
+func (dao *WebhookDdbDao) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	var items []map[string]types.AttributeValue
+	var startKey map[string]types.AttributeValue
+	for {
+		output, err := dao.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String(dao.tableName),
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, output.Items...)
+		if len(output.LastEvaluatedKey) == 0 {
+			return model.WebhooksFromDynamoDBAttributeValues(items)
+		}
+		startKey = output.LastEvaluatedKey
+	}
+}
+
+func (dao *WebhookDdbDao) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	item, err := model.WebhookToDynamoDbAttributes(webhook)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+	})
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "webhook not found")
+}
+
+func (dao *WebhookDdbDao) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := dao.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(dao.tableName),
+		Key:                 map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: id}},
+		ConditionExpression: aws.String("attribute_exists(ID)"),
+	})
+	return translateConditionalCheckFailure(err, apperror.KindNotFound, "webhook not found")
+}
+
+type WebhookDeliveryDdbDao struct {
+	client    DynamoDBAPI
+	tableName string
+}
+
+func NewWebhookDeliveryDdbDao(client DynamoDBAPI, tableName string) *WebhookDeliveryDdbDao {
+	return &WebhookDeliveryDdbDao{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+func (dao *WebhookDeliveryDdbDao) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	delivery.SetKeys()
+	item, err := model.WebhookDeliveryToDynamoDbAttributes(delivery)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(dao.tableName),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#sequence)"),
+		ExpressionAttributeNames: map[string]string{"#sequence": "Sequence"},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "event already delivered to webhook")
+}
+
+func (dao *WebhookDeliveryDdbDao) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery, previousAttempts int) error {
+	delivery.SetKeys()
+	item, err := model.WebhookDeliveryToDynamoDbAttributes(delivery)
+	if err != nil {
+		return err
+	}
+
+	_, err = dao.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(dao.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("Attempts = :previousAttempts"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":previousAttempts": &types.AttributeValueMemberN{Value: strconv.Itoa(previousAttempts)},
+		},
+	})
+	return translateConditionalCheckFailure(err, apperror.KindConflict, "webhook delivery was attempted concurrently")
+}
+
+// ListDeliveries returns a webhook's deliveries newest first; the cursor is the last Sequence.
+func (dao *WebhookDeliveryDdbDao) ListDeliveries(ctx context.Context, webhookID string, limit int, lastEvaluatedKey string) ([]*model.WebhookDelivery, string, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(dao.tableName),
+		KeyConditionExpression: aws.String("WebhookID = :webhookID"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":webhookID": &types.AttributeValueMemberS{Value: webhookID},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(int32(limit)),
+	}
+	if lastEvaluatedKey != "" {
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"WebhookID": &types.AttributeValueMemberS{Value: webhookID},
+			"Sequence":  &types.AttributeValueMemberS{Value: lastEvaluatedKey},
+		}
+	}
+
+	output, err := dao.client.Query(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+	nextKey := ""
+	if sequence, ok := output.LastEvaluatedKey["Sequence"].(*types.AttributeValueMemberS); ok {
+		nextKey = sequence.Value
+	}
+
+	result, err := model.WebhookDeliveriesFromDynamoDBAttributeValues(output.Items)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, nextKey, nil
+}
+
+// ListDueDeliveries returns pending deliveries whose next attempt is due, oldest first. Status is a
+// DynamoDB reserved word.
+func (dao *WebhookDeliveryDdbDao) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	output, err := dao.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                aws.String(dao.tableName),
+		IndexName:                aws.String(webhookDeliveryRetryIndex),
+		KeyConditionExpression:   aws.String("#status = :pending AND RetryAt <= :now"),
+		ExpressionAttributeNames: map[string]string{"#status": "Status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: string(model.DeliveryPending)},
+			":now":     &types.AttributeValueMemberS{Value: model.WebhookRetryAt(now)},
+		},
+		Limit: aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return model.WebhookDeliveriesFromDynamoDBAttributeValues(output.Items)
+}
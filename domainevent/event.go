@@ -0,0 +1,70 @@
+package domainevent
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// Event types. Consumers only ever see published posts: a post taken back to draft is reported as
+// PostDeleted, and drafts produce no events at all.
+const (
+	TypePostPublished = "PostPublished"
+	TypePostUpdated   = "PostUpdated"
+	TypePostDeleted   = "PostDeleted"
+)
+
+// Event is the envelope every sink delivers. ID is the stream record's event ID, so redeliveries of
+// the same change carry the same ID and consumers can drop duplicates.
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	PostID     string      `json:"postId"`
+	Detail     interface{} `json:"detail"`
+}
+
+type PostPublished struct {
+	Post *model.PostMetadata `json:"post"`
+}
+
+type PostUpdated struct {
+	Post     *model.PostMetadata `json:"post"`
+	Previous *model.PostMetadata `json:"previous"`
+}
+
+type PostDeleted struct {
+	Previous *model.PostMetadata `json:"previous"`
+}
+
+// postEvent maps a change to a post to the event consumers see, if any. Either image may be nil.
+// Imported and restored posts were already published elsewhere, so writing them isn't news; only
+// later changes to them are.
+func postEvent(id string, occurredAt time.Time, previous *model.PostMetadata, current *model.PostMetadata) *Event {
+	if previous == nil && current != nil && current.Origin != "" {
+		return nil
+	}
+	wasPublished := previous != nil && previous.Status == model.Posted
+	isPublished := current != nil && current.Status == model.Posted
+
+	event := &Event{ID: id, OccurredAt: occurredAt}
+	switch {
+	case isPublished && !wasPublished:
+		event.Type, event.PostID, event.Detail = TypePostPublished, current.ID, PostPublished{Post: current}
+	case isPublished && !samePost(previous, current):
+		event.Type, event.PostID, event.Detail = TypePostUpdated, current.ID, PostUpdated{Post: current, Previous: previous}
+	case wasPublished && !isPublished:
+		event.Type, event.PostID, event.Detail = TypePostDeleted, previous.ID, PostDeleted{Previous: previous}
+	default:
+		return nil
+	}
+	return event
+}
+
+// samePost ignores rewrites that only upgraded the item's schema.
+func samePost(previous *model.PostMetadata, current *model.PostMetadata) bool {
+	upgraded := *previous
+	upgraded.SchemaVersion = current.SchemaVersion
+	return reflect.DeepEqual(&upgraded, current)
+}
@@ -0,0 +1,101 @@
+package domainevent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/migration"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// publishedEventTTL keeps delivery marks for twice the stream's retention.
+const publishedEventTTL = 48 * time.Hour
+
+// Publisher turns the post table's stream into domain events. The stream must carry new and old
+// images.
+type Publisher struct {
+	sink              Sink
+	publishedEventDao dao.PublishedEventDao
+	migrations        *migration.Runner
+	now               func() time.Time
+}
+
+// NewPublisher builds a publisher; with a nil publishedEventDao, events redelivered by stream
+// retries are published again and consumers rely on the event ID alone to drop them.
+func NewPublisher(sink Sink, publishedEventDao dao.PublishedEventDao) *Publisher {
+	return &Publisher{
+		sink:              sink,
+		publishedEventDao: publishedEventDao,
+		migrations:        migration.PostMetadataRunner(),
+		now:               time.Now,
+	}
+}
+
+// HandleDynamoDBEvent is the Lambda entry point for the post table's stream. Records of a shard
+// must be delivered in order, so it stops at the first failure and reports it as the batch item
+// failure; Lambda retries the batch from that record on. Only sink and DAO failures are retried: a
+// record that can't be decoded would fail the same way every time and hold up the shard behind it,
+// so it is logged and skipped.
+func (publisher *Publisher) HandleDynamoDBEvent(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	for _, record := range event.Records {
+		if err := publisher.handleRecord(ctx, record); err != nil {
+			log.Printf("post events: record %s failed: %v", record.EventID, err)
+			return events.DynamoDBEventResponse{
+				BatchItemFailures: []events.DynamoDBBatchItemFailure{{ItemIdentifier: record.Change.SequenceNumber}},
+			}, nil
+		}
+	}
+	return events.DynamoDBEventResponse{}, nil
+}
+
+func (publisher *Publisher) handleRecord(ctx context.Context, record events.DynamoDBEventRecord) error {
+	previous, err := decodeImage(record.Change.OldImage, publisher.migrations)
+	if err != nil {
+		log.Printf("post events: skipping record %s at sequence %s: decode old image: %v", record.EventID, record.Change.SequenceNumber, err)
+		return nil
+	}
+	current, err := decodeImage(record.Change.NewImage, publisher.migrations)
+	if err != nil {
+		log.Printf("post events: skipping record %s at sequence %s: decode new image: %v", record.EventID, record.Change.SequenceNumber, err)
+		return nil
+	}
+
+	domainEvent := postEvent(record.EventID, record.Change.ApproximateCreationDateTime.UTC(), previous, current)
+	if domainEvent == nil {
+		return nil
+	}
+	return publisher.publish(ctx, domainEvent)
+}
+
+func (publisher *Publisher) publish(ctx context.Context, event *Event) error {
+	if publisher.publishedEventDao != nil {
+		published, err := publisher.publishedEventDao.IsEventPublished(ctx, event.ID)
+		if err != nil || published {
+			return err
+		}
+	}
+
+	if err := publisher.sink.Publish(ctx, event); err != nil {
+		return fmt.Errorf("publish %s %s: %w", event.Type, event.ID, err)
+	}
+
+	if publisher.publishedEventDao == nil {
+		return nil
+	}
+	now := publisher.now().UTC()
+	err := publisher.publishedEventDao.MarkEventPublished(ctx, &model.PublishedEvent{
+		ID:          event.ID,
+		PublishedAt: now,
+		DeleteAfter: now.Add(publishedEventTTL).Unix(),
+	})
+	// A concurrent retry got there first; the event is delivered either way.
+	if apperror.KindOf(err) == apperror.KindConflict {
+		return nil
+	}
+	return err
+}
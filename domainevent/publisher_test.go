@@ -0,0 +1,177 @@
+package domainevent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePublishedEventDao struct {
+	published map[string]bool
+}
+
+func (f *fakePublishedEventDao) IsEventPublished(ctx context.Context, id string) (bool, error) {
+	return f.published[id], nil
+}
+
+func (f *fakePublishedEventDao) MarkEventPublished(ctx context.Context, event *model.PublishedEvent) error {
+	if f.published[event.ID] {
+		return apperror.Conflict("event already published")
+	}
+	f.published[event.ID] = true
+	return nil
+}
+
+// failingSink records what it delivers and fails events listed in failures.
+type failingSink struct {
+	delivered []*Event
+	failures  map[string]bool
+}
+
+func (sink *failingSink) Publish(ctx context.Context, event *Event) error {
+	if sink.failures[event.ID] {
+		return errors.New("sink unavailable")
+	}
+	sink.delivered = append(sink.delivered, event)
+	return nil
+}
+
+func postImage(id string, title string, status model.Status) map[string]events.DynamoDBAttributeValue {
+	timestamp := events.NewStringAttribute("2024-05-01T10:00:00Z")
+	return map[string]events.DynamoDBAttributeValue{
+		"ID":            events.NewStringAttribute(id),
+		"Title":         events.NewStringAttribute(title),
+		"BodyUrl":       events.NewStringAttribute("https://example.com/" + id),
+		"Status":        events.NewStringAttribute(string(status)),
+		"CreatedAt":     timestamp,
+		"UpdatedAt":     timestamp,
+		"SchemaVersion": events.NewNumberAttribute("1"),
+	}
+}
+
+func streamRecord(eventID string, sequence string, oldImage map[string]events.DynamoDBAttributeValue, newImage map[string]events.DynamoDBAttributeValue) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventID: eventID,
+		Change: events.DynamoDBStreamRecord{
+			ApproximateCreationDateTime: events.SecondsEpochTime{Time: time.Unix(1714557600, 0)},
+			SequenceNumber:              sequence,
+			OldImage:                    oldImage,
+			NewImage:                    newImage,
+		},
+	}
+}
+
+func TestPostEvent_StatusTransitions_MapToDomainEvents(t *testing.T) {
+	draft := &model.PostMetadata{ID: "post-1", Title: "Hello", Status: model.Draft}
+	posted := &model.PostMetadata{ID: "post-1", Title: "Hello", Status: model.Posted}
+	retitled := &model.PostMetadata{ID: "post-1", Title: "Hello again", Status: model.Posted}
+	upgraded := &model.PostMetadata{ID: "post-1", Title: "Hello", Status: model.Posted, SchemaVersion: 1}
+	now := time.Now()
+
+	assert.Equal(t, TypePostPublished, postEvent("e1", now, draft, posted).Type)
+	assert.Equal(t, TypePostPublished, postEvent("e2", now, nil, posted).Type)
+	assert.Equal(t, TypePostUpdated, postEvent("e3", now, posted, retitled).Type)
+	assert.Equal(t, TypePostDeleted, postEvent("e4", now, posted, draft).Type)
+	assert.Equal(t, TypePostDeleted, postEvent("e5", now, posted, nil).Type)
+	assert.Nil(t, postEvent("e6", now, nil, draft))
+	assert.Nil(t, postEvent("e7", now, posted, upgraded))
+}
+
+func TestPostEvent_ImportedOrRestored_PublishesOnlyLaterChanges(t *testing.T) {
+	for _, origin := range []string{model.OriginImport, model.OriginRestore} {
+		draft := &model.PostMetadata{ID: "post-1", Title: "Hello", Status: model.Draft, Origin: origin}
+		posted := &model.PostMetadata{ID: "post-1", Title: "Hello", Status: model.Posted, Origin: origin}
+		now := time.Now()
+
+		assert.Nil(t, postEvent("e1", now, nil, posted), origin)
+		assert.Nil(t, postEvent("e2", now, nil, draft), origin)
+		assert.Equal(t, TypePostPublished, postEvent("e3", now, draft, posted).Type, origin)
+	}
+}
+
+func TestHandleDynamoDBEvent_RestoredPost_IsNotPublished(t *testing.T) {
+	restored := postImage("post-1", "Hello", model.Posted)
+	restored["Origin"] = events.NewStringAttribute(model.OriginRestore)
+	sink := &failingSink{}
+	sut := NewPublisher(sink, nil)
+
+	response, err := sut.HandleDynamoDBEvent(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord("event-1", "100", nil, restored),
+	}})
+
+	assert.NoError(t, err)
+	assert.Empty(t, response.BatchItemFailures)
+	assert.Empty(t, sink.delivered)
+}
+
+func TestHandleDynamoDBEvent_DecodesImagesAndPublishes(t *testing.T) {
+	sink := &failingSink{}
+	sut := NewPublisher(sink, nil)
+
+	response, err := sut.HandleDynamoDBEvent(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord("event-1", "100", postImage("post-1", "Hello", model.Draft), postImage("post-1", "Hello", model.Posted)),
+		streamRecord("event-2", "101", nil, postImage("post-2", "Draft", model.Draft)),
+	}})
+
+	assert.NoError(t, err)
+	assert.Empty(t, response.BatchItemFailures)
+	if assert.Len(t, sink.delivered, 1) {
+		assert.Equal(t, "event-1", sink.delivered[0].ID)
+		assert.Equal(t, "post-1", sink.delivered[0].PostID)
+		assert.Equal(t, "Hello", sink.delivered[0].Detail.(PostPublished).Post.Title)
+	}
+}
+
+func TestHandleDynamoDBEvent_SinkFailure_ReportsFirstFailedRecordAndStops(t *testing.T) {
+	sink := &failingSink{failures: map[string]bool{"event-2": true}}
+	sut := NewPublisher(sink, nil)
+
+	response, err := sut.HandleDynamoDBEvent(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord("event-1", "100", nil, postImage("post-1", "One", model.Posted)),
+		streamRecord("event-2", "101", nil, postImage("post-2", "Two", model.Posted)),
+		streamRecord("event-3", "102", nil, postImage("post-3", "Three", model.Posted)),
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []events.DynamoDBBatchItemFailure{{ItemIdentifier: "101"}}, response.BatchItemFailures)
+	assert.Len(t, sink.delivered, 1)
+}
+
+func TestHandleDynamoDBEvent_UndecodableRecord_IsSkipped(t *testing.T) {
+	sink := &failingSink{}
+	sut := NewPublisher(sink, nil)
+	malformed := postImage("post-1", "One", model.Posted)
+	malformed["CreatedAt"] = events.NewStringAttribute("yesterday")
+
+	response, err := sut.HandleDynamoDBEvent(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord("event-1", "100", nil, malformed),
+		streamRecord("event-2", "101", nil, postImage("post-2", "Two", model.Posted)),
+	}})
+
+	assert.NoError(t, err)
+	assert.Empty(t, response.BatchItemFailures)
+	if assert.Len(t, sink.delivered, 1) {
+		assert.Equal(t, "event-2", sink.delivered[0].ID)
+	}
+}
+
+func TestHandleDynamoDBEvent_RetriedBatch_SkipsPublishedEvents(t *testing.T) {
+	sink := &failingSink{}
+	sut := NewPublisher(sink, &fakePublishedEventDao{published: map[string]bool{}})
+	batch := events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		streamRecord("event-1", "100", nil, postImage("post-1", "One", model.Posted)),
+	}}
+
+	_, err := sut.HandleDynamoDBEvent(context.Background(), batch)
+	_, retryErr := sut.HandleDynamoDBEvent(context.Background(), batch)
+
+	assert.NoError(t, err)
+	assert.NoError(t, retryErr)
+	assert.Len(t, sink.delivered, 1)
+}
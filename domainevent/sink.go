@@ -0,0 +1,110 @@
+package domainevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// Sink delivers events to consumers. Publish succeeds only once the event is durably handed over.
+type Sink interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+type EventBridgeAPI interface {
+	PutEvents(ctx context.Context, input *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgeSink puts events on a bus with the event type as the detail type, for rules to route.
+type EventBridgeSink struct {
+	client  EventBridgeAPI
+	busName string
+	source  string
+}
+
+func NewEventBridgeSink(client EventBridgeAPI, busName string, source string) *EventBridgeSink {
+	return &EventBridgeSink{client: client, busName: busName, source: source}
+}
+
+func (sink *EventBridgeSink) Publish(ctx context.Context, event *Event) error {
+	detail, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	output, err := sink.client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []eventbridgetypes.PutEventsRequestEntry{{
+			EventBusName: aws.String(sink.busName),
+			Source:       aws.String(sink.source),
+			DetailType:   aws.String(event.Type),
+			Detail:       aws.String(string(detail)),
+			Time:         aws.Time(event.OccurredAt),
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	// PutEvents reports rejected entries in the output rather than as an error.
+	if output.FailedEntryCount > 0 && len(output.Entries) > 0 {
+		entry := output.Entries[0]
+		return fmt.Errorf("eventbridge rejected event %s: %s %s", event.ID, aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+	return nil
+}
+
+type SNSAPI interface {
+	Publish(ctx context.Context, input *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSSink publishes events to a topic with the type as a message attribute for subscription
+// filters. On a FIFO topic the event ID is the deduplication ID, so SNS drops redeliveries, and
+// events for one post stay in order.
+type SNSSink struct {
+	client   SNSAPI
+	topicARN string
+}
+
+func NewSNSSink(client SNSAPI, topicARN string) *SNSSink {
+	return &SNSSink{client: client, topicARN: topicARN}
+}
+
+func (sink *SNSSink) Publish(ctx context.Context, event *Event) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	input := &sns.PublishInput{
+		TopicArn: aws.String(sink.topicARN),
+		Message:  aws.String(string(message)),
+		MessageAttributes: map[string]snstypes.MessageAttributeValue{
+			"type": {DataType: aws.String("String"), StringValue: aws.String(event.Type)},
+		},
+	}
+	if strings.HasSuffix(sink.topicARN, ".fifo") {
+		input.MessageGroupId = aws.String(event.PostID)
+		input.MessageDeduplicationId = aws.String(event.ID)
+	}
+	_, err = sink.client.Publish(ctx, input)
+	return err
+}
+
+// ChannelSink hands events to an in-process consumer, for local development and tests. Publish
+// blocks until the consumer receives the event or the context ends.
+type ChannelSink chan<- *Event
+
+func (sink ChannelSink) Publish(ctx context.Context, event *Event) error {
+	select {
+	case sink <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
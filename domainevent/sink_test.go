@@ -0,0 +1,64 @@
+package domainevent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockEventBridge struct {
+	PutEventsFunc func(ctx context.Context, input *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+func (m *MockEventBridge) PutEvents(ctx context.Context, input *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	return m.PutEventsFunc(ctx, input, optFns...)
+}
+
+type MockSNS struct {
+	PublishFunc func(ctx context.Context, input *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+func (m *MockSNS) Publish(ctx context.Context, input *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	return m.PublishFunc(ctx, input, optFns...)
+}
+
+func TestEventBridgeSink_RejectedEntry_ReturnsErr(t *testing.T) {
+	var captured *eventbridge.PutEventsInput
+	sut := NewEventBridgeSink(&MockEventBridge{
+		PutEventsFunc: func(ctx context.Context, input *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+			captured = input
+			return &eventbridge.PutEventsOutput{
+				FailedEntryCount: 1,
+				Entries:          []eventbridgetypes.PutEventsResultEntry{{ErrorCode: aws.String("ThrottlingException")}},
+			}, nil
+		},
+	}, "blog", "blog.posts")
+
+	err := sut.Publish(context.Background(), &Event{ID: "event-1", Type: TypePostPublished, PostID: "post-1"})
+
+	assert.ErrorContains(t, err, "ThrottlingException")
+	assert.Equal(t, TypePostPublished, aws.ToString(captured.Entries[0].DetailType))
+	assert.Equal(t, "blog.posts", aws.ToString(captured.Entries[0].Source))
+}
+
+func TestSNSSink_FIFOTopic_SetsGroupAndDeduplicationIDs(t *testing.T) {
+	var captured *sns.PublishInput
+	sut := NewSNSSink(&MockSNS{
+		PublishFunc: func(ctx context.Context, input *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+			captured = input
+			return &sns.PublishOutput{}, nil
+		},
+	}, "arn:aws:sns:us-east-1:123456789012:posts.fifo")
+
+	err := sut.Publish(context.Background(), &Event{ID: "event-1", Type: TypePostDeleted, PostID: "post-1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "post-1", aws.ToString(captured.MessageGroupId))
+	assert.Equal(t, "event-1", aws.ToString(captured.MessageDeduplicationId))
+	assert.Equal(t, TypePostDeleted, aws.ToString(captured.MessageAttributes["type"].StringValue))
+}
@@ -0,0 +1,78 @@
+package domainevent
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/migration"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// decodeImage turns a stream image into post metadata with the same codec and schema upgrades the
+// DAO uses. An empty image, such as the old image of an insert, decodes to nil.
+func decodeImage(image map[string]events.DynamoDBAttributeValue, migrations *migration.Runner) (*model.PostMetadata, error) {
+	if len(image) == 0 {
+		return nil, nil
+	}
+	item, err := itemFromStream(image)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := migrations.Migrate(item); err != nil {
+		return nil, err
+	}
+	return model.FromDynamoDBAttributeValue(item)
+}
+
+// itemFromStream converts the Lambda event's attribute values into the SDK's.
+func itemFromStream(image map[string]events.DynamoDBAttributeValue) (map[string]types.AttributeValue, error) {
+	item := make(map[string]types.AttributeValue, len(image))
+	for name, value := range image {
+		converted, err := attributeFromStream(value)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", name, err)
+		}
+		item[name] = converted
+	}
+	return item, nil
+}
+
+func attributeFromStream(value events.DynamoDBAttributeValue) (types.AttributeValue, error) {
+	switch value.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: value.String()}, nil
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: value.Number()}, nil
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: value.Binary()}, nil
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: value.Boolean()}, nil
+	case events.DataTypeNull:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: value.StringSet()}, nil
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: value.NumberSet()}, nil
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: value.BinarySet()}, nil
+	case events.DataTypeList:
+		list := value.List()
+		converted := make([]types.AttributeValue, len(list))
+		for i, element := range list {
+			var err error
+			if converted[i], err = attributeFromStream(element); err != nil {
+				return nil, err
+			}
+		}
+		return &types.AttributeValueMemberL{Value: converted}, nil
+	case events.DataTypeMap:
+		converted, err := itemFromStream(value.Map())
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: converted}, nil
+	default:
+		return nil, fmt.Errorf("unsupported stream attribute type %v", value.DataType())
+	}
+}
@@ -0,0 +1,200 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// ghostURLPlaceholder stands for the site address in links in Ghost 4 and later exports.
+const ghostURLPlaceholder = "__GHOST_URL__"
+
+type ghostExport struct {
+	DB []struct {
+		Data ghostData `json:"data"`
+	} `json:"db"`
+	Data *ghostData `json:"data"`
+}
+
+type ghostData struct {
+	Posts     []ghostPost    `json:"posts"`
+	Tags      []ghostTag     `json:"tags"`
+	PostsTags []ghostPostTag `json:"posts_tags"`
+}
+
+type ghostPost struct {
+	ID          ghostID `json:"id"`
+	Title       string  `json:"title"`
+	Slug        string  `json:"slug"`
+	HTML        *string `json:"html"`
+	Status      string  `json:"status"`
+	Type        string  `json:"type"`
+	Page        bool    `json:"page"`
+	CreatedAt   *string `json:"created_at"`
+	PublishedAt *string `json:"published_at"`
+}
+
+type ghostTag struct {
+	ID   ghostID `json:"id"`
+	Name string  `json:"name"`
+	Slug string  `json:"slug"`
+}
+
+type ghostPostTag struct {
+	PostID    ghostID `json:"post_id"`
+	TagID     ghostID `json:"tag_id"`
+	SortOrder int     `json:"sort_order"`
+}
+
+// ghostID accepts the object IDs of current exports and the numbers of old ones.
+type ghostID string
+
+func (id *ghostID) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*id = ghostID(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("id must be a string or number")
+	}
+	*id = ghostID(number.String())
+	return nil
+}
+
+// ReadGhost reads the posts in a Ghost export. Pages are skipped. Published posts are imported as
+// published and drafts, scheduled and email-only posts as drafts, dated when they were published,
+// or created if they never were. Public tags are kept; internal #tags are dropped. Links between
+// posts are rewritten to the imported posts; siteURL is the blog's address, which exports leave out.
+func ReadGhost(in io.Reader, siteURL string) ([]*Document, error) {
+	var export ghostExport
+	if err := json.NewDecoder(in).Decode(&export); err != nil {
+		return nil, fmt.Errorf("read Ghost export: %w", err)
+	}
+	var data ghostData
+	switch {
+	case len(export.DB) > 0:
+		data = export.DB[0].Data
+	case export.Data != nil:
+		data = *export.Data
+	default:
+		return nil, fmt.Errorf("read Ghost export: no data")
+	}
+
+	// Without the site's address only placeholder and relative links can be recognized; a stand-in
+	// address resolves those, and other placeholder links become relative.
+	siteURL = strings.TrimRight(siteURL, "/")
+	base := siteURL
+	if base == "" {
+		base = "https://ghost.invalid"
+	}
+	links := newLinkMap(base)
+	tags := ghostPostTags(data)
+
+	var posts []ghostPost
+	var documents []*Document
+	for _, post := range data.Posts {
+		if post.Type == "page" || post.Page {
+			continue
+		}
+		document, err := ghostDocument(post, tags[post.ID], siteURL)
+		if err != nil {
+			return nil, fmt.Errorf("Ghost post %s: %w", post.Slug, err)
+		}
+		links.add(base+"/"+post.Slug+"/", PostPath(document.Slug))
+		posts = append(posts, post)
+		documents = append(documents, document)
+	}
+
+	resolve := func(href string) string {
+		return links.resolve(strings.Replace(href, ghostURLPlaceholder, siteURL, 1))
+	}
+	for i, post := range posts {
+		if post.HTML == nil {
+			continue
+		}
+		body, err := htmlToMarkdown(*post.HTML, resolve)
+		if err != nil {
+			return nil, fmt.Errorf("Ghost post %s: %w", post.Slug, err)
+		}
+		documents[i].Post.Body = body
+	}
+	return documents, nil
+}
+
+func ghostDocument(post ghostPost, tags []model.Tag, siteURL string) (*Document, error) {
+	status := model.Draft
+	if post.Status == "published" {
+		status = model.Posted
+	}
+	date, err := ghostDate(post)
+	if err != nil {
+		return nil, err
+	}
+	slug := Slugify(post.Slug)
+	if slug == "" {
+		slug = "ghost-" + Slugify(string(post.ID))
+	}
+
+	return &Document{
+		Source:      "ghost:" + string(post.ID),
+		OriginalURL: siteURL + "/" + post.Slug + "/",
+		Slug:        slug,
+		Post: model.Post{PostMetadata: model.PostMetadata{
+			Title:     strings.TrimSpace(post.Title),
+			Status:    status,
+			CreatedAt: date,
+			UpdatedAt: date,
+			Tags:      tags,
+		}},
+	}, nil
+}
+
+func ghostDate(post ghostPost) (time.Time, error) {
+	for _, value := range []*string{post.PublishedAt, post.CreatedAt} {
+		if value == nil || *value == "" {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339Nano, *value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", *value)
+		}
+		return date.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("no date")
+}
+
+// ghostPostTags returns each post's public tags in the author's order.
+func ghostPostTags(data ghostData) map[ghostID][]model.Tag {
+	tagsByID := make(map[ghostID]ghostTag, len(data.Tags))
+	for _, tag := range data.Tags {
+		tagsByID[tag.ID] = tag
+	}
+	postTags := append([]ghostPostTag(nil), data.PostsTags...)
+	sort.SliceStable(postTags, func(i, j int) bool { return postTags[i].SortOrder < postTags[j].SortOrder })
+
+	labels := map[ghostID][]string{}
+	for _, postTag := range postTags {
+		tag, ok := tagsByID[postTag.TagID]
+		if !ok || strings.HasPrefix(tag.Name, "#") {
+			continue
+		}
+		label := tag.Slug
+		if label == "" {
+			label = tag.Name
+		}
+		labels[postTag.PostID] = append(labels[postTag.PostID], label)
+	}
+
+	result := make(map[ghostID][]model.Tag, len(labels))
+	for postID, postLabels := range labels {
+		result[postID] = tagsFromLabels(postLabels)
+	}
+	return result
+}
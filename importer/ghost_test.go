@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const testGhostExport = `{
+  "db": [{
+    "meta": {"version": "5.0.0"},
+    "data": {
+      "posts": [
+        {"id": "p1", "title": "Hello", "slug": "hello", "type": "post", "status": "published",
+         "html": "<p>See <a href=\"__GHOST_URL__/second/\">the second</a> and <a href=\"__GHOST_URL__/about/\">about</a>.</p>",
+         "created_at": "2021-01-01T09:00:00.000Z", "published_at": "2021-01-02T10:00:00.000Z"},
+        {"id": "p2", "title": "Second", "slug": "second", "type": "post", "status": "draft",
+         "html": null, "created_at": "2021-02-03T04:05:06.000Z", "published_at": null},
+        {"id": "p3", "title": "About", "slug": "about", "type": "page", "status": "published",
+         "html": "<p>About</p>", "created_at": "2021-01-01T00:00:00.000Z"}
+      ],
+      "tags": [
+        {"id": "t1", "name": "Go", "slug": "go"},
+        {"id": "t2", "name": "#featured", "slug": "hash-featured"},
+        {"id": "t3", "name": "AWS", "slug": "aws"}
+      ],
+      "posts_tags": [
+        {"post_id": "p1", "tag_id": "t3", "sort_order": 1},
+        {"post_id": "p1", "tag_id": "t1", "sort_order": 0},
+        {"post_id": "p1", "tag_id": "t2", "sort_order": 2}
+      ]
+    }
+  }]
+}`
+
+func TestReadGhost_Export_ConvertsPosts(t *testing.T) {
+	documents, err := ReadGhost(strings.NewReader(testGhostExport), "https://old.example.com/")
+
+	assert.Nil(t, err)
+	assert.Len(t, documents, 2)
+	hello, second := documents[0], documents[1]
+
+	assert.Equal(t, "hello", hello.Slug)
+	assert.Equal(t, "https://old.example.com/hello/", hello.OriginalURL)
+	assert.Equal(t, model.Posted, hello.Post.Status)
+	assert.Equal(t, time.Date(2021, 1, 2, 10, 0, 0, 0, time.UTC), hello.Post.CreatedAt)
+	assert.Equal(t, []model.Tag{{Label: "go"}, {Label: "aws"}}, hello.Post.Tags)
+	assert.Equal(t, "See [the second]("+PostPath("second")+") and [about](https://old.example.com/about/).\n", hello.Post.Body)
+
+	assert.Equal(t, model.Draft, second.Post.Status)
+	assert.Equal(t, time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC), second.Post.CreatedAt)
+	assert.Empty(t, second.Post.Tags)
+	assert.Equal(t, "", second.Post.Body)
+}
+
+func TestReadGhost_NoSiteURL_KeepsPlaceholderLinksRelative(t *testing.T) {
+	documents, err := ReadGhost(strings.NewReader(testGhostExport), "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "/hello/", documents[0].OriginalURL)
+	assert.Equal(t, "See [the second]("+PostPath("second")+") and [about](/about/).\n", documents[0].Post.Body)
+}
@@ -0,0 +1,306 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// lineBreak marks a <br> in inline text until its paragraph is tidied; HTML text can't contain it.
+const lineBreak = "\x00"
+
+var (
+	whitespace       = regexp.MustCompile(`[ \t\r\n]+`)
+	markdownSpecials = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+)
+
+// blockElements start a new Markdown block. Elements Markdown can't express, such as tables and
+// embeds, are kept as HTML, which Markdown allows.
+var (
+	blockElements = map[atom.Atom]bool{
+		atom.P: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+		atom.Ul: true, atom.Ol: true, atom.Blockquote: true, atom.Pre: true, atom.Hr: true,
+		atom.Div: true, atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true,
+		atom.Main: true, atom.Aside: true, atom.Figure: true, atom.Figcaption: true,
+	}
+	rawElements = map[atom.Atom]bool{
+		atom.Table: true, atom.Iframe: true, atom.Video: true, atom.Audio: true,
+	}
+	droppedElements = map[atom.Atom]bool{
+		atom.Script: true, atom.Style: true, atom.Noscript: true,
+	}
+)
+
+// htmlToMarkdown converts post HTML to Markdown, passing every link target through resolveLink.
+func htmlToMarkdown(source string, resolveLink func(string) string) (string, error) {
+	body := &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"}
+	nodes, err := html.ParseFragment(strings.NewReader(source), body)
+	if err != nil {
+		return "", err
+	}
+	for _, node := range nodes {
+		body.AppendChild(node)
+	}
+
+	converter := &markdownConverter{resolveLink: resolveLink}
+	markdown := converter.joinBlocks(converter.blocks(body))
+	if markdown == "" {
+		return "", nil
+	}
+	return markdown + "\n", nil
+}
+
+type markdownBlock struct {
+	text string
+	list bool
+}
+
+type markdownConverter struct {
+	resolveLink func(string) string
+}
+
+// blocks renders the children of a block element, gathering runs of inline content into paragraphs.
+func (converter *markdownConverter) blocks(parent *html.Node) []markdownBlock {
+	var blocks []markdownBlock
+	var inline strings.Builder
+	flush := func() {
+		if text := paragraph(inline.String()); text != "" {
+			blocks = append(blocks, markdownBlock{text: text})
+		}
+		inline.Reset()
+	}
+
+	for child := parent.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || !(blockElements[child.DataAtom] || rawElements[child.DataAtom]) {
+			inline.WriteString(converter.inline(child))
+			continue
+		}
+		flush()
+		if block := converter.block(child); block.text != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	flush()
+	return blocks
+}
+
+func (converter *markdownConverter) joinBlocks(blocks []markdownBlock) string {
+	parts := make([]string, len(blocks))
+	for i, block := range blocks {
+		parts[i] = block.text
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (converter *markdownConverter) block(node *html.Node) markdownBlock {
+	if rawElements[node.DataAtom] {
+		var buffer bytes.Buffer
+		if err := html.Render(&buffer, node); err != nil {
+			return markdownBlock{}
+		}
+		return markdownBlock{text: buffer.String()}
+	}
+
+	switch node.DataAtom {
+	case atom.P:
+		return markdownBlock{text: paragraph(converter.children(node))}
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := paragraph(strings.ReplaceAll(converter.children(node), lineBreak, " "))
+		if text == "" {
+			return markdownBlock{}
+		}
+		return markdownBlock{text: strings.Repeat("#", int(node.Data[1]-'0')) + " " + text}
+	case atom.Ul, atom.Ol:
+		return markdownBlock{text: converter.list(node), list: true}
+	case atom.Blockquote:
+		return markdownBlock{text: prefixLines(converter.joinBlocks(converter.blocks(node)), "> ", "> ")}
+	case atom.Pre:
+		return markdownBlock{text: codeBlock(node)}
+	case atom.Hr:
+		return markdownBlock{text: "---"}
+	default:
+		return markdownBlock{text: converter.joinBlocks(converter.blocks(node))}
+	}
+}
+
+func (converter *markdownConverter) list(node *html.Node) string {
+	var items []string
+	number := 1
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if node.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		// Nested lists stay tight under their item; other blocks in an item are separate paragraphs.
+		var content strings.Builder
+		for i, block := range converter.blocks(child) {
+			if i > 0 && block.list {
+				content.WriteString("\n")
+			} else if i > 0 {
+				content.WriteString("\n\n")
+			}
+			content.WriteString(block.text)
+		}
+		items = append(items, prefixLines(content.String(), marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+func (converter *markdownConverter) inline(node *html.Node) string {
+	switch node.Type {
+	case html.TextNode:
+		return markdownSpecials.Replace(whitespace.ReplaceAllString(node.Data, " "))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	if droppedElements[node.DataAtom] {
+		return ""
+	}
+
+	switch node.DataAtom {
+	case atom.Br:
+		return lineBreak
+	case atom.Strong, atom.B:
+		return wrap(converter.children(node), "**")
+	case atom.Em, atom.I:
+		return wrap(converter.children(node), "*")
+	case atom.Del, atom.S:
+		return wrap(converter.children(node), "~~")
+	case atom.Code:
+		return inlineCode(textContent(node))
+	case atom.A:
+		text := converter.children(node)
+		href := strings.TrimSpace(attribute(node, "href"))
+		if href == "" || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return "[" + strings.TrimSpace(text) + "](" + linkDestination(converter.resolveLink(href)) + ")"
+	case atom.Img:
+		src := strings.TrimSpace(attribute(node, "src"))
+		if src == "" {
+			return ""
+		}
+		return "![" + markdownSpecials.Replace(attribute(node, "alt")) + "](" + linkDestination(src) + ")"
+	default:
+		return converter.children(node)
+	}
+}
+
+func (converter *markdownConverter) children(node *html.Node) string {
+	var builder strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(converter.inline(child))
+	}
+	return builder.String()
+}
+
+// paragraph tidies inline Markdown into lines of single-spaced words joined by hard line breaks,
+// dropping breaks at the start and end.
+func paragraph(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, lineBreak) {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\\\n")
+}
+
+// wrap puts emphasis markers around text, outside its surrounding spaces, which would otherwise
+// stop the markers from counting.
+func wrap(text string, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := text[:strings.Index(text, trimmed)]
+	end := text[len(start)+len(trimmed):]
+	return start + marker + trimmed + marker + end
+}
+
+func inlineCode(code string) string {
+	fence := "`"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		return fence + " " + code + " " + fence
+	}
+	return fence + code + fence
+}
+
+func codeBlock(node *html.Node) string {
+	code := strings.TrimRight(textContent(node), "\n")
+	language := ""
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Code {
+			for _, class := range strings.Fields(attribute(child, "class")) {
+				if strings.HasPrefix(class, "language-") {
+					language = strings.TrimPrefix(class, "language-")
+				}
+			}
+		}
+	}
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+func prefixLines(text string, first string, rest string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			prefix = strings.TrimRight(prefix, " ")
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// linkDestination wraps a URL in angle brackets when it has characters that would end it early.
+func linkDestination(url string) string {
+	if strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
+
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	var builder strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		builder.WriteString(textContent(child))
+	}
+	return builder.String()
+}
+
+func attribute(node *html.Node, name string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
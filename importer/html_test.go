@@ -0,0 +1,49 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func unchanged(href string) string { return href }
+
+func TestHTMLToMarkdown_CommonElements_Converts(t *testing.T) {
+	source := `<h2>Intro <em>here</em></h2>
+<p>Some <strong>bold</strong> and <a href="https://example.com/a">a link</a>,
+ with <code>x := 1</code> and a_b.<br>Next line.</p>
+<ul><li>One</li><li>Two<ul><li>Nested</li></ul></li></ul>
+<ol><li>First</li><li><p>Second</p><p>More</p></li></ol>
+<blockquote><p>Quoted</p><p>Twice</p></blockquote>
+<pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}
+</code></pre>
+<figure><img src="https://example.com/cat.png" alt="A cat"><figcaption>Cat</figcaption></figure>
+<hr>
+<table><tr><td>cell</td></tr></table>
+<script>alert(1)</script>`
+
+	markdown, err := htmlToMarkdown(source, unchanged)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "## Intro *here*\n\n"+
+		"Some **bold** and [a link](https://example.com/a), with `x := 1` and a\\_b.\\\nNext line.\n\n"+
+		"- One\n- Two\n  - Nested\n\n"+
+		"1. First\n2. Second\n\n   More\n\n"+
+		"> Quoted\n>\n> Twice\n\n"+
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n"+
+		"![A cat](https://example.com/cat.png)\n\nCat\n\n"+
+		"---\n\n"+
+		"<table><tbody><tr><td>cell</td></tr></tbody></table>\n", markdown)
+}
+
+func TestHTMLToMarkdown_Links_AreResolved(t *testing.T) {
+	links := newLinkMap("https://old.example.com")
+	links.add("https://old.example.com/2019/03/hello/", "/posts/new-id")
+
+	markdown, err := htmlToMarkdown(`<p><a href="http://www.old.example.com/2019/03/hello#comments">Hello</a> and <a href="/2019/03/hello">again</a>, not <a href="/about/">this</a>.</p>`, links.resolve)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "[Hello](/posts/new-id#comments) and [again](/posts/new-id), not [this](/about/).\n", markdown)
+}
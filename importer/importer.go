@@ -0,0 +1,116 @@
+// Package importer brings posts over from other blogs. Readers turn exported content into
+// Documents; the Importer creates a post for each, keyed by its slug, so running an import again
+// skips the posts it already created.
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// Document is a post read from an export, ready to import.
+type Document struct {
+	// Source names where the post came from, such as a file path, for the report.
+	Source string
+	// OriginalURL is the post's address on the old blog, when the export has one.
+	OriginalURL string
+	Slug        string
+	Post        model.Post
+}
+
+// PostImporter creates posts with their original ID and dates; api.PostService implements it.
+type PostImporter interface {
+	ImportPost(ctx context.Context, post *model.Post, dryRun bool) (bool, error)
+}
+
+type Options struct {
+	DryRun bool
+}
+
+type Report struct {
+	Created  int
+	Existing int
+	Invalid  int
+}
+
+type Importer struct {
+	posts   PostImporter
+	options Options
+	out     io.Writer
+}
+
+func NewImporter(posts PostImporter, options Options, out io.Writer) *Importer {
+	return &Importer{posts: posts, options: options, out: out}
+}
+
+// Run imports the documents in order and writes a line about each to out. Documents that fail
+// validation are reported and skipped; any other error stops the run, which can simply be repeated.
+func (importer *Importer) Run(ctx context.Context, documents []*Document) (*Report, error) {
+	report := &Report{}
+	sources := make(map[string]string, len(documents))
+	for _, document := range documents {
+		if document.Slug == "" {
+			report.Invalid++
+			fmt.Fprintf(importer.out, "invalid  %s: no slug\n", document.Source)
+			continue
+		}
+		if previous, ok := sources[document.Slug]; ok {
+			report.Invalid++
+			fmt.Fprintf(importer.out, "invalid  %s: slug %q is also used by %s\n", document.Source, document.Slug, previous)
+			continue
+		}
+		sources[document.Slug] = document.Source
+
+		post := document.Post
+		post.ID = PostID(document.Slug)
+		created, err := importer.posts.ImportPost(ctx, &post, importer.options.DryRun)
+		switch {
+		case apperror.KindOf(err) == apperror.KindValidation:
+			report.Invalid++
+			fmt.Fprintf(importer.out, "invalid  %s: %v\n", document.Source, err)
+		case err != nil:
+			return report, fmt.Errorf("import %s: %w", document.Source, err)
+		case created:
+			report.Created++
+			fmt.Fprintf(importer.out, "%s %s -> %s %q\n", importer.createdLabel(), document.Source, post.ID, post.Title)
+		default:
+			report.Existing++
+			fmt.Fprintf(importer.out, "exists   %s -> %s\n", document.Source, post.ID)
+		}
+	}
+	return report, nil
+}
+
+func (importer *Importer) createdLabel() string {
+	if importer.options.DryRun {
+		return "create  "
+	}
+	return "created "
+}
+
+// PostPath is where the front end serves an imported post, which internal links are rewritten to.
+func PostPath(slug string) string {
+	return "/posts/" + PostID(slug)
+}
+
+// PostID derives an imported post's ID from its slug, in the same form as IDs the API assigns.
+func PostID(slug string) string {
+	sum := sha256.Sum256([]byte("import:" + slug))
+	return hex.EncodeToString(sum[:16])
+}
+
+var nonSlugCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify lowercases text and joins its runs of letters and digits with hyphens, which also makes a
+// valid tag label.
+func Slugify(text string) string {
+	return strings.Trim(nonSlugCharacters.ReplaceAllString(strings.ToLower(text), "-"), "-")
+}
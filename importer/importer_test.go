@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/neuralcoral/BlogService/api"
+	"github.com/neuralcoral/BlogService/dao/daotest"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore/objectstoretest"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFiles = fstest.MapFS{
+	"2019-hello.md":         {Data: []byte("---\ntitle: Hello, world\ndate: 2019-03-04T05:06:07Z\ntags: [Go, AWS Lambda, go]\nslug: hello-world\n---\n\nFirst post.\n")},
+	"drafts/notes.markdown": {Data: []byte("+++\ntitle = \"Notes\" # working title\ndate = 2020-01-02\ndraft = true\ntags = [\n  \"ideas\",\n]\n\n[params]\ntitle = \"ignored\"\n+++\nSome notes.\n")},
+	"trip/index.md":         {Data: []byte("---\r\ntitle: A trip\r\ndate: 2021-06-07 08:09:10\r\nstatus: published\r\n---\r\nWe went.\r\n")},
+	"README.txt":            {Data: []byte("not a post")},
+}
+
+func TestReadMarkdownDir_FrontMatter_ParsesDocuments(t *testing.T) {
+	documents, err := ReadMarkdownDir(testFiles)
+
+	assert.Nil(t, err)
+	assert.Len(t, documents, 3)
+	hello, notes, trip := documents[0], documents[1], documents[2]
+
+	assert.Equal(t, "hello-world", hello.Slug)
+	assert.Equal(t, "Hello, world", hello.Post.Title)
+	assert.Equal(t, model.Posted, hello.Post.Status)
+	assert.Equal(t, time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC), hello.Post.CreatedAt)
+	assert.Equal(t, []model.Tag{{Label: "go"}, {Label: "aws-lambda"}}, hello.Post.Tags)
+	assert.Equal(t, "First post.\n", hello.Post.Body)
+
+	assert.Equal(t, "notes", notes.Slug)
+	assert.Equal(t, "Notes", notes.Post.Title)
+	assert.Equal(t, model.Draft, notes.Post.Status)
+	assert.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), notes.Post.CreatedAt)
+	assert.Equal(t, []model.Tag{{Label: "ideas"}}, notes.Post.Tags)
+
+	assert.Equal(t, "trip", trip.Slug)
+	assert.Equal(t, model.Posted, trip.Post.Status)
+	assert.Equal(t, "We went.\n", trip.Post.Body)
+}
+
+func TestParseMarkdown_InvalidFrontMatter_ReturnsErr(t *testing.T) {
+	for name, content := range map[string]string{
+		"no front matter": "# Title\n",
+		"unclosed":        "---\ntitle: x\n",
+		"no date":         "---\ntitle: x\n---\nbody",
+		"bad date":        "+++\ntitle = \"x\"\ndate = \"yesterday\"\n+++\n",
+		"bad status":      "---\ntitle: x\ndate: 2020-01-01\nstatus: archived\n---\n",
+		"bad toml":        "+++\ntitle = \"x\ndate = 2020-01-01\n+++\n",
+	} {
+		_, err := ParseMarkdown("post.md", []byte(content))
+		assert.NotNil(t, err, name)
+	}
+}
+
+func setupImporter(dryRun bool) (*Importer, *daotest.PostMetadataDao, *objectstoretest.PostStore, *bytes.Buffer) {
+	postMetadataDao := daotest.NewPostMetadataDao()
+	postStore := objectstoretest.NewPostStore()
+	var out bytes.Buffer
+	postService := api.NewPostService(postMetadataDao, postStore, nil, nil, nil)
+	return NewImporter(postService, Options{DryRun: dryRun}, &out), postMetadataDao, postStore, &out
+}
+
+func TestRun_Rerun_SkipsImportedPosts(t *testing.T) {
+	documents, _ := ReadMarkdownDir(testFiles)
+	sut, postMetadataDao, postStore, _ := setupImporter(false)
+
+	first, err := sut.Run(context.Background(), documents)
+	second, rerunErr := sut.Run(context.Background(), documents)
+
+	assert.Nil(t, err)
+	assert.Nil(t, rerunErr)
+	assert.Equal(t, &Report{Created: 3}, first)
+	assert.Equal(t, &Report{Existing: 3}, second)
+	post := postMetadataDao.Posts[PostID("hello-world")]
+	assert.Equal(t, time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC), post.CreatedAt)
+	assert.Equal(t, post.CreatedAt, post.UpdatedAt)
+	assert.Equal(t, "First post.\n", postStore.Bodies[post.BodyUrl])
+}
+
+func TestRun_DryRun_WritesNothing(t *testing.T) {
+	documents, _ := ReadMarkdownDir(testFiles)
+	sut, postMetadataDao, postStore, out := setupImporter(true)
+
+	report, err := sut.Run(context.Background(), documents)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Created: 3}, report)
+	assert.Empty(t, postMetadataDao.Posts)
+	assert.Empty(t, postStore.Bodies)
+	assert.Contains(t, out.String(), "create   2019-hello.md -> "+PostID("hello-world")+` "Hello, world"`)
+}
+
+func TestRun_InvalidDocuments_ReportsAndContinues(t *testing.T) {
+	sut, postMetadataDao, _, out := setupImporter(false)
+	date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	documents := []*Document{
+		{Source: "a.md", Slug: "same", Post: model.Post{PostMetadata: model.PostMetadata{Title: "A", CreatedAt: date}}},
+		{Source: "b.md", Slug: "same", Post: model.Post{PostMetadata: model.PostMetadata{Title: "B", CreatedAt: date}}},
+		{Source: "c.md", Slug: "untitled", Post: model.Post{PostMetadata: model.PostMetadata{CreatedAt: date}}},
+		{Source: "d.md", Slug: "d", Post: model.Post{PostMetadata: model.PostMetadata{Title: "D", CreatedAt: date}}},
+	}
+
+	report, err := sut.Run(context.Background(), documents)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Created: 2, Invalid: 2}, report)
+	assert.Len(t, postMetadataDao.Posts, 2)
+	assert.Contains(t, out.String(), `invalid  b.md: slug "same" is also used by a.md`)
+	assert.Contains(t, out.String(), "invalid  c.md: ")
+}
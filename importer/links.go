@@ -0,0 +1,84 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// linkMap rewrites links to posts on the old blog to where the imported posts are served.
+type linkMap struct {
+	base    *url.URL
+	targets map[string]string
+}
+
+func newLinkMap(siteURL string) *linkMap {
+	base, err := url.Parse(siteURL)
+	if err != nil {
+		base = &url.URL{}
+	}
+	return &linkMap{base: base, targets: map[string]string{}}
+}
+
+func (links *linkMap) add(address string, target string) {
+	if key := links.key(address); key != "" {
+		links.targets[key] = target
+	}
+}
+
+// resolve returns the new address for a link to an imported post, keeping its fragment, and any
+// other link unchanged.
+func (links *linkMap) resolve(href string) string {
+	target, ok := links.targets[links.key(href)]
+	if !ok {
+		return href
+	}
+	if index := strings.IndexByte(href, '#'); index >= 0 {
+		target += href[index:]
+	}
+	return target
+}
+
+// key normalizes an address so that the forms a post is linked by compare equal: relative or
+// absolute, http or https, with or without www and a trailing slash. Only the query parameters
+// WordPress uses for post links are kept.
+func (links *linkMap) key(address string) string {
+	parsed, err := url.Parse(strings.TrimSpace(address))
+	if err != nil {
+		return ""
+	}
+	parsed = links.base.ResolveReference(parsed)
+	if parsed.Host == "" {
+		return ""
+	}
+
+	query := url.Values{}
+	for _, name := range []string{"p", "page_id"} {
+		if value := parsed.Query().Get(name); value != "" {
+			query.Set(name, value)
+		}
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	key := host + strings.TrimRight(parsed.EscapedPath(), "/")
+	if len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+	return key
+}
+
+// WriteMapping writes a CSV row for each document with its old address, slug and new post ID, for
+// setting up redirects.
+func WriteMapping(out io.Writer, documents []*Document) error {
+	writer := csv.NewWriter(out)
+	if err := writer.Write([]string{"original_url", "source", "slug", "post_id"}); err != nil {
+		return err
+	}
+	for _, document := range documents {
+		if err := writer.Write([]string{document.OriginalURL, document.Source, document.Slug, PostID(document.Slug)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
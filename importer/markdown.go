@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+	"gopkg.in/yaml.v3"
+)
+
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// ReadMarkdownDir reads every .md and .markdown file under the directory, in lexical order. A file
+// that can't be parsed fails the whole read, so nothing is imported until every file is fixed.
+func ReadMarkdownDir(dir fs.FS) ([]*Document, error) {
+	var documents []*Document
+	err := fs.WalkDir(dir, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		extension := strings.ToLower(path.Ext(name))
+		if entry.IsDir() || (extension != ".md" && extension != ".markdown") {
+			return nil
+		}
+
+		content, err := fs.ReadFile(dir, name)
+		if err != nil {
+			return err
+		}
+		document, err := ParseMarkdown(name, content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		documents = append(documents, document)
+		return nil
+	})
+	return documents, err
+}
+
+// ParseMarkdown reads a post from Markdown with YAML front matter between "---" lines or TOML
+// front matter between "+++" lines. The front matter gives the title, date, status (draft or
+// published; a Hugo-style draft flag also works), tags and slug, which defaults to the file name.
+// Posts without a status are published.
+func ParseMarkdown(name string, content []byte) (*Document, error) {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	var fields map[string]interface{}
+	var body string
+	switch {
+	case strings.HasPrefix(text, "---\n"):
+		header, rest, ok := splitFrontMatter(text, "---")
+		if !ok {
+			return nil, fmt.Errorf("front matter isn't closed with ---")
+		}
+		if err := yaml.Unmarshal([]byte(header), &fields); err != nil {
+			return nil, fmt.Errorf("front matter: %w", err)
+		}
+		body = rest
+	case strings.HasPrefix(text, "+++\n"):
+		header, rest, ok := splitFrontMatter(text, "+++")
+		if !ok {
+			return nil, fmt.Errorf("front matter isn't closed with +++")
+		}
+		var err error
+		if fields, err = parseTOML(header); err != nil {
+			return nil, fmt.Errorf("front matter: %w", err)
+		}
+		body = rest
+	default:
+		return nil, fmt.Errorf("no front matter")
+	}
+
+	return documentFromFields(name, fields, strings.TrimLeft(body, "\n"))
+}
+
+// splitFrontMatter splits text after the opening delimiter line at the closing one.
+func splitFrontMatter(text string, delimiter string) (string, string, bool) {
+	rest := text[len(delimiter)+1:]
+	if strings.HasPrefix(rest, delimiter+"\n") {
+		return "", rest[len(delimiter)+1:], true
+	}
+	end := strings.Index(rest, "\n"+delimiter+"\n")
+	if end < 0 {
+		if !strings.HasSuffix(rest, "\n"+delimiter) {
+			return "", "", false
+		}
+		return rest[:len(rest)-len(delimiter)-1], "", true
+	}
+	return rest[:end], rest[end+len(delimiter)+2:], true
+}
+
+func documentFromFields(name string, fields map[string]interface{}, body string) (*Document, error) {
+	title, err := stringField(fields, "title")
+	if err != nil {
+		return nil, err
+	}
+	slug, err := stringField(fields, "slug")
+	if err != nil {
+		return nil, err
+	}
+	if slug == "" {
+		slug = fileSlug(name)
+	}
+	date, err := dateField(fields, "date")
+	if err != nil {
+		return nil, err
+	}
+	status, err := statusField(fields)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := tagsField(fields, "tags")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{
+		Source: name,
+		Slug:   Slugify(slug),
+		Post: model.Post{
+			PostMetadata: model.PostMetadata{
+				Title:     strings.TrimSpace(title),
+				Status:    status,
+				CreatedAt: date,
+				UpdatedAt: date,
+				Tags:      tags,
+			},
+			Body: body,
+		},
+	}, nil
+}
+
+// fileSlug names a post after its file, or after its directory for page bundles such as
+// hello-world/index.md.
+func fileSlug(name string) string {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	if (base == "index" || base == "_index") && path.Dir(name) != "." {
+		return path.Base(path.Dir(name))
+	}
+	return base
+}
+
+func stringField(fields map[string]interface{}, key string) (string, error) {
+	switch value := fields[key].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case int, int64, float64, bool:
+		return fmt.Sprint(value), nil
+	default:
+		return "", fmt.Errorf("%s must be a string", key)
+	}
+}
+
+func dateField(fields map[string]interface{}, key string) (time.Time, error) {
+	switch value := fields[key].(type) {
+	case nil:
+		return time.Time{}, fmt.Errorf("%s is required", key)
+	case time.Time:
+		return value.UTC(), nil
+	case string:
+		for _, layout := range dateLayouts {
+			if date, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+				return date.UTC(), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("%s %q isn't a date", key, value)
+	default:
+		return time.Time{}, fmt.Errorf("%s must be a date", key)
+	}
+}
+
+func statusField(fields map[string]interface{}) (model.Status, error) {
+	status, err := stringField(fields, "status")
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "draft":
+		return model.Draft, nil
+	case "published", "publish", "posted":
+		return model.Posted, nil
+	case "":
+		if draft, _ := fields["draft"].(bool); draft {
+			return model.Draft, nil
+		}
+		return model.Posted, nil
+	default:
+		return "", fmt.Errorf("status %q isn't draft or published", status)
+	}
+}
+
+// tagsField accepts a list or a comma separated string.
+func tagsField(fields map[string]interface{}, key string) ([]model.Tag, error) {
+	var labels []string
+	switch value := fields[key].(type) {
+	case nil:
+	case string:
+		labels = strings.Split(value, ",")
+	case []interface{}:
+		for _, item := range value {
+			label, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be strings", key)
+			}
+			labels = append(labels, label)
+		}
+	default:
+		return nil, fmt.Errorf("%s must be a list", key)
+	}
+
+	return tagsFromLabels(labels), nil
+}
+
+// tagsFromLabels turns labels into valid tag labels, dropping empty and repeated ones.
+func tagsFromLabels(labels []string) []model.Tag {
+	var tags []model.Tag
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		label = Slugify(label)
+		if label != "" && !seen[label] {
+			seen[label] = true
+			tags = append(tags, model.Tag{Label: label})
+		}
+	}
+	return tags
+}
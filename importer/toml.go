@@ -0,0 +1,192 @@
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// parseTOML parses the part of TOML front matter the importer reads: top-level keys whose values
+// are strings, booleans, numbers, dates or arrays of those. Keys in tables such as [params] are
+// skipped. Dates are returned as strings.
+func parseTOML(text string) (map[string]interface{}, error) {
+	parser := &tomlParser{text: text}
+	fields := map[string]interface{}{}
+	inTable := false
+	for {
+		parser.skipSpaceAndComments(true)
+		if parser.done() {
+			return fields, nil
+		}
+		line := parser.line()
+
+		if parser.peek() == '[' {
+			end := strings.IndexByte(parser.text[parser.position:], '\n')
+			if end < 0 {
+				end = len(parser.text) - parser.position
+			}
+			parser.position += end
+			inTable = true
+			continue
+		}
+
+		key, err := parser.key()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		parser.skipSpaceAndComments(false)
+		if parser.peek() != '=' {
+			return nil, fmt.Errorf("line %d: expected = after %s", line, key)
+		}
+		parser.position++
+		parser.skipSpaceAndComments(false)
+		value, err := parser.value()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		parser.skipSpaceAndComments(false)
+		if !parser.done() && parser.peek() != '\n' {
+			return nil, fmt.Errorf("line %d: unexpected text after %s", line, key)
+		}
+		if !inTable {
+			fields[key] = value
+		}
+	}
+}
+
+type tomlParser struct {
+	text     string
+	position int
+}
+
+func (parser *tomlParser) done() bool {
+	return parser.position >= len(parser.text)
+}
+
+func (parser *tomlParser) peek() byte {
+	return parser.text[parser.position]
+}
+
+func (parser *tomlParser) line() int {
+	return strings.Count(parser.text[:parser.position], "\n") + 1
+}
+
+// skipSpaceAndComments skips spaces, tabs and comments, and newlines too when newlines is set.
+func (parser *tomlParser) skipSpaceAndComments(newlines bool) {
+	for !parser.done() {
+		switch character := parser.peek(); {
+		case character == ' ' || character == '\t' || (newlines && character == '\n'):
+			parser.position++
+		case character == '#':
+			for !parser.done() && parser.peek() != '\n' {
+				parser.position++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (parser *tomlParser) key() (string, error) {
+	if parser.peek() == '"' || parser.peek() == '\'' {
+		return parser.string()
+	}
+	start := parser.position
+	for !parser.done() {
+		character := rune(parser.peek())
+		if !unicode.IsLetter(character) && !unicode.IsDigit(character) && character != '_' && character != '-' {
+			break
+		}
+		parser.position++
+	}
+	if start == parser.position {
+		return "", fmt.Errorf("expected a key")
+	}
+	return parser.text[start:parser.position], nil
+}
+
+func (parser *tomlParser) value() (interface{}, error) {
+	if parser.done() {
+		return nil, fmt.Errorf("expected a value")
+	}
+	switch parser.peek() {
+	case '"', '\'':
+		return parser.string()
+	case '[':
+		return parser.array()
+	}
+
+	start := parser.position
+	for !parser.done() && !strings.ContainsRune(",]#\n", rune(parser.peek())) {
+		parser.position++
+	}
+	raw := strings.TrimSpace(parser.text[start:parser.position])
+	switch {
+	case raw == "true" || raw == "false":
+		return raw == "true", nil
+	case raw == "":
+		return nil, fmt.Errorf("expected a value")
+	}
+	if number, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, 64); err == nil {
+		return number, nil
+	}
+	if number, err := strconv.ParseFloat(strings.ReplaceAll(raw, "_", ""), 64); err == nil {
+		return number, nil
+	}
+	if raw[0] >= '0' && raw[0] <= '9' {
+		return raw, nil
+	}
+	return nil, fmt.Errorf("unsupported value %q", raw)
+}
+
+func (parser *tomlParser) array() ([]interface{}, error) {
+	parser.position++
+	values := []interface{}{}
+	for {
+		parser.skipSpaceAndComments(true)
+		if parser.done() {
+			return nil, fmt.Errorf("array isn't closed")
+		}
+		if parser.peek() == ']' {
+			parser.position++
+			return values, nil
+		}
+		value, err := parser.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		parser.skipSpaceAndComments(true)
+		if !parser.done() && parser.peek() == ',' {
+			parser.position++
+		}
+	}
+}
+
+// string reads a basic "..." string, whose escapes match Go's closely enough, or a literal '...'
+// string. Multi-line strings aren't supported.
+func (parser *tomlParser) string() (string, error) {
+	quote := parser.peek()
+	start := parser.position
+	parser.position++
+	for !parser.done() && parser.peek() != quote && parser.peek() != '\n' {
+		if quote == '"' && parser.peek() == '\\' {
+			parser.position++
+		}
+		parser.position++
+	}
+	if parser.done() || parser.peek() != quote {
+		return "", fmt.Errorf("string isn't closed")
+	}
+	parser.position++
+	raw := parser.text[start:parser.position]
+	if quote == '\'' {
+		return raw[1 : len(raw)-1], nil
+	}
+	value, err := strconv.Unquote(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", raw)
+	}
+	return value, nil
+}
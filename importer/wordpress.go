@@ -0,0 +1,196 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+)
+
+const wordPressDateLayout = "2006-01-02 15:04:05"
+
+var (
+	wordPressBlockTags = regexp.MustCompile(`(?i)<(p|div|h[1-6]|ul|ol|blockquote|pre|figure|table)[\s>]`)
+	blankLines         = regexp.MustCompile(`\n\s*\n`)
+)
+
+type wxrDocument struct {
+	Channel struct {
+		Links []string  `xml:"link"`
+		Items []wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        string        `xml:"guid"`
+	Encoded     []wxrEncoded  `xml:"encoded"`
+	PostID      string        `xml:"post_id"`
+	PostName    string        `xml:"post_name"`
+	PostDate    string        `xml:"post_date"`
+	PostDateGMT string        `xml:"post_date_gmt"`
+	Status      string        `xml:"status"`
+	PostType    string        `xml:"post_type"`
+	Categories  []wxrCategory `xml:"category"`
+}
+
+// wxrEncoded is content:encoded or excerpt:encoded, told apart by namespace.
+type wxrEncoded struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+// ReadWordPress reads the posts in a WordPress export (WXR) file. Pages, attachments and trashed
+// posts are skipped. Published posts are imported as published and every other status as a draft;
+// categories and tags both become tags. Links between posts are rewritten to the imported posts,
+// resolving relative links against siteURL, which defaults to the site address in the export.
+func ReadWordPress(in io.Reader, siteURL string) ([]*Document, error) {
+	decoder := xml.NewDecoder(in)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	var export wxrDocument
+	if err := decoder.Decode(&export); err != nil {
+		return nil, fmt.Errorf("read WordPress export: %w", err)
+	}
+	if siteURL == "" {
+		for _, link := range export.Channel.Links {
+			if strings.TrimSpace(link) != "" {
+				siteURL = strings.TrimSpace(link)
+				break
+			}
+		}
+	}
+
+	links := newLinkMap(siteURL)
+	var items []wxrItem
+	var documents []*Document
+	for _, item := range export.Channel.Items {
+		status, ok := wordPressStatus(item.Status)
+		if item.PostType != "post" || !ok {
+			continue
+		}
+		document, err := wordPressDocument(item, status)
+		if err != nil {
+			return nil, fmt.Errorf("WordPress post %s: %w", item.PostID, err)
+		}
+		target := PostPath(document.Slug)
+		links.add(item.Link, target)
+		links.add(item.GUID, target)
+		if item.PostID != "" {
+			links.add("/?p="+item.PostID, target)
+		}
+		items = append(items, item)
+		documents = append(documents, document)
+	}
+
+	for i, item := range items {
+		body, err := htmlToMarkdown(wordPressAutoParagraphs(wordPressContent(item)), links.resolve)
+		if err != nil {
+			return nil, fmt.Errorf("WordPress post %s: %w", item.PostID, err)
+		}
+		documents[i].Post.Body = body
+	}
+	return documents, nil
+}
+
+func wordPressDocument(item wxrItem, status model.Status) (*Document, error) {
+	date, err := wordPressDate(item)
+	if err != nil {
+		return nil, err
+	}
+	slug := Slugify(item.PostName)
+	if slug == "" {
+		slug = Slugify(item.Title)
+	}
+	if slug == "" {
+		slug = "wordpress-" + item.PostID
+	}
+
+	var labels []string
+	for _, category := range item.Categories {
+		if category.Domain != "category" && category.Domain != "post_tag" {
+			continue
+		}
+		label := category.Nicename
+		if label == "" {
+			label = category.Name
+		}
+		labels = append(labels, label)
+	}
+
+	return &Document{
+		Source:      "wordpress:" + item.PostID,
+		OriginalURL: strings.TrimSpace(item.Link),
+		Slug:        slug,
+		Post: model.Post{PostMetadata: model.PostMetadata{
+			Title:     strings.TrimSpace(item.Title),
+			Status:    status,
+			CreatedAt: date,
+			UpdatedAt: date,
+			Tags:      tagsFromLabels(labels),
+		}},
+	}, nil
+}
+
+// wordPressStatus maps a post status to a post status here, or reports false for posts that
+// shouldn't be imported.
+func wordPressStatus(status string) (model.Status, bool) {
+	switch status {
+	case "publish":
+		return model.Posted, true
+	case "draft", "pending", "private", "future":
+		return model.Draft, true
+	default:
+		return "", false
+	}
+}
+
+// wordPressDate prefers the UTC date, which drafts leave as zeros, over the site's local time.
+func wordPressDate(item wxrItem) (time.Time, error) {
+	for _, value := range []string{item.PostDateGMT, item.PostDate} {
+		if value == "" || strings.HasPrefix(value, "0000") {
+			continue
+		}
+		date, err := time.Parse(wordPressDateLayout, strings.TrimSpace(value))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("no date")
+}
+
+func wordPressContent(item wxrItem) string {
+	for _, encoded := range item.Encoded {
+		if strings.HasPrefix(encoded.XMLName.Space, "http://purl.org/rss/1.0/modules/content") {
+			return encoded.Value
+		}
+	}
+	return ""
+}
+
+// wordPressAutoParagraphs wraps blank-line separated text in paragraphs, as WordPress does when it
+// displays classic editor content stored without them.
+func wordPressAutoParagraphs(content string) string {
+	if wordPressBlockTags.MatchString(content) {
+		return content
+	}
+	var builder strings.Builder
+	for _, chunk := range blankLines.Split(strings.ReplaceAll(content, "\r\n", "\n"), -1) {
+		if chunk = strings.TrimSpace(chunk); chunk != "" {
+			builder.WriteString("<p>" + strings.ReplaceAll(chunk, "\n", "<br>\n") + "</p>\n")
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWXR = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:excerpt="http://wordpress.org/export/1.2/excerpt/"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<title>Old blog</title>
+	<link>https://old.example.com</link>
+	<item>
+		<title>Hello &amp; welcome</title>
+		<link>https://old.example.com/2019/03/hello/</link>
+		<guid isPermaLink="false">https://old.example.com/?p=12</guid>
+		<content:encoded><![CDATA[First line
+second line.
+
+See <a href="https://old.example.com/?p=13">the next post</a>.]]></content:encoded>
+		<excerpt:encoded><![CDATA[Not the body]]></excerpt:encoded>
+		<wp:post_id>12</wp:post_id>
+		<wp:post_date><![CDATA[2019-03-04 07:06:07]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[2019-03-04 05:06:07]]></wp:post_date_gmt>
+		<wp:post_name><![CDATA[hello]]></wp:post_name>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+		<category domain="category" nicename="news"><![CDATA[News]]></category>
+		<category domain="post_tag" nicename="aws-lambda"><![CDATA[AWS Lambda]]></category>
+		<category domain="post_format" nicename="post-format-aside"><![CDATA[Aside]]></category>
+	</item>
+	<item>
+		<title>Work in progress</title>
+		<link>https://old.example.com/?p=13</link>
+		<content:encoded><![CDATA[<!-- wp:paragraph --><p>Back to <a href="/2019/03/hello/#top">the first</a>.</p><!-- /wp:paragraph -->]]></content:encoded>
+		<wp:post_id>13</wp:post_id>
+		<wp:post_date><![CDATA[2020-01-02 10:00:00]]></wp:post_date>
+		<wp:post_date_gmt><![CDATA[0000-00-00 00:00:00]]></wp:post_date_gmt>
+		<wp:post_name><![CDATA[]]></wp:post_name>
+		<wp:status><![CDATA[draft]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+	<item>
+		<title>About</title>
+		<wp:post_id>2</wp:post_id>
+		<wp:status><![CDATA[publish]]></wp:status>
+		<wp:post_type><![CDATA[page]]></wp:post_type>
+	</item>
+	<item>
+		<title>Deleted</title>
+		<wp:post_id>14</wp:post_id>
+		<wp:status><![CDATA[trash]]></wp:status>
+		<wp:post_type><![CDATA[post]]></wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+func TestReadWordPress_Export_ConvertsPosts(t *testing.T) {
+	documents, err := ReadWordPress(strings.NewReader(testWXR), "")
+
+	assert.Nil(t, err)
+	assert.Len(t, documents, 2)
+	hello, draft := documents[0], documents[1]
+
+	assert.Equal(t, "hello", hello.Slug)
+	assert.Equal(t, "wordpress:12", hello.Source)
+	assert.Equal(t, "https://old.example.com/2019/03/hello/", hello.OriginalURL)
+	assert.Equal(t, "Hello & welcome", hello.Post.Title)
+	assert.Equal(t, model.Posted, hello.Post.Status)
+	assert.Equal(t, time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC), hello.Post.CreatedAt)
+	assert.Equal(t, []model.Tag{{Label: "news"}, {Label: "aws-lambda"}}, hello.Post.Tags)
+	assert.Equal(t, "First line\\\nsecond line.\n\nSee [the next post]("+PostPath("work-in-progress")+").\n", hello.Post.Body)
+
+	assert.Equal(t, "work-in-progress", draft.Slug)
+	assert.Equal(t, model.Draft, draft.Post.Status)
+	assert.Equal(t, time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC), draft.Post.CreatedAt)
+	assert.Equal(t, "Back to [the first]("+PostPath("hello")+"#top).\n", draft.Post.Body)
+}
+
+func TestWriteMapping_Documents_WritesCSV(t *testing.T) {
+	documents, _ := ReadWordPress(strings.NewReader(testWXR), "")
+	var out bytes.Buffer
+
+	err := WriteMapping(&out, documents)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "original_url,source,slug,post_id\n"+
+		"https://old.example.com/2019/03/hello/,wordpress:12,hello,"+PostID("hello")+"\n"+
+		"https://old.example.com/?p=13,wordpress:13,work-in-progress,"+PostID("work-in-progress")+"\n", out.String())
+}
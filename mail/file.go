@@ -0,0 +1,32 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// FileMailer writes messages to w instead of sending them, for local development where the links
+// are copied out of a file or the log.
+type FileMailer struct {
+	from   string
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func NewFileMailer(from string, writer io.Writer) *FileMailer {
+	return &FileMailer{from: from, writer: writer}
+}
+
+func (mailer *FileMailer) Send(ctx context.Context, message Message) error {
+	data, err := encode(mailer.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+	_, err = mailer.writer.Write(append(data, "\r\n"...))
+	return err
+}
@@ -0,0 +1,183 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTP accepts one message per connection without TLS or authentication and records it.
+type fakeSMTP struct {
+	mutex      sync.Mutex
+	from       string
+	recipients []string
+	data       string
+	listener   net.Listener
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fakeSMTP{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (server *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("220 fake ESMTP\r\n"))
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimRight(line, "\r\n")
+		server.mutex.Lock()
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			conn.Write([]byte("250 fake\r\n"))
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			server.from = strings.TrimPrefix(command, "MAIL FROM:")
+			conn.Write([]byte("250 OK\r\n"))
+		case strings.HasPrefix(command, "RCPT TO:"):
+			server.recipients = append(server.recipients, strings.TrimPrefix(command, "RCPT TO:"))
+			conn.Write([]byte("250 OK\r\n"))
+		case command == "DATA":
+			conn.Write([]byte("354 go ahead\r\n"))
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil || dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			server.data = data.String()
+			conn.Write([]byte("250 queued\r\n"))
+		case command == "QUIT":
+			conn.Write([]byte("221 bye\r\n"))
+			server.mutex.Unlock()
+			return
+		default:
+			conn.Write([]byte("502 unsupported\r\n"))
+		}
+		server.mutex.Unlock()
+	}
+}
+
+func TestSMTPMailer_Send_DeliversToRelay(t *testing.T) {
+	server := startFakeSMTP(t)
+	mailer := NewSMTPMailer(SMTPOptions{Address: server.listener.Addr().String(), From: "Blog <noreply@example.com>"})
+
+	err := mailer.Send(context.Background(), Message{To: "alice@example.com", Subject: "Reset your password", Body: "Follow the link."})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	assert.Equal(t, "<noreply@example.com>", server.from)
+	assert.Equal(t, []string{"<alice@example.com>"}, server.recipients)
+	assert.Contains(t, server.data, "Subject: Reset your password\r\n")
+	assert.Contains(t, server.data, "Follow the link.")
+}
+
+func TestFileMailer_Send_WritesParseableMessage(t *testing.T) {
+	var buffer bytes.Buffer
+	mailer := NewFileMailer("noreply@example.com", &buffer)
+
+	err := mailer.Send(context.Background(), Message{To: "alice@example.com", Subject: "Willkommen bei Blög", Body: "Line one\nLine two"})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := netmail.ReadMessage(&buffer)
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.Equal(t, "alice@example.com", parsed.Header.Get("To"))
+	assert.Equal(t, "Willkommen bei Blög", subject)
+}
+
+func TestFileMailer_SendWithHTML_WritesAlternativeParts(t *testing.T) {
+	var buffer bytes.Buffer
+	mailer := NewFileMailer("noreply@example.com", &buffer)
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "alice@example.com",
+		Subject: "New post",
+		Body:    "Plain text",
+		HTML:    "<p>Rich text</p>",
+		Headers: map[string]string{"list-unsubscribe": "<https://api.example.com/newsletter/unsubscribe?token=t>"},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := netmail.ReadMessage(&buffer)
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	assert.Equal(t, "<https://api.example.com/newsletter/unsubscribe?token=t>", parsed.Header.Get("List-Unsubscribe"))
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var contentTypes, contents []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		content, _ := io.ReadAll(part)
+		contentTypes = append(contentTypes, part.Header.Get("Content-Type"))
+		contents = append(contents, strings.TrimSpace(string(content)))
+	}
+	assert.Equal(t, []string{"text/plain; charset=utf-8", "text/html; charset=utf-8"}, contentTypes)
+	assert.Equal(t, []string{"Plain text", "<p>Rich text</p>"}, contents)
+}
+
+func TestSend_ReservedHeader_Fails(t *testing.T) {
+	mailer := NewFileMailer("noreply@example.com", &bytes.Buffer{})
+
+	err := mailer.Send(context.Background(), Message{To: "alice@example.com", Subject: "Hi", Headers: map[string]string{"Bcc": "everyone@example.com"}})
+
+	assert.NotNil(t, err)
+}
+
+func TestSend_HeaderInjection_Fails(t *testing.T) {
+	mailer := NewFileMailer("noreply@example.com", &bytes.Buffer{})
+
+	err := mailer.Send(context.Background(), Message{To: "alice@example.com\r\nBcc: everyone@example.com", Subject: "Hi"})
+
+	assert.ErrorIs(t, err, errHeaderInjection)
+}
@@ -0,0 +1,132 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message is an email with a plain text body and, optionally, an HTML alternative. Headers adds
+// headers such as List-Unsubscribe; they can't replace the ones encode writes.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	HTML    string
+	Headers map[string]string
+}
+
+// Mailer delivers messages. Implementations must not retry in a way that could send a link twice.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+var errHeaderInjection = errors.New("mail: header contains a line break")
+
+var reservedHeaders = map[string]bool{
+	"From": true, "To": true, "Cc": true, "Bcc": true, "Subject": true, "Date": true,
+	"Mime-Version": true, "Content-Type": true, "Content-Transfer-Encoding": true,
+}
+
+// encode renders the message as RFC 5322 text with quoted-printable UTF-8 parts, as
+// multipart/alternative when there is an HTML body.
+func encode(from string, message Message, date time.Time) ([]byte, error) {
+	values := []string{from, message.To, message.Subject}
+	for name, value := range message.Headers {
+		if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			return nil, fmt.Errorf("mail: header %s can't be set", name)
+		}
+		values = append(values, name, value)
+	}
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, errHeaderInjection
+		}
+	}
+	if _, err := netmail.ParseAddress(message.To); err != nil {
+		return nil, fmt.Errorf("mail: invalid recipient: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", from)
+	fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	names := make([]string, 0, len(message.Headers))
+	for name := range message.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&buffer, "%s: %s\r\n", textproto.CanonicalMIMEHeaderKey(name), message.Headers[name])
+	}
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+
+	if message.HTML == "" {
+		header := partHeader("text/plain")
+		for _, name := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			fmt.Fprintf(&buffer, "%s: %s\r\n", name, header.Get(name))
+		}
+		buffer.WriteString("\r\n")
+		if err := writeContent(&buffer, message.Body); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buffer)
+	fmt.Fprintf(&buffer, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	for _, part := range []struct{ contentType, content string }{{"text/plain", message.Body}, {"text/html", message.HTML}} {
+		writer, err := parts.CreatePart(partHeader(part.contentType))
+		if err != nil {
+			return nil, err
+		}
+		if err := writeContent(writer, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	buffer.WriteString("\r\n")
+	return buffer.Bytes(), nil
+}
+
+func partHeader(contentType string) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}
+}
+
+// writeContent writes content as quoted-printable with CRLF line endings.
+func writeContent(out io.Writer, content string) error {
+	writer := quotedprintable.NewWriter(out)
+	if _, err := writer.Write([]byte(strings.ReplaceAll(content, "\n", "\r\n"))); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, "\r\n")
+	return err
+}
+
+// envelopeAddress strips the display name from "Blog <noreply@example.com>".
+func envelopeAddress(address string) (string, error) {
+	parsed, err := netmail.ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return parsed.Address, nil
+}
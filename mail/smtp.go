@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+type SMTPOptions struct {
+	// Address is host:port of the relay.
+	Address  string
+	Username string
+	Password string
+	From     string
+	Timeout  time.Duration
+}
+
+// SMTPMailer sends each message over a new connection to a relay, upgrading to TLS when the relay
+// offers STARTTLS. PLAIN authentication is only attempted over TLS or to localhost.
+type SMTPMailer struct {
+	options SMTPOptions
+}
+
+func NewSMTPMailer(options SMTPOptions) *SMTPMailer {
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	return &SMTPMailer{options: options}
+}
+
+func (mailer *SMTPMailer) Send(ctx context.Context, message Message) error {
+	data, err := encode(mailer.options.From, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mailer.options.Timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", mailer.options.Address)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(mailer.options.Address)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer client.Close()
+
+	if err := mailer.deliver(client, host, message.To, data); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return client.Quit()
+}
+
+func (mailer *SMTPMailer) deliver(client *smtp.Client, host string, to string, data []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if mailer.options.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", mailer.options.Username, mailer.options.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	from, err := envelopeAddress(mailer.options.From)
+	if err != nil {
+		return err
+	}
+	recipient, err := envelopeAddress(to)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(recipient); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -2,15 +2,208 @@ package main
 
 import (
 	"context"
+	"log"
+	"net/http"
+	"time"
 
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/neuralcoral/BlogService/api"
+	"github.com/neuralcoral/BlogService/audit"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/bootstrap"
+	"github.com/neuralcoral/BlogService/cache"
+	"github.com/neuralcoral/BlogService/config"
+	"github.com/neuralcoral/BlogService/controller"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore"
+	"github.com/neuralcoral/BlogService/oidc"
+	"github.com/neuralcoral/BlogService/ratelimit"
+	"github.com/neuralcoral/BlogService/webhook"
 )
 
-func handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	return events.APIGatewayProxyResponse{}, nil
+// webhookTimeout bounds each delivery attempt; test events are sent while the API request waits.
+const webhookTimeout = 10 * time.Second
+
+func newRouter(environment *bootstrap.Environment) (*controller.Router, error) {
+	cfg := environment.Config
+	dynamoDBClient := environment.DynamoDBClient()
+	s3Client := environment.S3Client()
+
+	var postMetadataDao dao.PostMetadataDao = dao.NewPostMetadataDdbDao(dynamoDBClient, cfg.PostTableName)
+	var postStore objectstore.PostObjectStore = objectstore.NewPostS3ObjectStore(s3Client, cfg.ContentBucket)
+	if cfg.CacheTTL > 0 {
+		var remote cache.Remote
+		if cfg.RemoteCacheAddress != "" {
+			remote = cache.NewRedisCache(cache.RedisOptions{Address: cfg.RemoteCacheAddress})
+		}
+		postMetadataDao = dao.NewCachedPostMetadataDao(postMetadataDao, cfg.CacheSize, cfg.CacheTTL, remote)
+		postStore = objectstore.NewCachedPostObjectStore(postStore, cfg.CacheSize, cfg.CacheTTL, remote)
+	}
+	userDao := dao.NewUserDdbDao(dynamoDBClient, cfg.UserTableName)
+	sessionDao := dao.NewSessionDdbDao(dynamoDBClient, cfg.SessionTableName)
+	apiKeyDao := dao.NewAPIKeyDdbDao(dynamoDBClient, cfg.APIKeyTableName)
+	accountTokenDao := dao.NewAccountTokenDdbDao(dynamoDBClient, cfg.AccountTokenTableName)
+	auditDao := dao.NewAuditDdbDao(dynamoDBClient, cfg.AuditTableName)
+	auditor := audit.NewRecorder(auditDao, cfg.AuditRetention)
+	tokenIssuer := auth.NewTokenIssuer(cfg.JWTSigningKey, cfg.JWTIssuer, cfg.AccessTokenTTL)
+
+	limit := func(policyName string, handler controller.HandlerFunc) controller.HandlerFunc {
+		return handler
+	}
+	var lockout api.LoginLockout
+	if cfg.RateLimitTableName != "" {
+		policies, err := ratelimit.ParsePolicies(append(config.Default().RateLimits, cfg.RateLimits...))
+		if err != nil {
+			return nil, err
+		}
+		rateLimitDao := dao.NewRateLimitDdbDao(dynamoDBClient, cfg.RateLimitTableName)
+		limiter := ratelimit.NewLimiter(rateLimitDao)
+		lockout = ratelimit.NewLockout(rateLimitDao, ratelimit.DefaultLockoutPolicy)
+		limit = func(policyName string, handler controller.HandlerFunc) controller.HandlerFunc {
+			if policy, ok := policies[policyName]; ok {
+				return controller.RateLimit(limiter, policy, handler)
+			}
+			return handler
+		}
+	}
+
+	var mediaAssetDao dao.MediaAssetDao
+	if cfg.FeatureEnabled("media") {
+		mediaAssetDao = dao.NewMediaAssetDdbDao(dynamoDBClient, cfg.MediaTableName)
+	}
+	postController := controller.NewPostController(api.NewPostService(postMetadataDao, postStore, userDao, mediaAssetDao, auditor), cfg.MaxPageSize)
+	secretBox, err := auth.NewSecretBox(cfg.TOTPEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	requiredRoles := make([]model.Role, 0, len(cfg.TwoFactorRequiredRoles))
+	for _, role := range cfg.TwoFactorRequiredRoles {
+		requiredRoles = append(requiredRoles, model.Role(role))
+	}
+	twoFactorService := api.NewTwoFactorService(userDao, sessionDao, accountTokenDao, secretBox, auditor, api.TwoFactorOptions{
+		Issuer:        cfg.TOTPIssuer,
+		RequiredRoles: requiredRoles,
+	})
+	twoFactorController := controller.NewTwoFactorController(twoFactorService)
+	loginService := api.NewLoginService(userDao, sessionDao, tokenIssuer, lockout, twoFactorService, auditor, cfg.RefreshTokenTTL)
+	loginController := controller.NewLoginController(loginService)
+	apiKeyService := api.NewAPIKeyService(apiKeyDao, auditor)
+	apiKeyController := controller.NewAPIKeyController(apiKeyService)
+	mailer, err := environment.Mailer()
+	if err != nil {
+		return nil, err
+	}
+	accountController := controller.NewAccountController(api.NewAccountService(userDao, accountTokenDao, loginService, mailer, auditor, api.AccountOptions{
+		LinkBaseURL:      cfg.AppBaseURL,
+		InvitationTTL:    cfg.InvitationTTL,
+		PasswordResetTTL: cfg.PasswordResetTTL,
+	}))
+
+	auditController := controller.NewAuditController(api.NewAuditService(auditDao, userDao), cfg.MaxPageSize)
+
+	router := controller.NewRouter(tokenIssuer, loginService, apiKeyService)
+	router.Handle(http.MethodPost, "/login", limit("login", loginController.Login))
+	router.Handle(http.MethodPost, "/login/two-factor", limit("login", loginController.CompleteTwoFactorLogin))
+	if cfg.OIDCIssuer != "" {
+		singleSignOnController, err := newSingleSignOnController(cfg, loginService, userDao, accountTokenDao, auditor)
+		if err != nil {
+			return nil, err
+		}
+		router.Handle(http.MethodPost, "/login/sso", limit("login", singleSignOnController.Start))
+		router.Handle(http.MethodPost, "/login/sso/callback", limit("login", singleSignOnController.Callback))
+		router.Handle(http.MethodPost, "/login/sso/link", controller.RequireUserSession(limit("login", singleSignOnController.StartLink)))
+	}
+	router.Handle(http.MethodPost, "/token/refresh", limit("login", loginController.Refresh))
+	router.Handle(http.MethodPost, "/logout", controller.AllowTwoFactorPending(loginController.Logout))
+	router.Handle(http.MethodPost, "/logout/all", controller.AllowTwoFactorPending(loginController.LogoutAll))
+	router.Handle(http.MethodPost, "/two-factor/enrolment", controller.AllowTwoFactorPending(twoFactorController.BeginEnrolment))
+	router.Handle(http.MethodPost, "/two-factor/enrolment/confirm", controller.AllowTwoFactorPending(limit("login", twoFactorController.ConfirmEnrolment)))
+	router.Handle(http.MethodPost, "/two-factor/disable", controller.RequireUserSession(limit("login", twoFactorController.Disable)))
+	router.Handle(http.MethodPost, "/invitations", controller.RequireUserSession(limit("write", accountController.CreateInvitation)))
+	router.Handle(http.MethodPost, "/invitations/verify", limit("invitation", accountController.VerifyInvitation))
+	router.Handle(http.MethodPost, "/invitations/accept", limit("invitation", accountController.AcceptInvitation))
+	router.Handle(http.MethodPost, "/password-reset", limit("password-reset", accountController.RequestPasswordReset))
+	router.Handle(http.MethodPost, "/password-reset/verify", limit("password-reset", accountController.VerifyPasswordReset))
+	router.Handle(http.MethodPost, "/password-reset/complete", limit("password-reset", accountController.ResetPassword))
+	router.Handle(http.MethodPost, "/api-keys", controller.RequireUserSession(apiKeyController.CreateAPIKey))
+	router.Handle(http.MethodGet, "/api-keys", controller.RequireUserSession(apiKeyController.ListAPIKeys))
+	router.Handle(http.MethodDelete, "/api-keys/{id}", controller.RequireUserSession(apiKeyController.RevokeAPIKey))
+	router.Handle(http.MethodGet, "/audit-events", controller.RequireUserSession(auditController.ListAuditEvents))
+	router.Handle(http.MethodGet, "/posts", controller.RequireScope(auth.ScopePostsRead, postController.ListPosts))
+	router.Handle(http.MethodPost, "/posts", controller.RequireScope(auth.ScopePostsWrite, limit("write", postController.CreatePost)))
+	router.Handle(http.MethodGet, "/posts/{id}", controller.RequireScope(auth.ScopePostsRead, postController.ReadPost))
+	router.Handle(http.MethodPut, "/posts/{id}", controller.RequireScope(auth.ScopePostsWrite, limit("write", postController.UpdatePost)))
+
+	if cfg.FeatureEnabled("media") {
+		mediaStore := objectstore.NewMediaS3ObjectStore(s3Client, s3.NewPresignClient(s3Client), cfg.ContentBucket)
+		mediaController := controller.NewMediaController(api.NewMediaService(mediaAssetDao, mediaStore, auditor, cfg.MaxMediaUploadBytes), cfg.MaxPageSize)
+
+		router.Handle(http.MethodPost, "/media/uploads", controller.RequireScope(auth.ScopeMediaWrite, limit("write", mediaController.CreateMediaUpload)))
+		router.Handle(http.MethodGet, "/media", controller.RequireScope(auth.ScopeMediaWrite, mediaController.ListMediaAssets))
+		router.Handle(http.MethodDelete, "/media/{id}", controller.RequireScope(auth.ScopeMediaWrite, limit("write", mediaController.DeleteMediaAsset)))
+	}
+
+	if cfg.FeatureEnabled("newsletter") {
+		subscriberDao := dao.NewSubscriberDdbDao(dynamoDBClient, cfg.SubscriberTableName)
+		newsletterController := controller.NewNewsletterController(api.NewNewsletterService(subscriberDao, accountTokenDao, mailer, secretBox, api.NewsletterOptions{
+			LinkBaseURL:     cfg.AppBaseURL,
+			ConfirmationTTL: cfg.NewsletterConfirmationTTL,
+		}))
+
+		router.Handle(http.MethodPost, "/newsletter/subscriptions", limit("newsletter", newsletterController.Subscribe))
+		router.Handle(http.MethodPost, "/newsletter/confirm", limit("newsletter", newsletterController.Confirm))
+		router.Handle(http.MethodPost, "/newsletter/unsubscribe", limit("unsubscribe", newsletterController.Unsubscribe))
+	}
+
+	if cfg.FeatureEnabled("webhooks") {
+		webhookDao := dao.NewWebhookDdbDao(dynamoDBClient, cfg.WebhookTableName)
+		deliveryDao := dao.NewWebhookDeliveryDdbDao(dynamoDBClient, cfg.WebhookDeliveryTableName)
+		dispatcher := webhook.NewDispatcher(webhookDao, deliveryDao, secretBox, webhook.NewHTTPClient(webhookTimeout), webhook.DefaultRetryPolicy)
+		webhookService := api.NewWebhookService(webhookDao, deliveryDao, userDao, dispatcher, secretBox, auditor, api.URLPolicy{Schemes: []string{"https"}})
+		webhookController := controller.NewWebhookController(webhookService, cfg.MaxPageSize)
+
+		router.Handle(http.MethodPost, "/webhooks", controller.RequireUserSession(limit("write", webhookController.CreateWebhook)))
+		router.Handle(http.MethodGet, "/webhooks", controller.RequireUserSession(webhookController.ListWebhooks))
+		router.Handle(http.MethodPut, "/webhooks/{id}", controller.RequireUserSession(limit("write", webhookController.UpdateWebhook)))
+		router.Handle(http.MethodDelete, "/webhooks/{id}", controller.RequireUserSession(limit("write", webhookController.DeleteWebhook)))
+		router.Handle(http.MethodPost, "/webhooks/{id}/secret", controller.RequireUserSession(limit("write", webhookController.RotateSecret)))
+		router.Handle(http.MethodGet, "/webhooks/{id}/deliveries", controller.RequireUserSession(webhookController.ListDeliveries))
+		router.Handle(http.MethodPost, "/webhooks/{id}/test", controller.RequireUserSession(limit("write", webhookController.SendTestEvent)))
+	}
+
+	return router, nil
+}
+
+func newSingleSignOnController(cfg *config.Config, loginService *api.LoginService, userDao dao.UserDao, accountTokenDao dao.AccountTokenDao, auditor api.Auditor) (*controller.SingleSignOnController, error) {
+	roleMappings, err := api.ParseRoleMappings(cfg.OIDCRoleMappings)
+	if err != nil {
+		return nil, err
+	}
+	provider := oidc.NewClient(oidc.Config{
+		Issuer:       cfg.OIDCIssuer,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+	})
+	return controller.NewSingleSignOnController(api.NewSingleSignOnService(loginService, provider, userDao, accountTokenDao, auditor, api.SingleSignOnOptions{
+		GroupsClaim:  cfg.OIDCGroupsClaim,
+		RoleMappings: roleMappings,
+		DefaultRole:  model.Role(cfg.OIDCDefaultRole),
+	})), nil
 }
 
 func main() {
-	lambda.Start(handler)
+	environment, err := bootstrap.Load(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	router, err := newRouter(environment)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lambda.Start(router.ServeAPIGateway)
 }
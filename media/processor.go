@@ -0,0 +1,143 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+const (
+	mediaKeyPrefix    = "media/"
+	originalKeySuffix = "/original"
+)
+
+type Processor struct {
+	mediaAssetDao dao.MediaAssetDao
+	mediaStore    objectstore.MediaObjectStore
+	variants      []VariantSpec
+}
+
+func NewProcessor(mediaAssetDao dao.MediaAssetDao, mediaStore objectstore.MediaObjectStore, variants []VariantSpec) *Processor {
+	return &Processor{
+		mediaAssetDao: mediaAssetDao,
+		mediaStore:    mediaStore,
+		variants:      variants,
+	}
+}
+
+// HandleS3Event is the Lambda entry point for ObjectCreated notifications on the media bucket.
+// Every record is attempted; the returned error joins the failures so Lambda retries the event.
+// Only failures a retry can fix are returned; see ProcessAsset.
+func (processor *Processor) HandleS3Event(ctx context.Context, event events.S3Event) error {
+	var errs []error
+	for _, record := range event.Records {
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("decode key %q: %w", record.S3.Object.Key, err))
+			continue
+		}
+
+		id, ok := assetIDFromKey(key)
+		if !ok {
+			continue
+		}
+
+		if err := processor.ProcessAsset(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("process asset %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProcessAsset renders and records the variants of an uploaded original. A file that isn't a
+// supported image marks the asset failed rather than returning an error, and an asset deleted
+// before or during processing is skipped, since retrying the event can't change either outcome.
+func (processor *Processor) ProcessAsset(ctx context.Context, id string) error {
+	asset, err := processor.mediaAssetDao.GetMediaAsset(ctx, id)
+	if err != nil {
+		return err
+	}
+	// The upload may outlive its record if the asset was deleted before the event arrived.
+	if asset == nil {
+		return nil
+	}
+
+	original, err := processor.mediaStore.GetMedia(ctx, asset.Key)
+	if apperror.KindOf(err) == apperror.KindNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer original.Close()
+
+	rendered, err := GenerateVariants(original, processor.variants)
+	if errors.Is(err, ErrUnsupportedImage) || errors.Is(err, ErrImageTooLarge) {
+		return ignoreNotFound(processor.mediaAssetDao.MarkMediaAssetFailed(ctx, asset.ID, err.Error()))
+	}
+	if err != nil {
+		return err
+	}
+
+	variants := make(map[string]model.MediaVariant, len(rendered))
+	for _, variant := range rendered {
+		key := VariantKey(asset.ID, variant.Name, variant.Extension)
+		if err := processor.mediaStore.PutMedia(ctx, key, variant.MimeType, variant.Body); err != nil {
+			return err
+		}
+		variants[variant.Name] = model.MediaVariant{
+			Key:      key,
+			MimeType: variant.MimeType,
+			Width:    variant.Width,
+			Height:   variant.Height,
+		}
+	}
+
+	err = processor.mediaAssetDao.UpdateMediaAssetVariants(ctx, asset.ID, variants)
+	if apperror.KindOf(err) == apperror.KindNotFound {
+		// The asset was deleted while its variants were rendered, so nothing else will remove them.
+		for _, variant := range variants {
+			if err := processor.mediaStore.DeleteMedia(ctx, variant.Key); err != nil {
+				log.Printf("media: delete variant %s of deleted asset: %v", variant.Key, err)
+			}
+		}
+		return nil
+	}
+	return err
+}
+
+func ignoreNotFound(err error) error {
+	if apperror.KindOf(err) == apperror.KindNotFound {
+		return nil
+	}
+	return err
+}
+
+func OriginalKey(id string) string {
+	return mediaKeyPrefix + id + originalKeySuffix
+}
+
+// VariantKey places variants outside the original key suffix so writing them doesn't retrigger the handler.
+func VariantKey(id string, name string, extension string) string {
+	return fmt.Sprintf("%s%s/variants/%s.%s", mediaKeyPrefix, id, name, extension)
+}
+
+func assetIDFromKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, mediaKeyPrefix) || !strings.HasSuffix(key, originalKeySuffix) {
+		return "", false
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(key, mediaKeyPrefix), originalKeySuffix)
+	if id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
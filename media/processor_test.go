@@ -0,0 +1,124 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/dao/daotest"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore/objectstoretest"
+	"github.com/stretchr/testify/assert"
+)
+
+var testVariants = []VariantSpec{
+	{Name: "thumbnail", MaxWidth: 20, Format: FormatSource},
+	{Name: "webp", MaxWidth: 40, Format: FormatWebP},
+}
+
+// deletingMediaAssetDao deletes the asset as soon as it has been read, like a user deleting it while
+// its variants are rendered.
+type deletingMediaAssetDao struct {
+	*daotest.MediaAssetDao
+}
+
+func (dao *deletingMediaAssetDao) GetMediaAsset(ctx context.Context, id string) (*model.MediaAsset, error) {
+	asset, err := dao.MediaAssetDao.GetMediaAsset(ctx, id)
+	delete(dao.Assets, id)
+	return asset, err
+}
+
+func setupProcessor(t *testing.T, original []byte) (*Processor, *daotest.MediaAssetDao, *objectstoretest.MediaStore) {
+	t.Helper()
+	mediaAssetDao := daotest.NewMediaAssetDao(&model.MediaAsset{ID: "asset1", OwnerID: "user1", Key: OriginalKey("asset1")})
+	mediaStore := objectstoretest.NewMediaStore()
+	mediaStore.Objects[OriginalKey("asset1")] = original
+	return NewProcessor(mediaAssetDao, mediaStore, testVariants), mediaAssetDao, mediaStore
+}
+
+func s3Event(keys ...string) events.S3Event {
+	var event events.S3Event
+	for _, key := range keys {
+		record := events.S3EventRecord{}
+		record.S3.Object.Key = key
+		event.Records = append(event.Records, record)
+	}
+	return event
+}
+
+func TestProcessAsset_Image_StoresAndRecordsVariants(t *testing.T) {
+	sut, mediaAssetDao, mediaStore := setupProcessor(t, encodeTestPNG(t, 80, 40))
+
+	err := sut.ProcessAsset(context.Background(), "asset1")
+
+	assert.Nil(t, err)
+	variants := mediaAssetDao.Assets["asset1"].Variants
+	assert.Equal(t, model.MediaVariant{Key: "media/asset1/variants/thumbnail.png", MimeType: "image/png", Width: 20, Height: 10}, variants["thumbnail"])
+	assert.Equal(t, "image/webp", variants["webp"].MimeType)
+	assert.Equal(t, "image/webp", mediaStore.Types["media/asset1/variants/webp.webp"])
+	assert.Empty(t, mediaAssetDao.Assets["asset1"].ProcessingError)
+}
+
+func TestProcessAsset_NotAnImage_MarksAssetFailedWithoutErr(t *testing.T) {
+	sut, mediaAssetDao, mediaStore := setupProcessor(t, []byte("%PDF-1.7"))
+
+	err := sut.ProcessAsset(context.Background(), "asset1")
+
+	assert.Nil(t, err)
+	assert.Contains(t, mediaAssetDao.Assets["asset1"].ProcessingError, ErrUnsupportedImage.Error())
+	assert.Empty(t, mediaAssetDao.Assets["asset1"].Variants)
+	assert.Len(t, mediaStore.Objects, 1)
+}
+
+func TestProcessAsset_DeletedDuringProcessing_RemovesVariantsWithoutErr(t *testing.T) {
+	_, mediaAssetDao, mediaStore := setupProcessor(t, encodeTestPNG(t, 80, 40))
+	sut := NewProcessor(&deletingMediaAssetDao{mediaAssetDao}, mediaStore, testVariants)
+
+	err := sut.ProcessAsset(context.Background(), "asset1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{OriginalKey("asset1")}, keys(mediaStore.Objects))
+}
+
+func TestProcessAsset_OriginalDeleted_SkipsWithoutErr(t *testing.T) {
+	sut, mediaAssetDao, mediaStore := setupProcessor(t, nil)
+	delete(mediaStore.Objects, OriginalKey("asset1"))
+
+	err := sut.ProcessAsset(context.Background(), "asset1")
+
+	assert.Nil(t, err)
+	assert.Empty(t, mediaAssetDao.Assets["asset1"].Variants)
+}
+
+func TestProcessAsset_StoreFailure_ReturnsErrForRetry(t *testing.T) {
+	sut, _, mediaStore := setupProcessor(t, encodeTestPNG(t, 80, 40))
+	mediaStore.Err = errors.New("s3 unavailable")
+
+	err := sut.ProcessAsset(context.Background(), "asset1")
+
+	assert.ErrorIs(t, err, mediaStore.Err)
+}
+
+func TestHandleS3Event_ProcessesOriginalsOnly(t *testing.T) {
+	sut, mediaAssetDao, mediaStore := setupProcessor(t, encodeTestPNG(t, 80, 40))
+
+	err := sut.HandleS3Event(context.Background(), s3Event(
+		"media/asset1/variants/thumbnail.png",
+		"posts/post1/body.md",
+		"media%2Fmissing%2Foriginal",
+		"media%2Fasset1%2Foriginal",
+	))
+
+	assert.Nil(t, err)
+	assert.Len(t, mediaAssetDao.Assets["asset1"].Variants, 2)
+	assert.Len(t, mediaStore.Objects, 3)
+}
+
+func keys(objects map[string][]byte) []string {
+	var result []string
+	for key := range objects {
+		result = append(result, key)
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+type Format string
+
+const (
+	// FormatSource keeps PNG and GIF sources lossless and re-encodes everything else as JPEG.
+	FormatSource Format = "source"
+	FormatWebP   Format = "webp"
+)
+
+const (
+	MaxSourcePixels = 50_000_000
+	jpegQuality     = 82
+)
+
+var (
+	ErrImageTooLarge = errors.New("image exceeds maximum pixel count")
+	// ErrUnsupportedImage reports a source that isn't an image in a supported format, or is corrupt.
+	ErrUnsupportedImage = errors.New("unsupported or corrupt image")
+)
+
+type VariantSpec struct {
+	Name     string
+	MaxWidth int
+	Format   Format
+}
+
+var DefaultVariants = []VariantSpec{
+	{Name: "thumbnail", MaxWidth: 320, Format: FormatSource},
+	{Name: "medium", MaxWidth: 800, Format: FormatSource},
+	{Name: "large", MaxWidth: 1600, Format: FormatSource},
+	{Name: "webp", MaxWidth: 1600, Format: FormatWebP},
+}
+
+type RenderedVariant struct {
+	Name      string
+	MimeType  string
+	Extension string
+	Width     int
+	Height    int
+	Body      []byte
+}
+
+// GenerateVariants decodes the source image once and renders every spec from it. Images are only
+// ever scaled down, so a spec wider than the source is rendered at the source size.
+func GenerateVariants(source io.Reader, specs []VariantSpec) ([]*RenderedVariant, error) {
+	data, err := io.ReadAll(source)
+	if err != nil {
+		return nil, err
+	}
+
+	config, sourceFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if config.Width*config.Height > MaxSourcePixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+
+	var result []*RenderedVariant
+	for _, spec := range specs {
+		resized := resize(img, spec.MaxWidth)
+		variant, err := encode(resized, spec, sourceFormat)
+		if err != nil {
+			return nil, fmt.Errorf("render %s variant: %w", spec.Name, err)
+		}
+		result = append(result, variant)
+	}
+	return result, nil
+}
+
+func resize(img image.Image, maxWidth int) image.Image {
+	bounds := img.Bounds()
+	if bounds.Dx() <= maxWidth {
+		return img
+	}
+
+	height := bounds.Dy() * maxWidth / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	resized := image.NewNRGBA(image.Rect(0, 0, maxWidth, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), img, bounds, draw.Src, nil)
+	return resized
+}
+
+func encode(img image.Image, spec VariantSpec, sourceFormat string) (*RenderedVariant, error) {
+	variant := &RenderedVariant{
+		Name:   spec.Name,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+	}
+
+	var buffer bytes.Buffer
+	var err error
+	switch {
+	case spec.Format == FormatWebP:
+		variant.MimeType, variant.Extension = "image/webp", "webp"
+		err = nativewebp.Encode(&buffer, img, nil)
+	case sourceFormat == "png" || sourceFormat == "gif":
+		variant.MimeType, variant.Extension = "image/png", "png"
+		err = png.Encode(&buffer, img)
+	default:
+		variant.MimeType, variant.Extension = "image/jpeg", "jpg"
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	variant.Body = buffer.Bytes()
+	return variant, nil
+}
@@ -0,0 +1,72 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeTestPNG(t testing.TB, width int, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestGenerateVariants_ScalesDownAndKeepsAspectRatio(t *testing.T) {
+	source := encodeTestPNG(t, 1000, 500)
+	specs := []VariantSpec{
+		{Name: "thumbnail", MaxWidth: 200, Format: FormatSource},
+		{Name: "large", MaxWidth: 1600, Format: FormatSource},
+		{Name: "webp", MaxWidth: 400, Format: FormatWebP},
+	}
+
+	result, err := GenerateVariants(bytes.NewReader(source), specs)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Len(t, result, 3)
+
+	assert.Equal(t, "image/png", result[0].MimeType)
+	assert.Equal(t, 200, result[0].Width)
+	assert.Equal(t, 100, result[0].Height)
+
+	assert.Equal(t, 1000, result[1].Width, "variants must never be upscaled")
+	assert.Equal(t, 500, result[1].Height)
+
+	assert.Equal(t, "image/webp", result[2].MimeType)
+	assert.Equal(t, "webp", result[2].Extension)
+	assert.Equal(t, 400, result[2].Width)
+	assert.NotEmpty(t, result[2].Body)
+}
+
+func TestGenerateVariants_NotAnImage_ReturnsErr(t *testing.T) {
+	_, err := GenerateVariants(bytes.NewReader([]byte("not an image")), DefaultVariants)
+
+	assert.ErrorIs(t, err, ErrUnsupportedImage)
+}
+
+func TestAssetIDFromKey(t *testing.T) {
+	id, ok := assetIDFromKey(OriginalKey("abc123"))
+	assert.True(t, ok)
+	assert.Equal(t, "abc123", id)
+
+	_, ok = assetIDFromKey(VariantKey("abc123", "thumbnail", "jpg"))
+	assert.False(t, ok, "variant keys must not be processed again")
+
+	_, ok = assetIDFromKey("posts/abc123/original")
+	assert.False(t, ok)
+}
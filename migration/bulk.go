@@ -0,0 +1,249 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/sync/errgroup"
+)
+
+type ScanAPI interface {
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+type BulkOptions struct {
+	TableName string
+	Segments  int
+	PageSize  int
+	DryRun    bool
+}
+
+type Report struct {
+	Scanned   int64
+	Migrated  int64
+	Conflicts int64
+}
+
+// BulkMigrator migrates a whole table with a parallel Scan. Progress is checkpointed after every
+// page, so an interrupted run resumes where each segment left off.
+type BulkMigrator struct {
+	client      ScanAPI
+	runner      *Runner
+	checkpoints CheckpointStore
+	options     BulkOptions
+	out         io.Writer
+
+	mutex      sync.Mutex
+	checkpoint *Checkpoint
+	report     Report
+}
+
+func NewBulkMigrator(client ScanAPI, runner *Runner, checkpoints CheckpointStore, options BulkOptions, out io.Writer) *BulkMigrator {
+	return &BulkMigrator{
+		client:      client,
+		runner:      runner,
+		checkpoints: checkpoints,
+		options:     options,
+		out:         out,
+	}
+}
+
+func (migrator *BulkMigrator) Run(ctx context.Context) (*Report, error) {
+	checkpoint, err := migrator.loadCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	migrator.checkpoint = checkpoint
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	for segment := 0; segment < migrator.options.Segments; segment++ {
+		segmentCheckpoint := checkpoint.Segments[segment]
+		if segmentCheckpoint.Done {
+			continue
+		}
+		group.Go(func() error {
+			return migrator.runSegment(groupCtx, segment, segmentCheckpoint.LastEvaluatedKey)
+		})
+	}
+	err = group.Wait()
+
+	report := Report{
+		Scanned:   atomic.LoadInt64(&migrator.report.Scanned),
+		Migrated:  atomic.LoadInt64(&migrator.report.Migrated),
+		Conflicts: atomic.LoadInt64(&migrator.report.Conflicts),
+	}
+	return &report, err
+}
+
+func (migrator *BulkMigrator) loadCheckpoint() (*Checkpoint, error) {
+	checkpoint, err := migrator.checkpoints.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint: %w", err)
+	}
+	// Dry runs always start from the beginning and never advance the real checkpoint.
+	if checkpoint == nil || migrator.options.DryRun {
+		checkpoint = &Checkpoint{
+			TableName:     migrator.options.TableName,
+			TotalSegments: migrator.options.Segments,
+			Segments:      map[int]*SegmentCheckpoint{},
+		}
+	}
+	if checkpoint.TableName != migrator.options.TableName || checkpoint.TotalSegments != migrator.options.Segments {
+		return nil, fmt.Errorf("checkpoint is for table %s with %d segments; resume with the same settings or remove it",
+			checkpoint.TableName, checkpoint.TotalSegments)
+	}
+	for segment := 0; segment < migrator.options.Segments; segment++ {
+		if checkpoint.Segments[segment] == nil {
+			checkpoint.Segments[segment] = &SegmentCheckpoint{}
+		}
+	}
+	return checkpoint, nil
+}
+
+func (migrator *BulkMigrator) runSegment(ctx context.Context, segment int, startKey map[string]string) error {
+	for {
+		output, err := migrator.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:         aws.String(migrator.options.TableName),
+			Segment:           aws.Int32(int32(segment)),
+			TotalSegments:     aws.Int32(int32(migrator.options.Segments)),
+			Limit:             aws.Int32(int32(migrator.options.PageSize)),
+			ExclusiveStartKey: toKey(startKey),
+			ConsistentRead:    aws.Bool(true),
+		})
+		if err != nil {
+			return fmt.Errorf("scan segment %d: %w", segment, err)
+		}
+
+		for _, item := range output.Items {
+			if err := migrator.migrateItem(ctx, item); err != nil {
+				return fmt.Errorf("segment %d item %s: %w", segment, describeKey(item), err)
+			}
+		}
+
+		startKey = fromKey(output.LastEvaluatedKey)
+		done := len(startKey) == 0
+		if err := migrator.saveSegment(segment, startKey, done); err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+func (migrator *BulkMigrator) migrateItem(ctx context.Context, item Item) error {
+	atomic.AddInt64(&migrator.report.Scanned, 1)
+
+	fromVersion, err := SchemaVersion(item)
+	if err != nil {
+		return err
+	}
+	changed, err := migrator.runner.Migrate(item)
+	if err != nil || !changed {
+		return err
+	}
+
+	if migrator.options.DryRun {
+		atomic.AddInt64(&migrator.report.Migrated, 1)
+		migrator.printf("would migrate %s from v%d to v%d\n", describeKey(item), fromVersion, migrator.runner.LatestVersion())
+		return nil
+	}
+
+	// The condition skips items rewritten since the scan; the application writes the latest schema.
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(migrator.options.TableName),
+		Item:      item,
+	}
+	if fromVersion == 0 {
+		input.ConditionExpression = aws.String("attribute_not_exists(SchemaVersion)")
+	} else {
+		input.ConditionExpression = aws.String("SchemaVersion = :fromVersion")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":fromVersion": &types.AttributeValueMemberN{Value: strconv.Itoa(fromVersion)},
+		}
+	}
+
+	_, err = migrator.client.PutItem(ctx, input)
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionalCheckFailed) {
+		atomic.AddInt64(&migrator.report.Conflicts, 1)
+		migrator.printf("skipped %s: modified during migration\n", describeKey(item))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	atomic.AddInt64(&migrator.report.Migrated, 1)
+	return nil
+}
+
+func (migrator *BulkMigrator) saveSegment(segment int, lastEvaluatedKey map[string]string, done bool) error {
+	if migrator.options.DryRun {
+		return nil
+	}
+
+	migrator.mutex.Lock()
+	defer migrator.mutex.Unlock()
+
+	migrator.checkpoint.Segments[segment] = &SegmentCheckpoint{LastEvaluatedKey: lastEvaluatedKey, Done: done}
+	if err := migrator.checkpoints.Save(migrator.checkpoint); err != nil {
+		return fmt.Errorf("save checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (migrator *BulkMigrator) printf(format string, args ...interface{}) {
+	migrator.mutex.Lock()
+	defer migrator.mutex.Unlock()
+	fmt.Fprintf(migrator.out, format, args...)
+}
+
+// toKey and fromKey convert between LastEvaluatedKey and its checkpoint form. Table keys are
+// strings, which is all the checkpoint supports.
+func toKey(key map[string]string) map[string]types.AttributeValue {
+	if len(key) == 0 {
+		return nil
+	}
+	result := make(map[string]types.AttributeValue, len(key))
+	for name, value := range key {
+		result[name] = &types.AttributeValueMemberS{Value: value}
+	}
+	return result
+}
+
+func fromKey(key map[string]types.AttributeValue) map[string]string {
+	if len(key) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(key))
+	for name, value := range key {
+		if s, ok := value.(*types.AttributeValueMemberS); ok {
+			result[name] = s.Value
+		}
+	}
+	return result
+}
+
+func describeKey(item Item) string {
+	if id, ok := item["ID"].(*types.AttributeValueMemberS); ok {
+		return id.Value
+	}
+	var names []string
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "{" + strings.Join(names, ",") + "}"
+}
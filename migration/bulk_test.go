@@ -0,0 +1,131 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockScanClient struct {
+	mutex       sync.Mutex
+	ScanFunc    func(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	PutItemFunc func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	puts        []*dynamodb.PutItemInput
+}
+
+func (m *MockScanClient) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return m.ScanFunc(ctx, input)
+}
+
+func (m *MockScanClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	m.mutex.Lock()
+	m.puts = append(m.puts, input)
+	m.mutex.Unlock()
+	if m.PutItemFunc != nil {
+		return m.PutItemFunc(ctx, input)
+	}
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+// twoPageScan serves one legacy item per page and ends after the second page.
+func twoPageScan(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	if input.ExclusiveStartKey == nil {
+		return &dynamodb.ScanOutput{
+			Items:            []map[string]types.AttributeValue{legacyPostMetadataItem()},
+			LastEvaluatedKey: map[string]types.AttributeValue{"ID": &types.AttributeValueMemberS{Value: "ID1"}},
+		}, nil
+	}
+	item := legacyPostMetadataItem()
+	item["ID"] = &types.AttributeValueMemberS{Value: "ID2"}
+	return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{item}}, nil
+}
+
+func TestBulkMigrator_Run_WritesConditionallyAndCompletesCheckpoint(t *testing.T) {
+	client := &MockScanClient{ScanFunc: twoPageScan}
+	checkpoints := NewFileCheckpointStore[Checkpoint](filepath.Join(t.TempDir(), "checkpoint.json"))
+	migrator := NewBulkMigrator(client, PostMetadataRunner(), checkpoints,
+		BulkOptions{TableName: "posts", Segments: 1, PageSize: 1}, &bytes.Buffer{})
+
+	report, err := migrator.Run(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, Report{Scanned: 2, Migrated: 2}, *report)
+	assert.Len(t, client.puts, 2)
+	assert.Equal(t, "attribute_not_exists(SchemaVersion)", aws.ToString(client.puts[0].ConditionExpression))
+	checkpoint, err := checkpoints.Load()
+	assert.NoError(t, err)
+	assert.True(t, checkpoint.Segments[0].Done)
+}
+
+func TestBulkMigrator_Run_ResumesFromCheckpoint(t *testing.T) {
+	var startKeys []map[string]types.AttributeValue
+	client := &MockScanClient{ScanFunc: func(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+		startKeys = append(startKeys, input.ExclusiveStartKey)
+		return twoPageScan(ctx, input)
+	}}
+	checkpoints := NewFileCheckpointStore[Checkpoint](filepath.Join(t.TempDir(), "checkpoint.json"))
+	assert.NoError(t, checkpoints.Save(&Checkpoint{
+		TableName:     "posts",
+		TotalSegments: 1,
+		Segments:      map[int]*SegmentCheckpoint{0: {LastEvaluatedKey: map[string]string{"ID": "ID1"}}},
+	}))
+	migrator := NewBulkMigrator(client, PostMetadataRunner(), checkpoints,
+		BulkOptions{TableName: "posts", Segments: 1, PageSize: 1}, &bytes.Buffer{})
+
+	report, err := migrator.Run(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), report.Scanned)
+	assert.Equal(t, []map[string]types.AttributeValue{{"ID": &types.AttributeValueMemberS{Value: "ID1"}}}, startKeys)
+}
+
+func TestBulkMigrator_Run_ConcurrentWrite_CountsConflict(t *testing.T) {
+	client := &MockScanClient{
+		ScanFunc: twoPageScan,
+		PutItemFunc: func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			return nil, &types.ConditionalCheckFailedException{}
+		},
+	}
+	migrator := NewBulkMigrator(client, PostMetadataRunner(), NewFileCheckpointStore[Checkpoint](filepath.Join(t.TempDir(), "c.json")),
+		BulkOptions{TableName: "posts", Segments: 1, PageSize: 1}, &bytes.Buffer{})
+
+	report, err := migrator.Run(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, Report{Scanned: 2, Conflicts: 2}, *report)
+}
+
+func TestBulkMigrator_Run_DryRun_WritesNothing(t *testing.T) {
+	client := &MockScanClient{ScanFunc: twoPageScan}
+	checkpoints := NewFileCheckpointStore[Checkpoint](filepath.Join(t.TempDir(), "checkpoint.json"))
+	var out bytes.Buffer
+	migrator := NewBulkMigrator(client, PostMetadataRunner(), checkpoints,
+		BulkOptions{TableName: "posts", Segments: 1, PageSize: 1, DryRun: true}, &out)
+
+	report, err := migrator.Run(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), report.Migrated)
+	assert.Empty(t, client.puts)
+	assert.Contains(t, out.String(), "would migrate ID1 from v0 to v1")
+	checkpoint, _ := checkpoints.Load()
+	assert.Nil(t, checkpoint)
+}
+
+func TestBulkMigrator_Run_MismatchedCheckpoint_ReturnsError(t *testing.T) {
+	checkpoints := NewFileCheckpointStore[Checkpoint](filepath.Join(t.TempDir(), "checkpoint.json"))
+	assert.NoError(t, checkpoints.Save(&Checkpoint{TableName: "posts", TotalSegments: 4}))
+	migrator := NewBulkMigrator(&MockScanClient{ScanFunc: twoPageScan}, PostMetadataRunner(), checkpoints,
+		BulkOptions{TableName: "posts", Segments: 2, PageSize: 1}, &bytes.Buffer{})
+
+	_, err := migrator.Run(context.Background())
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package migration
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+type SegmentCheckpoint struct {
+	LastEvaluatedKey map[string]string `json:"lastEvaluatedKey,omitempty"`
+	Done             bool              `json:"done"`
+}
+
+type Checkpoint struct {
+	TableName     string                     `json:"tableName"`
+	TotalSegments int                        `json:"totalSegments"`
+	Segments      map[int]*SegmentCheckpoint `json:"segments"`
+}
+
+type CheckpointStore interface {
+	Load() (*Checkpoint, error)
+	Save(checkpoint *Checkpoint) error
+}
+
+// FileCheckpointStore keeps a checkpoint as JSON in a file. T is the checkpoint's type: Checkpoint
+// for bulk migrations, and other long-running tools such as backup restores keep their own.
+type FileCheckpointStore[T any] struct {
+	path string
+}
+
+func NewFileCheckpointStore[T any](path string) *FileCheckpointStore[T] {
+	return &FileCheckpointStore[T]{path: path}
+}
+
+// Load returns nil when no checkpoint has been written yet.
+func (store *FileCheckpointStore[T]) Load() (*T, error) {
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint T
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+// Save writes to a temporary file and renames it over the checkpoint so an interrupted run never
+// leaves a truncated file behind.
+func (store *FileCheckpointStore[T]) Save(checkpoint *T) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temporary, err := os.CreateTemp(filepath.Dir(store.path), filepath.Base(store.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	if _, err := temporary.Write(data); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temporary.Name(), store.path)
+}
@@ -0,0 +1,79 @@
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const schemaVersionAttribute = "SchemaVersion"
+
+type Item = map[string]types.AttributeValue
+
+// Migration upgrades an item from Version-1 to Version in place. Apply must be idempotent: the
+// bulk command may replay it on an item whose write was interrupted.
+type Migration struct {
+	Version int
+	Name    string
+	Apply   func(item Item) error
+}
+
+type Runner struct {
+	migrations []Migration
+}
+
+// NewRunner orders the migrations and checks that their versions run 1, 2, 3, ... without gaps.
+func NewRunner(migrations ...Migration) (*Runner, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	for i, migration := range sorted {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration %q has version %d, expected %d", migration.Name, migration.Version, i+1)
+		}
+	}
+	return &Runner{migrations: sorted}, nil
+}
+
+func (runner *Runner) LatestVersion() int {
+	return len(runner.migrations)
+}
+
+// Migrate applies every migration newer than the item's SchemaVersion and stamps the new version.
+// It reports whether anything changed.
+func (runner *Runner) Migrate(item Item) (bool, error) {
+	version, err := SchemaVersion(item)
+	if err != nil {
+		return false, err
+	}
+	if version > runner.LatestVersion() {
+		return false, fmt.Errorf("item has schema version %d, newer than the latest known version %d", version, runner.LatestVersion())
+	}
+	if version == runner.LatestVersion() {
+		return false, nil
+	}
+
+	for _, migration := range runner.migrations[version:] {
+		if err := migration.Apply(item); err != nil {
+			return false, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		item[schemaVersionAttribute] = &types.AttributeValueMemberN{Value: strconv.Itoa(migration.Version)}
+	}
+	return true, nil
+}
+
+// SchemaVersion reads the item's version; items without one predate versioning and are version 0.
+func SchemaVersion(item Item) (int, error) {
+	attribute, ok := item[schemaVersionAttribute]
+	if !ok {
+		return 0, nil
+	}
+	number, ok := attribute.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("%s is not a number", schemaVersionAttribute)
+	}
+	return strconv.Atoi(number.Value)
+}
@@ -0,0 +1,65 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func legacyPostMetadataItem() Item {
+	return Item{
+		"ID":        &types.AttributeValueMemberS{Value: "ID1"},
+		"Title":     &types.AttributeValueMemberS{Value: "Title1"},
+		"BodyUrl":   &types.AttributeValueMemberS{Value: "posts/ID1/body.md"},
+		"CreatedAt": &types.AttributeValueMemberS{Value: "2023-05-01T10:00:00+02:00"},
+		"UpdatedAt": &types.AttributeValueMemberS{Value: "2023-05-02T10:00:00+02:00"},
+	}
+}
+
+func TestNewRunner_GapInVersions_ReturnsError(t *testing.T) {
+	noop := func(Item) error { return nil }
+
+	_, err := NewRunner(Migration{Version: 1, Apply: noop}, Migration{Version: 3, Apply: noop})
+
+	assert.Error(t, err)
+}
+
+func TestPostMetadataRunner_MatchesModelSchemaVersion(t *testing.T) {
+	assert.Equal(t, model.PostMetadataSchemaVersion, PostMetadataRunner().LatestVersion())
+}
+
+func TestMigrate_LegacyPostMetadata_DecodesAtLatestVersion(t *testing.T) {
+	item := legacyPostMetadataItem()
+
+	changed, err := PostMetadataRunner().Migrate(item)
+
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	post, err := model.FromDynamoDBAttributeValue(item)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PostMetadataSchemaVersion, post.SchemaVersion)
+	assert.Equal(t, model.Draft, post.Status)
+	assert.Equal(t, "2023-05-01T08:00:00Z", item["CreatedAt"].(*types.AttributeValueMemberS).Value)
+}
+
+func TestMigrate_CurrentItem_ReportsNoChange(t *testing.T) {
+	item := legacyPostMetadataItem()
+	runner := PostMetadataRunner()
+	_, _ = runner.Migrate(item)
+
+	changed, err := runner.Migrate(item)
+
+	assert.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestMigrate_NewerItem_ReturnsError(t *testing.T) {
+	item := legacyPostMetadataItem()
+	item["SchemaVersion"] = &types.AttributeValueMemberN{Value: "99"}
+
+	_, err := PostMetadataRunner().Migrate(item)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package migration
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PostMetadataMigrations upgrade post metadata items to model.PostMetadataSchemaVersion. Append new
+// migrations here and bump that constant in the same change.
+var PostMetadataMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "normalize legacy timestamps and defaults",
+		Apply:   normalizeLegacyPostMetadata,
+	},
+}
+
+func PostMetadataRunner() *Runner {
+	runner, err := NewRunner(PostMetadataMigrations...)
+	if err != nil {
+		panic(err)
+	}
+	return runner
+}
+
+// normalizeLegacyPostMetadata rewrites second-precision, zone-offset timestamps in UTC RFC 3339 with
+// nanoseconds, and fills in the status and preview text that early items could omit.
+func normalizeLegacyPostMetadata(item Item) error {
+	for _, name := range []string{"CreatedAt", "UpdatedAt"} {
+		attribute, ok := item[name].(*types.AttributeValueMemberS)
+		if !ok {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, attribute.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		item[name] = &types.AttributeValueMemberS{Value: parsed.UTC().Format(time.RFC3339Nano)}
+	}
+
+	if _, ok := item["Status"]; !ok {
+		item["Status"] = &types.AttributeValueMemberS{Value: "DRAFT"}
+	}
+	if _, ok := item["PreviewText"]; !ok {
+		item["PreviewText"] = &types.AttributeValueMemberS{Value: ""}
+	}
+	return nil
+}
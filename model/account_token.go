@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type TokenPurpose string
+
+const (
+	PurposeInvitation    TokenPurpose = "INVITATION"
+	PurposePasswordReset TokenPurpose = "PASSWORD_RESET"
+	// PurposeLoginChallenge carries a login from the password step to the two-factor step.
+	PurposeLoginChallenge TokenPurpose = "LOGIN_CHALLENGE"
+	// PurposeSingleSignOn is the state of a login at the identity provider, holding the nonce and
+	// PKCE code verifier the callback needs.
+	PurposeSingleSignOn TokenPurpose = "SINGLE_SIGN_ON"
+	// PurposeNewsletterConfirmation confirms a newsletter subscription for Email.
+	PurposeNewsletterConfirmation TokenPurpose = "NEWSLETTER_CONFIRMATION"
+)
+
+// AccountToken backs the single-use links mailed for invitations and password resets, and login
+// challenges. Only a hash of the secret is stored. Email is set for the mailed tokens, UserID for
+// password resets and login challenges, and CreatedBy for invitations.
+// DeleteAfter is the table's TTL attribute, in epoch seconds.
+type AccountToken struct {
+	ID           string       `dynamodbav:"ID" codec:"required"`
+	Purpose      TokenPurpose `dynamodbav:"Purpose" codec:"required"`
+	SecretHash   string       `dynamodbav:"SecretHash" codec:"required"`
+	Email        string       `dynamodbav:"Email"`
+	UserID       string       `dynamodbav:"UserID"`
+	CreatedBy    string       `dynamodbav:"CreatedBy"`
+	Nonce        string       `dynamodbav:"Nonce,omitempty"`
+	CodeVerifier string       `dynamodbav:"CodeVerifier,omitempty"`
+	CreatedAt    time.Time    `dynamodbav:"CreatedAt" codec:"required"`
+	ExpiresAt    time.Time    `dynamodbav:"ExpiresAt" codec:"required"`
+	Used         bool         `dynamodbav:"Used"`
+	UsedAt       time.Time    `dynamodbav:"UsedAt"`
+	DeleteAfter  int64        `dynamodbav:"DeleteAfter"`
+}
+
+func (token *AccountToken) Usable(now time.Time) bool {
+	return !token.Used && now.Before(token.ExpiresAt)
+}
+
+func AccountTokenToDynamoDbAttributes(token *AccountToken) (map[string]types.AttributeValue, error) {
+	if token == nil {
+		return nil, nil
+	}
+	return encodeItem(token)
+}
+
+func AccountTokenFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*AccountToken, error) {
+	token := &AccountToken{}
+	if err := decodeItem(ddbValue, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// APIKey lets a machine client act for its owner within Scopes. Only a hash of the secret is stored;
+// Prefix is the non-secret start of the key, shown so owners can tell their keys apart. A zero
+// ExpiresAt means the key doesn't expire.
+type APIKey struct {
+	ID         string    `json:"id" dynamodbav:"ID" codec:"required"`
+	OwnerID    string    `json:"ownerId" dynamodbav:"OwnerID" codec:"required"`
+	Name       string    `json:"name" dynamodbav:"Name" codec:"required"`
+	Prefix     string    `json:"prefix" dynamodbav:"Prefix" codec:"required"`
+	SecretHash string    `json:"-" dynamodbav:"SecretHash" codec:"required"`
+	Scopes     []string  `json:"scopes" dynamodbav:"Scopes,stringset"`
+	CreatedAt  time.Time `json:"createdAt" dynamodbav:"CreatedAt" codec:"required"`
+	ExpiresAt  time.Time `json:"expiresAt" dynamodbav:"ExpiresAt"`
+	LastUsedAt time.Time `json:"lastUsedAt" dynamodbav:"LastUsedAt"`
+	Revoked    bool      `json:"revoked" dynamodbav:"Revoked"`
+}
+
+func (key *APIKey) Active(now time.Time) bool {
+	return !key.Revoked && (key.ExpiresAt.IsZero() || now.Before(key.ExpiresAt))
+}
+
+func (key *APIKey) HasScope(scope string) bool {
+	for _, granted := range key.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func APIKeyToDynamoDbAttributes(key *APIKey) (map[string]types.AttributeValue, error) {
+	if key == nil {
+		return nil, nil
+	}
+	return encodeItem(key)
+}
+
+func APIKeyFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*APIKey, error) {
+	key := &APIKey{}
+	if err := decodeItem(ddbValue, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func APIKeysFromDynamoDBAttributeValues(ddbValues []map[string]types.AttributeValue) ([]*APIKey, error) {
+	keys := make([]*APIKey, 0, len(ddbValues))
+	for _, ddbValue := range ddbValues {
+		key, err := APIKeyFromDynamoDBAttributeValue(ddbValue)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// sortableTimeLayout is fixed width so key attributes holding times sort in time order.
+const sortableTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// AuditEvent records one change: who made it, to what, and how each field changed. Events are
+// partitioned by Day and sorted by Sequence, the time followed by the ID, so a time range reads
+// newest first day by day; ActorID and Target have indexes sorted the same way. DeleteAfter is the
+// table's TTL attribute, in epoch seconds.
+type AuditEvent struct {
+	ID          string                 `json:"id" dynamodbav:"ID" codec:"required"`
+	Day         string                 `json:"-" dynamodbav:"Day" codec:"required"`
+	Sequence    string                 `json:"-" dynamodbav:"Sequence" codec:"required"`
+	OccurredAt  time.Time              `json:"occurredAt" dynamodbav:"OccurredAt" codec:"required"`
+	Action      string                 `json:"action" dynamodbav:"Action" codec:"required"`
+	ActorID     string                 `json:"actorId,omitempty" dynamodbav:"ActorID,omitempty"`
+	APIKeyID    string                 `json:"apiKeyId,omitempty" dynamodbav:"APIKeyID,omitempty"`
+	TargetType  string                 `json:"targetType" dynamodbav:"TargetType" codec:"required"`
+	TargetID    string                 `json:"targetId" dynamodbav:"TargetID" codec:"required"`
+	Target      string                 `json:"-" dynamodbav:"Target" codec:"required"`
+	Changes     map[string]AuditChange `json:"changes,omitempty" dynamodbav:"Changes,omitempty"`
+	RequestID   string                 `json:"requestId,omitempty" dynamodbav:"RequestID,omitempty"`
+	SourceIP    string                 `json:"sourceIp,omitempty" dynamodbav:"SourceIP,omitempty"`
+	DeleteAfter int64                  `json:"-" dynamodbav:"DeleteAfter"`
+}
+
+// AuditChange holds a field's JSON encoded value before and after the change; fields that were
+// added have no Before and fields that were removed no After.
+type AuditChange struct {
+	Before string `json:"before,omitempty" dynamodbav:"Before,omitempty"`
+	After  string `json:"after,omitempty" dynamodbav:"After,omitempty"`
+}
+
+// AuditFilter selects events by actor or by target, optionally within From and To. Zero times leave
+// that end of the range open.
+type AuditFilter struct {
+	ActorID string
+	Target  string
+	From    time.Time
+	To      time.Time
+}
+
+// SetKeys derives Day, Sequence and Target from the other fields.
+func (event *AuditEvent) SetKeys() {
+	event.Day = AuditDay(event.OccurredAt)
+	event.Sequence = AuditSequence(event.OccurredAt, event.ID)
+	event.Target = AuditTarget(event.TargetType, event.TargetID)
+}
+
+func AuditDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// AuditSequence is the sort key of an event; with an empty id it is the lowest key at that time.
+func AuditSequence(t time.Time, id string) string {
+	return t.UTC().Format(sortableTimeLayout) + "#" + id
+}
+
+func AuditTarget(targetType string, targetID string) string {
+	return targetType + "#" + targetID
+}
+
+func AuditEventToDynamoDbAttributes(event *AuditEvent) (map[string]types.AttributeValue, error) {
+	if event == nil {
+		return nil, nil
+	}
+	return encodeItem(event)
+}
+
+func AuditEventsFromDynamoDBAttributeValues(ddbValues []map[string]types.AttributeValue) ([]*AuditEvent, error) {
+	var result []*AuditEvent
+	for _, ddbValue := range ddbValues {
+		event := &AuditEvent{}
+		if err := decodeItem(ddbValue, event); err != nil {
+			return nil, err
+		}
+		result = append(result, event)
+	}
+	return result, nil
+}
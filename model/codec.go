@@ -0,0 +1,143 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrMissingAttribute = errors.New("missing required attribute")
+
+// DecodeError names the attribute an item failed to decode on.
+type DecodeError struct {
+	Attribute string
+	Err       error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode attribute %q: %v", e.Attribute, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// encodeItem marshals a struct using its dynamodbav tags. Times are written in UTC with nanosecond
+// precision so they sort lexically and round-trip exactly.
+func encodeItem(item interface{}) (map[string]types.AttributeValue, error) {
+	return attributevalue.MarshalMapWithOptions(item, func(options *attributevalue.EncoderOptions) {
+		options.EncodeTime = func(t time.Time) (types.AttributeValue, error) {
+			return &types.AttributeValueMemberS{Value: t.UTC().Format(time.RFC3339Nano)}, nil
+		}
+	})
+}
+
+// decodeItem unmarshals an item into the struct out points to, one attribute at a time, so every
+// failure is reported with its attribute name. Fields tagged `codec:"required"` must be present.
+// Attributes the struct doesn't know about are ignored so older code can read newer items.
+func decodeItem(item map[string]types.AttributeValue, out interface{}) error {
+	value := reflect.ValueOf(out).Elem()
+	var errs []error
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := attributeName(field)
+		if name == "" {
+			continue
+		}
+
+		attribute, ok := item[name]
+		if _, isNull := attribute.(*types.AttributeValueMemberNULL); !ok || isNull {
+			if field.Tag.Get("codec") == "required" {
+				errs = append(errs, &DecodeError{Attribute: name, Err: ErrMissingAttribute})
+			}
+			continue
+		}
+
+		if err := checkAttributeType(field.Type, attribute); err != nil {
+			errs = append(errs, &DecodeError{Attribute: name, Err: err})
+			continue
+		}
+		if err := attributevalue.Unmarshal(attribute, value.Field(i).Addr().Interface()); err != nil {
+			errs = append(errs, &DecodeError{Attribute: name, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkAttributeType rejects attributes whose DynamoDB type doesn't fit the field, which the
+// attributevalue decoder would otherwise coerce, e.g. a number into a string field.
+func checkAttributeType(fieldType reflect.Type, attribute types.AttributeValue) error {
+	actual := attributeType(attribute)
+	var allowed []string
+	switch {
+	case fieldType == reflect.TypeOf(time.Time{}):
+		allowed = []string{"S"}
+	case fieldType.Kind() == reflect.String:
+		allowed = []string{"S"}
+	case fieldType.Kind() == reflect.Bool:
+		allowed = []string{"BOOL"}
+	case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Float64:
+		allowed = []string{"N"}
+	case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.String:
+		allowed = []string{"SS", "L"}
+	case fieldType.Kind() == reflect.Slice:
+		allowed = []string{"L"}
+	case fieldType.Kind() == reflect.Map || fieldType.Kind() == reflect.Struct:
+		allowed = []string{"M"}
+	default:
+		return nil
+	}
+
+	for _, candidate := range allowed {
+		if actual == candidate {
+			return nil
+		}
+	}
+	return fmt.Errorf("expected attribute type %s, got %s", strings.Join(allowed, " or "), actual)
+}
+
+func attributeType(attribute types.AttributeValue) string {
+	switch attribute.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	default:
+		return fmt.Sprintf("%T", attribute)
+	}
+}
+
+func attributeName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("dynamodbav"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
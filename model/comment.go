@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type Comment struct {
+	ID          string    `json:"id"`
+	PostID      string    `json:"postId"`
+	AuthorName  string    `json:"authorName"`
+	AuthorEmail string    `json:"authorEmail"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
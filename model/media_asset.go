@@ -0,0 +1,96 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type MediaAsset struct {
+	ID        string                  `json:"id" dynamodbav:"ID" codec:"required"`
+	OwnerID   string                  `json:"ownerId" dynamodbav:"OwnerID" codec:"required"`
+	Key       string                  `json:"key" dynamodbav:"Key" codec:"required"`
+	MimeType  string                  `json:"mimeType" dynamodbav:"MimeType" codec:"required"`
+	SizeBytes int64                   `json:"sizeBytes" dynamodbav:"SizeBytes"`
+	Width     int                     `json:"width" dynamodbav:"Width"`
+	Height    int                     `json:"height" dynamodbav:"Height"`
+	Checksum  string                  `json:"checksum" dynamodbav:"Checksum"`
+	CreatedAt time.Time               `json:"createdAt" dynamodbav:"CreatedAt" codec:"required"`
+	Variants  map[string]MediaVariant `json:"variants,omitempty" dynamodbav:"Variants,omitempty"`
+	// ProcessingError says why no variants could be rendered from the upload.
+	ProcessingError string `json:"processingError,omitempty" dynamodbav:"ProcessingError,omitempty"`
+	// PostIDs lists the posts whose AssetIDs reference the asset.
+	PostIDs []string `json:"postIds,omitempty" dynamodbav:"PostIDs,stringset,omitempty"`
+}
+
+type MediaVariant struct {
+	Key      string `json:"key" dynamodbav:"Key"`
+	MimeType string `json:"mimeType" dynamodbav:"MimeType"`
+	Width    int    `json:"width" dynamodbav:"Width"`
+	Height   int    `json:"height" dynamodbav:"Height"`
+}
+
+// SrcSet builds an HTML srcset value from the variants of the given MIME type, narrowest first.
+func (asset *MediaAsset) SrcSet(baseURL string, mimeType string) string {
+	var variants []MediaVariant
+	for _, variant := range asset.Variants {
+		if variant.MimeType == mimeType {
+			variants = append(variants, variant)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool {
+		return variants[i].Width < variants[j].Width
+	})
+
+	candidates := make([]string, 0, len(variants))
+	for _, variant := range variants {
+		candidates = append(candidates, fmt.Sprintf("%s/%s %dw", strings.TrimSuffix(baseURL, "/"), variant.Key, variant.Width))
+	}
+	return strings.Join(candidates, ", ")
+}
+
+func MediaAssetToDynamoDbAttributes(asset *MediaAsset) (map[string]types.AttributeValue, error) {
+	if asset == nil {
+		return nil, nil
+	}
+	return encodeItem(asset)
+}
+
+func MediaVariantsToDynamoDbAttribute(variants map[string]MediaVariant) (types.AttributeValue, error) {
+	return attributevalue.Marshal(variants)
+}
+
+func MediaAssetsFromDynamoDBAttributeValues(ddbValues []map[string]types.AttributeValue) ([]*MediaAsset, error) {
+	var result []*MediaAsset
+	for _, ddbValue := range ddbValues {
+		asset, err := MediaAssetFromDynamoDBAttributeValue(ddbValue)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, asset)
+	}
+	return result, nil
+}
+
+func MediaAssetFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*MediaAsset, error) {
+	asset := &MediaAsset{}
+	if err := decodeItem(ddbValue, asset); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// UnusedMediaAssets returns the assets that no post references.
+func UnusedMediaAssets(assets []*MediaAsset) []*MediaAsset {
+	var result []*MediaAsset
+	for _, asset := range assets {
+		if len(asset.PostIDs) == 0 {
+			result = append(result, asset)
+		}
+	}
+	return result
+}
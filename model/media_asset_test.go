@@ -0,0 +1,83 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMediaAssetToDynamoDbAttributes_WritesNumbers(t *testing.T) {
+	asset := &MediaAsset{ID: "asset1", SizeBytes: 2048, Width: 640, Height: 480}
+
+	result, err := MediaAssetToDynamoDbAttributes(asset)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "2048"}, result["SizeBytes"])
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "640"}, result["Width"])
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "480"}, result["Height"])
+}
+
+func TestMediaAssetToDynamoDbAttributes_NilAsset_ReturnsNil(t *testing.T) {
+	result, err := MediaAssetToDynamoDbAttributes(nil)
+
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+}
+
+func TestToDynamoDbAttributes_WritesAssetIDsOnlyWhenPresent(t *testing.T) {
+	withoutAssets, _ := ToDynamoDbAttributes(&PostMetadata{ID: "post1"})
+	withAssets, _ := ToDynamoDbAttributes(&PostMetadata{ID: "post2", AssetIDs: []string{"asset1"}})
+
+	assert.NotContains(t, withoutAssets, "AssetIDs")
+	assert.Equal(t, &types.AttributeValueMemberSS{Value: []string{"asset1"}}, withAssets["AssetIDs"])
+}
+
+func TestUnusedMediaAssets_ReturnsUnreferencedAssets(t *testing.T) {
+	assets := []*MediaAsset{
+		{ID: "asset1", PostIDs: []string{"post1"}},
+		{ID: "asset2"},
+		{ID: "asset3", PostIDs: []string{"post1", "post2"}},
+	}
+
+	result := UnusedMediaAssets(assets)
+
+	assert.Equal(t, []*MediaAsset{{ID: "asset2"}}, result)
+}
+
+func TestSrcSet_OrdersVariantsOfTypeByWidth(t *testing.T) {
+	asset := &MediaAsset{Variants: map[string]MediaVariant{
+		"large":     {Key: "media/a/variants/large.jpg", MimeType: "image/jpeg", Width: 1600},
+		"thumbnail": {Key: "media/a/variants/thumbnail.jpg", MimeType: "image/jpeg", Width: 320},
+		"webp":      {Key: "media/a/variants/webp.webp", MimeType: "image/webp", Width: 1600},
+	}}
+
+	result := asset.SrcSet("https://cdn.example.com/", "image/jpeg")
+
+	assert.Equal(t, "https://cdn.example.com/media/a/variants/thumbnail.jpg 320w, https://cdn.example.com/media/a/variants/large.jpg 1600w", result)
+}
+
+func TestMediaAssetVariants_RoundTrip(t *testing.T) {
+	asset := &MediaAsset{
+		ID:        "a",
+		OwnerID:   "user1",
+		Key:       "media/a/original",
+		MimeType:  "image/jpeg",
+		CreatedAt: time.Now().UTC(),
+		Variants: map[string]MediaVariant{
+			"thumbnail": {Key: "media/a/variants/thumbnail.jpg", MimeType: "image/jpeg", Width: 320, Height: 240},
+		},
+	}
+
+	encoded, err := MediaAssetToDynamoDbAttributes(asset)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := MediaAssetFromDynamoDBAttributeValue(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assert.Equal(t, asset, result)
+}
@@ -0,0 +1,6 @@
+package model
+
+type Post struct {
+	PostMetadata
+	Body string `json:"body"`
+}
@@ -13,61 +13,61 @@ const (
 	Posted Status = "POSTED"
 )
 
+// Origins mark posts that were written by bringing existing content in rather than through the API.
+const (
+	OriginImport  = "import"
+	OriginRestore = "restore"
+)
+
+// PostMetadataSchemaVersion is stamped on every item written. Items from before versioning decode
+// with SchemaVersion 0.
+const PostMetadataSchemaVersion = 1
+
+// PostMetadata is a post without its body. AuthorID is empty for imported posts and posts from
+// before authorship was recorded; only editors can change those. Version counts writes and is 0
+// for posts written before it was added. Origin is empty for posts created through the API.
 type PostMetadata struct {
-	ID          string
-	Title       string
-	BodyUrl     string
-	PreviewText string
-	Status      Status
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Tags        []Tag
+	ID            string    `json:"id" dynamodbav:"ID" codec:"required"`
+	Title         string    `json:"title" dynamodbav:"Title" codec:"required"`
+	BodyUrl       string    `json:"bodyUrl" dynamodbav:"BodyUrl" codec:"required"`
+	PreviewText   string    `json:"previewText" dynamodbav:"PreviewText"`
+	Status        Status    `json:"status" dynamodbav:"Status" codec:"required"`
+	CreatedAt     time.Time `json:"createdAt" dynamodbav:"CreatedAt" codec:"required"`
+	UpdatedAt     time.Time `json:"updatedAt" dynamodbav:"UpdatedAt" codec:"required"`
+	Tags          []Tag     `json:"tags,omitempty" dynamodbav:"Tags,omitempty"`
+	AssetIDs      []string  `json:"assetIds,omitempty" dynamodbav:"AssetIDs,stringset,omitempty"`
+	AuthorID      string    `json:"authorId,omitempty" dynamodbav:"AuthorID,omitempty"`
+	Version       int       `json:"version" dynamodbav:"Version,omitempty"`
+	Origin        string    `json:"-" dynamodbav:"Origin,omitempty"`
+	SchemaVersion int       `json:"-" dynamodbav:"SchemaVersion"`
 }
 
-func ToDynamoDbAttributes(post *PostMetadata) map[string]types.AttributeValue {
+func ToDynamoDbAttributes(post *PostMetadata) (map[string]types.AttributeValue, error) {
 	if post == nil {
-		return nil
+		return nil, nil
 	}
 
-	return map[string]types.AttributeValue{
-		"ID":          &types.AttributeValueMemberS{Value: post.ID},
-		"Title":       &types.AttributeValueMemberS{Value: post.Title},
-		"BodyUrl":     &types.AttributeValueMemberS{Value: post.BodyUrl},
-		"PreviewText": &types.AttributeValueMemberS{Value: post.PreviewText},
-		"Status":      &types.AttributeValueMemberS{Value: string(post.Status)},
-		"CreatedAt":   &types.AttributeValueMemberS{Value: post.CreatedAt.Format(time.RFC3339)},
-		"UpdatedAt":   &types.AttributeValueMemberS{Value: post.UpdatedAt.Format(time.RFC3339)},
-	}
+	versioned := *post
+	versioned.SchemaVersion = PostMetadataSchemaVersion
+	return encodeItem(&versioned)
 }
 
-func FromDynamoDBAttributeValues(ddbValues []map[string]types.AttributeValue) []*PostMetadata {
+func FromDynamoDBAttributeValues(ddbValues []map[string]types.AttributeValue) ([]*PostMetadata, error) {
 	var result []*PostMetadata
 	for _, ddbValue := range ddbValues {
-		result = append(result, FromDynamoDBAttributeValue(ddbValue))
+		post, err := FromDynamoDBAttributeValue(ddbValue)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, post)
 	}
-	return result
+	return result, nil
 }
 
-func FromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) *PostMetadata {
-	return &PostMetadata{
-		ID:          getStringAttribute(ddbValue["ID"]),
-		Title:       getStringAttribute(ddbValue["Title"]),
-		BodyUrl:     getStringAttribute(ddbValue["BodyUrl"]),
-		PreviewText: getStringAttribute(ddbValue["PreviewText"]),
-		Status:      Status(getStringAttribute(ddbValue["Status"])),
-		CreatedAt:   parseTime(getStringAttribute(ddbValue["CreatedAt"])),
-		UpdatedAt:   parseTime(getStringAttribute(ddbValue["UpdatedAt"])),
+func FromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*PostMetadata, error) {
+	post := &PostMetadata{}
+	if err := decodeItem(ddbValue, post); err != nil {
+		return nil, err
 	}
-}
-
-func getStringAttribute(attr types.AttributeValue) string {
-	if attrS, ok := attr.(*types.AttributeValueMemberS); ok {
-		return attrS.Value
-	}
-	return ""
-}
-
-func parseTime(timeStr string) time.Time {
-	parsedTime, _ := time.Parse(time.RFC3339, timeStr)
-	return parsedTime
+	return post, nil
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -8,7 +9,7 @@ import (
 )
 
 func TestToDynamoDbAttributes_Succeeds(t *testing.T) {
-	currentTime := time.Now().Truncate(time.Second) // Truncate to seconds for consistency
+	currentTime := time.Now().UTC()
 	post := &PostMetadata{
 		ID:          "ID1",
 		Title:       "Title1",
@@ -20,27 +21,33 @@ func TestToDynamoDbAttributes_Succeeds(t *testing.T) {
 	}
 
 	expected := map[string]types.AttributeValue{
-		"ID":          &types.AttributeValueMemberS{Value: "ID1"},
-		"Title":       &types.AttributeValueMemberS{Value: "Title1"},
-		"BodyUrl":     &types.AttributeValueMemberS{Value: "BodyUrl1"},
-		"PreviewText": &types.AttributeValueMemberS{Value: "PreviewText1"},
-		"Status":      &types.AttributeValueMemberS{Value: string(Posted)},
-		"CreatedAt":   &types.AttributeValueMemberS{Value: currentTime.Format(time.RFC3339)},
-		"UpdatedAt":   &types.AttributeValueMemberS{Value: currentTime.Format(time.RFC3339)},
+		"ID":            &types.AttributeValueMemberS{Value: "ID1"},
+		"Title":         &types.AttributeValueMemberS{Value: "Title1"},
+		"BodyUrl":       &types.AttributeValueMemberS{Value: "BodyUrl1"},
+		"PreviewText":   &types.AttributeValueMemberS{Value: "PreviewText1"},
+		"Status":        &types.AttributeValueMemberS{Value: string(Posted)},
+		"CreatedAt":     &types.AttributeValueMemberS{Value: currentTime.Format(time.RFC3339Nano)},
+		"UpdatedAt":     &types.AttributeValueMemberS{Value: currentTime.Format(time.RFC3339Nano)},
+		"SchemaVersion": &types.AttributeValueMemberN{Value: "1"},
 	}
 
-	result := ToDynamoDbAttributes(post)
+	result, err := ToDynamoDbAttributes(post)
 
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 	assert.Equal(t, expected, result, "The DynamoDB attribute values should match the expected map")
+	assert.Equal(t, 0, post.SchemaVersion, "Encoding should not modify the input post")
 }
 
 func TestToDynamoDbAttributes_NilPost_ReturnsNil(t *testing.T) {
-	result := ToDynamoDbAttributes(nil)
+	result, err := ToDynamoDbAttributes(nil)
+	assert.Nil(t, err)
 	assert.Nil(t, result, "Expected nil when input post is nil")
 }
 
 func TestFromDynamoDBAttributeValue_Succeeds(t *testing.T) {
-	currentTime := time.Now().Truncate(time.Second)
+	currentTime := time.Now().UTC()
 	var values []map[string]types.AttributeValue
 	ddbValue := map[string]types.AttributeValue{
 		"ID":          &types.AttributeValueMemberS{Value: "ID1"},
@@ -48,8 +55,8 @@ func TestFromDynamoDBAttributeValue_Succeeds(t *testing.T) {
 		"BodyUrl":     &types.AttributeValueMemberS{Value: "BodyUrl1"},
 		"PreviewText": &types.AttributeValueMemberS{Value: "PreviewText1"},
 		"Status":      &types.AttributeValueMemberS{Value: string(Posted)},
-		"CreatedAt":   &types.AttributeValueMemberS{Value: currentTime.Format(time.RFC3339)},
-		"UpdatedAt":   &types.AttributeValueMemberS{Value: currentTime.Format(time.RFC3339)},
+		"CreatedAt":   &types.AttributeValueMemberS{Value: currentTime.Format(time.RFC3339Nano)},
+		"UpdatedAt":   &types.AttributeValueMemberS{Value: currentTime.Format(time.RFC3339Nano)},
 	}
 
 	values = append(values, ddbValue)
@@ -69,9 +76,74 @@ func TestFromDynamoDBAttributeValue_Succeeds(t *testing.T) {
 	expectedResult = append(expectedResult, expected)
 	expectedResult = append(expectedResult, expected)
 
-	result := FromDynamoDBAttributeValues(values)
+	result, err := FromDynamoDBAttributeValues(values)
 
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Len(t, result, len(expectedResult))
 	for i, item := range result {
 		assert.Equal(t, expectedResult[i], item, "The PostMetadata content should match")
 	}
 }
+
+func TestPostMetadataCodec_RoundTripsEveryField(t *testing.T) {
+	post := &PostMetadata{
+		ID:          "ID1",
+		Title:       "Title1",
+		BodyUrl:     "BodyUrl1",
+		PreviewText: "PreviewText1",
+		Status:      Draft,
+		CreatedAt:   time.Date(2024, 11, 3, 10, 4, 5, 123456789, time.UTC),
+		UpdatedAt:   time.Date(2024, 11, 4, 10, 4, 5, 987654321, time.UTC),
+		Tags:        []Tag{{ID: "tag1", Label: "go"}, {ID: "tag2", Label: "aws"}},
+		AssetIDs:    []string{"asset1", "asset2"},
+	}
+
+	encoded, err := ToDynamoDbAttributes(post)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := FromDynamoDBAttributeValue(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := *post
+	expected.SchemaVersion = PostMetadataSchemaVersion
+	assert.Equal(t, &expected, result)
+}
+
+func TestFromDynamoDBAttributeValue_LegacyItem_HasSchemaVersionZero(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	result, err := FromDynamoDBAttributeValue(map[string]types.AttributeValue{
+		"ID":        &types.AttributeValueMemberS{Value: "ID1"},
+		"Title":     &types.AttributeValueMemberS{Value: "Title1"},
+		"BodyUrl":   &types.AttributeValueMemberS{Value: "BodyUrl1"},
+		"Status":    &types.AttributeValueMemberS{Value: string(Draft)},
+		"CreatedAt": &types.AttributeValueMemberS{Value: now},
+		"UpdatedAt": &types.AttributeValueMemberS{Value: now},
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, 0, result.SchemaVersion)
+}
+
+func TestFromDynamoDBAttributeValue_InvalidItem_ReportsAttributeNames(t *testing.T) {
+	_, err := FromDynamoDBAttributeValue(map[string]types.AttributeValue{
+		"ID":        &types.AttributeValueMemberS{Value: "ID1"},
+		"Title":     &types.AttributeValueMemberN{Value: "42"},
+		"BodyUrl":   &types.AttributeValueMemberS{Value: "BodyUrl1"},
+		"Status":    &types.AttributeValueMemberS{Value: string(Draft)},
+		"CreatedAt": &types.AttributeValueMemberS{Value: "yesterday"},
+	})
+
+	var decodeErr *DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.ErrorContains(t, err, `decode attribute "Title"`)
+	assert.ErrorContains(t, err, `decode attribute "CreatedAt"`)
+	assert.ErrorContains(t, err, `decode attribute "UpdatedAt": missing required attribute`)
+	assert.True(t, errors.Is(err, ErrMissingAttribute))
+}
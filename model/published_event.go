@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PublishedEvent marks a domain event as delivered so stream retries don't deliver it again. It only
+// needs to outlive the stream's 24 hour retention; DeleteAfter is the table's TTL attribute, in
+// epoch seconds.
+type PublishedEvent struct {
+	ID          string    `dynamodbav:"ID" codec:"required"`
+	PublishedAt time.Time `dynamodbav:"PublishedAt" codec:"required"`
+	DeleteAfter int64     `dynamodbav:"DeleteAfter"`
+}
+
+func PublishedEventToDynamoDbAttributes(event *PublishedEvent) (map[string]types.AttributeValue, error) {
+	if event == nil {
+		return nil, nil
+	}
+	return encodeItem(event)
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TokenBucket is the stored state of one rate limit bucket. ExpiresAt is the table's TTL attribute,
+// in epoch seconds, so idle buckets disappear on their own.
+type TokenBucket struct {
+	Key       string    `dynamodbav:"Key" codec:"required"`
+	Tokens    float64   `dynamodbav:"Tokens" codec:"required"`
+	UpdatedAt time.Time `dynamodbav:"UpdatedAt" codec:"required"`
+	ExpiresAt int64     `dynamodbav:"ExpiresAt"`
+}
+
+// LoginLockout counts recent failed logins for one username and the time it is locked until.
+type LoginLockout struct {
+	Key         string    `dynamodbav:"Key" codec:"required"`
+	Failures    int       `dynamodbav:"Failures"`
+	LockedUntil time.Time `dynamodbav:"LockedUntil"`
+	ExpiresAt   int64     `dynamodbav:"ExpiresAt"`
+}
+
+func TokenBucketToDynamoDbAttributes(bucket *TokenBucket) (map[string]types.AttributeValue, error) {
+	if bucket == nil {
+		return nil, nil
+	}
+	return encodeItem(bucket)
+}
+
+func TokenBucketFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*TokenBucket, error) {
+	bucket := &TokenBucket{}
+	if err := decodeItem(ddbValue, bucket); err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
+
+func LoginLockoutFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*LoginLockout, error) {
+	lockout := &LoginLockout{}
+	if err := decodeItem(ddbValue, lockout); err != nil {
+		return nil, err
+	}
+	return lockout, nil
+}
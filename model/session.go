@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Session is one login on one device. Its refresh token is rotated on every use, and RefreshTokenHash
+// always holds the hash of the only token that is still valid. TwoFactorPending marks sessions of
+// users who must enrol in two-factor authentication before doing anything else. DeleteAfter is the
+// table's TTL attribute, in epoch seconds.
+type Session struct {
+	ID               string    `dynamodbav:"ID" codec:"required"`
+	UserID           string    `dynamodbav:"UserID" codec:"required"`
+	RefreshTokenHash string    `dynamodbav:"RefreshTokenHash" codec:"required"`
+	CreatedAt        time.Time `dynamodbav:"CreatedAt" codec:"required"`
+	ExpiresAt        time.Time `dynamodbav:"ExpiresAt" codec:"required"`
+	Revoked          bool      `dynamodbav:"Revoked"`
+	TwoFactorPending bool      `dynamodbav:"TwoFactorPending"`
+	DeleteAfter      int64     `dynamodbav:"DeleteAfter"`
+}
+
+func (session *Session) Active(now time.Time) bool {
+	return !session.Revoked && now.Before(session.ExpiresAt)
+}
+
+func SessionToDynamoDbAttributes(session *Session) (map[string]types.AttributeValue, error) {
+	if session == nil {
+		return nil, nil
+	}
+	return encodeItem(session)
+}
+
+func SessionFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*Session, error) {
+	session := &Session{}
+	if err := decodeItem(ddbValue, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
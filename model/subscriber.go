@@ -0,0 +1,89 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type SubscriberStatus string
+
+const (
+	// SubscriberPending subscribers have asked for the newsletter but not yet confirmed their address.
+	SubscriberPending      SubscriberStatus = "PENDING"
+	SubscriberConfirmed    SubscriberStatus = "CONFIRMED"
+	SubscriberUnsubscribed SubscriberStatus = "UNSUBSCRIBED"
+)
+
+// Subscriber is a newsletter recipient. The ID is derived from the email address, so an address has
+// one record however often it subscribes. The unsubscribe token goes into every newsletter, so it is
+// sealed with auth.SecretBox, with the ID as context, rather than hashed.
+type Subscriber struct {
+	ID                     string           `dynamodbav:"ID" codec:"required"`
+	Email                  string           `dynamodbav:"Email" codec:"required"`
+	Status                 SubscriberStatus `dynamodbav:"Status" codec:"required"`
+	SealedUnsubscribeToken string           `dynamodbav:"SealedUnsubscribeToken" codec:"required"`
+	CreatedAt              time.Time        `dynamodbav:"CreatedAt" codec:"required"`
+	ConfirmedAt            time.Time        `dynamodbav:"ConfirmedAt"`
+	UnsubscribedAt         time.Time        `dynamodbav:"UnsubscribedAt"`
+}
+
+type IssueStatus string
+
+const (
+	IssueSending IssueStatus = "SENDING"
+	IssueSent    IssueStatus = "SENT"
+)
+
+// NewsletterIssue tracks mailing a post to subscribers, who are mailed in ID order. Cursor is the
+// last subscriber ID of the last completed batch, so a retried send resumes after it.
+type NewsletterIssue struct {
+	PostID      string      `dynamodbav:"PostID" codec:"required"`
+	Status      IssueStatus `dynamodbav:"Status" codec:"required"`
+	Cursor      string      `dynamodbav:"Cursor"`
+	Sent        int         `dynamodbav:"Sent"`
+	StartedAt   time.Time   `dynamodbav:"StartedAt" codec:"required"`
+	CompletedAt time.Time   `dynamodbav:"CompletedAt"`
+}
+
+func SubscriberToDynamoDbAttributes(subscriber *Subscriber) (map[string]types.AttributeValue, error) {
+	if subscriber == nil {
+		return nil, nil
+	}
+	return encodeItem(subscriber)
+}
+
+func SubscriberFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*Subscriber, error) {
+	subscriber := &Subscriber{}
+	if err := decodeItem(ddbValue, subscriber); err != nil {
+		return nil, err
+	}
+	return subscriber, nil
+}
+
+func SubscribersFromDynamoDBAttributeValues(ddbValues []map[string]types.AttributeValue) ([]*Subscriber, error) {
+	subscribers := make([]*Subscriber, 0, len(ddbValues))
+	for _, ddbValue := range ddbValues {
+		subscriber, err := SubscriberFromDynamoDBAttributeValue(ddbValue)
+		if err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, subscriber)
+	}
+	return subscribers, nil
+}
+
+func NewsletterIssueToDynamoDbAttributes(issue *NewsletterIssue) (map[string]types.AttributeValue, error) {
+	if issue == nil {
+		return nil, nil
+	}
+	return encodeItem(issue)
+}
+
+func NewsletterIssueFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*NewsletterIssue, error) {
+	issue := &NewsletterIssue{}
+	if err := decodeItem(ddbValue, issue); err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
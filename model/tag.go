@@ -1,6 +1,6 @@
 package model
 
 type Tag struct {
-	id    string
-	label string
+	ID    string `json:"id" dynamodbav:"ID"`
+	Label string `json:"label" dynamodbav:"Label"`
 }
@@ -1,7 +1,70 @@
 package model
 
+import (
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type Role string
+
+const (
+	RoleAuthor Role = "AUTHOR"
+	RoleEditor Role = "EDITOR"
+	RoleAdmin  Role = "ADMIN"
+)
+
+// User is an author. Email is where invitations and password resets are sent; accounts created
+// before invitations may not have one. Users without a role are authors. ExternalSubject links the
+// user to an identity provider account as "<issuer>#<subject>"; users created through single
+// sign-on have no password.
 type User struct {
-	ID             string
-	Username       string
-	HashedPassword string
+	ID              string     `dynamodbav:"ID" codec:"required"`
+	Username        string     `dynamodbav:"Username" codec:"required"`
+	HashedPassword  string     `dynamodbav:"HashedPassword,omitempty"`
+	Email           string     `dynamodbav:"Email,omitempty"`
+	ExternalSubject string     `dynamodbav:"ExternalSubject,omitempty"`
+	Role            Role       `dynamodbav:"Role,omitempty"`
+	TwoFactor       *TwoFactor `dynamodbav:"TwoFactor,omitempty"`
+}
+
+// TwoFactor is a user's TOTP enrolment. The secret is sealed with auth.SecretBox; it is set when
+// enrolment starts and Enabled once the user has proven their app works. LastStep is the TOTP step
+// of the last accepted code, to refuse replays. Every write bumps Revision, which updates are
+// conditioned on.
+type TwoFactor struct {
+	SealedSecret       string   `dynamodbav:"SealedSecret"`
+	Enabled            bool     `dynamodbav:"Enabled"`
+	LastStep           int64    `dynamodbav:"LastStep"`
+	RecoveryCodeHashes []string `dynamodbav:"RecoveryCodeHashes,stringset,omitempty"`
+	Revision           int      `dynamodbav:"Revision"`
+}
+
+func (user *User) EffectiveRole() Role {
+	if user.Role == "" {
+		return RoleAuthor
+	}
+	return user.Role
+}
+
+func (user *User) TwoFactorEnabled() bool {
+	return user.TwoFactor != nil && user.TwoFactor.Enabled
+}
+
+func UserToDynamoDbAttributes(user *User) (map[string]types.AttributeValue, error) {
+	if user == nil {
+		return nil, nil
+	}
+	return encodeItem(user)
+}
+
+func UserFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*User, error) {
+	user := &User{}
+	if err := decodeItem(ddbValue, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func TwoFactorToDynamoDbAttribute(twoFactor *TwoFactor) (types.AttributeValue, error) {
+	return attributevalue.Marshal(twoFactor)
 }
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type DeliveryStatus string
+
+// A delivery is PENDING until an attempt succeeds or it runs out of attempts; DEAD_LETTER
+// deliveries are kept for inspection and not retried.
+const (
+	DeliveryPending    DeliveryStatus = "PENDING"
+	DeliveryDelivered  DeliveryStatus = "DELIVERED"
+	DeliveryDeadLetter DeliveryStatus = "DEAD_LETTER"
+)
+
+// Webhook is a subscription to the domain event types in Events. The signing secret is sealed with
+// auth.SecretBox, with the webhook ID as context.
+type Webhook struct {
+	ID           string    `json:"id" dynamodbav:"ID" codec:"required"`
+	OwnerID      string    `json:"ownerId" dynamodbav:"OwnerID" codec:"required"`
+	URL          string    `json:"url" dynamodbav:"URL" codec:"required"`
+	Events       []string  `json:"events" dynamodbav:"Events,stringset"`
+	SealedSecret string    `json:"-" dynamodbav:"SealedSecret" codec:"required"`
+	Active       bool      `json:"active" dynamodbav:"Active"`
+	CreatedAt    time.Time `json:"createdAt" dynamodbav:"CreatedAt" codec:"required"`
+	UpdatedAt    time.Time `json:"updatedAt" dynamodbav:"UpdatedAt" codec:"required"`
+}
+
+func (webhook *Webhook) Subscribed(eventType string) bool {
+	if !webhook.Active {
+		return false
+	}
+	for _, subscribed := range webhook.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent to one webhook, with the outcome of its latest attempt. There
+// is a single delivery per event and webhook: deliveries are keyed by WebhookID and Sequence, the
+// event's time followed by its ID, so the log reads newest first. Pending deliveries have RetryAt
+// set and appear in the index of due retries. DeleteAfter is the table's TTL attribute, in epoch
+// seconds.
+type WebhookDelivery struct {
+	WebhookID      string         `json:"webhookId" dynamodbav:"WebhookID" codec:"required"`
+	Sequence       string         `json:"-" dynamodbav:"Sequence" codec:"required"`
+	EventID        string         `json:"eventId" dynamodbav:"EventID" codec:"required"`
+	EventType      string         `json:"eventType" dynamodbav:"EventType" codec:"required"`
+	OccurredAt     time.Time      `json:"occurredAt" dynamodbav:"OccurredAt" codec:"required"`
+	Payload        string         `json:"-" dynamodbav:"Payload" codec:"required"`
+	Status         DeliveryStatus `json:"status" dynamodbav:"Status" codec:"required"`
+	Attempts       int            `json:"attempts" dynamodbav:"Attempts"`
+	LastAttemptAt  time.Time      `json:"lastAttemptAt" dynamodbav:"LastAttemptAt"`
+	LastStatusCode int            `json:"lastStatusCode,omitempty" dynamodbav:"LastStatusCode,omitempty"`
+	LastError      string         `json:"lastError,omitempty" dynamodbav:"LastError,omitempty"`
+	NextAttemptAt  time.Time      `json:"nextAttemptAt" dynamodbav:"NextAttemptAt"`
+	RetryAt        string         `json:"-" dynamodbav:"RetryAt,omitempty"`
+	DeleteAfter    int64          `json:"-" dynamodbav:"DeleteAfter"`
+}
+
+// SetKeys derives Sequence and RetryAt from the other fields.
+func (delivery *WebhookDelivery) SetKeys() {
+	delivery.Sequence = delivery.OccurredAt.UTC().Format(sortableTimeLayout) + "#" + delivery.EventID
+	delivery.RetryAt = ""
+	if delivery.Status == DeliveryPending {
+		delivery.RetryAt = WebhookRetryAt(delivery.NextAttemptAt)
+	}
+}
+
+// WebhookRetryAt is the sort key of the due retries index.
+func WebhookRetryAt(t time.Time) string {
+	return t.UTC().Format(sortableTimeLayout)
+}
+
+func WebhookToDynamoDbAttributes(webhook *Webhook) (map[string]types.AttributeValue, error) {
+	if webhook == nil {
+		return nil, nil
+	}
+	return encodeItem(webhook)
+}
+
+func WebhookFromDynamoDBAttributeValue(ddbValue map[string]types.AttributeValue) (*Webhook, error) {
+	webhook := &Webhook{}
+	if err := decodeItem(ddbValue, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func WebhooksFromDynamoDBAttributeValues(ddbValues []map[string]types.AttributeValue) ([]*Webhook, error) {
+	webhooks := make([]*Webhook, 0, len(ddbValues))
+	for _, ddbValue := range ddbValues {
+		webhook, err := WebhookFromDynamoDBAttributeValue(ddbValue)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+func WebhookDeliveryToDynamoDbAttributes(delivery *WebhookDelivery) (map[string]types.AttributeValue, error) {
+	if delivery == nil {
+		return nil, nil
+	}
+	return encodeItem(delivery)
+}
+
+func WebhookDeliveriesFromDynamoDBAttributeValues(ddbValues []map[string]types.AttributeValue) ([]*WebhookDelivery, error) {
+	deliveries := make([]*WebhookDelivery, 0, len(ddbValues))
+	for _, ddbValue := range ddbValues {
+		delivery := &WebhookDelivery{}
+		if err := decodeItem(ddbValue, delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,218 @@
+package newsletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/domainevent"
+	"github.com/neuralcoral/BlogService/mail"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore/objectstoretest"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecretBoxKey = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+type fakeSubscriberDao struct {
+	subscribers map[string]*model.Subscriber
+}
+
+func (f *fakeSubscriberDao) GetSubscriber(ctx context.Context, id string) (*model.Subscriber, error) {
+	return f.subscribers[id], nil
+}
+
+func (f *fakeSubscriberDao) PutSubscriber(ctx context.Context, subscriber *model.Subscriber) error {
+	f.subscribers[subscriber.ID] = subscriber
+	return nil
+}
+
+func (f *fakeSubscriberDao) ListConfirmedSubscribers(ctx context.Context, limit int, afterID string) ([]*model.Subscriber, string, error) {
+	var confirmed []*model.Subscriber
+	for _, subscriber := range f.subscribers {
+		if subscriber.Status == model.SubscriberConfirmed && subscriber.ID > afterID {
+			confirmed = append(confirmed, subscriber)
+		}
+	}
+	sort.Slice(confirmed, func(i, j int) bool { return confirmed[i].ID < confirmed[j].ID })
+	if len(confirmed) <= limit {
+		return confirmed, "", nil
+	}
+	return confirmed[:limit], confirmed[limit-1].ID, nil
+}
+
+type fakeIssueDao struct {
+	issues  map[string]model.NewsletterIssue
+	updates []model.NewsletterIssue
+}
+
+func (f *fakeIssueDao) GetIssue(ctx context.Context, postID string) (*model.NewsletterIssue, error) {
+	issue, ok := f.issues[postID]
+	if !ok {
+		return nil, nil
+	}
+	return &issue, nil
+}
+
+func (f *fakeIssueDao) CreateIssue(ctx context.Context, issue *model.NewsletterIssue) error {
+	if _, exists := f.issues[issue.PostID]; exists {
+		return apperror.Conflict("newsletter issue already exists")
+	}
+	f.issues[issue.PostID] = *issue
+	return nil
+}
+
+func (f *fakeIssueDao) UpdateIssue(ctx context.Context, issue *model.NewsletterIssue) error {
+	f.issues[issue.PostID] = *issue
+	f.updates = append(f.updates, *issue)
+	return nil
+}
+
+// fakeMailer records messages and fails once after failAfter sends when failAfter is positive.
+type fakeMailer struct {
+	messages  []mail.Message
+	failAfter int
+}
+
+func (m *fakeMailer) Send(ctx context.Context, message mail.Message) error {
+	if m.failAfter > 0 && len(m.messages) == m.failAfter {
+		m.failAfter = 0
+		return errors.New("relay unavailable")
+	}
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+func (m *fakeMailer) recipients() []string {
+	var recipients []string
+	for _, message := range m.messages {
+		recipients = append(recipients, message.To)
+	}
+	return recipients
+}
+
+type senderFixture struct {
+	sender      *Sender
+	subscribers *fakeSubscriberDao
+	issues      *fakeIssueDao
+	mailer      *fakeMailer
+}
+
+func setupSender(t *testing.T, emails ...string) *senderFixture {
+	t.Helper()
+	secretBox, err := auth.NewSecretBox(testSecretBoxKey)
+	if err != nil {
+		t.Fatalf("secret box: %v", err)
+	}
+	fixture := &senderFixture{
+		subscribers: &fakeSubscriberDao{subscribers: map[string]*model.Subscriber{}},
+		issues:      &fakeIssueDao{issues: map[string]model.NewsletterIssue{}},
+		mailer:      &fakeMailer{},
+	}
+	for _, email := range emails {
+		id := SubscriberID(email)
+		sealed, err := secretBox.Seal("token-"+email, id)
+		if err != nil {
+			t.Fatalf("seal: %v", err)
+		}
+		fixture.subscribers.subscribers[id] = &model.Subscriber{ID: id, Email: email, Status: model.SubscriberConfirmed, SealedUnsubscribeToken: sealed}
+	}
+	postStore := objectstoretest.NewPostStore()
+	postStore.Bodies["posts/post-1"] = "First *paragraph*.\n\nSecond <script>alert(1)</script> paragraph, where 1 < 2 and [a link](javascript:alert(1)) goes nowhere."
+	fixture.sender = NewSender(fixture.subscribers, fixture.issues, postStore, fixture.mailer, secretBox, Options{
+		LinkBaseURL: "https://blog.example.com/",
+		APIBaseURL:  "https://api.example.com",
+		BatchSize:   2,
+	})
+	fixture.sender.now = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+	return fixture
+}
+
+func testPost() *model.PostMetadata {
+	return &model.PostMetadata{ID: "post-1", Title: "Hello", BodyUrl: "posts/post-1", PreviewText: "A greeting", Status: model.Posted}
+}
+
+func TestSendIssue_ManySubscribers_SendsInBatches(t *testing.T) {
+	fixture := setupSender(t, "a@example.com", "b@example.com", "c@example.com")
+	fixture.subscribers.subscribers["pending"] = &model.Subscriber{ID: "pending", Email: "p@example.com", Status: model.SubscriberPending}
+
+	err := fixture.sender.SendIssue(context.Background(), testPost())
+
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"a@example.com", "b@example.com", "c@example.com"}, fixture.mailer.recipients())
+	assert.Len(t, fixture.issues.updates, 2)
+	issue := fixture.issues.issues["post-1"]
+	assert.Equal(t, model.IssueSent, issue.Status)
+	assert.Equal(t, 3, issue.Sent)
+	assert.Equal(t, time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC), issue.CompletedAt)
+}
+
+func TestSendIssue_MessageContent_HasUnsubscribeLinksAndSafeRenderedMarkdown(t *testing.T) {
+	fixture := setupSender(t, "a@example.com")
+
+	err := fixture.sender.SendIssue(context.Background(), testPost())
+
+	assert.Nil(t, err)
+	message := fixture.mailer.messages[0]
+	assert.Equal(t, "Hello", message.Subject)
+	assert.Equal(t, "<https://api.example.com/newsletter/unsubscribe?token=token-a%40example.com>", message.Headers["List-Unsubscribe"])
+	assert.Equal(t, "List-Unsubscribe=One-Click", message.Headers["List-Unsubscribe-Post"])
+	assert.Contains(t, message.Body, "https://blog.example.com/newsletter/unsubscribe?token=token-a%40example.com")
+	assert.Contains(t, message.Body, "https://blog.example.com/posts/post-1")
+	assert.Contains(t, message.Body, "First *paragraph*.")
+	assert.Contains(t, message.HTML, "<p>First <em>paragraph</em>.</p>")
+	assert.Contains(t, message.HTML, "where 1 &lt; 2")
+	assert.NotContains(t, message.HTML, "<script>")
+	assert.NotContains(t, message.HTML, "javascript:")
+}
+
+func TestSendIssue_FailedBatch_ResumesFromCheckpoint(t *testing.T) {
+	fixture := setupSender(t, "a@example.com", "b@example.com", "c@example.com")
+	fixture.mailer.failAfter = 2
+
+	err := fixture.sender.SendIssue(context.Background(), testPost())
+	assert.NotNil(t, err)
+	assert.Equal(t, model.IssueSending, fixture.issues.issues["post-1"].Status)
+	assert.Equal(t, 2, fixture.issues.issues["post-1"].Sent)
+
+	err = fixture.sender.SendIssue(context.Background(), testPost())
+
+	assert.Nil(t, err)
+	recipients := fixture.mailer.recipients()
+	assert.Len(t, recipients, 3)
+	assert.ElementsMatch(t, []string{"a@example.com", "b@example.com", "c@example.com"}, recipients)
+	assert.Equal(t, model.IssueSent, fixture.issues.issues["post-1"].Status)
+}
+
+func TestSendIssue_AlreadySent_SendsNothing(t *testing.T) {
+	fixture := setupSender(t, "a@example.com")
+	fixture.issues.issues["post-1"] = model.NewsletterIssue{PostID: "post-1", Status: model.IssueSent, Sent: 1}
+
+	err := fixture.sender.SendIssue(context.Background(), testPost())
+
+	assert.Nil(t, err)
+	assert.Empty(t, fixture.mailer.messages)
+	assert.Empty(t, fixture.issues.updates)
+}
+
+func TestHandleEventBridgeEvent_OtherEventType_IsIgnored(t *testing.T) {
+	fixture := setupSender(t, "a@example.com")
+	updated, _ := json.Marshal(domainevent.Event{ID: "event-1", Type: domainevent.TypePostUpdated, PostID: "post-1", Detail: domainevent.PostUpdated{Post: testPost()}})
+	published, _ := json.Marshal(domainevent.Event{ID: "event-2", Type: domainevent.TypePostPublished, PostID: "post-1", Detail: domainevent.PostPublished{Post: testPost()}})
+
+	updatedErr := fixture.sender.HandleEventBridgeEvent(context.Background(), events.EventBridgeEvent{DetailType: domainevent.TypePostUpdated, Detail: updated})
+	assert.Empty(t, fixture.mailer.messages)
+	publishedErr := fixture.sender.HandleEventBridgeEvent(context.Background(), events.EventBridgeEvent{DetailType: domainevent.TypePostPublished, Detail: published})
+
+	assert.Nil(t, updatedErr)
+	assert.Nil(t, publishedErr)
+	assert.Len(t, fixture.mailer.messages, 1)
+	assert.True(t, strings.HasPrefix(fixture.mailer.messages[0].Headers["List-Unsubscribe"], "<https://api.example.com/"))
+}
@@ -0,0 +1,176 @@
+package newsletter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/domainevent"
+	"github.com/neuralcoral/BlogService/mail"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+const defaultBatchSize = 100
+
+// SubscriberID derives a subscriber's ID from their normalized email address.
+func SubscriberID(email string) string {
+	sum := sha256.Sum256([]byte(email))
+	return hex.EncodeToString(sum[:16])
+}
+
+type Options struct {
+	// LinkBaseURL is the front end serving /posts/{id} and /newsletter/unsubscribe, which reads the
+	// token from the query string.
+	LinkBaseURL string
+	// APIBaseURL is where mail clients send RFC 8058 one-click unsubscribe requests.
+	APIBaseURL string
+	BatchSize  int
+}
+
+// Sender mails newly published posts to confirmed subscribers in batches. Progress is recorded
+// after every batch, so a retried send resumes where it stopped and each post is mailed once;
+// only the batch that was interrupted can reach some subscribers twice.
+type Sender struct {
+	subscriberDao dao.SubscriberDao
+	issueDao      dao.NewsletterIssueDao
+	postStore     objectstore.PostObjectStore
+	mailer        mail.Mailer
+	secretBox     *auth.SecretBox
+	options       Options
+	now           func() time.Time
+}
+
+func NewSender(subscriberDao dao.SubscriberDao, issueDao dao.NewsletterIssueDao, postStore objectstore.PostObjectStore, mailer mail.Mailer, secretBox *auth.SecretBox, options Options) *Sender {
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultBatchSize
+	}
+	return &Sender{
+		subscriberDao: subscriberDao,
+		issueDao:      issueDao,
+		postStore:     postStore,
+		mailer:        mailer,
+		secretBox:     secretBox,
+		options:       options,
+		now:           time.Now,
+	}
+}
+
+// HandleEventBridgeEvent is the Lambda entry point, triggered by a bus rule for PostPublished
+// events; other events are ignored.
+func (sender *Sender) HandleEventBridgeEvent(ctx context.Context, event events.EventBridgeEvent) error {
+	var published struct {
+		Type   string                    `json:"type"`
+		Detail domainevent.PostPublished `json:"detail"`
+	}
+	if err := json.Unmarshal(event.Detail, &published); err != nil {
+		return fmt.Errorf("decode domain event: %w", err)
+	}
+	if published.Type != domainevent.TypePostPublished || published.Detail.Post == nil {
+		return nil
+	}
+	return sender.SendIssue(ctx, published.Detail.Post)
+}
+
+// SendIssue mails the post to every confirmed subscriber, unless it was mailed before.
+func (sender *Sender) SendIssue(ctx context.Context, post *model.PostMetadata) error {
+	issue, err := sender.startIssue(ctx, post.ID)
+	if err != nil || issue == nil {
+		return err
+	}
+
+	body, err := sender.postStore.GetPost(ctx, post.BodyUrl)
+	if err != nil {
+		return err
+	}
+
+	for {
+		subscribers, nextID, err := sender.subscriberDao.ListConfirmedSubscribers(ctx, sender.options.BatchSize, issue.Cursor)
+		if err != nil {
+			return err
+		}
+		for _, subscriber := range subscribers {
+			if err := sender.send(ctx, post, body, subscriber); err != nil {
+				return fmt.Errorf("mail %s to subscriber %s: %w", post.ID, subscriber.ID, err)
+			}
+		}
+
+		issue.Sent += len(subscribers)
+		if len(subscribers) > 0 {
+			issue.Cursor = subscribers[len(subscribers)-1].ID
+		}
+		if nextID == "" {
+			issue.Status = model.IssueSent
+			issue.CompletedAt = sender.now().UTC()
+		}
+		if err := sender.issueDao.UpdateIssue(ctx, issue); err != nil {
+			return err
+		}
+		if nextID == "" {
+			return nil
+		}
+	}
+}
+
+// startIssue returns the issue to continue sending, or nil if the post was already mailed.
+func (sender *Sender) startIssue(ctx context.Context, postID string) (*model.NewsletterIssue, error) {
+	issue, err := sender.issueDao.GetIssue(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if issue != nil {
+		if issue.Status == model.IssueSent {
+			return nil, nil
+		}
+		return issue, nil
+	}
+
+	issue = &model.NewsletterIssue{PostID: postID, Status: model.IssueSending, StartedAt: sender.now().UTC()}
+	err = sender.issueDao.CreateIssue(ctx, issue)
+	if apperror.KindOf(err) == apperror.KindConflict {
+		return nil, fmt.Errorf("newsletter for post %s is already being sent", postID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+func (sender *Sender) send(ctx context.Context, post *model.PostMetadata, body string, subscriber *model.Subscriber) error {
+	token, err := sender.secretBox.Open(subscriber.SealedUnsubscribeToken, subscriber.ID)
+	if err != nil {
+		return err
+	}
+	rendered, err := Render(post, body, link(sender.options.LinkBaseURL, "/posts/"+url.PathEscape(post.ID), ""), link(sender.options.LinkBaseURL, "/newsletter/unsubscribe", token))
+	if err != nil {
+		return err
+	}
+
+	return sender.mailer.Send(ctx, mail.Message{
+		To:      subscriber.Email,
+		Subject: rendered.Subject,
+		Body:    rendered.Text,
+		HTML:    rendered.HTML,
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + link(sender.options.APIBaseURL, "/newsletter/unsubscribe", token) + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	})
+}
+
+func link(baseURL string, path string, token string) string {
+	result := strings.TrimRight(baseURL, "/") + path
+	if token != "" {
+		result += "?token=" + url.QueryEscape(token)
+	}
+	return result
+}
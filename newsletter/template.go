@@ -0,0 +1,96 @@
+package newsletter
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+const textLayout = `{{.Post.Title}}
+{{if .Post.PreviewText}}
+{{.Post.PreviewText}}
+{{end}}
+{{range .Paragraphs}}{{.}}
+
+{{end}}Read it on the blog: {{.PostURL}}
+
+--
+You're receiving this because you subscribed to the blog's newsletter.
+Unsubscribe: {{.UnsubscribeURL}}
+`
+
+const htmlLayout = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Post.Title}}</title></head>
+<body style="font-family: Georgia, serif; max-width: 40em; margin: 0 auto; padding: 1em;">
+<h1>{{.Post.Title}}</h1>
+{{if .Post.PreviewText}}<p><em>{{.Post.PreviewText}}</em></p>
+{{end}}{{.BodyHTML}}<p><a href="{{.PostURL}}">Read it on the blog</a></p>
+<hr>
+<p style="font-size: small; color: #666;">You're receiving this because you subscribed to the blog's newsletter. <a href="{{.UnsubscribeURL}}">Unsubscribe</a></p>
+</body>
+</html>
+`
+
+var (
+	textTemplate = texttemplate.Must(texttemplate.New("text").Parse(textLayout))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(htmlLayout))
+	// markdown leaves out raw HTML and dangerous link schemes such as javascript:, since it renders
+	// without goldmark's unsafe option.
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+)
+
+type templateData struct {
+	Post           *model.PostMetadata
+	Paragraphs     []string
+	BodyHTML       htmltemplate.HTML
+	PostURL        string
+	UnsubscribeURL string
+}
+
+// Rendered is a newsletter rendered for one recipient.
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Render renders the newsletter for a post. The HTML part renders the Markdown body; the text part
+// keeps its source, split into paragraphs at blank lines.
+func Render(post *model.PostMetadata, body string, postURL string, unsubscribeURL string) (*Rendered, error) {
+	var bodyHTML bytes.Buffer
+	if err := markdown.Convert([]byte(body), &bodyHTML); err != nil {
+		return nil, err
+	}
+	data := templateData{
+		Post:           post,
+		Paragraphs:     paragraphs(body),
+		BodyHTML:       htmltemplate.HTML(bodyHTML.String()),
+		PostURL:        postURL,
+		UnsubscribeURL: unsubscribeURL,
+	}
+
+	var text, html strings.Builder
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return nil, err
+	}
+	return &Rendered{Subject: post.Title, Text: text.String(), HTML: html.String()}, nil
+}
+
+func paragraphs(body string) []string {
+	var result []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			result = append(result, paragraph)
+		}
+	}
+	return result
+}
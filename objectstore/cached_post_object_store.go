@@ -0,0 +1,116 @@
+package objectstore
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/neuralcoral/BlogService/cache"
+	"golang.org/x/sync/singleflight"
+)
+
+const postBodyCachePrefix = "post-body:"
+
+// CachedPostObjectStore is a read-through cache of post bodies in front of another PostObjectStore,
+// with the same local, remote and singleflight layering as dao.CachedPostMetadataDao.
+type CachedPostObjectStore struct {
+	next     PostObjectStore
+	local    *cache.LRU[string, string]
+	remote   cache.Remote
+	ttl      time.Duration
+	loads    singleflight.Group
+	inFlight *cache.LoadTracker
+}
+
+// NewCachedPostObjectStore caches up to size bodies for ttl. remote may be nil.
+func NewCachedPostObjectStore(next PostObjectStore, size int, ttl time.Duration, remote cache.Remote) *CachedPostObjectStore {
+	return &CachedPostObjectStore{
+		next:     next,
+		local:    cache.NewLRU[string, string](size, ttl),
+		remote:   remote,
+		ttl:      ttl,
+		inFlight: cache.NewLoadTracker(),
+	}
+}
+
+func (store *CachedPostObjectStore) GetPost(ctx context.Context, location string) (string, error) {
+	if body, ok := store.local.Get(location); ok {
+		return body, nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	loaded, err, _ := store.loads.Do(location, func() (interface{}, error) {
+		load := store.inFlight.Start(location)
+		defer load.Done()
+
+		if store.remote != nil {
+			data, ok, err := store.remote.Get(ctx, postBodyCachePrefix+location)
+			if err != nil {
+				log.Printf("remote cache: get body %s: %v", location, err)
+			}
+			if ok {
+				store.local.Set(location, string(data))
+				store.discardIfInvalidated(ctx, load)
+				return string(data), nil
+			}
+		}
+
+		body, err := store.next.GetPost(ctx, location)
+		if err != nil {
+			return "", err
+		}
+		store.local.Set(location, body)
+		if store.remote != nil {
+			if err := store.remote.Set(ctx, postBodyCachePrefix+location, []byte(body), store.ttl); err != nil {
+				log.Printf("remote cache: set body %s: %v", location, err)
+			}
+		}
+		store.discardIfInvalidated(ctx, load)
+		return body, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return loaded.(string), nil
+}
+
+func (store *CachedPostObjectStore) PutPost(ctx context.Context, location string, body string) error {
+	defer store.invalidate(ctx, location)
+	return store.next.PutPost(ctx, location, body)
+}
+
+func (store *CachedPostObjectStore) CreatePost(ctx context.Context, location string, body string) error {
+	defer store.invalidate(ctx, location)
+	return store.next.CreatePost(ctx, location, body)
+}
+
+func (store *CachedPostObjectStore) DeletePost(ctx context.Context, location string) error {
+	defer store.invalidate(ctx, location)
+	return store.next.DeletePost(ctx, location)
+}
+
+func (store *CachedPostObjectStore) invalidate(ctx context.Context, location string) {
+	store.inFlight.Invalidate(location)
+	store.loads.Forget(location)
+	store.local.Delete(location)
+	if store.remote == nil {
+		return
+	}
+	if err := store.remote.Delete(ctx, postBodyCachePrefix+location); err != nil {
+		log.Printf("remote cache: invalidate body %s: %v", location, err)
+	}
+}
+
+// discardIfInvalidated removes a body a load cached if a write invalidated it during the load.
+func (store *CachedPostObjectStore) discardIfInvalidated(ctx context.Context, load *cache.TrackedLoad) {
+	if !load.Invalidated() {
+		return
+	}
+	store.local.Delete(load.Key())
+	if store.remote == nil {
+		return
+	}
+	if err := store.remote.Delete(ctx, postBodyCachePrefix+load.Key()); err != nil {
+		log.Printf("remote cache: discard body %s: %v", load.Key(), err)
+	}
+}
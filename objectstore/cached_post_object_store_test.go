@@ -0,0 +1,183 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/cache/cachetest"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockPostObjectStore struct {
+	GetFunc    func(ctx context.Context, location string) (string, error)
+	PutFunc    func(ctx context.Context, location string, body string) error
+	CreateFunc func(ctx context.Context, location string, body string) error
+	DeleteFunc func(ctx context.Context, location string) error
+}
+
+func (m *MockPostObjectStore) GetPost(ctx context.Context, location string) (string, error) {
+	return m.GetFunc(ctx, location)
+}
+
+func (m *MockPostObjectStore) PutPost(ctx context.Context, location string, body string) error {
+	return m.PutFunc(ctx, location, body)
+}
+
+func (m *MockPostObjectStore) CreatePost(ctx context.Context, location string, body string) error {
+	return m.CreateFunc(ctx, location, body)
+}
+
+func (m *MockPostObjectStore) DeletePost(ctx context.Context, location string) error {
+	return m.DeleteFunc(ctx, location)
+}
+
+// mutableStore serves one body that PutPost replaces.
+func mutableStore(body string) *MockPostObjectStore {
+	var mutex sync.Mutex
+	return &MockPostObjectStore{
+		GetFunc: func(ctx context.Context, location string) (string, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return body, nil
+		},
+		PutFunc: func(ctx context.Context, location string, newBody string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			body = newBody
+			return nil
+		},
+	}
+}
+
+func TestCachedPostObjectStore_ConcurrentMisses_LoadOnce(t *testing.T) {
+	var loads int32
+	release := make(chan struct{})
+	next := &MockPostObjectStore{GetFunc: func(ctx context.Context, location string) (string, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "body", nil
+	}}
+	sut := NewCachedPostObjectStore(next, 10, time.Minute, nil)
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = sut.GetPost(context.Background(), "posts/1/body.md")
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+	for _, result := range results {
+		assert.Equal(t, "body", result)
+	}
+}
+
+func TestCachedPostObjectStore_Hit_SkipsNext(t *testing.T) {
+	loads := 0
+	next := &MockPostObjectStore{GetFunc: func(ctx context.Context, location string) (string, error) {
+		loads++
+		return "body", nil
+	}}
+	remote := cachetest.NewRemote()
+	sut := NewCachedPostObjectStore(next, 10, time.Minute, remote)
+
+	_, _ = sut.GetPost(context.Background(), "posts/1/body.md")
+	result, err := sut.GetPost(context.Background(), "posts/1/body.md")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "body", result)
+	assert.Equal(t, 1, loads)
+	assert.Equal(t, []byte("body"), remote.Values["post-body:posts/1/body.md"])
+}
+
+func TestCachedPostObjectStore_RemoteHit_SkipsNext(t *testing.T) {
+	next := &MockPostObjectStore{GetFunc: func(ctx context.Context, location string) (string, error) {
+		t.Fatal("next should not be called on a remote hit")
+		return "", nil
+	}}
+	remote := cachetest.NewRemote()
+	remote.Values["post-body:posts/1/body.md"] = []byte("remote body")
+	sut := NewCachedPostObjectStore(next, 10, time.Minute, remote)
+
+	result, err := sut.GetPost(context.Background(), "posts/1/body.md")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "remote body", result)
+}
+
+func TestCachedPostObjectStore_LoadFailure_IsNotCached(t *testing.T) {
+	loads := 0
+	next := &MockPostObjectStore{GetFunc: func(ctx context.Context, location string) (string, error) {
+		loads++
+		if loads == 1 {
+			return "", errors.New("bucket unavailable")
+		}
+		return "body", nil
+	}}
+	sut := NewCachedPostObjectStore(next, 10, time.Minute, nil)
+
+	_, err := sut.GetPost(context.Background(), "posts/1/body.md")
+	assert.Error(t, err)
+	result, err := sut.GetPost(context.Background(), "posts/1/body.md")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "body", result)
+}
+
+func TestCachedPostObjectStore_PutAndDelete_InvalidateLocalAndRemote(t *testing.T) {
+	next := mutableStore("before")
+	next.DeleteFunc = func(ctx context.Context, location string) error { return nil }
+	remote := cachetest.NewRemote()
+	sut := NewCachedPostObjectStore(next, 10, time.Minute, remote)
+	_, _ = sut.GetPost(context.Background(), "posts/1/body.md")
+
+	assert.NoError(t, sut.PutPost(context.Background(), "posts/1/body.md", "after"))
+	assert.NotContains(t, remote.Values, "post-body:posts/1/body.md")
+	result, _ := sut.GetPost(context.Background(), "posts/1/body.md")
+	assert.Equal(t, "after", result)
+
+	assert.NoError(t, sut.DeletePost(context.Background(), "posts/1/body.md"))
+	assert.NotContains(t, remote.Values, "post-body:posts/1/body.md")
+}
+
+func TestCachedPostObjectStore_PutDuringLoad_DoesNotCacheStaleBody(t *testing.T) {
+	next := mutableStore("before")
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	get := next.GetFunc
+	next.GetFunc = func(ctx context.Context, location string) (string, error) {
+		body, err := get(ctx, location)
+		if body == "before" {
+			close(loading)
+			<-release
+		}
+		return body, err
+	}
+	remote := cachetest.NewRemote()
+	sut := NewCachedPostObjectStore(next, 10, time.Minute, remote)
+
+	staleRead := make(chan string)
+	go func() {
+		body, _ := sut.GetPost(context.Background(), "posts/1/body.md")
+		staleRead <- body
+	}()
+	<-loading
+	assert.NoError(t, sut.PutPost(context.Background(), "posts/1/body.md", "after"))
+	close(release)
+	assert.Equal(t, "before", <-staleRead)
+
+	assert.NotContains(t, remote.Values, "post-body:posts/1/body.md")
+	result, err := sut.GetPost(context.Background(), "posts/1/body.md")
+	assert.NoError(t, err)
+	assert.Equal(t, "after", result)
+}
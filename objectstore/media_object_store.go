@@ -0,0 +1,30 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+type MediaUpload struct {
+	Key       string
+	MimeType  string
+	SizeBytes int64
+	Checksum  string
+	ExpiresIn time.Duration
+}
+
+type PresignedUpload struct {
+	URL          string
+	Method       string
+	SignedHeader http.Header
+	ExpiresAt    time.Time
+}
+
+type MediaObjectStore interface {
+	PresignUpload(ctx context.Context, upload *MediaUpload) (*PresignedUpload, error)
+	GetMedia(ctx context.Context, key string) (io.ReadCloser, error)
+	PutMedia(ctx context.Context, key string, mimeType string, body []byte) error
+	DeleteMedia(ctx context.Context, key string) error
+}
@@ -0,0 +1,99 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/neuralcoral/BlogService/apperror"
+)
+
+type S3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+type S3PresignAPI interface {
+	PresignPutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+type MediaS3ObjectStore struct {
+	client    S3API
+	presigner S3PresignAPI
+	bucket    string
+}
+
+func NewMediaS3ObjectStore(client S3API, presigner S3PresignAPI, bucket string) *MediaS3ObjectStore {
+	return &MediaS3ObjectStore{
+		client:    client,
+		presigner: presigner,
+		bucket:    bucket,
+	}
+}
+
+// PresignUpload signs the content type, length and SHA-256 checksum into the URL so S3 rejects
+// uploads that differ from what was declared when the asset was registered.
+func (store *MediaS3ObjectStore) PresignUpload(ctx context.Context, upload *MediaUpload) (*PresignedUpload, error) {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(store.bucket),
+		Key:           aws.String(upload.Key),
+		ContentType:   aws.String(upload.MimeType),
+		ContentLength: aws.Int64(upload.SizeBytes),
+	}
+	if upload.Checksum != "" {
+		input.ChecksumSHA256 = aws.String(upload.Checksum)
+	}
+
+	request, err := store.presigner.PresignPutObject(ctx, input, s3.WithPresignExpires(upload.ExpiresIn))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PresignedUpload{
+		URL:          request.URL,
+		Method:       request.Method,
+		SignedHeader: request.SignedHeader,
+		ExpiresAt:    time.Now().Add(upload.ExpiresIn),
+	}, nil
+}
+
+func (store *MediaS3ObjectStore) GetMedia(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := store.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, apperror.Wrap(apperror.KindNotFound, "media not found", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (store *MediaS3ObjectStore) PutMedia(ctx context.Context, key string, mimeType string, body []byte) error {
+	_, err := store.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(store.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(mimeType),
+		ContentLength: aws.Int64(int64(len(body))),
+		Body:          bytes.NewReader(body),
+	})
+	return err
+}
+
+func (store *MediaS3ObjectStore) DeleteMedia(ctx context.Context, key string) error {
+	_, err := store.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
@@ -0,0 +1,76 @@
+package objectstoretest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/objectstore"
+)
+
+// MediaStore keeps media objects in memory. Set Err to make every call fail.
+type MediaStore struct {
+	mutex   sync.Mutex
+	Objects map[string][]byte
+	Types   map[string]string
+	Err     error
+}
+
+func NewMediaStore() *MediaStore {
+	return &MediaStore{Objects: map[string][]byte{}, Types: map[string]string{}}
+}
+
+func (store *MediaStore) PresignUpload(ctx context.Context, upload *objectstore.MediaUpload) (*objectstore.PresignedUpload, error) {
+	if store.Err != nil {
+		return nil, store.Err
+	}
+	header := http.Header{"Content-Type": {upload.MimeType}}
+	if upload.Checksum != "" {
+		header.Set("X-Amz-Checksum-Sha256", upload.Checksum)
+	}
+	return &objectstore.PresignedUpload{
+		URL:          "https://media.test/" + upload.Key,
+		Method:       http.MethodPut,
+		SignedHeader: header,
+		ExpiresAt:    time.Now().Add(upload.ExpiresIn),
+	}, nil
+}
+
+func (store *MediaStore) GetMedia(ctx context.Context, key string) (io.ReadCloser, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.Err != nil {
+		return nil, store.Err
+	}
+	data, ok := store.Objects[key]
+	if !ok {
+		return nil, apperror.NotFound("media not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (store *MediaStore) PutMedia(ctx context.Context, key string, mimeType string, body []byte) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.Err != nil {
+		return store.Err
+	}
+	store.Objects[key] = body
+	store.Types[key] = mimeType
+	return nil
+}
+
+func (store *MediaStore) DeleteMedia(ctx context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if store.Err != nil {
+		return store.Err
+	}
+	delete(store.Objects, key)
+	delete(store.Types, key)
+	return nil
+}
@@ -0,0 +1,2 @@
+// Package objectstoretest provides in-memory object stores for tests.
+package objectstoretest
@@ -0,0 +1,61 @@
+package objectstoretest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/neuralcoral/BlogService/apperror"
+)
+
+// PostStore keeps post bodies in memory. Set FailPut to make PutPost and CreatePost fail for one
+// location.
+type PostStore struct {
+	mutex   sync.Mutex
+	Bodies  map[string]string
+	FailPut string
+}
+
+func NewPostStore() *PostStore {
+	return &PostStore{Bodies: map[string]string{}}
+}
+
+func (store *PostStore) GetPost(ctx context.Context, location string) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	body, ok := store.Bodies[location]
+	if !ok {
+		return "", fmt.Errorf("no post body at %s", location)
+	}
+	return body, nil
+}
+
+func (store *PostStore) PutPost(ctx context.Context, location string, body string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if location == store.FailPut {
+		return fmt.Errorf("put %s: bucket unavailable", location)
+	}
+	store.Bodies[location] = body
+	return nil
+}
+
+func (store *PostStore) CreatePost(ctx context.Context, location string, body string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	if location == store.FailPut {
+		return fmt.Errorf("put %s: bucket unavailable", location)
+	}
+	if _, exists := store.Bodies[location]; exists {
+		return apperror.Conflict("post body already exists")
+	}
+	store.Bodies[location] = body
+	return nil
+}
+
+func (store *PostStore) DeletePost(ctx context.Context, location string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.Bodies, location)
+	return nil
+}
@@ -1,6 +1,11 @@
 package objectstore
 
+import "context"
+
 type PostObjectStore interface {
-	GetPost(location string) (string, error)
-	DeletePost(location string) (string, error)
+	GetPost(ctx context.Context, location string) (string, error)
+	PutPost(ctx context.Context, location string, body string) error
+	// CreatePost is PutPost that fails with a conflict if a body is already stored at the location.
+	CreatePost(ctx context.Context, location string, body string) error
+	DeletePost(ctx context.Context, location string) error
 }
@@ -0,0 +1,81 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/neuralcoral/BlogService/apperror"
+)
+
+const postBodyContentType = "text/markdown; charset=utf-8"
+
+type PostS3ObjectStore struct {
+	client S3API
+	bucket string
+}
+
+func NewPostS3ObjectStore(client S3API, bucket string) *PostS3ObjectStore {
+	return &PostS3ObjectStore{
+		client: client,
+		bucket: bucket,
+	}
+}
+
+func (store *PostS3ObjectStore) GetPost(ctx context.Context, location string) (string, error) {
+	output, err := store.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(location),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer output.Body.Close()
+
+	body, err := io.ReadAll(output.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (store *PostS3ObjectStore) PutPost(ctx context.Context, location string, body string) error {
+	_, err := store.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(store.bucket),
+		Key:           aws.String(location),
+		ContentType:   aws.String(postBodyContentType),
+		ContentLength: aws.Int64(int64(len(body))),
+		Body:          strings.NewReader(body),
+	})
+	return err
+}
+
+// CreatePost writes with If-None-Match, which S3 answers with PreconditionFailed when the key exists
+// and ConditionalRequestConflict when a concurrent write to it is in progress.
+func (store *PostS3ObjectStore) CreatePost(ctx context.Context, location string, body string) error {
+	_, err := store.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(store.bucket),
+		Key:           aws.String(location),
+		ContentType:   aws.String(postBodyContentType),
+		ContentLength: aws.Int64(int64(len(body))),
+		Body:          strings.NewReader(body),
+		IfNoneMatch:   aws.String("*"),
+	})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "PreconditionFailed" || apiErr.ErrorCode() == "ConditionalRequestConflict") {
+		return apperror.Wrap(apperror.KindConflict, "post body already exists", err)
+	}
+	return err
+}
+
+func (store *PostS3ObjectStore) DeletePost(ctx context.Context, location string) error {
+	_, err := store.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(store.bucket),
+		Key:    aws.String(location),
+	})
+	return err
+}
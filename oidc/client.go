@@ -0,0 +1,236 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidIDToken = errors.New("oidc: invalid ID token")
+
+type Config struct {
+	// Issuer is the provider's issuer URL; its discovery document is read from
+	// Issuer/.well-known/openid-configuration.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// Scopes are requested in addition to "openid".
+	Scopes     []string
+	KeySetTTL  time.Duration
+	HTTPClient *http.Client
+}
+
+// Identity is what a verified ID token says about the user.
+type Identity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Claims            jwt.MapClaims
+}
+
+// Strings reads a claim that is a string or a list of strings, such as a groups claim.
+func (identity *Identity) Strings(name string) []string {
+	switch value := identity.Claims[name].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var result []string
+		for _, item := range value {
+			if text, ok := item.(string); ok {
+				result = append(result, text)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client runs the authorization code flow with PKCE against one provider. The discovery document is
+// fetched on first use and kept; a failed fetch is retried on the next call.
+type Client struct {
+	config Config
+
+	mutex     sync.Mutex
+	discovery *discovery
+	keySet    *KeySet
+}
+
+func NewClient(config Config) *Client {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if config.KeySetTTL <= 0 {
+		config.KeySetTTL = time.Hour
+	}
+	config.Issuer = strings.TrimRight(config.Issuer, "/")
+	return &Client{config: config}
+}
+
+// NewPKCE returns a code verifier and its S256 challenge (RFC 7636).
+func NewPKCE() (verifier string, challenge string, err error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(random)
+	digest := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(digest[:]), nil
+}
+
+// AuthorizationURL is where the browser is sent to log in at the provider.
+func (client *Client) AuthorizationURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	provider, _, err := client.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", client.config.ClientID)
+	query.Set("redirect_uri", client.config.RedirectURL)
+	query.Set("scope", strings.Join(append([]string{"openid"}, client.config.Scopes...), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(provider.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return provider.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified identity from the ID token.
+func (client *Client) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Identity, error) {
+	provider, _, err := client.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", client.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", client.config.ClientID)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if client.config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(client.config.ClientID), url.QueryEscape(client.config.ClientSecret))
+	}
+
+	response, err := client.config.HTTPClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request: %w", err)
+	}
+	defer response.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("oidc: token response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK || body.Error != "" {
+		return nil, fmt.Errorf("oidc: token request failed: %s %s %s", response.Status, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, errors.New("oidc: token response has no ID token")
+	}
+	return client.VerifyIDToken(ctx, body.IDToken, nonce)
+}
+
+// VerifyIDToken checks the token's signature against the provider's key set, its issuer, audience,
+// expiry and nonce.
+func (client *Client) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*Identity, error) {
+	provider, keySet, err := client.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return keySet.Key(ctx, keyID)
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithIssuer(provider.Issuer),
+		jwt.WithAudience(client.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidIDToken, err)
+	}
+	if claimNonce, _ := claims["nonce"].(string); claimNonce == "" || claimNonce != nonce {
+		return nil, errors.Join(ErrInvalidIDToken, errors.New("nonce does not match"))
+	}
+
+	identity := &Identity{Issuer: provider.Issuer, Claims: claims}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.PreferredUsername, _ = claims["preferred_username"].(string)
+	// Some providers send email_verified as a string.
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	if identity.Subject == "" {
+		return nil, errors.Join(ErrInvalidIDToken, errors.New("missing subject"))
+	}
+	return identity, nil
+}
+
+func (client *Client) discover(ctx context.Context) (*discovery, *KeySet, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if client.discovery != nil {
+		return client.discovery, client.keySet, nil
+	}
+
+	var document discovery
+	if err := getJSON(ctx, client.config.HTTPClient, client.config.Issuer+"/.well-known/openid-configuration", &document); err != nil {
+		return nil, nil, fmt.Errorf("oidc: discovery: %w", err)
+	}
+	if strings.TrimRight(document.Issuer, "/") != client.config.Issuer {
+		return nil, nil, fmt.Errorf("oidc: discovery document is for issuer %q, expected %q", document.Issuer, client.config.Issuer)
+	}
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.JWKSURI == "" {
+		return nil, nil, errors.New("oidc: discovery document is missing endpoints")
+	}
+
+	client.discovery = &document
+	client.keySet = NewKeySet(document.JWKSURI, client.config.HTTPClient, client.config.KeySetTTL)
+	return client.discovery, client.keySet, nil
+}
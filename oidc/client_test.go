@@ -0,0 +1,107 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/neuralcoral/BlogService/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupProvider(t *testing.T) (*oidctest.Server, *Client) {
+	t.Helper()
+	provider, err := oidctest.NewServer("blog", "client-secret")
+	if err != nil {
+		t.Fatalf("start provider: %v", err)
+	}
+	t.Cleanup(provider.Close)
+	client := NewClient(Config{
+		Issuer:       provider.URL,
+		ClientID:     "blog",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://blog.example.com/login/callback",
+		Scopes:       []string{"email", "profile"},
+	})
+	return provider, client
+}
+
+// authorize follows the authorization URL like a browser would and returns the code from the
+// redirect back to the blog.
+func authorize(t *testing.T, authorizationURL string) (string, string) {
+	t.Helper()
+	noRedirects := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	response, err := noRedirects.Get(authorizationURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	response.Body.Close()
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("parse redirect: %v", err)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestExchange_AuthorizationCodeWithPKCE_ReturnsIdentity(t *testing.T) {
+	provider, client := setupProvider(t)
+	provider.Claims = map[string]interface{}{"sub": "abc", "email": "alice@example.com", "email_verified": "true", "groups": []string{"blog-editors"}}
+	verifier, challenge, _ := NewPKCE()
+
+	authorizationURL, err := client.AuthorizationURL(context.Background(), "state-1", "nonce-1", challenge)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code, state := authorize(t, authorizationURL)
+	identity, err := client.Exchange(context.Background(), code, verifier, "nonce-1")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "state-1", state)
+	assert.Equal(t, "abc", identity.Subject)
+	assert.Equal(t, provider.URL, identity.Issuer)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, []string{"blog-editors"}, identity.Strings("groups"))
+}
+
+func TestExchange_WrongCodeVerifier_Fails(t *testing.T) {
+	_, client := setupProvider(t)
+	_, challenge, _ := NewPKCE()
+	otherVerifier, _, _ := NewPKCE()
+	authorizationURL, _ := client.AuthorizationURL(context.Background(), "state-1", "nonce-1", challenge)
+	code, _ := authorize(t, authorizationURL)
+
+	_, err := client.Exchange(context.Background(), code, otherVerifier, "nonce-1")
+
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestVerifyIDToken_WrongNonceOrAudience_Fails(t *testing.T) {
+	provider, client := setupProvider(t)
+	token, _ := provider.IDToken("nonce-1")
+	provider.Claims["aud"] = "someone-else"
+	otherAudience, _ := provider.IDToken("nonce-1")
+
+	_, nonceErr := client.VerifyIDToken(context.Background(), token, "nonce-2")
+	_, audienceErr := client.VerifyIDToken(context.Background(), otherAudience, "nonce-1")
+
+	assert.True(t, errors.Is(nonceErr, ErrInvalidIDToken))
+	assert.True(t, errors.Is(audienceErr, ErrInvalidIDToken))
+}
+
+func TestVerifyIDToken_CachesKeySet(t *testing.T) {
+	provider, client := setupProvider(t)
+	token, _ := provider.IDToken("nonce-1")
+
+	_, firstErr := client.VerifyIDToken(context.Background(), token, "nonce-1")
+	_, secondErr := client.VerifyIDToken(context.Background(), token, "nonce-1")
+
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, 1, provider.KeySetRequests)
+}
@@ -0,0 +1,150 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval stops tokens with made-up key IDs from making us fetch the key set on every
+// request.
+var minRefreshInterval = time.Minute
+
+type jsonWebKey struct {
+	KeyID string `json:"kid"`
+	Type  string `json:"kty"`
+	Use   string `json:"use"`
+	N     string `json:"n"`
+	E     string `json:"e"`
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+// KeySet caches the provider's signing keys. Keys are refetched when the cache is older than ttl, or
+// sooner when a token names a key the cache doesn't have, which is how providers roll keys.
+type KeySet struct {
+	url        string
+	httpClient *http.Client
+	ttl        time.Duration
+	now        func() time.Time
+
+	mutex     sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func NewKeySet(url string, httpClient *http.Client, ttl time.Duration) *KeySet {
+	return &KeySet{url: url, httpClient: httpClient, ttl: ttl, now: time.Now}
+}
+
+// Key returns the public key with the key ID.
+func (keySet *KeySet) Key(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	keySet.mutex.Lock()
+	defer keySet.mutex.Unlock()
+
+	now := keySet.now()
+	stale := now.Sub(keySet.fetchedAt) >= keySet.ttl
+	if key, ok := keySet.keys[keyID]; ok && !stale {
+		return key, nil
+	}
+	if stale || now.Sub(keySet.fetchedAt) >= minRefreshInterval {
+		if err := keySet.refresh(ctx, now); err != nil {
+			return nil, err
+		}
+	}
+	if key, ok := keySet.keys[keyID]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc: no signing key with ID %q", keyID)
+}
+
+func (keySet *KeySet) refresh(ctx context.Context, now time.Time) error {
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, keySet.httpClient, keySet.url, &document); err != nil {
+		return fmt.Errorf("oidc: fetch key set: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return fmt.Errorf("oidc: key %q: %w", jwk.KeyID, err)
+		}
+		if key != nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	keySet.keys = keys
+	keySet.fetchedAt = now
+	return nil
+}
+
+// publicKey decodes RSA and P-256 keys; other key types are skipped.
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Type {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, nil
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+func getJSON(ctx context.Context, httpClient *http.Client, url string, target interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
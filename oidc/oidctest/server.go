@@ -0,0 +1,214 @@
+// Package oidctest is a minimal OpenID Connect provider for tests and local development. It logs in
+// whichever user is configured without asking, and checks PKCE like a real provider.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "oidctest-key"
+
+// Server is the mock provider. Set Claims to choose who logs in; "sub" defaults to "user-1".
+type Server struct {
+	ClientID     string
+	ClientSecret string
+	Claims       map[string]interface{}
+	URL          string
+
+	httpServer *httptest.Server
+	handler    http.Handler
+	key        *rsa.PrivateKey
+
+	mutex sync.Mutex
+	codes map[string]authorization
+	// KeySetRequests counts fetches of the key set, to test caching.
+	KeySetRequests int
+}
+
+type authorization struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// NewProvider builds a provider to serve at issuerURL with an http.Server of your own.
+func NewProvider(issuerURL string, clientID string, clientSecret string) (*Server, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	server := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Claims:       map[string]interface{}{"sub": "user-1"},
+		URL:          issuerURL,
+		key:          key,
+		codes:        map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", server.discovery)
+	mux.HandleFunc("/jwks", server.jwks)
+	mux.HandleFunc("/authorize", server.authorize)
+	mux.HandleFunc("/token", server.token)
+	server.handler = mux
+	return server, nil
+}
+
+// NewServer starts a provider on a local port. Close it when done.
+func NewServer(clientID string, clientSecret string) (*Server, error) {
+	server, err := NewProvider("", clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	server.httpServer = httptest.NewServer(server)
+	server.URL = server.httpServer.URL
+	return server, nil
+}
+
+func (server *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	server.handler.ServeHTTP(writer, request)
+}
+
+// Close stops a server started with NewServer.
+func (server *Server) Close() {
+	if server.httpServer != nil {
+		server.httpServer.Close()
+	}
+}
+
+// IDToken signs an ID token with the provider's key, for tests that skip the redirect.
+func (server *Server) IDToken(nonce string) (string, error) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.signIDToken(nonce)
+}
+
+func (server *Server) signIDToken(nonce string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   server.URL,
+		"aud":   server.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+	}
+	for name, value := range server.Claims {
+		claims[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	return token.SignedString(server.key)
+}
+
+func (server *Server) discovery(writer http.ResponseWriter, request *http.Request) {
+	writeJSON(writer, http.StatusOK, map[string]string{
+		"issuer":                 server.URL,
+		"authorization_endpoint": server.URL + "/authorize",
+		"token_endpoint":         server.URL + "/token",
+		"jwks_uri":               server.URL + "/jwks",
+	})
+}
+
+func (server *Server) jwks(writer http.ResponseWriter, request *http.Request) {
+	server.mutex.Lock()
+	server.KeySetRequests++
+	server.mutex.Unlock()
+
+	writeJSON(writer, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+		"kid": keyID,
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(server.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(server.key.E)).Bytes()),
+	}}})
+}
+
+// authorize approves every request and redirects back with a code, as if the user had logged in.
+func (server *Server) authorize(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	if query.Get("client_id") != server.ClientID || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		http.Error(writer, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	server.mutex.Lock()
+	server.codes[code] = authorization{
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	server.mutex.Unlock()
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(writer, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(writer, request, redirect.String(), http.StatusFound)
+}
+
+func (server *Server) token(writer http.ResponseWriter, request *http.Request) {
+	if err := request.ParseForm(); err != nil {
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, clientSecret, _ := request.BasicAuth()
+	if clientID != server.ClientID || clientSecret != server.ClientSecret {
+		writeJSON(writer, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	code := request.PostForm.Get("code")
+	grant, ok := server.codes[code]
+	delete(server.codes, code)
+	digest := sha256.Sum256([]byte(request.PostForm.Get("code_verifier")))
+	if !ok || grant.redirectURI != request.PostForm.Get("redirect_uri") || grant.codeChallenge != base64.RawURLEncoding.EncodeToString(digest[:]) {
+		writeJSON(writer, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := server.signIDToken(grant.nonce)
+	if err != nil {
+		writeJSON(writer, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(writer, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(writer http.ResponseWriter, status int, body interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(body)
+}
+
+func randomString() string {
+	random := make([]byte, 16)
+	rand.Read(random)
+	return base64.RawURLEncoding.EncodeToString(random)
+}
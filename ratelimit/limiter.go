@@ -0,0 +1,111 @@
+// Package ratelimit implements token bucket rate limits and login lockouts whose state lives in
+// DynamoDB, so limits hold across Lambda instances.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// maxAttempts bounds the optimistic retries when concurrent requests update the same bucket.
+const maxAttempts = 3
+
+// Policy allows bursts of Capacity requests and refills Capacity tokens every Per.
+type Policy struct {
+	Name     string
+	Capacity int
+	Per      time.Duration
+}
+
+type Decision struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// ParsePolicies reads specs of the form "name=capacity/period", e.g. "login=10/1m". A later spec
+// for a name replaces an earlier one.
+func ParsePolicies(specs []string) (map[string]Policy, error) {
+	policies := map[string]Policy{}
+	for _, spec := range specs {
+		name, limit, found := strings.Cut(spec, "=")
+		capacityText, periodText, foundPeriod := strings.Cut(limit, "/")
+		if !found || !foundPeriod || name == "" {
+			return nil, fmt.Errorf("rate limit %q: expected name=capacity/period", spec)
+		}
+		capacity, err := strconv.Atoi(capacityText)
+		if err != nil || capacity <= 0 {
+			return nil, fmt.Errorf("rate limit %q: capacity must be a positive integer", spec)
+		}
+		period, err := time.ParseDuration(periodText)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("rate limit %q: period must be a positive duration", spec)
+		}
+		policies[name] = Policy{Name: name, Capacity: capacity, Per: period}
+	}
+	return policies, nil
+}
+
+type Limiter struct {
+	rateLimitDao dao.RateLimitDao
+	now          func() time.Time
+}
+
+func NewLimiter(rateLimitDao dao.RateLimitDao) *Limiter {
+	return &Limiter{rateLimitDao: rateLimitDao, now: time.Now}
+}
+
+// Allow takes a token from the subject's bucket for the policy. Buckets are read, refilled for the
+// time since their last update and written back conditionally, retrying when another instance wrote
+// in between.
+func (limiter *Limiter) Allow(ctx context.Context, policy Policy, subject string) (Decision, error) {
+	key := "bucket#" + policy.Name + "#" + subject
+	refillPerSecond := float64(policy.Capacity) / policy.Per.Seconds()
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		bucket, err := limiter.rateLimitDao.GetTokenBucket(ctx, key)
+		if err != nil {
+			return Decision{}, err
+		}
+
+		now := limiter.now().UTC()
+		tokens := float64(policy.Capacity)
+		var previousUpdatedAt time.Time
+		if bucket != nil {
+			previousUpdatedAt = bucket.UpdatedAt
+			elapsed := now.Sub(bucket.UpdatedAt).Seconds()
+			tokens = math.Min(float64(policy.Capacity), bucket.Tokens+math.Max(elapsed, 0)*refillPerSecond)
+		}
+
+		if tokens < 1 {
+			wait := time.Duration((1 - tokens) / refillPerSecond * float64(time.Second))
+			return Decision{RetryAfter: wait}, nil
+		}
+
+		err = limiter.rateLimitDao.PutTokenBucket(ctx, &model.TokenBucket{
+			Key:       key,
+			Tokens:    tokens - 1,
+			UpdatedAt: now,
+			// An untouched bucket is full again after Per, so there's no state worth keeping past it.
+			ExpiresAt: now.Add(policy.Per).Unix() + 1,
+		}, previousUpdatedAt)
+		if apperror.KindOf(err) == apperror.KindConflict {
+			continue
+		}
+		if err != nil {
+			return Decision{}, err
+		}
+		return Decision{Allowed: true, Remaining: int(tokens - 1)}, nil
+	}
+
+	// The bucket is too contended to update; that in itself means the subject is sending too much.
+	return Decision{RetryAfter: time.Second}, nil
+}
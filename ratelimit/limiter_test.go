@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRateLimitDao keeps items in memory and enforces the same conditions as the DynamoDB DAO.
+type fakeRateLimitDao struct {
+	buckets   map[string]model.TokenBucket
+	lockouts  map[string]model.LoginLockout
+	conflicts int
+}
+
+func newFakeRateLimitDao() *fakeRateLimitDao {
+	return &fakeRateLimitDao{buckets: map[string]model.TokenBucket{}, lockouts: map[string]model.LoginLockout{}}
+}
+
+func (f *fakeRateLimitDao) GetTokenBucket(ctx context.Context, key string) (*model.TokenBucket, error) {
+	bucket, ok := f.buckets[key]
+	if !ok {
+		return nil, nil
+	}
+	return &bucket, nil
+}
+
+func (f *fakeRateLimitDao) PutTokenBucket(ctx context.Context, bucket *model.TokenBucket, previousUpdatedAt time.Time) error {
+	if f.conflicts > 0 {
+		f.conflicts--
+		return apperror.Conflict("token bucket changed concurrently")
+	}
+	if current, ok := f.buckets[bucket.Key]; ok != !previousUpdatedAt.IsZero() || (ok && !current.UpdatedAt.Equal(previousUpdatedAt)) {
+		return apperror.Conflict("token bucket changed concurrently")
+	}
+	f.buckets[bucket.Key] = *bucket
+	return nil
+}
+
+func (f *fakeRateLimitDao) GetLoginLockout(ctx context.Context, key string) (*model.LoginLockout, error) {
+	lockout, ok := f.lockouts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &lockout, nil
+}
+
+func (f *fakeRateLimitDao) RecordLoginFailure(ctx context.Context, key string, now time.Time, expiresAt time.Time) (int, error) {
+	lockout := f.lockouts[key]
+	lockout.Key = key
+	if lockout.ExpiresAt != 0 && lockout.ExpiresAt <= now.Unix() {
+		lockout.Failures = 0
+	}
+	lockout.Failures++
+	lockout.ExpiresAt = expiresAt.Unix()
+	f.lockouts[key] = lockout
+	return lockout.Failures, nil
+}
+
+func (f *fakeRateLimitDao) LockLogin(ctx context.Context, key string, until time.Time) error {
+	lockout := f.lockouts[key]
+	lockout.LockedUntil = until
+	f.lockouts[key] = lockout
+	return nil
+}
+
+func (f *fakeRateLimitDao) DeleteLoginLockout(ctx context.Context, key string) error {
+	delete(f.lockouts, key)
+	return nil
+}
+
+func TestLimiter_Allow_ExhaustsAndRefills(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(newFakeRateLimitDao())
+	limiter.now = func() time.Time { return now }
+	policy := Policy{Name: "login", Capacity: 2, Per: time.Minute}
+
+	first, _ := limiter.Allow(context.Background(), policy, "ip:1.2.3.4")
+	second, _ := limiter.Allow(context.Background(), policy, "ip:1.2.3.4")
+	denied, _ := limiter.Allow(context.Background(), policy, "ip:1.2.3.4")
+	other, _ := limiter.Allow(context.Background(), policy, "ip:5.6.7.8")
+	now = now.Add(30 * time.Second)
+	refilled, err := limiter.Allow(context.Background(), policy, "ip:1.2.3.4")
+
+	assert.NoError(t, err)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+	assert.True(t, second.Allowed)
+	assert.False(t, denied.Allowed)
+	assert.Equal(t, 30*time.Second, denied.RetryAfter)
+	assert.True(t, other.Allowed)
+	assert.True(t, refilled.Allowed)
+}
+
+func TestLimiter_Allow_RetriesConcurrentUpdate(t *testing.T) {
+	rateLimitDao := newFakeRateLimitDao()
+	rateLimitDao.conflicts = 2
+	limiter := NewLimiter(rateLimitDao)
+
+	decision, err := limiter.Allow(context.Background(), Policy{Name: "write", Capacity: 5, Per: time.Minute}, "user:1")
+
+	assert.NoError(t, err)
+	assert.True(t, decision.Allowed)
+}
+
+func TestLimiter_Allow_PersistentContention_Denies(t *testing.T) {
+	rateLimitDao := newFakeRateLimitDao()
+	rateLimitDao.conflicts = maxAttempts
+	limiter := NewLimiter(rateLimitDao)
+
+	decision, err := limiter.Allow(context.Background(), Policy{Name: "write", Capacity: 5, Per: time.Minute}, "user:1")
+
+	assert.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, time.Second, decision.RetryAfter)
+}
+
+func TestParsePolicies(t *testing.T) {
+	policies, err := ParsePolicies([]string{"login=10/1m", "write=60/1h"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Policy{Name: "login", Capacity: 10, Per: time.Minute}, policies["login"])
+	assert.Equal(t, time.Hour, policies["write"].Per)
+
+	for _, spec := range []string{"login", "login=10", "login=0/1m", "login=10/soon", "=1/1m"} {
+		_, err := ParsePolicies([]string{spec})
+		assert.Error(t, err, strconv.Quote(spec))
+	}
+}
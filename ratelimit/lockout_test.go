@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockout_LocksAfterThresholdWithDoublingDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rateLimitDao := newFakeRateLimitDao()
+	lockout := NewLockout(rateLimitDao, LockoutPolicy{Threshold: 3, Window: time.Hour, BaseDelay: time.Minute, MaxDelay: 3 * time.Minute})
+	lockout.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, lockout.RecordFailure(ctx, "Alice"))
+	}
+	assert.NoError(t, lockout.Check(ctx, "alice"))
+
+	assert.NoError(t, lockout.RecordFailure(ctx, "alice"))
+	err := lockout.Check(ctx, "ALICE")
+	assert.Equal(t, apperror.KindRateLimited, apperror.KindOf(err))
+	assert.Equal(t, time.Minute, apperror.As(err).RetryAfter)
+
+	assert.NoError(t, lockout.RecordFailure(ctx, "alice"))
+	assert.Equal(t, 2*time.Minute, apperror.As(lockout.Check(ctx, "alice")).RetryAfter)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, lockout.RecordFailure(ctx, "alice"))
+	}
+	assert.Equal(t, 3*time.Minute, apperror.As(lockout.Check(ctx, "alice")).RetryAfter)
+
+	now = now.Add(3 * time.Minute)
+	assert.NoError(t, lockout.Check(ctx, "alice"))
+}
+
+func TestLockout_FailuresAfterWindow_StartNewCount(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rateLimitDao := newFakeRateLimitDao()
+	lockout := NewLockout(rateLimitDao, LockoutPolicy{Threshold: 3, Window: time.Hour, BaseDelay: time.Minute, MaxDelay: time.Hour})
+	lockout.now = func() time.Time { return now }
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, lockout.RecordFailure(ctx, "alice"))
+	}
+
+	// The expired item is still there, as it may be long after its TTL.
+	now = now.Add(2 * time.Hour)
+	assert.NoError(t, lockout.RecordFailure(ctx, "alice"))
+
+	assert.NoError(t, lockout.Check(ctx, "alice"))
+	assert.Equal(t, 1, rateLimitDao.lockouts["login#alice"].Failures)
+}
+
+func TestLockout_Reset_ClearsFailures(t *testing.T) {
+	lockout := NewLockout(newFakeRateLimitDao(), LockoutPolicy{Threshold: 1, Window: time.Hour, BaseDelay: time.Minute, MaxDelay: time.Minute})
+	ctx := context.Background()
+	assert.NoError(t, lockout.RecordFailure(ctx, "alice"))
+
+	assert.NoError(t, lockout.Reset(ctx, "alice"))
+
+	assert.NoError(t, lockout.Check(ctx, "alice"))
+}
@@ -0,0 +1,165 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+func Required() Rule[string] {
+	return func(value string) string {
+		if strings.TrimSpace(value) == "" {
+			return "is required"
+		}
+		return ""
+	}
+}
+
+func MinLength(min int) Rule[string] {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) < min {
+			return fmt.Sprintf("must be at least %d characters", min)
+		}
+		return ""
+	}
+}
+
+func MaxLength(max int) Rule[string] {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) > max {
+			return fmt.Sprintf("must be at most %d characters", max)
+		}
+		return ""
+	}
+}
+
+// MaxBytes limits the encoded size, for values such as bcrypt passwords where bytes rather than
+// characters matter.
+func MaxBytes(max int) Rule[string] {
+	return func(value string) string {
+		if len(value) > max {
+			return fmt.Sprintf("must be at most %d bytes", max)
+		}
+		return ""
+	}
+}
+
+func Matches(pattern *regexp.Regexp, description string) Rule[string] {
+	return func(value string) string {
+		if !pattern.MatchString(value) {
+			return "must be " + description
+		}
+		return ""
+	}
+}
+
+func OneOf[T comparable](allowed ...T) Rule[T] {
+	return func(value T) string {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %v", allowed)
+	}
+}
+
+// Optional skips the rules when the value is the zero value.
+func Optional[T comparable](rules ...Rule[T]) Rule[T] {
+	return func(value T) string {
+		var zero T
+		if value == zero {
+			return ""
+		}
+		for _, rule := range rules {
+			if message := rule(value); message != "" {
+				return message
+			}
+		}
+		return ""
+	}
+}
+
+func MinItems[T any](min int) Rule[[]T] {
+	return func(values []T) string {
+		if len(values) < min {
+			return fmt.Sprintf("must have at least %d items", min)
+		}
+		return ""
+	}
+}
+
+func MaxItems[T any](max int) Rule[[]T] {
+	return func(values []T) string {
+		if len(values) > max {
+			return fmt.Sprintf("must have at most %d items", max)
+		}
+		return ""
+	}
+}
+
+func Unique[T comparable]() Rule[[]T] {
+	return func(values []T) string {
+		seen := make(map[T]bool, len(values))
+		for _, value := range values {
+			if seen[value] {
+				return fmt.Sprintf("must not contain %v more than once", value)
+			}
+			seen[value] = true
+		}
+		return ""
+	}
+}
+
+// URL accepts absolute URLs whose scheme and host are allowed. Hosts match exactly or as a parent
+// domain, so "example.com" also allows "cdn.example.com". An empty host list allows any host.
+func URL(schemes []string, hosts []string) Rule[string] {
+	return func(value string) string {
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return "must be an absolute URL"
+		}
+		if !containsFold(schemes, parsed.Scheme) {
+			return fmt.Sprintf("must use one of the schemes %v", schemes)
+		}
+		if len(hosts) > 0 && !hostAllowed(hosts, parsed.Hostname()) {
+			return "must point at an allowed host"
+		}
+		return ""
+	}
+}
+
+func hostAllowed(hosts []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnyOf passes when at least one rule passes and otherwise reports the last rule's message.
+func AnyOf[T any](rules ...Rule[T]) Rule[T] {
+	return func(value T) string {
+		message := ""
+		for _, rule := range rules {
+			if message = rule(value); message == "" {
+				return ""
+			}
+		}
+		return message
+	}
+}
@@ -0,0 +1,73 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/neuralcoral/BlogService/apperror"
+)
+
+// Rule checks a single value and returns a message describing the problem, or "" when it's valid.
+type Rule[T any] func(value T) string
+
+// Check validates one field and reports the problems it found.
+type Check func() []apperror.FieldError
+
+// Field applies the rules in order and reports the first failure, so each field gets one message.
+func Field[T any](name string, value T, rules ...Rule[T]) Check {
+	return func() []apperror.FieldError {
+		for _, rule := range rules {
+			if message := rule(value); message != "" {
+				return []apperror.FieldError{{Field: name, Message: message}}
+			}
+		}
+		return nil
+	}
+}
+
+// Items applies the rules to every element, naming failures by index, e.g. "tags[2]".
+func Items[T any](name string, values []T, rules ...Rule[T]) Check {
+	return func() []apperror.FieldError {
+		var result []apperror.FieldError
+		for i, value := range values {
+			result = append(result, Field(fmt.Sprintf("%s[%d]", name, i), value, rules...)()...)
+		}
+		return result
+	}
+}
+
+// Nested validates a child object and prefixes its field names, e.g. "tags[0].label".
+func Nested(prefix string, checks ...Check) Check {
+	return func() []apperror.FieldError {
+		var result []apperror.FieldError
+		for _, check := range checks {
+			for _, fieldError := range check() {
+				fieldError.Field = prefix + "." + fieldError.Field
+				result = append(result, fieldError)
+			}
+		}
+		return result
+	}
+}
+
+// All combines several checks into one.
+func All(checks ...Check) Check {
+	return func() []apperror.FieldError {
+		var result []apperror.FieldError
+		for _, check := range checks {
+			result = append(result, check()...)
+		}
+		return result
+	}
+}
+
+// Validate runs every check and returns a validation error listing all failing fields, or nil.
+func Validate(checks ...Check) error {
+	var fields []apperror.FieldError
+	for _, check := range checks {
+		fields = append(fields, check()...)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return apperror.InvalidFields(fields)
+}
@@ -0,0 +1,71 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/stretchr/testify/assert"
+)
+
+func fieldErrors(t testing.TB, err error) []apperror.FieldError {
+	t.Helper()
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		t.Fatalf("expected a domain error, got %v", err)
+	}
+	assert.Equal(t, apperror.KindValidation, appErr.Kind)
+	return appErr.Fields
+}
+
+func TestValidate_AllValid_ReturnsNil(t *testing.T) {
+	err := Validate(
+		Field("title", "Hello", Required(), MaxLength(10)),
+		Items("tags", []string{"go", "aws"}, MaxLength(5)),
+	)
+
+	assert.Nil(t, err)
+}
+
+func TestValidate_ReportsFirstFailurePerFieldForEveryField(t *testing.T) {
+	err := Validate(
+		Field("title", "", Required(), MaxLength(10)),
+		Field("status", "ARCHIVED", OneOf("DRAFT", "POSTED")),
+		Items("tags", []string{"go", "much-too-long"}, MaxLength(5)),
+		Nested("author", Field("name", "", Required())),
+	)
+
+	assert.Equal(t, []apperror.FieldError{
+		{Field: "title", Message: "is required"},
+		{Field: "status", Message: "must be one of [DRAFT POSTED]"},
+		{Field: "tags[1]", Message: "must be at most 5 characters"},
+		{Field: "author.name", Message: "is required"},
+	}, fieldErrors(t, err))
+}
+
+func TestMaxLength_CountsCharactersNotBytes(t *testing.T) {
+	assert.Equal(t, "", MaxLength(3)("héé"))
+	assert.NotEqual(t, "", MaxBytes(3)("héé"))
+}
+
+func TestOptional_SkipsZeroValue(t *testing.T) {
+	rule := Optional(OneOf("DRAFT", "POSTED"))
+
+	assert.Equal(t, "", rule(""))
+	assert.NotEqual(t, "", rule("ARCHIVED"))
+}
+
+func TestURL_EnforcesSchemesAndHosts(t *testing.T) {
+	rule := URL([]string{"https"}, []string{"example.com"})
+
+	assert.Equal(t, "", rule("https://example.com/a"))
+	assert.Equal(t, "", rule("https://cdn.example.com/a"))
+	assert.Equal(t, "must use one of the schemes [https]", rule("javascript://example.com/%0Aalert(1)"))
+	assert.Equal(t, "must point at an allowed host", rule("https://evil-example.com/a"))
+	assert.Equal(t, "must be an absolute URL", rule("/relative/path"))
+}
+
+func TestUnique_RejectsDuplicates(t *testing.T) {
+	assert.Equal(t, "", Unique[string]()([]string{"a", "b"}))
+	assert.Equal(t, "must not contain a more than once", Unique[string]()([]string{"a", "b", "a"}))
+}
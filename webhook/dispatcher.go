@@ -0,0 +1,315 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao"
+	"github.com/neuralcoral/BlogService/domainevent"
+	"github.com/neuralcoral/BlogService/model"
+)
+
+// TypeTest is the type of the events sent to check a webhook; subscriptions can't filter on it.
+const TypeTest = "WebhookTest"
+
+const (
+	deliveryRetention = 30 * 24 * time.Hour
+	retryBatchSize    = 100
+	maxResponseBytes  = 64 << 10
+	maxErrorLength    = 256
+)
+
+// EventTypes lists the event types webhooks can subscribe to.
+var EventTypes = []string{domainevent.TypePostPublished, domainevent.TypePostUpdated, domainevent.TypePostDeleted}
+
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt; a delivery that fails them all is dead-lettered.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy retries for about twelve hours.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 12, InitialBackoff: 30 * time.Second, MaxBackoff: 4 * time.Hour}
+
+// Backoff is the wait after the given number of failed attempts, doubling from InitialBackoff up to
+// MaxBackoff.
+func (policy RetryPolicy) Backoff(failedAttempts int) time.Duration {
+	backoff := policy.InitialBackoff
+	for i := 1; i < failedAttempts && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, policy.MaxBackoff)
+}
+
+var ErrBlockedAddress = errors.New("webhook receivers must not be on private, loopback or link-local addresses")
+
+// blockedPrefixes are the special-purpose ranges netip.Addr doesn't classify, on top of the private,
+// loopback, link-local and multicast ones it does.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// NewHTTPClient returns the client deliveries are sent with. Redirects are not followed, so a
+// receiver can't point deliveries somewhere its URL wasn't checked against. The address is checked
+// when dialing, after DNS resolution, so a public name can't resolve into the VPC or the instance
+// metadata service; allowed exempts ranges, such as loopback for a local receiver.
+func NewHTTPClient(timeout time.Duration, allowed ...netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			return checkAddress(address, allowed)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed in place of the receiver and make the check meaningless.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func checkAddress(address string, allowed []netip.Prefix) error {
+	addressPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	ip := addressPort.Addr().Unmap()
+	for _, prefix := range allowed {
+		if prefix.Contains(ip) {
+			return nil
+		}
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return ErrBlockedAddress
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return ErrBlockedAddress
+		}
+	}
+	return nil
+}
+
+// Dispatcher delivers domain events to subscribed webhooks. Every delivery is recorded before its
+// first attempt, so one that fails, or whose attempt is cut short, is retried by RetryDue.
+type Dispatcher struct {
+	webhookDao  dao.WebhookDao
+	deliveryDao dao.WebhookDeliveryDao
+	secretBox   *auth.SecretBox
+	client      *http.Client
+	policy      RetryPolicy
+	now         func() time.Time
+}
+
+func NewDispatcher(webhookDao dao.WebhookDao, deliveryDao dao.WebhookDeliveryDao, secretBox *auth.SecretBox, client *http.Client, policy RetryPolicy) *Dispatcher {
+	return &Dispatcher{
+		webhookDao:  webhookDao,
+		deliveryDao: deliveryDao,
+		secretBox:   secretBox,
+		client:      client,
+		policy:      policy,
+		now:         time.Now,
+	}
+}
+
+// HandleEventBridgeEvent is the Lambda entry point. Domain events from the bus are delivered to the
+// webhooks subscribed to them; scheduled events retry due deliveries.
+func (dispatcher *Dispatcher) HandleEventBridgeEvent(ctx context.Context, event events.EventBridgeEvent) error {
+	if event.Source == "aws.events" && event.DetailType == "Scheduled Event" {
+		return dispatcher.RetryDue(ctx)
+	}
+
+	var domainEvent domainevent.Event
+	if err := json.Unmarshal(event.Detail, &domainEvent); err != nil {
+		return fmt.Errorf("decode domain event: %w", err)
+	}
+	return dispatcher.Publish(ctx, &domainEvent)
+}
+
+// Publish delivers the event to every active webhook subscribed to its type, so the dispatcher can
+// also serve as a domainevent.Sink. Only failures to record deliveries are returned; receivers that
+// fail are retried later. Events already delivered to a webhook are skipped.
+func (dispatcher *Dispatcher) Publish(ctx context.Context, event *domainevent.Event) error {
+	webhooks, err := dispatcher.webhookDao.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(event.Type) {
+			continue
+		}
+		_, err := dispatcher.Send(ctx, webhook, event)
+		if apperror.KindOf(err) == apperror.KindConflict {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", webhook.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Send records a delivery of the event to the webhook, whatever its subscriptions, and makes the
+// first attempt. It fails with a conflict if the event was already delivered to the webhook.
+func (dispatcher *Dispatcher) Send(ctx context.Context, webhook *model.Webhook, event *domainevent.Event) (*model.WebhookDelivery, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	now := dispatcher.now().UTC()
+	delivery := &model.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		OccurredAt:    event.OccurredAt.UTC(),
+		Payload:       string(payload),
+		Status:        model.DeliveryPending,
+		NextAttemptAt: now,
+		DeleteAfter:   now.Add(deliveryRetention).Unix(),
+	}
+	if err := dispatcher.deliveryDao.CreateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	if err := dispatcher.attempt(ctx, webhook, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// RetryDue attempts pending deliveries whose next attempt is due. It is meant to run every minute or
+// so and handles a bounded batch each time.
+func (dispatcher *Dispatcher) RetryDue(ctx context.Context) error {
+	deliveries, err := dispatcher.deliveryDao.ListDueDeliveries(ctx, dispatcher.now(), retryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	webhooks := map[string]*model.Webhook{}
+	var errs []error
+	for _, delivery := range deliveries {
+		webhook, found := webhooks[delivery.WebhookID]
+		if !found {
+			if webhook, err = dispatcher.webhookDao.GetWebhook(ctx, delivery.WebhookID); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		if webhook == nil || !webhook.Active {
+			err = dispatcher.abandon(ctx, delivery, "webhook was deleted or deactivated")
+		} else {
+			err = dispatcher.attempt(ctx, webhook, delivery)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("delivery of %s to webhook %s: %w", delivery.EventID, delivery.WebhookID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// attempt sends the delivery once and records the outcome. It only fails if the outcome can't be
+// recorded; an attempt recorded concurrently by another invocation wins.
+func (dispatcher *Dispatcher) attempt(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) error {
+	secret, err := dispatcher.secretBox.Open(webhook.SealedSecret, webhook.ID)
+	if err != nil {
+		return err
+	}
+
+	previousAttempts := delivery.Attempts
+	now := dispatcher.now().UTC()
+	statusCode, sendErr := dispatcher.post(ctx, webhook.URL, secret, delivery, now)
+	delivery.Attempts++
+	delivery.LastAttemptAt = now
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+	switch {
+	case sendErr == nil:
+		delivery.Status = model.DeliveryDelivered
+		delivery.NextAttemptAt = time.Time{}
+	case delivery.Attempts >= dispatcher.policy.MaxAttempts:
+		log.Printf("webhooks: dead-lettering delivery of %s to webhook %s after %d attempts: %v", delivery.EventID, webhook.ID, delivery.Attempts, sendErr)
+		delivery.Status = model.DeliveryDeadLetter
+		delivery.LastError = truncateError(sendErr)
+		delivery.NextAttemptAt = time.Time{}
+	default:
+		delivery.LastError = truncateError(sendErr)
+		delivery.NextAttemptAt = now.Add(dispatcher.policy.Backoff(delivery.Attempts))
+	}
+
+	err = dispatcher.deliveryDao.UpdateDelivery(ctx, delivery, previousAttempts)
+	if apperror.KindOf(err) == apperror.KindConflict {
+		return nil
+	}
+	return err
+}
+
+func (dispatcher *Dispatcher) abandon(ctx context.Context, delivery *model.WebhookDelivery, reason string) error {
+	delivery.Status = model.DeliveryDeadLetter
+	delivery.LastError = reason
+	delivery.NextAttemptAt = time.Time{}
+	err := dispatcher.deliveryDao.UpdateDelivery(ctx, delivery, delivery.Attempts)
+	if apperror.KindOf(err) == apperror.KindConflict {
+		return nil
+	}
+	return err
+}
+
+// post sends the payload and returns the response status; anything but a 2xx response is an error.
+func (dispatcher *Dispatcher) post(ctx context.Context, url string, secret string, delivery *model.WebhookDelivery, now time.Time) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "BlogService-Webhooks/1")
+	request.Header.Set(HeaderEventID, delivery.EventID)
+	request.Header.Set(HeaderEventType, delivery.EventType)
+	request.Header.Set(HeaderSignature, Sign(secret, now, []byte(delivery.Payload)))
+
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxResponseBytes))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("receiver responded with status %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+func truncateError(err error) string {
+	message := err.Error()
+	if len(message) > maxErrorLength {
+		return message[:maxErrorLength]
+	}
+	return message
+}
@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers sent with every delivery. The event ID is the same on every attempt, so receivers can drop
+// duplicates.
+const (
+	HeaderEventID   = "X-Blog-Event-Id"
+	HeaderEventType = "X-Blog-Event-Type"
+	HeaderSignature = "X-Blog-Signature"
+)
+
+const secretPrefix = "whsec_"
+
+var ErrInvalidSignature = errors.New("webhook signature is missing, malformed or wrong")
+
+// NewSecret generates a signing secret.
+func NewSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// Sign returns the signature header for a body sent at timestamp: "t=<unix seconds>,v1=<hex>", where
+// v1 is the HMAC-SHA256 of "<unix seconds>.<body>" keyed with the secret. Including the timestamp
+// lets receivers reject replays of old deliveries.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	seconds := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + seconds + ",v1=" + signature(secret, seconds, body)
+}
+
+// Verify checks a signature header the way receivers should: the signature must match and the
+// timestamp must be within tolerance of now.
+func Verify(secret string, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var seconds, signed string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			seconds = value
+		case "v1":
+			signed = value
+		}
+	}
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil || signed == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signed), []byte(signature(secret, seconds, body))) {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func signature(secret string, seconds string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(seconds))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
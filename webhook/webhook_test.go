@@ -0,0 +1,194 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/neuralcoral/BlogService/apperror"
+	"github.com/neuralcoral/BlogService/auth"
+	"github.com/neuralcoral/BlogService/dao/daotest"
+	"github.com/neuralcoral/BlogService/domainevent"
+	"github.com/neuralcoral/BlogService/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecretBoxKey = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+type fakeDeliveryDao struct {
+	deliveries map[string]model.WebhookDelivery
+}
+
+func (f *fakeDeliveryDao) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	key := delivery.WebhookID + "/" + delivery.EventID
+	if _, exists := f.deliveries[key]; exists {
+		return apperror.Conflict("event already delivered to webhook")
+	}
+	f.deliveries[key] = *delivery
+	return nil
+}
+
+func (f *fakeDeliveryDao) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery, previousAttempts int) error {
+	key := delivery.WebhookID + "/" + delivery.EventID
+	if f.deliveries[key].Attempts != previousAttempts {
+		return apperror.Conflict("webhook delivery was attempted concurrently")
+	}
+	f.deliveries[key] = *delivery
+	return nil
+}
+
+func (f *fakeDeliveryDao) ListDeliveries(ctx context.Context, webhookID string, limit int, lastEvaluatedKey string) ([]*model.WebhookDelivery, string, error) {
+	return nil, "", nil
+}
+
+func (f *fakeDeliveryDao) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var result []*model.WebhookDelivery
+	for _, delivery := range f.deliveries {
+		if delivery.Status == model.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			copied := delivery
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+type dispatcherFixture struct {
+	dispatcher *Dispatcher
+	deliveries *fakeDeliveryDao
+	received   []*http.Request
+	bodies     []string
+	statusCode int
+	now        time.Time
+}
+
+// setupDispatcher subscribes a webhook with the given secret to PostPublished, delivering to a local
+// receiver that answers with fixture.statusCode.
+func setupDispatcher(t *testing.T, secret string) *dispatcherFixture {
+	t.Helper()
+	fixture := &dispatcherFixture{
+		deliveries: &fakeDeliveryDao{deliveries: map[string]model.WebhookDelivery{}},
+		statusCode: http.StatusNoContent,
+		now:        time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fixture.received = append(fixture.received, r)
+		fixture.bodies = append(fixture.bodies, string(body))
+		w.WriteHeader(fixture.statusCode)
+	}))
+	t.Cleanup(receiver.Close)
+
+	secretBox, err := auth.NewSecretBox(testSecretBoxKey)
+	if err != nil {
+		t.Fatalf("secret box: %v", err)
+	}
+	sealed, err := secretBox.Seal(secret, "hook-1")
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	webhooks := daotest.NewWebhookDao(&model.Webhook{ID: "hook-1", URL: receiver.URL, Events: []string{domainevent.TypePostPublished}, SealedSecret: sealed, Active: true})
+
+	// The receiver listens on loopback, which deliveries are otherwise refused.
+	fixture.dispatcher = NewDispatcher(webhooks, fixture.deliveries, secretBox, NewHTTPClient(time.Second, netip.MustParsePrefix("127.0.0.0/8")), RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Hour})
+	fixture.dispatcher.now = func() time.Time { return fixture.now }
+	return fixture
+}
+
+func publishedEvent(id string) *domainevent.Event {
+	return &domainevent.Event{ID: id, Type: domainevent.TypePostPublished, OccurredAt: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC), PostID: "post-1"}
+}
+
+func TestSignAndVerify_RoundTripsAndRejectsTampering(t *testing.T) {
+	now := time.Unix(1714557600, 0)
+	body := []byte(`{"id":"event-1"}`)
+	header := Sign("secret", now, body)
+
+	assert.NoError(t, Verify("secret", header, body, now.Add(time.Minute), 5*time.Minute))
+	assert.Equal(t, ErrInvalidSignature, Verify("other secret", header, body, now, 5*time.Minute))
+	assert.Equal(t, ErrInvalidSignature, Verify("secret", header, []byte(`{"id":"event-2"}`), now, 5*time.Minute))
+	assert.Equal(t, ErrInvalidSignature, Verify("secret", header, body, now.Add(time.Hour), 5*time.Minute))
+	assert.Equal(t, ErrInvalidSignature, Verify("secret", "v1=abc", body, now, 5*time.Minute))
+}
+
+func TestRetryPolicy_Backoff_DoublesUpToMax(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Minute, MaxBackoff: 5 * time.Minute}
+
+	assert.Equal(t, time.Minute, policy.Backoff(1))
+	assert.Equal(t, 4*time.Minute, policy.Backoff(3))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(4))
+	assert.Equal(t, 5*time.Minute, policy.Backoff(100))
+}
+
+func TestCheckAddress_NonPublicAddresses_Blocked(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:80", "10.0.0.5:443", "172.16.0.1:443", "192.168.1.1:443", "169.254.169.254:80", "100.64.0.1:443", "0.0.0.0:443", "[::1]:443", "[fd00::1]:443", "[fe80::1]:443", "[::ffff:169.254.169.254]:80"} {
+		assert.ErrorIs(t, checkAddress(address, nil), ErrBlockedAddress, address)
+	}
+	assert.NoError(t, checkAddress("93.184.216.34:443", nil))
+	assert.NoError(t, checkAddress("[2606:2800:220:1::1]:443", nil))
+	assert.NoError(t, checkAddress("127.0.0.1:8080", []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}))
+}
+
+func TestNewHTTPClient_LoopbackReceiver_RefusedAtDial(t *testing.T) {
+	received := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+	}))
+	defer receiver.Close()
+
+	_, err := NewHTTPClient(time.Second).Get(receiver.URL)
+
+	assert.ErrorIs(t, err, ErrBlockedAddress)
+	assert.False(t, received)
+}
+
+func TestPublish_SubscribedWebhook_DeliversSignedPayloadOnce(t *testing.T) {
+	fixture := setupDispatcher(t, "whsec_test")
+
+	err := fixture.dispatcher.Publish(context.Background(), publishedEvent("event-1"))
+	redeliveryErr := fixture.dispatcher.Publish(context.Background(), publishedEvent("event-1"))
+	unsubscribedErr := fixture.dispatcher.Publish(context.Background(), &domainevent.Event{ID: "event-2", Type: domainevent.TypePostDeleted})
+
+	assert.NoError(t, err)
+	assert.NoError(t, redeliveryErr)
+	assert.NoError(t, unsubscribedErr)
+	if assert.Len(t, fixture.received, 1) {
+		request := fixture.received[0]
+		assert.Equal(t, "event-1", request.Header.Get(HeaderEventID))
+		assert.Equal(t, domainevent.TypePostPublished, request.Header.Get(HeaderEventType))
+		assert.NoError(t, Verify("whsec_test", request.Header.Get(HeaderSignature), []byte(fixture.bodies[0]), fixture.now, time.Minute))
+		assert.Contains(t, fixture.bodies[0], `"postId":"post-1"`)
+	}
+	delivery := fixture.deliveries.deliveries["hook-1/event-1"]
+	assert.Equal(t, model.DeliveryDelivered, delivery.Status)
+	assert.Equal(t, http.StatusNoContent, delivery.LastStatusCode)
+}
+
+func TestRetryDue_FailingReceiver_BacksOffThenDeadLetters(t *testing.T) {
+	fixture := setupDispatcher(t, "whsec_test")
+	fixture.statusCode = http.StatusInternalServerError
+
+	err := fixture.dispatcher.Publish(context.Background(), publishedEvent("event-1"))
+	pending := fixture.deliveries.deliveries["hook-1/event-1"]
+	notDueErr := fixture.dispatcher.RetryDue(context.Background())
+	fixture.now = fixture.now.Add(time.Minute)
+	retryErr := fixture.dispatcher.RetryDue(context.Background())
+	fixture.now = fixture.now.Add(2 * time.Minute)
+	lastRetryErr := fixture.dispatcher.RetryDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.NoError(t, notDueErr)
+	assert.NoError(t, retryErr)
+	assert.NoError(t, lastRetryErr)
+	assert.Equal(t, model.DeliveryPending, pending.Status)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 1, 0, 0, time.UTC), pending.NextAttemptAt)
+	assert.Len(t, fixture.received, 3)
+	deadLetter := fixture.deliveries.deliveries["hook-1/event-1"]
+	assert.Equal(t, model.DeliveryDeadLetter, deadLetter.Status)
+	assert.Equal(t, 3, deadLetter.Attempts)
+	assert.Equal(t, "receiver responded with status 500", deadLetter.LastError)
+	assert.True(t, deadLetter.NextAttemptAt.IsZero())
+}